@@ -1,59 +1,235 @@
 package analyze
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/analyzerflags"
+	"github.com/yanodincov/json-schema-detector/internal/autocommit"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/internal/summary"
 	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/fetch"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/graphql"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
 )
 
 var (
-	outputFile string
-	autoCommit bool
+	analyzerFlags *analyzerflags.Flags
+	outputFile    string
+	autoCommit    bool
+	noGraphQL     bool
+	recursive     bool
+	perFile       bool
+	headers       []string
+	timeout       time.Duration
+	retries       int
 )
 
+// Result представляет результат команды analyze в режиме --json
+type Result struct {
+	Input      string                    `json:"input"`
+	Inputs     []string                  `json:"inputs,omitempty"`
+	Output     string                    `json:"output"`
+	Version    string                    `json:"version"`
+	Statistics *types.AnalysisStatistics `json:"statistics"`
+	Summary    *summary.Summary          `json:"summary"`
+	Signature  string                    `json:"signature,omitempty"`
+	Committed  bool                      `json:"committed"`
+}
+
+// FilesResult представляет результат команды analyze --per-file в режиме --json
+type FilesResult struct {
+	Schemas   []FileResult `json:"schemas"`
+	Committed bool         `json:"committed"`
+}
+
+// FileResult описывает схему одного входного файла
+type FileResult struct {
+	Input     string `json:"input"`
+	Output    string `json:"output"`
+	Version   string `json:"version"`
+	Objects   int    `json:"objects"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// GraphQLResult представляет результат анализа ответа GraphQL в режиме --json
+type GraphQLResult struct {
+	Input      string            `json:"input"`
+	Operations []OperationResult `json:"operations"`
+	Errors     *OperationResult  `json:"errors,omitempty"`
+	Committed  bool              `json:"committed"`
+}
+
+// OperationResult описывает схему одной операции GraphQL или схему ошибок
+type OperationResult struct {
+	Name      string `json:"name"`
+	Output    string `json:"output"`
+	Signature string `json:"signature,omitempty"`
+}
+
 // Cmd представляет команду analyze
 var Cmd = &cobra.Command{
-	Use:   "analyze [input.json]",
+	Use:   "analyze [input.json|url...]",
 	Short: "Анализирует JSON файл и создает схему",
 	Long: `Анализирует структуру JSON файла и генерирует соответствующую 
-JSON Schema с автоматическим определением типов и структур.`,
-	Args: cobra.ExactArgs(1),
+JSON Schema с автоматическим определением типов и структур.
+
+Несколько файлов и шаблоны путей (data/*.json) объединяются в одну схему,
+как если бы схема первого файла обновлялась остальными командой update;
+выходной файл в этом случае указывается флагом --output. С флагом
+--recursive аргументами могут быть директории: анализируются все файлы
+*.json и *.ndjson в них и их поддиректориях, кроме схем (*.schema.json).
+С флагом --per-file для каждого файла создается отдельная схема рядом с
+ним или, если указан --output, в этой директории с той же структурой
+поддиректорий.
+
+Вместо файла можно указать HTTP(S) URL: ответ загружается во временный
+файл и анализируется как локальный, схема по умолчанию создается в текущей
+директории по последнему сегменту пути URL (users.schema.json). Заголовки
+авторизации передаются флагом --header, после сетевой ошибки или ответа
+429/5xx запрос повторяется --retries раз.
+
+Ответ GraphQL (конверт data/errors) распознается автоматически: для каждой
+операции - корневого поля data - создается отдельная схема <output>.<операция>.schema.json,
+а для массива errors - схема <output>.errors.schema.json. Флаг --no-graphql
+отключает распознавание.
+
+Таблица CSV с заголовком (файл .csv или --format csv) анализируется как
+массив объектов - по одному на строку. Тип колонки определяется по всем
+ее значениям: integer, number, boolean или string; даты распознаются как
+format строк, пустые ячейки становятся null. Разделитель задается флагом
+--csv-delimiter.
+
+Файлы YAML (.yaml, .yml или --format yaml) анализируются так же, как JSON:
+порядок ключей сохраняется, якоря и ссылки раскрываются, а несколько
+документов, разделенных ---, считаются записями массива. Документ XML
+(.xml или --format xml) становится объектом с полем корневого элемента:
+атрибуты - полями @имя, повторяющиеся элементы - массивами.
+
+Журнал (.log или --format log) читается построчно: из каждой строки
+берется встроенный JSON - первый объект {...} или, с флагом --log-prefix,
+значение после указанного текста, - и эти записи анализируются как NDJSON.
+Строки без JSON пропускаются.
+
+Примеры использования:
+  analyze data.json
+  analyze export.txt --format csv --csv-delimiter ';'
+  analyze fixtures/users.yaml
+  analyze legacy/feed.xml
+  analyze app.log --log-prefix 'payload='
+  analyze logs/ -r -o logs.schema.json
+  analyze https://api.example.com/v1/users -H "Authorization: Bearer $TOKEN"`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: runAnalyze,
 }
 
 func init() {
 	Cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Выходной файл для схемы")
 	Cmd.Flags().BoolVarP(&autoCommit, "auto-commit", "a", false, "Автоматический коммит изменений схемы")
+	Cmd.Flags().BoolVar(&noGraphQL, "no-graphql", false, "Не распознавать ответы GraphQL")
+	Cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Анализировать файлы *.json и *.ndjson в указанных директориях и их поддиректориях")
+	Cmd.Flags().BoolVar(&perFile, "per-file", false, "Создать отдельную схему для каждого входного файла вместо одной объединенной (--output - директория схем)")
+	Cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "Заголовок запроса для входных данных по URL в формате \"Имя: значение\"")
+	Cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Таймаут загрузки входных данных по URL")
+	Cmd.Flags().IntVar(&retries, "retries", 2, "Сколько раз повторить загрузку по URL после сетевой ошибки или ответа 429/5xx")
+	analyzerFlags = analyzerflags.Register(Cmd)
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
-	inputFile := args[0]
+	parsedHeaders, err := fetch.ParseHeaders(headers)
+	if err != nil {
+		return err
+	}
+	d := &downloads{ctx: cmd.Context(), config: fetch.Config{Headers: parsedHeaders, Timeout: timeout, Retries: retries}}
+	defer d.cleanup()
 
-	// Проверяем существование входного файла
-	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
-		return fmt.Errorf("входной файл не найден: %s", inputFile)
+	inputs, err := expandInputs(args, d)
+	if err != nil {
+		return err
+	}
+	if perFile {
+		return analyzePerFile(analyzerFlags.New(), inputs, outputFile)
+	}
+
+	inputFiles := make([]string, 0, len(inputs))
+	names := make([]string, 0, len(inputs))
+	for _, in := range inputs {
+		inputFiles = append(inputFiles, in.path)
+		names = append(names, in.name())
+	}
+	inputFile := inputFiles[0]
+	ndjson := analyzer.IsNDJSON(inputFile)
+
+	if len(inputFiles) > 1 && outputFile == "" {
+		return fmt.Errorf("для нескольких входных файлов укажите выходной файл флагом --output")
+	}
+
+	// Имя схемы вместо пути размещаем в директории схем проекта
+	schemaName := ""
+	if project.IsSchemaName(outputFile) {
+		schemaName = outputFile
+	}
+	outputFile, err := project.ResolveOutput(outputFile)
+	if err != nil {
+		return err
 	}
 
 	// Если выходной файл не указан, создаем его на основе входного
 	if outputFile == "" {
-		ext := filepath.Ext(inputFile)
-		outputFile = inputFile[:len(inputFile)-len(ext)] + ".schema.json"
+		outputFile = inputs[0].schema()
 	}
 
-	fmt.Printf("Анализ файла: %s\n", inputFile)
-	fmt.Printf("Выходной файл: %s\n", outputFile)
+	if len(inputFiles) > 1 {
+		output.Printf("Анализ файлов (%d): %s\n", len(inputFiles), strings.Join(names, ", "))
+	} else {
+		output.Printf("Анализ файла: %s\n", names[0])
+	}
 
 	// Создаем анализатор
-	analyzer := analyzer.New()
+	analyzer := analyzerFlags.New()
 
-	// Анализируем файл
-	result, err := analyzer.AnalyzeFile(inputFile)
+	// Автоматический режим выбирает настройки по размеру и форме файла;
+	// таблица CSV всегда читается построчно, а YAML и XML - целиком
+	config := analyzerFlags.Config()
+	streaming := config.Stream
+	converted := analyzer.IsConverted(inputFile)
+	if config.Auto && len(inputFiles) == 1 && !converted {
+		profile, err := analyzer.ChooseProfile(inputFile)
+		if err != nil {
+			return err
+		}
+		streaming = profile.Stream
+		output.Printf("⚙️ Автоматический режим: %s\n", profile)
+	}
+
+	// Ответ GraphQL разбиваем на схемы операций; при потоковом анализе файл
+	// целиком не читается, а ответы GraphQL так велики не бывают
+	if !noGraphQL && !streaming && !ndjson && !converted && len(inputFiles) == 1 {
+		if response, ok, err := readGraphQL(analyzer, inputFile); err != nil {
+			return err
+		} else if ok {
+			return analyzeGraphQL(analyzer, names[0], outputFile, response)
+		}
+	}
+
+	output.Printf("Выходной файл: %s\n", outputFile)
+
+	// Анализируем файлы
+	result, err := analyzer.AnalyzeFiles(inputFiles)
 	if err != nil {
-		return fmt.Errorf("ошибка анализа: %w", err)
+		return fmt.Errorf("ошибка анализа: %w", analyzerflags.Explain(err))
 	}
 
 	// Сохраняем результат
@@ -61,41 +237,395 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("ошибка сохранения схемы: %w", err)
 	}
 
-	fmt.Printf("Схема успешно создана: %s\n", outputFile)
-	fmt.Printf("Проанализировано объектов: %d\n", result.Statistics.TotalObjects)
-	fmt.Printf("Уникальных структур: %d\n", result.Statistics.UniqueStructures)
+	output.Printf("Схема успешно создана: %s\n", outputFile)
+	output.Printf("Проанализировано объектов: %d\n", result.Statistics.TotalObjects)
+	printSampling(result.Metadata.Sampling)
+	analyzerflags.PrintWarnings(result.Warnings)
+	output.Printf("Уникальных структур: %d\n", result.Statistics.UniqueStructures)
+	if result.Statistics.UniqueStructures > 1 {
+		for _, structure := range result.Statistics.TopStructures(5) {
+			path := structure.Path
+			if path == "" {
+				path = "<root>"
+			}
+			output.Printf("   %d × %s {%s}\n", structure.Count, path, strings.Join(structure.Fields, ", "))
+		}
+	}
+
+	// Подписываем схему, если настроен ключ подписи
+	signatureFile, err := signing.SignSchema(outputFile)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+
+	// Регистрируем схему под именем в индексе проекта
+	if schemaName != "" && outputFile != schemaName {
+		registered, err := registerSchema(schemaName, outputFile)
+		if err != nil {
+			output.Printf("⚠️ Ошибка регистрации схемы: %v\n", err)
+		} else if registered {
+			output.Printf("Схема зарегистрирована под именем: %s\n", schemaName)
+		}
+	}
 
 	// Автоматический коммит если флаг установлен
+	committed := false
 	if autoCommit {
-		if err := commitSchemaChanges(outputFile, "analyze"); err != nil {
-			fmt.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
+		var extraFiles []string
+		if signatureFile != "" {
+			extraFiles = append(extraFiles, signatureFile)
+		}
+		if err := autocommit.Commit(outputFile, "analyze", extraFiles...); err != nil {
+			output.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
 		} else {
-			fmt.Printf("✅ Изменения схемы закоммичены\n")
+			committed = true
+			output.Printf("✅ Изменения схемы закоммичены\n")
 		}
 	}
 
-	return nil
+	sum := summary.Build(summary.Input{
+		Program: cmd.Root().Name(),
+		Schema:  outputFile,
+		Result:  result,
+	})
+	sum.Print()
+
+	return output.Result(Result{
+		Input:      names[0],
+		Inputs:     multiple(names),
+		Output:     outputFile,
+		Version:    result.Metadata.Version,
+		Statistics: result.Statistics,
+		Summary:    sum,
+		Signature:  signatureFile,
+		Committed:  committed,
+	})
 }
 
-// commitSchemaChanges выполняет автоматический коммит изменений схемы
-func commitSchemaChanges(schemaFile, operation string) error {
-	// Проверяем, что мы в git репозитории
-	if _, err := exec.LookPath("git"); err != nil {
-		return fmt.Errorf("git не найден")
+// input - входной файл и его путь относительно директории, в которой он
+// найден при обходе (для явно указанного файла - имя файла). Для данных,
+// загруженных по URL, path - временный файл, а url - исходный адрес
+type input struct {
+	path string
+	rel  string
+	url  string
+}
+
+// name возвращает имя входных данных для вывода: URL или путь файла
+func (in input) name() string {
+	if in.url != "" {
+		return in.url
 	}
+	return in.path
+}
 
-	// Добавляем файл схемы в git
-	cmd := exec.Command("git", "add", schemaFile)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ошибка git add: %w", err)
+// schema возвращает путь схемы по умолчанию: рядом с файлом, а для данных,
+// загруженных по URL, - в текущей директории
+func (in input) schema() string {
+	if in.url != "" {
+		return schemaPath(in.rel)
 	}
+	return schemaPath(in.path)
+}
 
-	// Создаем коммит
-	commitMessage := fmt.Sprintf("schema: %s %s", operation, filepath.Base(schemaFile))
-	cmd = exec.Command("git", "commit", "-m", commitMessage)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ошибка git commit: %w", err)
+// downloads загружает входные данные по URL во временную директорию,
+// которая удаляется после выполнения команды
+type downloads struct {
+	ctx    context.Context
+	config fetch.Config
+	dir    string
+}
+
+// fetch загружает данные по URL в отдельную поддиректорию, чтобы ответы с
+// одинаковым последним сегментом пути не перезаписывали друг друга
+func (d *downloads) fetch(url string) (input, error) {
+	if d.dir == "" {
+		dir, err := os.MkdirTemp("", "json-schema-detector-")
+		if err != nil {
+			return input{}, fmt.Errorf("ошибка создания временной директории: %w", err)
+		}
+		d.dir = dir
+	}
+	dir, err := os.MkdirTemp(d.dir, "url-")
+	if err != nil {
+		return input{}, fmt.Errorf("ошибка создания временной директории: %w", err)
 	}
 
+	output.Printf("🌐 Загрузка: %s\n", url)
+	file, err := fetch.Download(d.ctx, url, d.config, dir)
+	if err != nil {
+		return input{}, fmt.Errorf("ошибка загрузки: %w", err)
+	}
+	return input{path: file, rel: filepath.Base(file), url: url}, nil
+}
+
+// cleanup удаляет загруженные файлы
+func (d *downloads) cleanup() {
+	if d.dir != "" {
+		os.RemoveAll(d.dir)
+	}
+}
+
+// expandInputs загружает данные по URL и раскрывает шаблоны путей и, с
+// флагом --recursive, директории во входные файлы
+func expandInputs(args []string, d *downloads) ([]input, error) {
+	seen := make(map[string]bool)
+	var inputs []input
+	add := func(path, rel string) {
+		if !seen[path] {
+			seen[path] = true
+			inputs = append(inputs, input{path: path, rel: rel})
+		}
+	}
+	for _, arg := range args {
+		if fetch.IsURL(arg) {
+			if !seen[arg] {
+				seen[arg] = true
+				in, err := d.fetch(arg)
+				if err != nil {
+					return nil, err
+				}
+				inputs = append(inputs, in)
+			}
+			continue
+		}
+		if err := expandPath(arg, add); err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+// expandPath раскрывает шаблон пути или директорию во входные файлы
+func expandPath(arg string, add func(path, rel string)) error {
+	files, err := fileutil.ExpandGlobs([]string{arg})
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("входной файл не найден: %s", file)
+		}
+		if err != nil || !info.IsDir() {
+			add(file, filepath.Base(file))
+			continue
+		}
+		if !recursive {
+			return fmt.Errorf("%s - директория; для анализа ее файлов укажите флаг --recursive", file)
+		}
+		found, err := fileutil.WalkFiles(file, analyzable)
+		if err != nil {
+			return err
+		}
+		for _, path := range found {
+			rel, err := filepath.Rel(file, path)
+			if err != nil {
+				rel = filepath.Base(path)
+			}
+			add(path, rel)
+		}
+	}
 	return nil
 }
+
+// analyzable сообщает, что файл, найденный при обходе директории,
+// анализируется: это JSON или NDJSON, возможно сжатый, но не схема
+func analyzable(path string) bool {
+	if strings.HasSuffix(path, project.SchemaFileSuffix) {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(decompress.TrimExt(path)))
+	return ext == ".json" || analyzer.IsNDJSON(path)
+}
+
+// schemaPath возвращает путь схемы по умолчанию для входного файла:
+// users.json и users.json.gz дают users.schema.json
+func schemaPath(inputFile string) string {
+	inputFile = decompress.TrimExt(inputFile)
+	ext := filepath.Ext(inputFile)
+	return inputFile[:len(inputFile)-len(ext)] + project.SchemaFileSuffix
+}
+
+// analyzePerFile создает отдельную схему для каждого входного файла: рядом
+// с ним или в директории outputDir с той же структурой поддиректорий
+func analyzePerFile(a *analyzer.Analyzer, inputs []input, outputDir string) error {
+	output.Printf("Анализ файлов по отдельности (%d)\n", len(inputs))
+
+	res := FilesResult{Schemas: make([]FileResult, 0, len(inputs))}
+	var files []string
+	for _, in := range inputs {
+		schemaFile := in.schema()
+		if outputDir != "" {
+			schemaFile = schemaPath(filepath.Join(outputDir, in.rel))
+			if err := os.MkdirAll(filepath.Dir(schemaFile), 0755); err != nil {
+				return fmt.Errorf("ошибка создания директории схем: %w", err)
+			}
+		}
+
+		result, err := a.AnalyzeFile(in.path)
+		if err != nil {
+			return fmt.Errorf("ошибка анализа: %s: %w", in.name(), analyzerflags.Explain(err))
+		}
+		if err := a.SaveSchema(result, schemaFile); err != nil {
+			return fmt.Errorf("ошибка сохранения схемы: %w", err)
+		}
+		signatureFile, err := signing.SignSchema(schemaFile)
+		if err != nil {
+			return fmt.Errorf("ошибка подписи схемы: %w", err)
+		}
+
+		files = append(files, schemaFile)
+		if signatureFile != "" {
+			files = append(files, signatureFile)
+		}
+		res.Schemas = append(res.Schemas, FileResult{
+			Input:     in.name(),
+			Output:    schemaFile,
+			Version:   result.Metadata.Version,
+			Objects:   result.Statistics.TotalObjects,
+			Signature: signatureFile,
+		})
+		output.Printf("   • %s → %s (объектов: %d)\n", in.name(), schemaFile, result.Statistics.TotalObjects)
+		analyzerflags.PrintWarnings(result.Warnings)
+	}
+	output.Printf("Создано схем: %d\n", len(res.Schemas))
+
+	// Автоматический коммит если флаг установлен
+	if autoCommit {
+		if err := autocommit.Commit(files[0], "analyze", files[1:]...); err != nil {
+			output.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
+		} else {
+			res.Committed = true
+			output.Printf("✅ Изменения схемы закоммичены\n")
+		}
+	}
+
+	return output.Result(res)
+}
+
+// printSampling выводит размер выборки записей, по которой построена схема
+func printSampling(sampling *types.Sampling) {
+	if sampling == nil {
+		return
+	}
+	if sampling.Total > 0 {
+		output.Printf("🎲 Выборка: %d из %d записей\n", sampling.Sampled, sampling.Total)
+		return
+	}
+	output.Printf("🎲 Выборка: первые %d записей, остаток входа не читался\n", sampling.Sampled)
+}
+
+// multiple возвращает список входных файлов, если их больше одного
+func multiple(files []string) []string {
+	if len(files) < 2 {
+		return nil
+	}
+	return files
+}
+
+// readGraphQL читает входной файл и распознает в нем ответ GraphQL
+func readGraphQL(a *analyzer.Analyzer, inputFile string) (*graphql.Response, bool, error) {
+	data, err := a.ReadInput(inputFile)
+	if err != nil {
+		return nil, false, fmt.Errorf("ошибка анализа: %w", analyzerflags.Explain(err))
+	}
+
+	// Несколько документов подряд - не ответ GraphQL, а поток записей.
+	// Ошибку разбора сообщит анализ: с --depth-policy truncate он читает и
+	// вход, вложенный глубже, чем разбирает json.Decoder
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var value interface{}
+	if err := dec.Decode(&value); err != nil || dec.More() {
+		return nil, false, nil
+	}
+
+	response, ok := graphql.Split(value)
+	return response, ok, nil
+}
+
+// analyzeGraphQL создает отдельные схемы для операций и ошибок ответа GraphQL
+func analyzeGraphQL(a *analyzer.Analyzer, inputFile, outputFile string, response *graphql.Response) error {
+	output.Printf("🔎 Обнаружен ответ GraphQL: операций %d, ошибок %d\n", len(response.Operations), len(response.Errors))
+
+	res := GraphQLResult{Input: inputFile, Operations: make([]OperationResult, 0, len(response.Operations))}
+	var files []string
+
+	save := func(name string, value interface{}, description string) (*OperationResult, error) {
+		result, err := a.AnalyzeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка анализа %s: %w", name, err)
+		}
+		result.Schema.Description = description
+
+		file := graphQLOutput(outputFile, name)
+		if err := a.SaveSchema(result, file); err != nil {
+			return nil, fmt.Errorf("ошибка сохранения схемы: %w", err)
+		}
+		signatureFile, err := signing.SignSchema(file)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка подписи схемы: %w", err)
+		}
+
+		files = append(files, file)
+		if signatureFile != "" {
+			files = append(files, signatureFile)
+		}
+		return &OperationResult{Name: name, Output: file, Signature: signatureFile}, nil
+	}
+
+	for _, name := range response.OperationNames() {
+		op, err := save(name, response.Operations[name], fmt.Sprintf("GraphQL operation %s (data.%s)", name, name))
+		if err != nil {
+			return err
+		}
+		res.Operations = append(res.Operations, *op)
+		output.Printf("   • %s → %s\n", name, op.Output)
+	}
+
+	if len(response.Errors) > 0 {
+		op, err := save(graphql.KeyErrors, response.Errors, "GraphQL errors")
+		if err != nil {
+			return err
+		}
+		res.Errors = op
+		output.Printf("   • errors → %s\n", op.Output)
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("ответ GraphQL не содержит ни данных операций, ни ошибок")
+	}
+
+	// Автоматический коммит если флаг установлен
+	if autoCommit {
+		if err := autocommit.Commit(files[0], "analyze", files[1:]...); err != nil {
+			output.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
+		} else {
+			res.Committed = true
+			output.Printf("✅ Изменения схемы закоммичены\n")
+		}
+	}
+
+	return output.Result(res)
+}
+
+// graphQLOutput возвращает путь схемы операции: users.schema.json → users.<name>.schema.json
+func graphQLOutput(outputFile, name string) string {
+	suffix := project.SchemaFileSuffix
+	if !strings.HasSuffix(outputFile, suffix) {
+		suffix = filepath.Ext(outputFile)
+	}
+	return strings.TrimSuffix(outputFile, suffix) + "." + name + suffix
+}
+
+// registerSchema добавляет схему в индекс найденного проекта, если он есть
+func registerSchema(name, schemaFile string) (bool, error) {
+	p, err := project.Current()
+	if err != nil || p == nil {
+		return false, err
+	}
+	if err := p.Register(name, schemaFile); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,243 @@
+package analyzerflags
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// Flags связывает флаги команды с настройками анализатора
+type Flags struct {
+	config analyzer.Config
+	cmd    *cobra.Command
+}
+
+// Register добавляет флаги настроек анализатора к команде
+func Register(cmd *cobra.Command) *Flags {
+	f := &Flags{config: analyzer.DefaultConfig(), cmd: cmd}
+
+	cmd.Flags().IntVar(&f.config.MaxArraySamples, "max-array-samples", f.config.MaxArraySamples, "Сколько элементов вложенного массива анализировать для схемы items (0 - все); записи верхнего уровня анализируются все")
+	cmd.Flags().Float64Var(&f.config.RequiredPercent, "required-threshold", f.config.RequiredPercent, "Доля объектов в процентах, в которой поле должно встречаться, чтобы стать обязательным")
+
+	cmd.Flags().BoolVar(&f.config.DetectIntegers, "detect-integers", f.config.DetectIntegers, "Описывать числа без дробной части как integer (--detect-integers=false - все числа как number)")
+	cmd.Flags().BoolVar(&f.config.DetectFormats, "detect-formats", f.config.DetectFormats, "Выводить format строк (date-time, date, time, uuid, ipv4, ipv6, email, uri, hostname)")
+	cmd.Flags().IntVar(&f.config.Examples, "examples", f.config.Examples, "Сколько примеров значений записывать в examples строковых полей (0 - не записывать)")
+	cmd.Flags().IntVar(&f.config.ExampleCardinality, "example-cardinality", f.config.ExampleCardinality, "Число различных значений поля, начиная с которого примеры обезличиваются")
+	cmd.Flags().IntVar(&f.config.EnumThreshold, "enum-threshold", f.config.EnumThreshold, "Выводить enum для строковых полей с не более чем N различными значениями (0 - не выводить)")
+	cmd.Flags().Var(&modeValue{target: &f.config.EnumOrder, parse: analyzer.ParseEnumOrder}, "enum-order", "Порядок значений enum: "+analyzer.EnumAlphabetical+" (по умолчанию), "+analyzer.EnumFirstSeen+" - в порядке появления, "+analyzer.EnumFrequency+" - по убыванию частоты")
+	cmd.Flags().Var(&modeValue{target: &f.config.PropertyOrder, parse: analyzer.ParsePropertyOrder}, "property-order", "Порядок полей объектов в схеме: "+analyzer.PropertyAlphabetical+" (по умолчанию) или "+analyzer.PropertyFirstSeen+" - в порядке появления в данных")
+	cmd.Flags().BoolVar(&f.config.Patterns, "patterns", f.config.Patterns, "Выводить pattern для строковых полей с общей структурой значений (ORD-1234, хеши, slug)")
+	cmd.Flags().IntVar(&f.config.PatternMinSamples, "pattern-min-samples", f.config.PatternMinSamples, "Минимальное число различных значений поля для вывода pattern")
+	cmd.Flags().Var(&modeValue{target: &f.config.LengthMode, parse: analyzer.ParseLengthMode}, "string-lengths", "Выводить minLength/maxLength строк, считая длину в "+analyzer.LengthCodePoints+" или "+analyzer.LengthGraphemes)
+	cmd.Flags().BoolVar(&f.config.ArrayLimits, "array-limits", f.config.ArrayLimits, "Выводить minItems/maxItems по длине массивов и uniqueItems для массивов без повторов")
+	cmd.Flags().Var(&modeValue{target: &f.config.RangeMode, parse: analyzer.ParseRangeMode}, "numeric-ranges", "Выводить диапазон чисел: "+analyzer.RangeConstraint+" - как minimum/maximum, "+analyzer.RangeObserved+" - в x-observed-range")
+
+	cmd.Flags().BoolVar(&f.config.DetectMaps, "detect-maps", f.config.DetectMaps, "Описывать объекты-словари (ключи - id, даты, хеши) схемой значений в patternProperties/additionalProperties")
+	cmd.Flags().IntVar(&f.config.MapMinKeys, "map-min-keys", f.config.MapMinKeys, "Число различных ключей, начиная с которого объект с редкими ключами считается словарем (0 - только ключи-идентификаторы)")
+	cmd.Flags().BoolVar(&f.config.MixedItems, "mixed-items", f.config.MixedItems, "Описывать элементы массива разных типов (строки и объекты) вариантами items.anyOf")
+	cmd.Flags().BoolVar(&f.config.DetectPolymorphic, "detect-polymorphic", f.config.DetectPolymorphic, "Описывать объекты с полем-дискриминатором (type, kind) и разными наборами полей вариантами oneOf")
+	cmd.Flags().BoolVar(&f.config.DetectTuples, "detect-tuples", f.config.DetectTuples, "Описывать короткие массивы с разными типами позиций ([name, count]) как кортежи (items со списком позиций)")
+	cmd.Flags().BoolVar(&f.config.DetectRecursion, "detect-recursion", f.config.DetectRecursion, "Описывать рекурсивные структуры (деревья с children) ссылкой $ref на определение в $defs")
+	cmd.Flags().BoolVar(&f.config.Dedupe, "dedupe", f.config.Dedupe, "Выносить объекты одной формы, встреченные по разным путям (author, editor), в общие определения $defs")
+	cmd.Flags().Float64Var(&f.config.DedupeSimilarity, "dedupe-similarity", f.config.DedupeSimilarity, "Доля общих полей (0..1), начиная с которой объекты считаются одной формой при --dedupe")
+	cmd.Flags().BoolVar(&f.config.Confidence, "confidence", f.config.Confidence, "Записывать полям оценки уверенности в типе, обязательности, enum и формате (x-confidence)")
+	cmd.Flags().IntVar(&f.config.MinSamples, "min-samples", f.config.MinSamples, "Сколько значений поля нужно, чтобы записать в схему required, enum, format и границы; по меньшей выборке они откладываются в x-pending (0 - всегда)")
+	cmd.Flags().BoolVar(&f.config.ClosedObjects, "closed-objects", f.config.ClosedObjects, "Запрещать объектам поля сверх найденных (additionalProperties: false)")
+
+	cmd.Flags().Var(&overridesValue{target: &f.config.Overrides}, "overrides", "JSON файл с принудительными типами и форматами полей по шаблону пути (data.*.id → string)")
+
+	cmd.Flags().BoolVar(&f.config.Stream, "stream", f.config.Stream, "Анализировать файл потоком: записи объединяются по одной, лимит --max-input-size не действует")
+	cmd.Flags().BoolVar(&f.config.Tokenize, "tokenize", f.config.Tokenize, "При потоковом анализе разбирать записи по токенам, не строя дерево значений: быстрее и экономнее на плоских записях")
+	cmd.Flags().BoolVar(&f.config.Auto, "auto", f.config.Auto, "Выбрать потоковый режим и выборку элементов массивов по размеру и форме входного файла")
+	cmd.Flags().Var(&sizeValue{target: &f.config.MaxInputSize}, "max-input-size", "Предел размера входного файла (512MB, 2GB; 0 - без ограничения)")
+	cmd.Flags().IntVar(&f.config.MaxRecords, "max-records", f.config.MaxRecords, "Предел числа записей верхнего уровня во входных данных (0 - без ограничения)")
+	cmd.Flags().IntVar(&f.config.MaxDepth, "max-depth", f.config.MaxDepth, "Предел вложенности объектов и массивов (0 - без ограничения)")
+	cmd.Flags().Var(&modeValue{target: &f.config.DepthPolicy, parse: analyzer.ParseDepthPolicy}, "depth-policy", "Что делать с вложенностью глубже --max-depth: "+analyzer.DepthError+" (по умолчанию) - завершить анализ ошибкой, "+analyzer.DepthTruncate+" - описать глубокие объекты и массивы только типом с отметкой x-truncated")
+	cmd.Flags().IntVar(&f.config.SampleRecords, "sample-records", f.config.SampleRecords, "Анализировать только первые N записей верхнего уровня и не читать вход дальше (0 - все записи)")
+	cmd.Flags().Var(&rateValue{target: &f.config.SampleRate}, "sample-rate", "Анализировать случайную долю записей верхнего уровня (0.01 - каждую сотую в среднем)")
+	cmd.Flags().Int64Var(&f.config.SampleSeed, "sample-seed", f.config.SampleSeed, "Начальное значение генератора случайной выборки --sample-rate; при одном значении выборка повторяется")
+	cmd.Flags().Var(&modeValue{target: &f.config.Format, parse: analyzer.ParseFormat}, "format", "Формат входных файлов: "+analyzer.FormatJSON+", "+analyzer.FormatCSV+" - таблица с заголовком, "+analyzer.FormatYAML+", "+analyzer.FormatXML+" или "+analyzer.FormatLog+" - журнал со строками JSON (по умолчанию - по расширению файла)")
+	cmd.Flags().Var(&delimiterValue{target: &f.config.CSVDelimiter}, "csv-delimiter", "Разделитель значений CSV: один символ или tab (по умолчанию - запятая)")
+	cmd.Flags().StringVar(&f.config.LogPrefix, "log-prefix", "", "Текст в строке журнала, после которого начинается JSON (по умолчанию - первый объект {...} строки)")
+	cmd.Flags().IntVar(&f.config.Workers, "workers", f.config.Workers, "Сколько файлов или пакетов записей NDJSON анализировать параллельно (0 - по числу процессоров, 1 - последовательно)")
+
+	return f
+}
+
+// Explain дополняет ошибку превышения лимита входных данных подсказкой,
+// как проанализировать такие данные; прочие ошибки возвращаются как есть
+func Explain(err error) error {
+	var limitErr *analyzer.LimitError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+	switch limitErr.Kind {
+	case analyzer.LimitRecords:
+		return fmt.Errorf("%w. Увеличьте лимит флагом --max-records (0 - без ограничения), проанализируйте выборку флагами --sample-records или --sample-rate или разбейте данные на части и дополните схему командой update", err)
+	case analyzer.LimitDepth:
+		return fmt.Errorf("%w. Увеличьте лимит флагом --max-depth (0 - без ограничения) или опишите глубокие уровни без структуры флагом --depth-policy %s", err, analyzer.DepthTruncate)
+	}
+	return fmt.Errorf("%w. Проанализируйте файл потоком с флагом --stream, разбейте его на части и дополните схему командой update или увеличьте лимит флагом --max-input-size, если памяти достаточно (0 - без ограничения)", err)
+}
+
+// PrintWarnings выводит предупреждения анализа о вложенных массивах, элементы
+// которых проанализированы не все, с подсказкой, как учесть все элементы
+func PrintWarnings(warnings []string) {
+	for _, warning := range warnings {
+		output.Printf("⚠️ %s\n", warning)
+	}
+	if len(warnings) > 0 {
+		output.Printf("   Поля, которые есть только в последующих элементах, могли не попасть в схему. Проанализировать все элементы: --max-array-samples 0\n")
+	}
+}
+
+// Config возвращает настройки анализатора с учетом флагов
+func (f *Flags) Config() analyzer.Config {
+	return f.config
+}
+
+// New создает анализатор с настройками из флагов
+func (f *Flags) New() *analyzer.Analyzer {
+	return analyzer.NewWithConfig(f.config)
+}
+
+// ForSchema создает анализатор для обновления схемы: настройки, сохраненные в
+// метаданных схемы (--min-samples, --enum-order, --property-order, обрезка
+// вложенности --max-depth с --depth-policy truncate), действуют, если флаг не
+// задан явно
+func (f *Flags) ForSchema(meta *types.AnalysisMetadata) *analyzer.Analyzer {
+	config := f.config
+	if meta != nil && !f.cmd.Flags().Changed("min-samples") {
+		config.MinSamples = meta.MinSamples
+	}
+	if meta != nil && !f.cmd.Flags().Changed("enum-order") {
+		config.EnumOrder = meta.EnumOrder
+	}
+	if meta != nil && !f.cmd.Flags().Changed("property-order") {
+		config.PropertyOrder = meta.PropertyOrder
+	}
+	if meta != nil && meta.MaxDepth > 0 && !f.cmd.Flags().Changed("max-depth") && !f.cmd.Flags().Changed("depth-policy") {
+		config.MaxDepth, config.DepthPolicy = meta.MaxDepth, analyzer.DepthTruncate
+	}
+	return analyzer.NewWithConfig(config)
+}
+
+// modeValue - значение флага режима с проверкой через parse
+type modeValue struct {
+	target *string
+	parse  func(string) (string, error)
+}
+
+func (m *modeValue) String() string {
+	if m.target == nil {
+		return ""
+	}
+	return *m.target
+}
+
+func (m *modeValue) Type() string { return "mode" }
+
+func (m *modeValue) Set(value string) error {
+	mode, err := m.parse(value)
+	if err != nil {
+		return err
+	}
+	*m.target = mode
+	return nil
+}
+
+// sizeValue - значение флага размера в байтах с суффиксами KB, MB, GB
+type sizeValue struct {
+	target *int64
+}
+
+func (v *sizeValue) String() string {
+	if v.target == nil || *v.target == 0 {
+		return "0"
+	}
+	return analyzer.FormatSize(*v.target)
+}
+
+func (v *sizeValue) Type() string { return "size" }
+
+func (v *sizeValue) Set(value string) error {
+	size, err := analyzer.ParseSize(value)
+	if err != nil {
+		return err
+	}
+	*v.target = size
+	return nil
+}
+
+// rateValue - значение флага доли выборки записей
+type rateValue struct {
+	target *float64
+}
+
+func (v *rateValue) String() string {
+	if v.target == nil || *v.target == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(*v.target, 'g', -1, 64)
+}
+
+func (v *rateValue) Type() string { return "rate" }
+
+func (v *rateValue) Set(value string) error {
+	rate, err := analyzer.ParseSampleRate(value)
+	if err != nil {
+		return err
+	}
+	*v.target = rate
+	return nil
+}
+
+// delimiterValue - значение флага разделителя CSV
+type delimiterValue struct {
+	target *rune
+}
+
+func (v *delimiterValue) String() string {
+	switch {
+	case v.target == nil || *v.target == 0:
+		return ""
+	case *v.target == '\t':
+		return "tab"
+	}
+	return string(*v.target)
+}
+
+func (v *delimiterValue) Type() string { return "char" }
+
+func (v *delimiterValue) Set(value string) error {
+	delimiter, err := analyzer.ParseCSVDelimiter(value)
+	if err != nil {
+		return err
+	}
+	*v.target = delimiter
+	return nil
+}
+
+// overridesValue - флаг с путем к файлу переопределений, который читается при разборе флагов
+type overridesValue struct {
+	target *map[string]types.Override
+	file   string
+}
+
+func (v *overridesValue) String() string { return v.file }
+
+func (v *overridesValue) Type() string { return "file" }
+
+func (v *overridesValue) Set(value string) error {
+	overrides, err := analyzer.LoadOverrides(value)
+	if err != nil {
+		return err
+	}
+	v.file, *v.target = value, overrides
+	return nil
+}
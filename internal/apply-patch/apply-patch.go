@@ -0,0 +1,269 @@
+package applypatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/autocommit"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/changelog"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/jsonpatch"
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
+)
+
+// Форматы патчей
+const (
+	FormatAuto       = "auto"
+	FormatJSONPatch  = "json-patch"
+	FormatMergePatch = "merge-patch"
+)
+
+var (
+	patchFormat string
+	dryRun      bool
+	autoCommit  bool
+	changeLog   bool
+)
+
+// Result представляет результат команды apply-patch в режиме --json
+type Result struct {
+	Schema          string                      `json:"schema"`
+	Patch           string                      `json:"patch"`
+	Format          string                      `json:"format"`
+	Applied         bool                        `json:"applied"`
+	PreviousVersion string                      `json:"previous_version"`
+	Version         string                      `json:"version"`
+	Bump            string                      `json:"bump"`
+	Changes         []compat.Change             `json:"changes"`
+	Errors          []validator.ValidationError `json:"errors,omitempty"`
+	Changelog       string                      `json:"changelog,omitempty"`
+	Signature       string                      `json:"signature,omitempty"`
+	Committed       bool                        `json:"committed"`
+}
+
+// Cmd представляет команду apply-patch
+var Cmd = &cobra.Command{
+	Use:   "apply-patch [schema.json] [patch.json]",
+	Short: "Применяет JSON Patch или JSON Merge Patch к схеме",
+	Long: `Применяет к схеме патч в стандартном формате:
+- JSON Patch (RFC 6902) — массив операций add/remove/replace/move/copy/test
+- JSON Merge Patch (RFC 7386) — объект, который накладывается на схему
+
+Формат определяется автоматически: массив считается JSON Patch, объект — Merge Patch.
+Перед сохранением результат проверяется на соответствие мета-схеме, а версия
+схемы повышается согласно характеру изменений.
+
+Примеры использования:
+  apply-patch users.schema.json changes.json
+  apply-patch users changes.json --format merge-patch
+  apply-patch users changes.json --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: runApplyPatch,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&patchFormat, "format", "f", FormatAuto, "Формат патча (auto, json-patch, merge-patch)")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Проверить и показать результат без сохранения")
+	Cmd.Flags().BoolVarP(&autoCommit, "auto-commit", "a", false, "Автоматический коммит изменений схемы")
+	Cmd.Flags().BoolVar(&changeLog, "changelog", false, "Дописать запись в файл истории изменений рядом со схемой")
+}
+
+func runApplyPatch(cmd *cobra.Command, args []string) error {
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
+	patchFile := args[1]
+
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+
+	schemaContent, err := fileutil.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения схемы: %w", err)
+	}
+	patchContent, err := fileutil.ReadFile(patchFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения патча: %w", err)
+	}
+
+	format, err := detectFormat(patchContent, patchFormat)
+	if err != nil {
+		return err
+	}
+
+	output.Printf("🩹 Применение патча к схеме\n")
+	output.Printf("📄 Файл схемы: %s\n", schemaFile)
+	output.Printf("📝 Файл патча: %s (%s)\n", patchFile, format)
+	output.Println()
+
+	// Загружаем исходную схему для определения уровня изменения версии
+	analyzer := analyzer.New()
+	previous, err := analyzer.LoadSchemaBytes(schemaContent)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы: %w", err)
+	}
+
+	// Применяем патч к исходному JSON документу
+	var patched []byte
+	switch format {
+	case FormatJSONPatch:
+		patch, err := jsonpatch.Parse(patchContent)
+		if err != nil {
+			return err
+		}
+		patched, err = patch.ApplyBytes(schemaContent)
+		if err != nil {
+			return fmt.Errorf("ошибка применения JSON Patch: %w", err)
+		}
+	case FormatMergePatch:
+		patched, err = jsonpatch.MergePatchBytes(schemaContent, patchContent)
+		if err != nil {
+			return fmt.Errorf("ошибка применения JSON Merge Patch: %w", err)
+		}
+	}
+
+	res := Result{
+		Schema:          schemaFile,
+		Patch:           patchFile,
+		Format:          format,
+		PreviousVersion: previous.Metadata.Version,
+		Version:         previous.Metadata.Version,
+		Bump:            compat.BumpNone.String(),
+		Changes:         []compat.Change{},
+	}
+
+	// Проверяем результат на соответствие мета-схеме до загрузки в модель
+	check, err := validator.New(false).ValidateSchemaBytes(patched)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки схемы: %w", err)
+	}
+	if !check.Valid {
+		output.Printf("❌ Результат не соответствует мета-схеме, схема не изменена:\n")
+		for _, e := range check.Errors {
+			output.Printf("   • %s: %s\n", e.Field, e.Description)
+		}
+		res.Errors = check.Errors
+		if err := output.Result(res); err != nil {
+			return err
+		}
+		return fmt.Errorf("патч приводит к некорректной схеме")
+	}
+
+	schema, err := analyzer.LoadSchemaBytes(patched)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки результата: %w", err)
+	}
+	for _, warning := range schema.Warnings {
+		output.Printf("⚠️ %s\n", warning)
+	}
+
+	// Повышаем версию схемы согласно характеру изменений
+	report, oldVersion, err := compat.StampVersion(previous.Schema, schema)
+	if err != nil {
+		return fmt.Errorf("ошибка определения версии схемы: %w", err)
+	}
+	res.PreviousVersion = oldVersion
+	res.Version = schema.Metadata.Version
+	res.Bump = report.Bump.String()
+	res.Changes = report.Changes
+
+	output.Printf("🔍 Изменений: %d\n", len(report.Changes))
+	for _, change := range report.Changes {
+		output.Printf("   • %s\n", changelog.Describe(change))
+	}
+
+	if dryRun {
+		output.Printf("💡 Режим --dry-run: схема не сохранена\n")
+		if report.Bump != compat.BumpNone {
+			output.Printf("🏷️ Версия схемы будет: %s → %s (%s)\n", oldVersion, schema.Metadata.Version, report.Bump)
+		}
+		return output.Result(res)
+	}
+
+	if err := analyzer.SaveSchema(schema, schemaFile); err != nil {
+		return fmt.Errorf("ошибка сохранения схемы: %w", err)
+	}
+	res.Applied = true
+
+	// Подписываем схему, если настроен ключ подписи
+	signatureFile, err := signing.SignSchema(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+	res.Signature = signatureFile
+
+	output.Printf("✅ Патч применен: %s\n", schemaFile)
+	if report.Bump != compat.BumpNone {
+		output.Printf("🏷️ Версия схемы: %s → %s (%s)\n", oldVersion, schema.Metadata.Version, report.Bump)
+	}
+
+	// Записываем историю изменений если флаг установлен
+	var changedFiles []string
+	if changeLog && len(report.Changes) > 0 {
+		path, err := changelog.Append(schemaFile, &changelog.Entry{
+			Date:    time.Now(),
+			Version: schema.Metadata.Version,
+			Bump:    report.Bump,
+			Source:  "apply-patch " + filepath.Base(patchFile),
+			Changes: report.Changes,
+		})
+		if err != nil {
+			return err
+		}
+		changedFiles = append(changedFiles, path)
+		res.Changelog = path
+		output.Printf("📝 История изменений дополнена: %s\n", path)
+	}
+
+	if signatureFile != "" {
+		changedFiles = append(changedFiles, signatureFile)
+	}
+
+	// Автоматический коммит если флаг установлен
+	if autoCommit {
+		if err := autocommit.Commit(schemaFile, "apply-patch", changedFiles...); err != nil {
+			output.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
+		} else {
+			res.Committed = true
+			output.Printf("✅ Изменения схемы закоммичены\n")
+		}
+	}
+
+	return output.Result(res)
+}
+
+// detectFormat определяет формат патча по флагу или по содержимому
+func detectFormat(patch []byte, requested string) (string, error) {
+	switch requested {
+	case FormatJSONPatch, FormatMergePatch:
+		return requested, nil
+	case FormatAuto, "":
+	default:
+		return "", fmt.Errorf("неподдерживаемый формат патча: %s. Доступные: %s, %s, %s", requested, FormatAuto, FormatJSONPatch, FormatMergePatch)
+	}
+
+	trimmed := bytes.TrimSpace(patch)
+	if !json.Valid(trimmed) {
+		return "", fmt.Errorf("файл патча не является корректным JSON")
+	}
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return FormatJSONPatch, nil
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatMergePatch, nil
+	default:
+		return "", fmt.Errorf("патч должен быть массивом операций (RFC 6902) или объектом (RFC 7386)")
+	}
+}
@@ -0,0 +1,32 @@
+package autocommit
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// Commit выполняет автоматический коммит изменений схемы (флаг
+// --auto-commit): добавляет в git файл схемы и extraFiles и создает коммит
+// "schema: <operation> <имя схемы>"
+func Commit(schemaFile, operation string, extraFiles ...string) error {
+	// Проверяем, что мы в git репозитории
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git не найден")
+	}
+
+	// Добавляем файлы схем в git
+	cmd := exec.Command("git", append([]string{"add", schemaFile}, extraFiles...)...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ошибка git add: %w", err)
+	}
+
+	// Создаем коммит
+	commitMessage := fmt.Sprintf("schema: %s %s", operation, filepath.Base(schemaFile))
+	cmd = exec.Command("git", "commit", "-m", commitMessage)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ошибка git commit: %w", err)
+	}
+
+	return nil
+}
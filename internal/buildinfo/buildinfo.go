@@ -0,0 +1,29 @@
+package buildinfo
+
+import "runtime/debug"
+
+// Version задается при сборке релиза:
+//
+//	go build -ldflags "-X github.com/yanodincov/json-schema-detector/internal/buildinfo.Version=v1.2.3"
+var Version = ""
+
+// ReleaseKey - открытый ключ подписи релизов (строка base64 из файла .pub
+// команды keygen), которым self-update проверяет подпись контрольных сумм.
+// Задается при сборке релиза:
+//
+//	go build -ldflags "-X github.com/yanodincov/json-schema-detector/internal/buildinfo.ReleaseKey=RW..."
+var ReleaseKey = ""
+
+// Repository - GitHub репозиторий, из которого публикуются релизы
+const Repository = "yanodincov/json-schema-detector"
+
+// CurrentVersion возвращает версию текущей сборки. Для go install берется версия модуля.
+func CurrentVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
@@ -0,0 +1,118 @@
+package bundlecmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/buildinfo"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/bundle"
+)
+
+var (
+	outputFile    string
+	bundleName    string
+	bundleVersion string
+	defs          []string
+)
+
+// Result представляет результат команды bundle в режиме --json
+type Result struct {
+	Output   string          `json:"output"`
+	Manifest bundle.Manifest `json:"manifest"`
+}
+
+// Cmd представляет команду bundle
+var Cmd = &cobra.Command{
+	Use:   "bundle [schema...]",
+	Short: "Упаковывает схемы в версионированный архив для публикации",
+	Long: `Собирает выбранные схемы, их историю изменений и подписи, общие определения
+и манифест index.json в архив tar.gz, пригодный для публикации в реестр артефактов.
+
+Без аргументов в архив попадают все зарегистрированные схемы и схемы
+из директории схем проекта. Общие определения добавляются флагом --defs
+(файл или директория) и размещаются в каталоге defs/ архива.
+
+Примеры использования:
+  bundle -o contracts.tar.gz
+  bundle users orders --version 1.4.0 -o contracts-1.4.0.tar.gz
+  bundle --defs schemas/common --name payments-contracts`,
+	RunE: runBundle,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл архива (по умолчанию <name>-<version>.tar.gz)")
+	Cmd.Flags().StringVar(&bundleName, "name", "contracts", "Имя архива в манифесте")
+	Cmd.Flags().StringVar(&bundleVersion, "version", "", "Версия архива (по умолчанию дата и время сборки)")
+	Cmd.Flags().StringSliceVar(&defs, "defs", nil, "Файлы или директории с общими определениями")
+}
+
+func runBundle(cmd *cobra.Command, args []string) error {
+	schemas, err := project.SelectSchemas(args)
+	if err != nil {
+		return err
+	}
+	if len(schemas) == 0 {
+		return fmt.Errorf("не найдено ни одной схемы для упаковки")
+	}
+
+	version := bundleVersion
+	if version == "" {
+		version = time.Now().UTC().Format("2006.01.02-150405")
+	}
+	archive := outputFile
+	if archive == "" {
+		archive = fmt.Sprintf("%s-%s.tar.gz", bundleName, version)
+	}
+
+	output.Printf("📦 Сборка архива схем: %s %s\n", bundleName, version)
+
+	b := bundle.New(bundleName, version)
+	b.Manifest.Generator = "json-schema-detector " + buildinfo.CurrentVersion()
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := b.AddSchema(name, schemas[name]); err != nil {
+			return err
+		}
+		output.Printf("   • %s (%s)\n", name, schemas[name])
+	}
+
+	for _, def := range defs {
+		info, err := os.Stat(def)
+		if err != nil {
+			return fmt.Errorf("общие определения не найдены: %s", def)
+		}
+		if info.IsDir() {
+			err = b.AddDir(bundle.DefsDir, def)
+		} else {
+			err = b.AddFile(path.Join(bundle.DefsDir, filepath.Base(def)), def)
+		}
+		if err != nil {
+			return err
+		}
+		output.Printf("   • общие определения: %s\n", def)
+	}
+
+	if err := b.WriteFile(archive); err != nil {
+		return err
+	}
+
+	output.Printf("✅ Архив создан: %s (схем: %d, файлов: %d)\n", archive, len(b.Manifest.Schemas), len(b.Manifest.Files)+1)
+
+	return output.Result(Result{
+		Output:   archive,
+		Manifest: b.Manifest,
+	})
+}
@@ -0,0 +1,114 @@
+package checkcompat
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/exitcode"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+)
+
+var verbose bool
+
+// Result представляет результат команды check-compat в режиме --json
+type Result struct {
+	Old        string `json:"old"`
+	New        string `json:"new"`
+	Compatible bool   `json:"compatible"`
+	*compat.Report
+}
+
+// Cmd представляет команду check-compat
+var Cmd = &cobra.Command{
+	Use:   "check-compat [old.schema.json] [new.schema.json]",
+	Short: "Проверяет обратную совместимость двух версий схемы",
+	Long: `Сравнивает две версии JSON Schema, перечисляет изменения и определяет
+необходимый уровень повышения версии:
+- patch - изменились описания или default значения
+- minor - добавлены необязательные поля или значения enum
+- major - удалены поля, изменены типы, изменен список required
+
+Команда завершается с кодом 1, если найдены ломающие изменения.
+
+Примеры использования:
+  check-compat schema.v1.json schema.v2.json
+  check-compat schema.v1.json schema.v2.json --verbose`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCheckCompat,
+}
+
+func init() {
+	Cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Показать все изменения, включая совместимые")
+}
+
+func runCheckCompat(cmd *cobra.Command, args []string) error {
+	files := make([]string, len(args))
+	for i, arg := range args {
+		file, err := project.ResolveSchema(arg)
+		if err != nil {
+			return err
+		}
+
+		// Проверяем существование файлов
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return fmt.Errorf("файл схемы не найден: %s", file)
+		}
+		files[i] = file
+	}
+	oldFile, newFile := files[0], files[1]
+
+	analyzer := analyzer.New()
+
+	oldResult, err := analyzer.LoadSchema(oldFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы %s: %w", oldFile, err)
+	}
+
+	newResult, err := analyzer.LoadSchema(newFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы %s: %w", newFile, err)
+	}
+
+	report := compat.Compare(oldResult.Schema, newResult.Schema)
+
+	output.Printf("🔍 Проверка совместимости: %s → %s\n", oldFile, newFile)
+	output.Printf("📊 Изменений: %d, уровень версии: %s\n", len(report.Changes), report.Bump)
+	output.Println()
+
+	for _, change := range report.Changes {
+		if !verbose && !change.Breaking() {
+			continue
+		}
+
+		marker := output.Marker("✅", "ok")
+		if change.Breaking() {
+			marker = output.Marker("❌", "breaking")
+		}
+		output.Printf("%s %s: %s", marker, change.Path, change.Kind)
+		if change.Details != "" {
+			output.Printf(" (%s)", change.Details)
+		}
+		output.Println()
+	}
+
+	res := Result{Old: oldFile, New: newFile, Compatible: report.Compatible(), Report: report}
+
+	if !report.Compatible() {
+		if err := output.Result(res); err != nil {
+			return err
+		}
+
+		output.Println()
+		output.Printf("❌ Найдены ломающие изменения: %d\n", len(report.Breaking()))
+
+		// Возвращаем код ошибки для CI/CD
+		return exitcode.Fail(cmd, 1)
+	}
+
+	output.Printf("✅ Изменения обратно совместимы\n")
+	return output.Result(res)
+}
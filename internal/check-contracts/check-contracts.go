@@ -0,0 +1,150 @@
+package checkcontracts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/exitcode"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/contract"
+)
+
+var contractsDir string
+
+// Result представляет результат команды check-contracts в режиме --json
+type Result struct {
+	Schema     string               `json:"schema"`
+	Proposed   string               `json:"proposed"`
+	Contracts  []string             `json:"contracts"`
+	Compatible bool                 `json:"compatible"`
+	Violations []contract.Violation `json:"violations"`
+}
+
+// Cmd представляет команду check-contracts
+var Cmd = &cobra.Command{
+	Use:   "check-contracts [schema] [proposed.schema.json]",
+	Short: "Проверяет схему против контрактов потребителей",
+	Long: `Проверяет, что предлагаемая версия схемы не нарушает контракты потребителей.
+
+Контракт потребителя - файл <consumer>` + contract.FileSuffix + ` в директории контрактов
+проекта (по умолчанию ` + project.DefaultContractsDir + `/ в корне проекта или contracts_dir
+в ` + project.ConfigFileName + `), в котором команда-потребитель перечисляет поля,
+от которых она зависит:
+
+  {
+    "consumer": "billing",
+    "schema": "users",
+    "fields": [
+      {"path": "data.0.id", "type": "number", "required": true},
+      {"path": "data.0.role", "type": "string", "enum": ["admin", "user"]}
+    ]
+  }
+
+Для каждого поля проверяется, что оно осталось в схеме, не сменило тип,
+осталось обязательным (required) и что в enum не появились значения,
+неизвестные потребителю. Без второго аргумента проверяется текущая схема.
+Команда завершается с кодом 1, если найдены нарушения.
+
+Примеры использования:
+  check-contracts users
+  check-contracts users proposed/users.schema.json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runCheckContracts,
+}
+
+func init() {
+	Cmd.Flags().StringVar(&contractsDir, "contracts", "", "Директория контрактов потребителей")
+}
+
+func runCheckContracts(cmd *cobra.Command, args []string) error {
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
+	name := args[0]
+	if !project.IsSchemaName(name) {
+		name = schemaName(schemaFile)
+	}
+
+	proposedFile := schemaFile
+	if len(args) == 2 {
+		proposedFile = args[1]
+	}
+	if _, err := os.Stat(proposedFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", proposedFile)
+	}
+
+	dir := contractsDir
+	if dir == "" {
+		if dir, err = project.ResolveContractsDir(); err != nil {
+			return err
+		}
+	}
+
+	all, err := contract.LoadDir(dir)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки контрактов: %w", err)
+	}
+	contracts := contract.ForSchema(all, name)
+
+	output.Printf("🤝 Проверка контрактов потребителей схемы %s\n", name)
+	output.Printf("📄 Схема: %s\n", proposedFile)
+	output.Printf("📁 Контракты: %s (найдено: %d)\n", dir, len(contracts))
+
+	schema, err := analyzer.New().LoadSchema(proposedFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы: %w", err)
+	}
+
+	res := Result{
+		Schema:     name,
+		Proposed:   proposedFile,
+		Contracts:  make([]string, 0, len(contracts)),
+		Violations: make([]contract.Violation, 0),
+	}
+	for _, c := range contracts {
+		res.Contracts = append(res.Contracts, c.Consumer)
+		violations := c.Check(schema.Schema)
+		if len(violations) == 0 {
+			output.Printf("   ✅ %s (%d полей)\n", c.Consumer, len(c.Fields))
+			continue
+		}
+		output.Printf("   ❌ %s\n", c.Consumer)
+		for _, v := range violations {
+			output.Printf("      • %s: %s\n", v.Path, v.Reason)
+		}
+		res.Violations = append(res.Violations, violations...)
+	}
+	res.Compatible = len(res.Violations) == 0
+
+	if len(contracts) == 0 {
+		output.Printf("💡 Для схемы %s не объявлено ни одного контракта\n", name)
+	}
+
+	if !res.Compatible {
+		output.Printf("\n❌ Нарушено требований: %d\n", len(res.Violations))
+		if err := output.Result(res); err != nil {
+			return err
+		}
+
+		// Возвращаем код ошибки для CI/CD
+		return exitcode.Fail(cmd, 1)
+	}
+
+	output.Printf("✅ Контракты потребителей не нарушены\n")
+	return output.Result(res)
+}
+
+// schemaName возвращает имя схемы по имени файла
+func schemaName(schemaFile string) string {
+	base := filepath.Base(schemaFile)
+	if strings.HasSuffix(base, project.SchemaFileSuffix) {
+		return strings.TrimSuffix(base, project.SchemaFileSuffix)
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
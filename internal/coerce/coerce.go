@@ -0,0 +1,121 @@
+package coerce
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
+)
+
+var (
+	outputFile  string
+	types       bool
+	defaults    bool
+	dropUnknown bool
+	verbose     bool
+)
+
+// Result представляет результат команды coerce в режиме --json
+type Result struct {
+	Data   string `json:"data"`
+	Schema string `json:"schema"`
+	Output string `json:"output"`
+	*validator.CoerceResult
+}
+
+// Cmd представляет команду coerce
+var Cmd = &cobra.Command{
+	Use:   "coerce [data.json] [schema.json]",
+	Short: "Приводит данные к схеме безопасными исправлениями",
+	Long: `Исправляет документ JSON по схеме, чтобы устаревшие данные соответствовали
+контракту, и записывает результат в отдельный файл:
+- значения, тип которых однозначно приводится к ожидаемому ("42" → 42,
+  "true" → true, 42 → "42"), приводятся к нему
+- отсутствующие поля, у которых в схеме есть default, добавляются, в том
+  числе необязательные
+- поля, не описанные схемой (нет в properties, не подходят под
+  patternProperties, additionalProperties не задает схему), удаляются
+
+Каждое исправление отключается своим флагом. Порядок полей сохраняется.
+После исправлений документ проверяется по схеме, и оставшиеся ошибки
+выводятся: их нужно исправить вручную.
+
+Примеры использования:
+  coerce legacy.json users.schema.json -o fixed.json
+  coerce legacy.json users -o fixed.json --drop-unknown=false`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCoerce,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл для исправленных данных")
+	Cmd.Flags().BoolVar(&types, "types", true, "Приводить значения к типу схемы (--types=false - не приводить)")
+	Cmd.Flags().BoolVar(&defaults, "defaults", true, "Добавлять отсутствующие поля со значением default (--defaults=false - не добавлять)")
+	Cmd.Flags().BoolVar(&dropUnknown, "drop-unknown", true, "Удалять поля, не описанные схемой (--drop-unknown=false - оставлять)")
+	Cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Выводить каждое исправление")
+	Cmd.MarkFlagRequired("output")
+}
+
+func runCoerce(cmd *cobra.Command, args []string) error {
+	dataFile := args[0]
+	schemaFile, err := project.ResolveSchema(args[1])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dataFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл данных не найден: %s", dataFile)
+	}
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+
+	output.Printf("Исправление данных: %s\n", dataFile)
+	output.Printf("По схеме: %s\n", schemaFile)
+
+	opts := validator.CoerceOptions{Types: types, Defaults: defaults, DropUnknown: dropUnknown}
+	result, err := validator.New(false).CoerceFile(dataFile, schemaFile, opts)
+	if err != nil {
+		return fmt.Errorf("ошибка исправления данных: %w", err)
+	}
+	if err := fileutil.WriteFile(outputFile, result.Data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи данных: %w", err)
+	}
+
+	printFixes(result.Fixes)
+	output.Printf("💾 Исправленные данные: %s\n", outputFile)
+	if result.Remaining.Valid {
+		output.Printf("✅ Данные соответствуют схеме\n")
+	} else {
+		output.Printf("⚠️ После исправлений осталось ошибок: %d\n", len(result.Remaining.Errors))
+		for i, e := range result.Remaining.Errors {
+			output.Printf("  %d. %s: %s\n", i+1, e.Field, e.Description)
+		}
+	}
+
+	return output.Result(Result{Data: dataFile, Schema: schemaFile, Output: outputFile, CoerceResult: result})
+}
+
+// printFixes выводит число исправлений каждого вида, с --verbose - каждое
+func printFixes(fixes []validator.Fix) {
+	if len(fixes) == 0 {
+		output.Printf("🔧 Исправлений не потребовалось\n")
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, fix := range fixes {
+		counts[fix.Reason]++
+	}
+	output.Printf("🔧 Исправлений: %d (типы: %d, значения по умолчанию: %d, удалено полей: %d)\n",
+		len(fixes), counts[validator.FixCoerce], counts[validator.FixDefault], counts[validator.FixDrop])
+	if !verbose {
+		return
+	}
+	for _, fix := range fixes {
+		output.Printf("   %s: %s\n", fix.Field, fix.Description())
+	}
+}
@@ -0,0 +1,155 @@
+package compareenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/analyzerflags"
+	"github.com/yanodincov/json-schema-detector/internal/exitcode"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+)
+
+var failOnDrift bool
+
+// Environment описывает выборку данных одного окружения
+type Environment struct {
+	Name  string `json:"name"`
+	Input string `json:"input"`
+	Total int    `json:"total_objects"`
+}
+
+// Drift описывает структурные различия окружения относительно базового
+type Drift struct {
+	Baseline    string          `json:"baseline"`
+	Environment string          `json:"environment"`
+	Changes     []compat.Change `json:"changes"`
+}
+
+// Result представляет результат команды compare-env в режиме --json
+type Result struct {
+	Environments []Environment `json:"environments"`
+	Drifts       []Drift       `json:"drifts"`
+	Consistent   bool          `json:"consistent"`
+}
+
+// Cmd представляет команду compare-env
+var Cmd = &cobra.Command{
+	Use:   "compare-env [env=]input.json [env=]input.json...",
+	Short: "Сравнивает структуру данных одного источника в разных окружениях",
+	Long: `Анализирует выборки одного и того же эндпоинта или топика, снятые в разных
+окружениях (например, staging и prod), и показывает структурные различия
+выведенных схем: отсутствующие поля, расхождение типов, required и enum.
+
+Первое окружение считается базовым, остальные сравниваются с ним. Имя окружения
+задается префиксом env=, иначе используется имя файла. Различия в описаниях
+и default значениях не считаются расхождением.
+
+Примеры использования:
+  compare-env staging=staging-users.json prod=prod-users.json
+  compare-env dev.json staging.json prod.json --fail-on-drift`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runCompareEnv,
+}
+
+func init() {
+	Cmd.Flags().BoolVar(&failOnDrift, "fail-on-drift", false, "Завершиться с кодом 1, если найдены расхождения")
+}
+
+func runCompareEnv(cmd *cobra.Command, args []string) error {
+	analyzer := analyzer.New()
+
+	environments := make([]Environment, 0, len(args))
+
+	for _, arg := range args {
+		name, input := parseEnvironment(arg)
+		if _, err := os.Stat(input); os.IsNotExist(err) {
+			return fmt.Errorf("входной файл не найден: %s", input)
+		}
+		environments = append(environments, Environment{Name: name, Input: input})
+	}
+
+	output.Printf("🌍 Сравнение окружений: %s\n", strings.Join(environmentNames(environments), ", "))
+	output.Println()
+
+	baseline, err := analyzer.AnalyzeFile(environments[0].Input)
+	if err != nil {
+		return fmt.Errorf("ошибка анализа окружения %s: %w", environments[0].Name, analyzerflags.Explain(err))
+	}
+	environments[0].Total = baseline.Statistics.TotalObjects
+
+	res := Result{Drifts: make([]Drift, 0), Consistent: true}
+	for i := 1; i < len(environments); i++ {
+		env := &environments[i]
+		result, err := analyzer.AnalyzeFile(env.Input)
+		if err != nil {
+			return fmt.Errorf("ошибка анализа окружения %s: %w", env.Name, analyzerflags.Explain(err))
+		}
+		env.Total = result.Statistics.TotalObjects
+
+		changes := structuralChanges(compat.Compare(baseline.Schema, result.Schema))
+		drift := Drift{Baseline: environments[0].Name, Environment: env.Name, Changes: changes}
+		res.Drifts = append(res.Drifts, drift)
+
+		if len(changes) == 0 {
+			output.Printf("✅ %s → %s: структура совпадает\n", drift.Baseline, drift.Environment)
+			continue
+		}
+
+		res.Consistent = false
+		output.Printf("⚠️ %s → %s: расхождений %d\n", drift.Baseline, drift.Environment, len(changes))
+		for _, change := range changes {
+			output.Printf("   • %s: %s", change.Path, change.Kind)
+			if change.Details != "" {
+				output.Printf(" (%s)", change.Details)
+			}
+			output.Println()
+		}
+	}
+	res.Environments = environments
+
+	if !res.Consistent && failOnDrift {
+		if err := output.Result(res); err != nil {
+			return err
+		}
+
+		// Возвращаем код ошибки для CI/CD
+		return exitcode.Fail(cmd, 1)
+	}
+
+	return output.Result(res)
+}
+
+// parseEnvironment разбирает аргумент вида env=input.json
+func parseEnvironment(arg string) (string, string) {
+	if name, input, ok := strings.Cut(arg, "="); ok && name != "" && !strings.ContainsAny(name, `/\`) {
+		return name, input
+	}
+	base := filepath.Base(arg)
+	return strings.TrimSuffix(base, filepath.Ext(base)), arg
+}
+
+// environmentNames возвращает имена окружений в порядке аргументов
+func environmentNames(environments []Environment) []string {
+	names := make([]string, len(environments))
+	for i, env := range environments {
+		names[i] = env.Name
+	}
+	return names
+}
+
+// structuralChanges оставляет только изменения структуры, отбрасывая описания и default значения
+func structuralChanges(report *compat.Report) []compat.Change {
+	changes := make([]compat.Change, 0, len(report.Changes))
+	for _, change := range report.Changes {
+		if change.Kind == compat.ChangeDescription || change.Kind == compat.ChangeDefault {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,106 @@
+package correlations
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/pkg/correlation"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+)
+
+var (
+	minSupport int
+	maxValues  int
+)
+
+// Rule представляет найденную зависимость вместе с предлагаемым условием if/then
+type Rule struct {
+	correlation.Rule
+	Description string                 `json:"description"`
+	Constraint  map[string]interface{} `json:"constraint"`
+}
+
+// Result представляет результат команды correlations в режиме --json
+type Result struct {
+	Input string `json:"input"`
+	Rules []Rule `json:"rules"`
+}
+
+// Cmd представляет команду correlations
+var Cmd = &cobra.Command{
+	Use:   "correlations [input.json]",
+	Short: "Ищет зависимости между полями и предлагает условия if/then",
+	Long: `Анализирует объекты во входных данных и находит простые зависимости между полями:
+- присутствие одного поля влечет присутствие другого
+- значение поля влечет присутствие или отсутствие другого (status = "closed" ⇒ closedAt не null)
+
+Найденные зависимости выводятся как предлагаемые условия if/then JSON Schema
+для ручной проверки; схема не изменяется.
+
+Примеры использования:
+  correlations orders.json
+  correlations orders.json --min-support 10 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCorrelations,
+}
+
+func init() {
+	defaults := correlation.DefaultOptions()
+	Cmd.Flags().IntVar(&minSupport, "min-support", defaults.MinSupport, "Минимальное число объектов, в которых выполняется условие зависимости")
+	Cmd.Flags().IntVar(&maxValues, "max-values", defaults.MaxValues, "Максимальное число различных значений поля, чтобы использовать их в условиях")
+}
+
+func runCorrelations(cmd *cobra.Command, args []string) error {
+	inputFile := args[0]
+
+	data, err := fileutil.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("ошибка парсинга JSON: %w", err)
+	}
+
+	output.Printf("🔗 Поиск зависимостей между полями: %s\n", inputFile)
+
+	rules := correlation.Infer(correlation.Collect(value), correlation.Options{
+		MinSupport: minSupport,
+		MaxValues:  maxValues,
+	})
+
+	res := Result{Input: inputFile, Rules: make([]Rule, 0, len(rules))}
+	if len(rules) == 0 {
+		output.Printf("✅ Зависимости не найдены\n")
+		return output.Result(res)
+	}
+
+	output.Printf("🎯 Найдено зависимостей: %d\n", len(rules))
+	currentPath := ""
+	for i, rule := range rules {
+		if i == 0 || rule.Path != currentPath {
+			currentPath = rule.Path
+			path := rule.Path
+			if path == "" {
+				path = "$"
+			}
+			output.Println()
+			output.Printf("📍 %s\n", path)
+		}
+		output.Printf("  %s (%d из %d объектов)\n", rule, rule.Support, rule.Objects)
+
+		res.Rules = append(res.Rules, Rule{
+			Rule:        rule,
+			Description: rule.String(),
+			Constraint:  rule.Constraint(),
+		})
+	}
+
+	output.Println()
+	output.Printf("💡 Зависимости найдены по выборке и требуют проверки перед добавлением в схему как if/then\n")
+
+	return output.Result(res)
+}
@@ -0,0 +1,37 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Error завершает программу кодом Code после того, как команда уже вывела
+// свой результат (ломающие изменения, ошибки валидации): сообщение об
+// ошибке не печатается. Команда возвращает Error из RunE вместо вызова
+// os.Exit, чтобы root.Execute успел завершить запись профилей
+type Error struct {
+	Code int
+}
+
+// Error возвращает описание ошибки
+func (e *Error) Error() string {
+	return fmt.Sprintf("код завершения %d", e.Code)
+}
+
+// Fail возвращает Error с кодом code для команды cmd; cobra не печатает для
+// нее сообщение и справку по флагам
+func Fail(cmd *cobra.Command, code int) error {
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	return &Error{Code: code}
+}
+
+// As возвращает Error из цепочки err; nil - обычная ошибка
+func As(err error) *Error {
+	var exitErr *Error
+	if errors.As(err, &exitErr) {
+		return exitErr
+	}
+	return nil
+}
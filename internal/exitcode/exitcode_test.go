@@ -0,0 +1,33 @@
+package exitcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFail(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{
+		Use:  "check",
+		RunE: func(cmd *cobra.Command, args []string) error { return Fail(cmd, 2) },
+	}
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(nil)
+
+	err := cmd.Execute()
+	exitErr := As(fmt.Errorf("обертка: %w", err))
+	if exitErr == nil || exitErr.Code != 2 {
+		t.Fatalf("As(%v) = %v, want код 2", err, exitErr)
+	}
+	if out.Len() > 0 {
+		t.Errorf("cobra вывела %q, want пустой вывод", out.String())
+	}
+	if As(errors.New("ошибка")) != nil {
+		t.Error("As(обычная ошибка) != nil")
+	}
+}
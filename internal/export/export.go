@@ -0,0 +1,179 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/diagram"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/jtd"
+)
+
+var (
+	format     string
+	outputFile string
+)
+
+// Result представляет результат команды export diagram в режиме --json
+type Result struct {
+	Schema  string         `json:"schema"`
+	Format  string         `json:"format"`
+	Graph   *diagram.Graph `json:"graph"`
+	Diagram string         `json:"diagram,omitempty"`
+	Output  string         `json:"output,omitempty"`
+}
+
+// JTDResult представляет результат команды export jtd в режиме --json
+type JTDResult struct {
+	Schema   string        `json:"schema"`
+	JTD      *jtd.Schema   `json:"jtd,omitempty"`
+	Warnings []jtd.Warning `json:"warnings"`
+	Output   string        `json:"output,omitempty"`
+}
+
+// Cmd представляет команду export
+var Cmd = &cobra.Command{
+	Use:   "export",
+	Short: "Экспортирует схему в другие представления",
+	Long: `Экспортирует схему в представления для документации.
+
+Доступные виды экспорта:
+  diagram - диаграмма структуры схемы (Mermaid или Graphviz DOT)
+  jtd     - JSON Type Definition (RFC 8927) для генераторов кода`,
+}
+
+// diagramCmd представляет команду export diagram
+var diagramCmd = &cobra.Command{
+	Use:   "diagram [schema.json]",
+	Short: "Строит диаграмму структуры схемы (mermaid, dot)",
+	Long: `Изображает вложенность объектов и массивов схемы и ветвление oneOf/anyOf
+в виде диаграммы для архитектурной документации. Объект - узел со списком
+скалярных полей, вложенный объект или массив объектов - отдельный узел,
+варианты полиморфного поля - пунктирные ветки. Необязательные поля отмечены "?".
+
+Примеры использования:
+  export diagram users
+  export diagram schema.json --format dot -o schema.dot`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDiagram,
+}
+
+// jtdCmd представляет команду export jtd
+var jtdCmd = &cobra.Command{
+	Use:   "jtd [schema.json]",
+	Short: "Преобразует схему в JSON Type Definition (RFC 8927)",
+	Long: `Преобразует схему в JSON Type Definition для генераторов кода.
+Обязательные поля становятся properties, остальные - optionalProperties,
+oneOf с дискриминатором - формой discriminator, строковые enum - enum,
+nullable поля - nullable, $defs - definitions.
+
+JTD выражает не все возможности JSON Schema: кортежи, anyOf из нескольких
+типов и oneOf без дискриминатора заменяются схемой любого значения, о каждой
+такой замене выводится предупреждение. Ограничения значений (pattern, длины,
+диапазоны, форматы кроме date-time) отбрасываются.
+
+Примеры использования:
+  export jtd users
+  export jtd schema.json -o schema.jtd.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJTD,
+}
+
+func init() {
+	diagramCmd.Flags().StringVarP(&format, "format", "f", diagram.FormatMermaid, "Формат диаграммы: "+diagram.FormatMermaid+" или "+diagram.FormatDOT)
+	diagramCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл для диаграммы (по умолчанию stdout)")
+	Cmd.AddCommand(diagramCmd)
+
+	jtdCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл для схемы JTD (по умолчанию stdout)")
+	Cmd.AddCommand(jtdCmd)
+}
+
+func runDiagram(cmd *cobra.Command, args []string) error {
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+
+	result, err := analyzer.New().LoadSchema(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы: %w", err)
+	}
+
+	graph := diagram.Build(project.SchemaName(schemaFile), result.Schema)
+	rendered, err := graph.Render(format)
+	if err != nil {
+		return err
+	}
+
+	res := Result{Schema: schemaFile, Format: format, Graph: graph, Output: outputFile}
+	if outputFile != "" {
+		if err := fileutil.WriteFile(outputFile, []byte(rendered), 0644); err != nil {
+			return fmt.Errorf("ошибка записи диаграммы: %w", err)
+		}
+		output.Printf("🗺️ Диаграмма структуры %s (%d узлов) сохранена в %s\n", schemaFile, len(graph.Nodes), outputFile)
+		return output.Result(res)
+	}
+
+	if output.JSON {
+		res.Diagram = rendered
+		return output.Result(res)
+	}
+	fmt.Print(rendered)
+	return nil
+}
+
+func runJTD(cmd *cobra.Command, args []string) error {
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+
+	result, err := analyzer.New().LoadSchema(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы: %w", err)
+	}
+
+	converted, warnings := jtd.FromSchema(result.Schema)
+	data, err := json.MarshalIndent(converted, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации схемы JTD: %w", err)
+	}
+	for _, warning := range warnings {
+		if outputFile == "" && !output.JSON {
+			// stdout занят схемой JTD
+			fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+			continue
+		}
+		output.Printf("⚠️  %s\n", warning)
+	}
+
+	res := JTDResult{Schema: schemaFile, Warnings: warnings, Output: outputFile}
+	if res.Warnings == nil {
+		res.Warnings = []jtd.Warning{}
+	}
+	if outputFile != "" {
+		if err := fileutil.WriteFile(outputFile, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("ошибка записи схемы JTD: %w", err)
+		}
+		output.Printf("📐 Схема JTD для %s сохранена в %s (предупреждений: %d)\n", schemaFile, outputFile, len(warnings))
+		return output.Result(res)
+	}
+
+	if output.JSON {
+		res.JTD = converted
+		return output.Result(res)
+	}
+	fmt.Println(string(data))
+	return nil
+}
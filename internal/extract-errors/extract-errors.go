@@ -0,0 +1,314 @@
+package extracterrors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/autocommit"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/errorshape"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// DefaultDefinitionFile - файл общего определения ошибки относительно директории схем
+const DefaultDefinitionFile = "common/error" + project.SchemaFileSuffix
+
+var (
+	definitionFile string
+	dryRun         bool
+	autoCommit     bool
+)
+
+// SchemaResult описывает замену конвертов ошибок в одной схеме
+type SchemaResult struct {
+	Name    string   `json:"name"`
+	Schema  string   `json:"schema"`
+	Paths   []string `json:"paths"`
+	Version string   `json:"version"`
+	Bump    string   `json:"bump"`
+}
+
+// Result представляет результат команды extract-errors в режиме --json
+type Result struct {
+	Definition string         `json:"definition"`
+	Version    string         `json:"version,omitempty"`
+	Required   []string       `json:"required,omitempty"`
+	Fields     []string       `json:"fields,omitempty"`
+	Schemas    []SchemaResult `json:"schemas"`
+	DryRun     bool           `json:"dry_run"`
+	Committed  bool           `json:"committed"`
+}
+
+// Cmd представляет команду extract-errors
+var Cmd = &cobra.Command{
+	Use:   "extract-errors [schema...]",
+	Short: "Выносит конверты ошибок схем проекта в общее определение Error",
+	Long: `Находит в схемах типовые конверты ошибок (code/message/details и похожие),
+объединяет их в общее определение и заменяет найденные узлы ссылками $ref на него.
+
+Без аргументов обрабатываются все схемы проекта. Общее определение по умолчанию
+сохраняется в ` + DefaultDefinitionFile + ` внутри директории схем.
+
+Примеры использования:
+  extract-errors
+  extract-errors users orders --dry-run
+  extract-errors -o schemas/common/api-error.schema.json`,
+	RunE: runExtractErrors,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&definitionFile, "output", "o", "", "Файл общего определения ошибки (по умолчанию "+DefaultDefinitionFile+" в директории схем)")
+	Cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Только показать найденные конверты, не изменяя файлы")
+	Cmd.Flags().BoolVarP(&autoCommit, "auto-commit", "a", false, "Автоматический коммит изменений схем")
+}
+
+// found хранит найденные в схеме конверты
+type found struct {
+	name    string
+	file    string
+	result  *types.AnalysisResult
+	matches []errorshape.Match
+}
+
+func runExtractErrors(cmd *cobra.Command, args []string) error {
+	defFile, err := resolveDefinitionFile()
+	if err != nil {
+		return err
+	}
+	defAbs, _ := filepath.Abs(defFile)
+
+	schemas, err := project.SelectSchemas(args)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	analyzer := analyzer.New()
+
+	// Ищем конверты ошибок во всех схемах
+	var schemasWithErrors []*found
+	var envelopes []*types.Property
+	for _, name := range names {
+		schemaFile := schemas[name]
+		if abs, _ := filepath.Abs(schemaFile); abs == defAbs {
+			continue
+		}
+
+		result, err := analyzer.LoadSchema(schemaFile)
+		if err != nil {
+			return fmt.Errorf("ошибка загрузки схемы %s: %w", schemaFile, err)
+		}
+
+		matches := errorshape.Find(result.Schema)
+		if len(matches) == 0 {
+			continue
+		}
+
+		schemasWithErrors = append(schemasWithErrors, &found{name: name, file: schemaFile, result: result, matches: matches})
+		for _, match := range matches {
+			envelopes = append(envelopes, match.Property)
+			output.Printf("🔎 %s: %s\n", name, match.Path)
+		}
+	}
+
+	res := Result{Definition: defFile, Schemas: make([]SchemaResult, 0), DryRun: dryRun}
+	if len(envelopes) == 0 {
+		output.Printf("✅ Конверты ошибок не найдены\n")
+		return output.Result(res)
+	}
+
+	// Объединяем найденные конверты с уже существующим определением
+	definition, previous, err := loadDefinition(analyzer, defFile)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		envelopes = append([]*types.Property{definitionProperty(definition.Schema)}, envelopes...)
+	}
+	merged := errorshape.Merge(envelopes)
+	definition.Schema.Type = merged.Type
+	definition.Schema.Properties = merged.Properties
+	definition.Schema.Required = merged.Required
+
+	res.Required = merged.Required
+	for field := range merged.Properties {
+		res.Fields = append(res.Fields, field)
+	}
+	sort.Strings(res.Fields)
+
+	output.Printf("📦 Общее определение ошибки: %s\n", defFile)
+	output.Printf("   Поля: %v, обязательные: %v\n", res.Fields, res.Required)
+
+	if dryRun {
+		for _, f := range schemasWithErrors {
+			res.Schemas = append(res.Schemas, SchemaResult{Name: f.name, Schema: f.file, Paths: matchPaths(f.matches)})
+		}
+		output.Printf("ℹ️ Режим --dry-run: файлы не изменены\n")
+		return output.Result(res)
+	}
+
+	// Сохраняем общее определение
+	var changedFiles []string
+	if previous != nil {
+		if _, _, err := compat.StampVersion(previous, definition); err != nil {
+			return fmt.Errorf("ошибка определения версии схемы: %w", err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(defFile), 0755); err != nil {
+		return fmt.Errorf("ошибка создания директории: %w", err)
+	}
+	if err := analyzer.SaveSchema(definition, defFile); err != nil {
+		return fmt.Errorf("ошибка сохранения схемы: %w", err)
+	}
+	signatureFile, err := signing.SignSchema(defFile)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+	if signatureFile != "" {
+		changedFiles = append(changedFiles, signatureFile)
+	}
+	res.Version = definition.Metadata.Version
+
+	// Заменяем конверты ссылками на общее определение
+	for _, f := range schemasWithErrors {
+		ref, err := definitionRef(f.file, defFile)
+		if err != nil {
+			return err
+		}
+
+		previousSchema, err := f.result.Schema.Clone()
+		if err != nil {
+			return fmt.Errorf("ошибка копирования схемы: %w", err)
+		}
+		errorshape.Replace(f.result.Schema, ref)
+
+		report, _, err := compat.StampVersion(previousSchema, f.result)
+		if err != nil {
+			return fmt.Errorf("ошибка определения версии схемы: %w", err)
+		}
+		if err := analyzer.SaveSchema(f.result, f.file); err != nil {
+			return fmt.Errorf("ошибка сохранения схемы: %w", err)
+		}
+		changedFiles = append(changedFiles, f.file)
+
+		signatureFile, err := signing.SignSchema(f.file)
+		if err != nil {
+			return fmt.Errorf("ошибка подписи схемы: %w", err)
+		}
+		if signatureFile != "" {
+			changedFiles = append(changedFiles, signatureFile)
+		}
+
+		output.Printf("🔗 %s: конвертов заменено ссылкой %s: %d\n", f.file, ref, len(f.matches))
+		res.Schemas = append(res.Schemas, SchemaResult{
+			Name:    f.name,
+			Schema:  f.file,
+			Paths:   matchPaths(f.matches),
+			Version: f.result.Metadata.Version,
+			Bump:    report.Bump.String(),
+		})
+	}
+
+	// Автоматический коммит если флаг установлен
+	if autoCommit {
+		if err := autocommit.Commit(defFile, "extract-errors", changedFiles...); err != nil {
+			output.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
+		} else {
+			res.Committed = true
+			output.Printf("✅ Изменения схем закоммичены\n")
+		}
+	}
+
+	return output.Result(res)
+}
+
+// resolveDefinitionFile определяет путь к файлу общего определения ошибки
+func resolveDefinitionFile() (string, error) {
+	if definitionFile != "" {
+		return definitionFile, nil
+	}
+
+	dir, err := project.ResolveSchemasDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DefaultDefinitionFile), nil
+}
+
+// loadDefinition загружает существующее определение ошибки или создает новое.
+// Для существующего определения также возвращается его копия до изменений.
+func loadDefinition(analyzer *analyzer.Analyzer, defFile string) (*types.AnalysisResult, *types.JSONSchema, error) {
+	if _, err := os.Stat(defFile); os.IsNotExist(err) {
+		now := time.Now()
+		return &types.AnalysisResult{
+			Schema: &types.JSONSchema{
+				Schema:      "http://json-schema.org/draft-07/schema#",
+				Type:        "object",
+				Description: "Shared error envelope",
+			},
+			Metadata: &types.AnalysisMetadata{
+				GeneratedAt: now,
+				UpdatedAt:   now,
+				Version:     "1.0.0",
+			},
+		}, nil, nil
+	}
+
+	definition, err := analyzer.LoadSchema(defFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка загрузки определения ошибки: %w", err)
+	}
+	previous, err := definition.Schema.Clone()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка копирования схемы: %w", err)
+	}
+	definition.Metadata.UpdatedAt = time.Now()
+	return definition, previous, nil
+}
+
+// definitionProperty представляет корень определения как Property
+func definitionProperty(schema *types.JSONSchema) *types.Property {
+	return &types.Property{
+		Type:       schema.Type,
+		Properties: schema.Properties,
+		Required:   schema.Required,
+	}
+}
+
+// definitionRef строит относительную ссылку из схемы на общее определение
+func definitionRef(schemaFile, defFile string) (string, error) {
+	schemaDir, err := filepath.Abs(filepath.Dir(schemaFile))
+	if err != nil {
+		return "", err
+	}
+	defAbs, err := filepath.Abs(defFile)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(schemaDir, defAbs)
+	if err != nil {
+		return "", fmt.Errorf("ошибка построения ссылки на %s: %w", defFile, err)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// matchPaths возвращает пути найденных конвертов
+func matchPaths(matches []errorshape.Match) []string {
+	paths := make([]string, 0, len(matches))
+	for _, match := range matches {
+		paths = append(paths, match.Path)
+	}
+	return paths
+}
@@ -0,0 +1,92 @@
+// Package golden сравнивает вывод тестов с эталонными файлами в testdata.
+// Эталоны перезаписываются запуском тестов с флагом -update:
+//
+//	go test ./pkg/... -run Golden -update
+//
+// после чего изменения эталонов просматриваются в git diff вместе с кодом
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные файлы testdata текущим выводом")
+
+// Assert сравнивает got с эталонным файлом path; с флагом -update файл
+// перезаписывается. Окончания строк эталона нормализуются, чтобы тесты
+// проходили при checkout с CRLF
+func Assert(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ошибка создания директории эталона: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("ошибка записи эталона: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ошибка чтения эталона (запустите тест с -update): %v", err)
+	}
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("вывод отличается от эталона %s (обновить: go test -update):\n%s", path, diff(string(want), string(got)))
+	}
+}
+
+// AssertJSON сериализует value с отступами и сравнивает с эталоном path
+func AssertJSON(t testing.TB, path string, value interface{}) {
+	t.Helper()
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatalf("ошибка сериализации: %v", err)
+	}
+	Assert(t, path, append(data, '\n'))
+}
+
+// Inputs возвращает файлы testdata по шаблону glob; тест без входов падает,
+// чтобы опечатка в шаблоне не выключала проверку молча
+func Inputs(t testing.TB, pattern string) []string {
+	t.Helper()
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatalf("некорректный шаблон %s: %v", pattern, err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("нет входных файлов по шаблону %s", pattern)
+	}
+	return files
+}
+
+// diff показывает первую отличающуюся строку с соседними, чего достаточно,
+// чтобы понять изменение без внешних утилит
+func diff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	line := 0
+	for line < len(wantLines) && line < len(gotLines) && wantLines[line] == gotLines[line] {
+		line++
+	}
+
+	var b strings.Builder
+	from := max(line-2, 0)
+	for i := from; i < line; i++ {
+		b.WriteString("   " + wantLines[i] + "\n")
+	}
+	for i := line; i < min(line+3, len(wantLines)); i++ {
+		b.WriteString(" - " + wantLines[i] + "\n")
+	}
+	for i := line; i < min(line+3, len(gotLines)); i++ {
+		b.WriteString(" + " + gotLines[i] + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package importcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/jtd"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+var (
+	outputFile string
+	force      bool
+)
+
+// Result представляет результат команды import jtd в режиме --json
+type Result struct {
+	Input     string `json:"input"`
+	Schema    string `json:"schema"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// Cmd представляет команду import
+var Cmd = &cobra.Command{
+	Use:   "import",
+	Short: "Импортирует схему из других представлений",
+	Long: `Создает схему из описания в другом формате.
+
+Доступные виды импорта:
+  jtd - JSON Type Definition (RFC 8927)`,
+}
+
+// jtdCmd представляет команду import jtd
+var jtdCmd = &cobra.Command{
+	Use:   "jtd [schema.jtd.json]",
+	Short: "Создает схему из JSON Type Definition (RFC 8927)",
+	Long: `Преобразует схему JTD в JSON Schema: properties становятся обязательными
+полями, optionalProperties - необязательными, discriminator - oneOf с полем
+дискриминатора в каждом варианте, enum - строковым enum, nullable - типом
+с null, definitions - $defs. Целочисленные типы получают minimum и maximum
+своего диапазона, timestamp становится строкой формата date-time. Объекты
+без additionalProperties закрыты для полей сверх описанных.
+
+По умолчанию схема сохраняется рядом с исходным файлом с суффиксом ` + project.SchemaFileSuffix + `.
+
+Примеры использования:
+  import jtd user.jtd.json
+  import jtd user.jtd.json -o users`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJTD,
+}
+
+func init() {
+	jtdCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл или имя создаваемой схемы")
+	jtdCmd.Flags().BoolVarP(&force, "force", "f", false, "Перезаписать существующую схему")
+	Cmd.AddCommand(jtdCmd)
+}
+
+func runJTD(cmd *cobra.Command, args []string) error {
+	inputFile := args[0]
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	source, err := jtd.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	schemaFile := outputFile
+	if schemaFile == "" {
+		schemaFile = defaultSchemaFile(inputFile)
+	}
+	if schemaFile, err = project.ResolveOutput(schemaFile); err != nil {
+		return err
+	}
+	if _, err := os.Stat(schemaFile); err == nil && !force {
+		return fmt.Errorf("схема %s уже существует, используйте --force для перезаписи", schemaFile)
+	}
+
+	now := time.Now()
+	result := &types.AnalysisResult{
+		Schema: jtd.ToSchema(source),
+		Metadata: &types.AnalysisMetadata{
+			GeneratedAt: now,
+			UpdatedAt:   now,
+			Version:     "1.0.0",
+		},
+	}
+	if err := analyzer.New().SaveSchema(result, schemaFile); err != nil {
+		return fmt.Errorf("ошибка сохранения схемы: %w", err)
+	}
+
+	// Подписываем схему, если настроен ключ подписи
+	signatureFile, err := signing.SignSchema(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+
+	output.Printf("📥 Схема JTD %s импортирована в %s\n", inputFile, schemaFile)
+	return output.Result(Result{Input: inputFile, Schema: schemaFile, Signature: signatureFile})
+}
+
+// defaultSchemaFile возвращает путь схемы рядом с файлом JTD: user.jtd.json
+// становится user.schema.json
+func defaultSchemaFile(inputFile string) string {
+	base := strings.TrimSuffix(inputFile, filepath.Ext(inputFile))
+	base = strings.TrimSuffix(base, ".jtd")
+	return base + project.SchemaFileSuffix
+}
@@ -0,0 +1,222 @@
+package initcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+)
+
+var (
+	force  bool
+	noHook bool
+)
+
+// defaultSchemasDir используется, если не задан флаг --schemas-dir
+const defaultSchemasDir = "schemas"
+
+// hookMarker позволяет отличить наш pre-commit hook от пользовательского
+const hookMarker = "# json-schema-detector pre-commit hook"
+
+// preCommitHook проверяет обратную совместимость измененных схем перед коммитом
+const preCommitHook = `#!/bin/sh
+` + hookMarker + `
+# Проверяет обратную совместимость измененных схем перед коммитом.
+# Чтобы закоммитить ломающие изменения осознанно: SCHEMA_ALLOW_BREAKING=1 git commit ...
+
+[ -n "$SCHEMA_ALLOW_BREAKING" ] && exit 0
+command -v json-schema-detector >/dev/null 2>&1 || exit 0
+
+# Проверяется содержимое индекса, а не рабочей копии: незафиксированные
+# правки файла не влияют на результат
+status=0
+for file in $(git diff --cached --name-only --diff-filter=M -- '%[1]s/*.json'); do
+	previous=$(mktemp)
+	staged=$(mktemp)
+	if git show "HEAD:$file" > "$previous" 2>/dev/null && git show ":$file" > "$staged"; then
+		json-schema-detector check-compat "$previous" "$staged" || { echo "Ломающие изменения в $file" >&2; status=1; }
+	fi
+	rm -f "$previous" "$staged"
+done
+
+exit $status
+`
+
+// makefileTargets содержит пример целей Makefile для работы со схемами
+const makefileTargets = `# Цели для работы со схемами json-schema-detector.
+# Подключите в Makefile проекта: include schemas.mk
+
+SCHEMA_TOOL ?= json-schema-detector
+SCHEMAS_DIR ?= %[1]s
+FIXTURES_DIR ?= fixtures
+
+.PHONY: schemas-list schemas-validate
+
+# Показать поля всех схем проекта
+schemas-list:
+	@for schema in $(SCHEMAS_DIR)/*.schema.json; do \
+		[ -f "$$schema" ] || continue; \
+		$(SCHEMA_TOOL) list-fields "$$schema" --types || exit 1; \
+	done
+
+# Проверить примеры данных fixtures/<name>.json против schemas/<name>.schema.json
+schemas-validate:
+	@for schema in $(SCHEMAS_DIR)/*.schema.json; do \
+		[ -f "$$schema" ] || continue; \
+		name=$$(basename "$$schema" .schema.json); \
+		if [ -f "$(FIXTURES_DIR)/$$name.json" ]; then \
+			$(SCHEMA_TOOL) validate "$(FIXTURES_DIR)/$$name.json" "$$schema" || exit 1; \
+		fi; \
+	done
+`
+
+// Result представляет результат команды init в режиме --json
+type Result struct {
+	Root    string   `json:"root"`
+	Created []string `json:"created"`
+	Skipped []string `json:"skipped"`
+}
+
+// Cmd представляет команду init
+var Cmd = &cobra.Command{
+	Use:   "init [directory]",
+	Short: "Создает проект для хранения схем",
+	Long: `Подготавливает директорию для работы со схемами:
+- создает директорию схем и конфигурацию проекта ` + project.ConfigFileName + `
+- устанавливает git pre-commit hook с проверкой совместимости схем
+- создает schemas.mk с примером целей Makefile
+
+Существующие файлы не перезаписываются без флага --force.
+
+Примеры использования:
+  init
+  init ./contracts --schemas-dir api-schemas
+  init --no-hook`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInit,
+}
+
+func init() {
+	Cmd.Flags().BoolVarP(&force, "force", "f", false, "Перезаписать существующие файлы")
+	Cmd.Flags().BoolVar(&noHook, "no-hook", false, "Не устанавливать git pre-commit hook")
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	root := "."
+	if len(args) == 1 {
+		root = args[0]
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("ошибка определения директории: %w", err)
+	}
+
+	schemasDir := project.SchemasDir
+	if schemasDir == "" {
+		schemasDir = defaultSchemasDir
+	}
+
+	output.Printf("🚀 Инициализация проекта схем: %s\n", root)
+	output.Println()
+
+	res := Result{Root: root, Created: make([]string, 0), Skipped: make([]string, 0)}
+	track := func(path string, created bool) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		if created {
+			res.Created = append(res.Created, rel)
+			output.Printf("✅ Создан: %s\n", rel)
+		} else {
+			res.Skipped = append(res.Skipped, rel)
+			output.Printf("⏭️ Пропущен (уже существует): %s\n", rel)
+		}
+	}
+
+	// Директория схем
+	schemasPath := schemasDir
+	if !filepath.IsAbs(schemasPath) {
+		schemasPath = filepath.Join(root, schemasDir)
+	}
+	_, statErr := os.Stat(schemasPath)
+	if err := os.MkdirAll(schemasPath, 0755); err != nil {
+		return fmt.Errorf("ошибка создания директории схем: %w", err)
+	}
+	track(schemasPath, os.IsNotExist(statErr))
+
+	// Конфигурация проекта
+	configPath := filepath.Join(root, project.ConfigFileName)
+	created, err := writeIfAbsent(configPath, func(path string) error {
+		return project.SaveConfig(path, &project.Config{SchemasDir: filepath.ToSlash(schemasDir)})
+	})
+	if err != nil {
+		return err
+	}
+	track(configPath, created)
+
+	// Пример целей Makefile
+	makefilePath := filepath.Join(root, "schemas.mk")
+	created, err = writeIfAbsent(makefilePath, func(path string) error {
+		return os.WriteFile(path, []byte(fmt.Sprintf(makefileTargets, filepath.ToSlash(schemasDir))), 0644)
+	})
+	if err != nil {
+		return err
+	}
+	track(makefilePath, created)
+
+	// Git pre-commit hook
+	if !noHook {
+		if err := installHook(root, schemasDir, track); err != nil {
+			output.Printf("⚠️ Hook не установлен: %v\n", err)
+		}
+	}
+
+	output.Println()
+	output.Printf("💡 Дальнейшие шаги:\n")
+	output.Printf("   json-schema-detector analyze data.json -o <name>\n")
+	output.Printf("   Добавьте в Makefile: include schemas.mk\n")
+
+	return output.Result(res)
+}
+
+// installHook устанавливает pre-commit hook, если директория является git репозиторием
+func installHook(root, schemasDir string, track func(string, bool)) error {
+	hooksDir := filepath.Join(root, ".git", "hooks")
+	if info, err := os.Stat(filepath.Join(root, ".git")); err != nil || !info.IsDir() {
+		return fmt.Errorf("директория не является корнем git репозитория")
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("ошибка создания директории hooks: %w", err)
+	}
+
+	hookPath := filepath.Join(hooksDir, "pre-commit")
+	if data, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(data), hookMarker) && !force {
+		return fmt.Errorf("уже существует пользовательский pre-commit hook: %s", hookPath)
+	}
+
+	created, err := writeIfAbsent(hookPath, func(path string) error {
+		return os.WriteFile(path, []byte(fmt.Sprintf(preCommitHook, filepath.ToSlash(schemasDir))), 0755)
+	})
+	if err != nil {
+		return err
+	}
+	track(hookPath, created)
+	return nil
+}
+
+// writeIfAbsent создает файл, если он отсутствует или установлен флаг --force
+func writeIfAbsent(path string, write func(string) error) (bool, error) {
+	if _, err := os.Stat(path); err == nil && !force {
+		return false, nil
+	}
+	if err := write(path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
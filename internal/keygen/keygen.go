@@ -0,0 +1,96 @@
+package keygen
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/signature"
+)
+
+// Расширения файлов ключей
+const (
+	PrivateKeySuffix = ".key"
+	PublicKeySuffix  = ".pub"
+)
+
+var force bool
+
+// Result представляет результат команды keygen в режиме --json
+type Result struct {
+	KeyID      string `json:"key_id"`
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+// Cmd представляет команду keygen
+var Cmd = &cobra.Command{
+	Use:   "keygen [name]",
+	Short: "Создает пару ключей для подписи схем",
+	Long: `Создает пару ключей Ed25519: закрытый ключ <name>.key для подписи схем
+в пайплайне команды-владельца и открытый ключ <name>.pub для проверки
+подписи потребителями командой verify-signature.
+
+Закрытый ключ указывается флагом --sign-key или полем signing_key в ` + project.ConfigFileName + `,
+после чего каждая сохраненная схема получает файл подписи <schema>.sig.
+
+Примеры использования:
+  keygen
+  keygen team-payments`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runKeygen,
+}
+
+func init() {
+	Cmd.Flags().BoolVar(&force, "force", false, "Перезаписать существующие ключи")
+}
+
+func runKeygen(cmd *cobra.Command, args []string) error {
+	name := "schema-signing"
+	if len(args) > 0 {
+		name = args[0]
+	}
+	privateFile := name + PrivateKeySuffix
+	publicFile := name + PublicKeySuffix
+
+	if !force {
+		for _, path := range []string{privateFile, publicFile} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("файл %s уже существует, используйте --force для перезаписи", path)
+			}
+		}
+	}
+
+	pub, priv, err := signature.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	privateData, err := priv.MarshalText()
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования закрытого ключа: %w", err)
+	}
+	publicData, err := pub.MarshalText()
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования открытого ключа: %w", err)
+	}
+
+	if err := os.WriteFile(privateFile, privateData, 0600); err != nil {
+		return fmt.Errorf("ошибка записи закрытого ключа: %w", err)
+	}
+	if err := os.WriteFile(publicFile, publicData, 0644); err != nil {
+		return fmt.Errorf("ошибка записи открытого ключа: %w", err)
+	}
+
+	output.Printf("🔑 Создана пара ключей %s\n", pub.KeyID())
+	output.Printf("🔒 Закрытый ключ: %s (не добавляйте его в репозиторий)\n", privateFile)
+	output.Printf("📢 Открытый ключ: %s\n", publicFile)
+
+	return output.Result(Result{
+		KeyID:      pub.KeyID(),
+		PrivateKey: privateFile,
+		PublicKey:  publicFile,
+	})
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
 	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
 	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
 )
 
 var (
@@ -15,6 +19,23 @@ var (
 	verbose   bool
 )
 
+// Field описывает поле схемы в режиме --json
+type Field struct {
+	Path        string        `json:"path"`
+	Type        string        `json:"type,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Variants    int           `json:"variants,omitempty"`
+	// Confidence - оценки уверенности в выводе поля, если схема создана с --confidence
+	Confidence *types.Confidence `json:"confidence,omitempty"`
+}
+
+// Result представляет результат команды list-fields в режиме --json
+type Result struct {
+	Schema string  `json:"schema"`
+	Fields []Field `json:"fields"`
+}
+
 // Cmd представляет команду list-fields
 var Cmd = &cobra.Command{
 	Use:   "list-fields [schema.json]",
@@ -36,15 +57,18 @@ func init() {
 }
 
 func runListFields(cmd *cobra.Command, args []string) error {
-	schemaFile := args[0]
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
 
 	// Проверяем существование файла схемы
 	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
 		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
 	}
 
-	fmt.Printf("📋 Список полей в схеме: %s\n", schemaFile)
-	fmt.Println()
+	output.Printf("📋 Список полей в схеме: %s\n", schemaFile)
+	output.Println()
 
 	// Загружаем схему
 	analyzer := analyzer.New()
@@ -53,57 +77,90 @@ func runListFields(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("ошибка загрузки схемы: %w", err)
 	}
 
-	// Создаем менеджер полей
-	fieldManager := fieldmanager.New()
+	// Строим индекс полей, чтобы не обходить схему для каждого поля
+	index := fieldmanager.New().NewIndex(schema.Schema)
 
 	// Получаем список полей
-	fields := fieldManager.ListFields(schema.Schema)
+	fields := index.Fields()
 
+	res := Result{Schema: schemaFile, Fields: make([]Field, 0, len(fields))}
 	if len(fields) == 0 {
-		fmt.Println("⚠️ Поля не найдены в схеме")
-		return nil
+		output.Println("⚠️ Поля не найдены в схеме")
+		return output.Result(res)
 	}
 
 	// Сортируем поля для удобства
 	sort.Strings(fields)
 
-	fmt.Printf("🎯 Найдено полей: %d\n", len(fields))
-	fmt.Println()
+	output.Printf("🎯 Найдено полей: %d\n", len(fields))
+	output.Println()
 
 	// Выводим список полей
 	for i, fieldPath := range fields {
-		fmt.Printf("%3d. %s", i+1, fieldPath)
+		output.Printf("%3d. %s", i+1, fieldPath)
+
+		// Получаем информацию о поле
+		field, err := index.Lookup(fieldPath)
+		if err == nil {
+			res.Fields = append(res.Fields, Field{
+				Path:        fieldPath,
+				Type:        field.Type,
+				Description: field.Description,
+				Enum:        field.Enum,
+				Variants:    len(field.OneOf),
+				Confidence:  field.Confidence,
+			})
+		} else {
+			res.Fields = append(res.Fields, Field{Path: fieldPath})
+		}
 
 		if showTypes || verbose {
-			// Получаем информацию о поле
-			field, err := fieldManager.FindField(schema.Schema, fieldPath)
 			if err == nil {
-				fmt.Printf(" (%s)", field.Type)
+				output.Printf(" (%s)", field.Type)
 
 				if verbose {
 					// Дополнительная информация
 					if field.Description != "" {
-						fmt.Printf(" - %s", field.Description)
+						output.Printf(" - %s", field.Description)
 					}
 
 					if field.Enum != nil {
-						fmt.Printf(" [enum: %v]", field.Enum)
+						output.Printf(" [enum: %v]", field.Enum)
 					}
 
 					if field.OneOf != nil {
-						fmt.Printf(" [polymorphic: %d variants]", len(field.OneOf))
+						output.Printf(" [polymorphic: %d variants]", len(field.OneOf))
+					}
+
+					if field.Confidence != nil {
+						output.Printf(" [confidence: %s]", formatConfidence(field.Confidence))
 					}
 				}
 			}
 		}
 
-		fmt.Println()
+		output.Println()
 	}
 
-	fmt.Println()
-	fmt.Printf("💡 Используйте пути из списка с командой update-field:\n")
-	fmt.Printf("   ./json-schema-detector update-field %s \"<path>\" <operation>\n", schemaFile)
-	fmt.Println()
+	output.Println()
+	output.Printf("💡 Используйте пути из списка с командой update-field:\n")
+	output.Printf("   ./json-schema-detector update-field %s \"<path>\" <operation>\n", schemaFile)
+	output.Println()
+
+	return output.Result(res)
+}
 
-	return nil
+// formatConfidence перечисляет оценки уверенности поля и число значений выборки
+func formatConfidence(c *types.Confidence) string {
+	parts := make([]string, 0, 5)
+	for _, aspect := range []struct {
+		name  string
+		score float64
+	}{{"type", c.Type}, {"required", c.Required}, {"enum", c.Enum}, {"format", c.Format}} {
+		if aspect.score > 0 {
+			parts = append(parts, fmt.Sprintf("%s %.2f", aspect.name, aspect.score))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("samples %d", c.Samples))
+	return strings.Join(parts, ", ")
 }
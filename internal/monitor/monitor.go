@@ -0,0 +1,171 @@
+package monitorcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/fetch"
+	"github.com/yanodincov/json-schema-detector/pkg/monitor"
+)
+
+var (
+	schemaRef  string
+	interval   time.Duration
+	timeout    time.Duration
+	webhook    string
+	headers    []string
+	alertLevel string
+	once       bool
+)
+
+// Cmd представляет команду monitor
+var Cmd = &cobra.Command{
+	Use:   "monitor [url]",
+	Short: "Периодически опрашивает эндпоинт и оповещает об изменении структуры ответа",
+	Long: `Опрашивает HTTP эндпоинт с заданным интервалом, объединяет каждый ответ
+со схемой и при изменении структуры сохраняет схему с повышенной версией
+и отправляет оповещение на webhook.
+
+Тела ответов с ошибками не смешиваются с успешными: ответы 4xx и 5xx
+накапливаются в отдельных схемах рядом с основной (users.4xx.schema.json,
+users.5xx.schema.json).
+
+Оповещение - POST запрос с JSON телом, поле text которого совместимо
+с входящими webhook Slack и Mattermost. Оповещения отправляются для изменений
+не ниже уровня --alert-level. Если файла схемы нет, он создается по первому ответу.
+
+В режиме --json каждая проверка выводится отдельной строкой JSON.
+
+Примеры использования:
+  monitor https://api.example.com/v1/users -s users --interval 10m
+  monitor https://api.example.com/v1/users -s users.schema.json \
+    -H "Authorization: Bearer $TOKEN" --webhook https://hooks.slack.com/services/...
+  monitor https://api.example.com/v1/users -s users --once`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMonitor,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&schemaRef, "schema", "s", "", "Файл или имя схемы ответа")
+	Cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "Интервал между проверками")
+	Cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Таймаут сетевых запросов")
+	Cmd.Flags().StringVar(&webhook, "webhook", "", "URL для оповещений об изменениях")
+	Cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "Заголовок запроса в формате \"Имя: значение\"")
+	Cmd.Flags().StringVar(&alertLevel, "alert-level", compat.BumpMinor.String(), "Минимальный уровень изменения для оповещения (patch, minor, major)")
+	Cmd.Flags().BoolVar(&once, "once", false, "Выполнить одну проверку и завершиться")
+	Cmd.MarkFlagRequired("schema")
+}
+
+func runMonitor(cmd *cobra.Command, args []string) error {
+	url := args[0]
+
+	schemaFile, err := project.ResolveOutput(schemaRef)
+	if err != nil {
+		return err
+	}
+
+	level, err := compat.ParseBumpLevel(alertLevel)
+	if err != nil {
+		return err
+	}
+
+	parsedHeaders, err := fetch.ParseHeaders(headers)
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("интервал должен быть положительным")
+	}
+
+	m := monitor.New(monitor.Config{
+		URL:        url,
+		Headers:    parsedHeaders,
+		SchemaFile: schemaFile,
+		Webhook:    webhook,
+		Timeout:    timeout,
+		AlertLevel: level,
+	})
+
+	output.Printf("👀 Мониторинг эндпоинта: %s\n", url)
+	output.Printf("📄 Схема: %s\n", schemaFile)
+	if !once {
+		output.Printf("⏱️ Интервал: %s\n", interval)
+	}
+	output.Println()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if once {
+		event, err := check(ctx, m)
+		if err != nil {
+			return err
+		}
+		return output.Result(event)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		event, err := check(ctx, m)
+		if err != nil {
+			output.Printf("⚠️ %s %v\n", time.Now().Format("15:04:05"), err)
+		} else if err := output.Stream(event); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			output.Printf("👋 Мониторинг остановлен\n")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// check выполняет одну проверку, подписывает обновленную схему и отправляет оповещение
+func check(ctx context.Context, m *monitor.Monitor) (*monitor.Event, error) {
+	event, err := m.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stamp := event.Time.Format("15:04:05")
+	switch {
+	case event.Created:
+		output.Printf("🆕 %s [%d] схема создана по первому ответу: %s (версия %s)\n", stamp, event.Status, event.Schema, event.Version)
+	case !event.Changed:
+		output.Printf("✅ %s [%d] без изменений (версия %s)\n", stamp, event.Status, event.Version)
+		return event, nil
+	default:
+		output.Printf("🔄 %s [%d] структура изменилась: %s → %s (%s, изменений: %d)\n", stamp, event.Status, event.PreviousVersion, event.Version, event.Bump, len(event.Changes))
+		output.Printf("   📄 %s\n", event.Schema)
+		for _, change := range event.Changes {
+			output.Printf("   • %s: %s\n", change.Path, change.Kind)
+		}
+	}
+
+	if _, err := signing.SignSchema(event.Schema); err != nil {
+		return nil, fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+
+	if m.ShouldAlert(event) {
+		if err := m.Notify(ctx, event); err != nil {
+			output.Printf("⚠️ Ошибка отправки оповещения: %v\n", err)
+		} else if webhook != "" {
+			output.Printf("📣 Оповещение отправлено\n")
+		}
+	}
+
+	return event, nil
+}
@@ -0,0 +1,194 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// JSON включает машиночитаемый режим вывода (флаг --json)
+var JSON bool
+
+var (
+	plainOnce sync.Once
+	plainMode bool
+)
+
+// Plain сообщает, что текстовый вывод идет не в терминал или задана
+// переменная NO_COLOR (либо TERM=dumb): тогда эмодзи из сообщений убираются,
+// чтобы логи CI и cron оставались чистыми
+func Plain() bool {
+	plainOnce.Do(func() {
+		plainMode = os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !isTerminal(Writer())
+	})
+	return plainMode
+}
+
+// Interactive сообщает, что stdin подключен к терминалу и пользователю можно задавать вопросы
+func Interactive() bool {
+	return isTerminal(os.Stdin)
+}
+
+// InputRequired возвращает ошибку для случая, когда команде нужен ввод
+// пользователя, а stdin не является терминалом; hint подсказывает, как
+// передать данные без диалога
+func InputRequired(hint string) error {
+	return fmt.Errorf("требуется ввод пользователя, но stdin не является терминалом: %s", hint)
+}
+
+// isTerminal сообщает, что поток подключен к терминалу
+func isTerminal(w interface{}) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// /dev/null тоже символьное устройство, но терминалом не является
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+// Writer возвращает поток для текстового вывода: stdout в обычном режиме,
+// stderr в режиме --json, чтобы stdout содержал только результат
+func Writer() io.Writer {
+	if JSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Printf выводит форматированный текст для человека. В режиме Plain эмодзи
+// убираются из шаблона; значения аргументов выводятся как есть
+func Printf(format string, a ...interface{}) {
+	if Plain() {
+		format = stripEmoji(format)
+	}
+	fmt.Fprintf(Writer(), format, a...)
+}
+
+// Println выводит строку текста для человека
+func Println(a ...interface{}) {
+	fmt.Fprintln(Writer(), plainArgs(a)...)
+}
+
+// Print выводит текст для человека без перевода строки
+func Print(a ...interface{}) {
+	fmt.Fprint(Writer(), plainArgs(a)...)
+}
+
+// Prompt выводит приглашение к вводу. Без терминала приглашение не выводится,
+// а ответы читаются из stdin как есть
+func Prompt(a ...interface{}) {
+	if Interactive() {
+		Print(a...)
+	}
+}
+
+// Marker возвращает эмодзи-маркер строки или его текстовую замену в режиме Plain
+func Marker(emoji, text string) string {
+	if Plain() {
+		return "[" + text + "]"
+	}
+	return emoji
+}
+
+// plainArgs убирает эмодзи из строковых аргументов в режиме Plain
+func plainArgs(a []interface{}) []interface{} {
+	if !Plain() {
+		return a
+	}
+	result := make([]interface{}, len(a))
+	for i, arg := range a {
+		if s, ok := arg.(string); ok {
+			arg = stripEmoji(s)
+		}
+		result[i] = arg
+	}
+	return result
+}
+
+// stripEmoji убирает из текста эмодзи вместе с пробелом после них.
+// Стрелки, маркеры списков и прочие символы текста сохраняются
+func stripEmoji(s string) string {
+	var b strings.Builder
+	removed := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case isEmoji(r):
+			removed = true
+			continue
+		case removed && r == ' ':
+			removed = false
+			continue
+		}
+		removed = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isEmoji сообщает, что символ относится к эмодзи или служит для их составления
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // пиктограммы, эмодзи, флаги
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // разные символы и дингбаты (✅ ❌ ⚠ ⚔)
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF, r >= 0x23E9 && r <= 0x23FA: // ⬆ ⭐ ⏱ ⏳
+		return true
+	case r == 0x2139, r == 0xFE0F, r == 0x200D: // ℹ, селектор варианта, ZWJ
+		return true
+	}
+	return false
+}
+
+// Result выводит структурированный результат команды в stdout в режиме --json
+func Result(v interface{}) error {
+	if !JSON {
+		return nil
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("ошибка вывода результата: %w", err)
+	}
+	return nil
+}
+
+// Stream выводит одно событие длительной команды отдельной строкой JSON (JSON Lines)
+// в stdout в режиме --json
+func Stream(v interface{}) error {
+	if !JSON {
+		return nil
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		return fmt.Errorf("ошибка вывода события: %w", err)
+	}
+	return nil
+}
+
+// ErrorResult описывает ошибку выполнения команды в режиме --json
+type ErrorResult struct {
+	Error string `json:"error"`
+}
+
+// Error выводит ошибку выполнения команды в stdout в режиме --json
+func Error(err error) {
+	if !JSON || err == nil {
+		return
+	}
+	_ = Result(ErrorResult{Error: err.Error()})
+}
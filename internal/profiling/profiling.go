@@ -0,0 +1,90 @@
+// Package profiling записывает профили CPU, памяти и трассировку выполнения
+// команды для отчетов о медленном анализе.
+package profiling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Пути файлов профилей; пустой путь - профиль не записывается
+var (
+	CPUProfile string
+	MemProfile string
+	Trace      string
+)
+
+// Start начинает запись профиля CPU и трассировки и возвращает функцию,
+// которая завершает их и записывает профиль памяти
+func Start() (func() error, error) {
+	var stops []func() error
+
+	if CPUProfile != "" {
+		file, err := os.Create(CPUProfile)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка создания профиля CPU: %w", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("ошибка запуска профиля CPU: %w", err)
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return file.Close()
+		})
+	}
+
+	if Trace != "" {
+		file, err := os.Create(Trace)
+		if err != nil {
+			stopAll(stops)
+			return nil, fmt.Errorf("ошибка создания трассировки: %w", err)
+		}
+		if err := trace.Start(file); err != nil {
+			file.Close()
+			stopAll(stops)
+			return nil, fmt.Errorf("ошибка запуска трассировки: %w", err)
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return file.Close()
+		})
+	}
+
+	if MemProfile != "" {
+		stops = append(stops, writeMemProfile)
+	}
+
+	return func() error { return stopAll(stops) }, nil
+}
+
+// writeMemProfile записывает профиль памяти после сборки мусора, чтобы в нем
+// были актуальные данные о живых объектах
+func writeMemProfile() error {
+	file, err := os.Create(MemProfile)
+	if err != nil {
+		return fmt.Errorf("ошибка создания профиля памяти: %w", err)
+	}
+	defer file.Close()
+
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		return fmt.Errorf("ошибка записи профиля памяти: %w", err)
+	}
+	return nil
+}
+
+// stopAll завершает запись профилей и объединяет ошибки
+func stopAll(stops []func() error) error {
+	var errs []error
+	for _, stop := range stops {
+		if err := stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
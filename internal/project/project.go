@@ -0,0 +1,448 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decisions"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+)
+
+// ConfigFileName - имя файла конфигурации проекта
+const ConfigFileName = ".json-schema-detector.json"
+
+// SchemaFileSuffix - стандартное окончание имени файла схемы
+const SchemaFileSuffix = ".schema.json"
+
+// DefaultContractsDir - директория контрактов потребителей относительно корня проекта по умолчанию
+const DefaultContractsDir = "contracts"
+
+// DefaultSnapshotsDir - директория снимков схем относительно корня проекта по умолчанию
+const DefaultSnapshotsDir = "snapshots"
+
+// SchemasDir задается глобальным флагом --schemas-dir и имеет приоритет над конфигурацией
+var SchemasDir string
+
+// SigningKey задается глобальным флагом --sign-key и имеет приоритет над конфигурацией
+var SigningKey string
+
+// Config представляет конфигурацию проекта
+type Config struct {
+	SchemasDir   string            `json:"schemas_dir,omitempty"`
+	Schemas      map[string]string `json:"schemas,omitempty"`       // Имя схемы → путь относительно корня проекта
+	SigningKey   string            `json:"signing_key,omitempty"`   // Закрытый ключ для подписи сохраняемых схем
+	ContractsDir string            `json:"contracts_dir,omitempty"` // Директория контрактов потребителей
+	SnapshotsDir string            `json:"snapshots_dir,omitempty"` // Директория датированных снимков схем
+	// Decisions - решения пользователя по полям схем: имя схемы → путь поля → решение
+	Decisions map[string]map[string]decisions.Decision `json:"decisions,omitempty"`
+}
+
+// Project представляет найденный проект со схемами
+type Project struct {
+	Root       string  `json:"root"`
+	ConfigPath string  `json:"config_path"`
+	Config     *Config `json:"config"`
+}
+
+// Discover ищет файл конфигурации, поднимаясь от указанной директории к корню
+// файловой системы. Возвращает nil без ошибки, если конфигурация не найдена.
+func Discover(start string) (*Project, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка определения директории: %w", err)
+	}
+
+	for {
+		configPath := filepath.Join(dir, ConfigFileName)
+		if info, err := os.Stat(configPath); err == nil && !info.IsDir() {
+			config, err := LoadConfig(configPath)
+			if err != nil {
+				return nil, err
+			}
+			return &Project{Root: dir, ConfigPath: configPath, Config: config}, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Current ищет проект, начиная с текущей рабочей директории
+func Current() (*Project, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка определения рабочей директории: %w", err)
+	}
+	return Discover(cwd)
+}
+
+// LoadConfig читает конфигурацию проекта из файла
+func LoadConfig(path string) (*Config, error) {
+	data, err := fileutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения конфигурации: %w", err)
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга конфигурации %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// SaveConfig записывает конфигурацию проекта в файл
+func SaveConfig(path string, config *Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+	}
+
+	if err := fileutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("ошибка записи конфигурации: %w", err)
+	}
+	return nil
+}
+
+// SchemasDirPath возвращает абсолютный путь к директории схем проекта
+func (p *Project) SchemasDirPath() string {
+	if p.Config == nil || p.Config.SchemasDir == "" {
+		return ""
+	}
+	if filepath.IsAbs(p.Config.SchemasDir) {
+		return p.Config.SchemasDir
+	}
+	return filepath.Join(p.Root, p.Config.SchemasDir)
+}
+
+// SigningKeyPath возвращает абсолютный путь к ключу подписи проекта
+func (p *Project) SigningKeyPath() string {
+	if p.Config == nil || p.Config.SigningKey == "" {
+		return ""
+	}
+	path := filepath.FromSlash(p.Config.SigningKey)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.Root, path)
+}
+
+// ContractsDirPath возвращает абсолютный путь к директории контрактов потребителей
+func (p *Project) ContractsDirPath() string {
+	dir := DefaultContractsDir
+	if p.Config != nil && p.Config.ContractsDir != "" {
+		dir = filepath.FromSlash(p.Config.ContractsDir)
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(p.Root, dir)
+}
+
+// SnapshotsDirPath возвращает абсолютный путь к директории снимков схем
+func (p *Project) SnapshotsDirPath() string {
+	dir := DefaultSnapshotsDir
+	if p.Config != nil && p.Config.SnapshotsDir != "" {
+		dir = filepath.FromSlash(p.Config.SnapshotsDir)
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(p.Root, dir)
+}
+
+// Lookup возвращает путь к зарегистрированной схеме по имени
+func (p *Project) Lookup(name string) (string, bool) {
+	if p.Config == nil || p.Config.Schemas == nil {
+		return "", false
+	}
+
+	path, ok := p.Config.Schemas[name]
+	if !ok {
+		return "", false
+	}
+	path = filepath.FromSlash(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.Root, path)
+	}
+	return path, true
+}
+
+// NameOf возвращает имя схемы по пути к файлу: зарегистрированное имя или имя файла
+func (p *Project) NameOf(schemaFile string) string {
+	if abs, err := filepath.Abs(schemaFile); err == nil && p.Config != nil {
+		for name := range p.Config.Schemas {
+			path, _ := p.Lookup(name)
+			if registered, err := filepath.Abs(path); err == nil && registered == abs {
+				return name
+			}
+		}
+	}
+	return SchemaName(schemaFile)
+}
+
+// Decisions возвращает сохраненные решения по полям схемы
+func (p *Project) Decisions(schemaFile string) map[string]decisions.Decision {
+	if p.Config == nil {
+		return nil
+	}
+	return p.Config.Decisions[p.NameOf(schemaFile)]
+}
+
+// RememberDecision сохраняет решение по полю схемы и записывает конфигурацию;
+// прежнее решение по тому же полю заменяется
+func (p *Project) RememberDecision(schemaFile, path string, decision decisions.Decision) error {
+	if p.Config == nil {
+		p.Config = &Config{}
+	}
+	if p.Config.Decisions == nil {
+		p.Config.Decisions = make(map[string]map[string]decisions.Decision)
+	}
+	name := p.NameOf(schemaFile)
+	if p.Config.Decisions[name] == nil {
+		p.Config.Decisions[name] = make(map[string]decisions.Decision)
+	}
+	p.Config.Decisions[name][path] = decision
+
+	return SaveConfig(p.ConfigPath, p.Config)
+}
+
+// Register добавляет схему в индекс проекта и сохраняет конфигурацию
+func (p *Project) Register(name, schemaFile string) error {
+	if !IsSchemaName(name) {
+		return fmt.Errorf("некорректное имя схемы: %s", name)
+	}
+
+	absPath, err := filepath.Abs(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка определения пути схемы: %w", err)
+	}
+
+	path := absPath
+	if rel, err := filepath.Rel(p.Root, absPath); err == nil && !isOutside(rel) {
+		path = filepath.ToSlash(rel)
+	}
+
+	if p.Config == nil {
+		p.Config = &Config{}
+	}
+	if p.Config.Schemas == nil {
+		p.Config.Schemas = make(map[string]string)
+	}
+	p.Config.Schemas[name] = path
+
+	return SaveConfig(p.ConfigPath, p.Config)
+}
+
+// ResolveSchemasDir возвращает директорию схем с учетом флага и конфигурации проекта
+func ResolveSchemasDir() (string, error) {
+	if SchemasDir != "" {
+		return SchemasDir, nil
+	}
+
+	project, err := Current()
+	if err != nil {
+		return "", err
+	}
+	if project == nil {
+		return "", nil
+	}
+	return project.SchemasDirPath(), nil
+}
+
+// ResolveSigningKey возвращает путь к ключу подписи с учетом флага и конфигурации проекта.
+// Пустая строка означает, что подпись схем не настроена.
+func ResolveSigningKey() (string, error) {
+	if SigningKey != "" {
+		return SigningKey, nil
+	}
+
+	project, err := Current()
+	if err != nil {
+		return "", err
+	}
+	if project == nil {
+		return "", nil
+	}
+	return project.SigningKeyPath(), nil
+}
+
+// ResolveContractsDir возвращает директорию контрактов потребителей проекта
+func ResolveContractsDir() (string, error) {
+	project, err := Current()
+	if err != nil {
+		return "", err
+	}
+	if project == nil {
+		return DefaultContractsDir, nil
+	}
+	return project.ContractsDirPath(), nil
+}
+
+// ResolveSnapshotsDir возвращает директорию снимков схем проекта
+func ResolveSnapshotsDir() (string, error) {
+	project, err := Current()
+	if err != nil {
+		return "", err
+	}
+	if project == nil {
+		return DefaultSnapshotsDir, nil
+	}
+	return project.SnapshotsDirPath(), nil
+}
+
+// ResolveSchema превращает ссылку на схему в путь к файлу. Существующие файлы
+// возвращаются как есть, иначе ссылка ищется в директории схем как имя схемы.
+func ResolveSchema(ref string) (string, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return ref, nil
+	}
+
+	// Зарегистрированные имена имеют приоритет над соглашениями об именах файлов
+	if IsSchemaName(ref) {
+		project, err := Current()
+		if err != nil {
+			return "", err
+		}
+		if project != nil {
+			if path, ok := project.Lookup(ref); ok {
+				return path, nil
+			}
+		}
+	}
+
+	dir, err := ResolveSchemasDir()
+	if err != nil {
+		return "", err
+	}
+	if dir == "" {
+		return ref, nil
+	}
+
+	for _, candidate := range []string{ref, ref + SchemaFileSuffix, ref + ".json"} {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+
+	return ref, nil
+}
+
+// ResolveOutput определяет путь для новой схемы. Имя без расширения и разделителей
+// пути размещается в директории схем проекта.
+func ResolveOutput(ref string) (string, error) {
+	if !IsSchemaName(ref) {
+		return ref, nil
+	}
+
+	project, err := Current()
+	if err != nil {
+		return "", err
+	}
+	if project != nil {
+		if path, ok := project.Lookup(ref); ok {
+			return path, nil
+		}
+	}
+
+	dir, err := ResolveSchemasDir()
+	if err != nil {
+		return "", err
+	}
+	if dir == "" {
+		return ref, nil
+	}
+
+	return filepath.Join(dir, ref+SchemaFileSuffix), nil
+}
+
+// IsSchemaName сообщает, что ссылка является именем схемы, а не путем к файлу
+func IsSchemaName(ref string) bool {
+	return ref != "" && filepath.Ext(ref) == "" && !strings.ContainsAny(ref, `/\`)
+}
+
+// SelectSchemas определяет набор схем: имя схемы → путь к файлу. Без ссылок
+// возвращаются зарегистрированные схемы и схемы из директории схем проекта.
+func SelectSchemas(refs []string) (map[string]string, error) {
+	schemas := make(map[string]string)
+
+	if len(refs) > 0 {
+		for _, ref := range refs {
+			schemaFile, err := ResolveSchema(ref)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+				return nil, fmt.Errorf("файл схемы не найден: %s", schemaFile)
+			}
+
+			name := ref
+			if !IsSchemaName(ref) {
+				name = SchemaName(schemaFile)
+			}
+			schemas[name] = schemaFile
+		}
+		return schemas, nil
+	}
+
+	// Зарегистрированные схемы проекта
+	seen := make(map[string]bool)
+	p, err := Current()
+	if err != nil {
+		return nil, err
+	}
+	if p != nil && p.Config != nil {
+		for name := range p.Config.Schemas {
+			schemaFile, _ := p.Lookup(name)
+			schemas[name] = schemaFile
+			if abs, err := filepath.Abs(schemaFile); err == nil {
+				seen[abs] = true
+			}
+		}
+	}
+
+	// Схемы из директории схем, не попавшие в индекс
+	dir, err := ResolveSchemasDir()
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return schemas, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+SchemaFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, schemaFile := range matches {
+		if abs, err := filepath.Abs(schemaFile); err == nil && seen[abs] {
+			continue
+		}
+		name := SchemaName(schemaFile)
+		if _, exists := schemas[name]; exists {
+			continue
+		}
+		schemas[name] = schemaFile
+	}
+
+	return schemas, nil
+}
+
+// SchemaName возвращает имя схемы по имени файла
+func SchemaName(schemaFile string) string {
+	base := filepath.Base(schemaFile)
+	if strings.HasSuffix(base, SchemaFileSuffix) {
+		return strings.TrimSuffix(base, SchemaFileSuffix)
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// isOutside сообщает, что относительный путь выходит за пределы базовой директории
+func isOutside(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
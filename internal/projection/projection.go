@@ -0,0 +1,96 @@
+package projection
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
+)
+
+var (
+	outputFile   string
+	requiredOnly bool
+	verbose      bool
+)
+
+// Result представляет результат команды project в режиме --json
+type Result struct {
+	Data   string `json:"data"`
+	Schema string `json:"schema"`
+	Output string `json:"output"`
+	*validator.CoerceResult
+}
+
+// Cmd представляет команду project
+var Cmd = &cobra.Command{
+	Use:   "project [data.json] [schema.json]",
+	Short: "Оставляет в данных только поля схемы",
+	Long: `Проецирует документ JSON на схему: в объектах остаются только поля,
+описанные схемой (properties, patternProperties или additionalProperties со
+схемой), остальные удаляются вместе с вложенными значениями. С флагом
+--required-only остаются только обязательные поля.
+
+Так из рабочих выгрузок получаются минимальные фикстуры, а из данных
+убираются лишние, в том числе чувствительные, поля. Значения не меняются,
+порядок полей сохраняется. Внутри oneOf/anyOf поля берутся из подошедших
+вариантов или ближайшего, если не подошел ни один.
+
+Примеры использования:
+  project dump.json users.schema.json -o slim.json
+  project dump.json users -o fixture.json --required-only`,
+	Args: cobra.ExactArgs(2),
+	RunE: runProject,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл для данных после проекции")
+	Cmd.Flags().BoolVar(&requiredOnly, "required-only", false, "Оставить только обязательные поля")
+	Cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Выводить каждое удаленное поле")
+	Cmd.MarkFlagRequired("output")
+}
+
+func runProject(cmd *cobra.Command, args []string) error {
+	dataFile := args[0]
+	schemaFile, err := project.ResolveSchema(args[1])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dataFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл данных не найден: %s", dataFile)
+	}
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+
+	output.Printf("Проекция данных: %s\n", dataFile)
+	output.Printf("На схему: %s\n", schemaFile)
+
+	result, err := validator.New(false).ProjectFile(dataFile, schemaFile, validator.ProjectOptions{RequiredOnly: requiredOnly})
+	if err != nil {
+		return fmt.Errorf("ошибка проекции данных: %w", err)
+	}
+	if err := fileutil.WriteFile(outputFile, result.Data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи данных: %w", err)
+	}
+
+	if len(result.Fixes) == 0 {
+		output.Printf("✂️ Лишних полей нет\n")
+	} else {
+		output.Printf("✂️ Удалено полей: %d\n", len(result.Fixes))
+		if verbose {
+			for _, fix := range result.Fixes {
+				output.Printf("   %s\n", fix.Field)
+			}
+		}
+	}
+	output.Printf("💾 Данные после проекции: %s\n", outputFile)
+	if !result.Remaining.Valid {
+		output.Printf("⚠️ Данные не соответствуют схеме, ошибок: %d (проверьте командой validate)\n", len(result.Remaining.Errors))
+	}
+
+	return output.Result(Result{Data: dataFile, Schema: schemaFile, Output: outputFile, CoerceResult: result})
+}
@@ -0,0 +1,81 @@
+package register
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+)
+
+// Result представляет результат команды register в режиме --json
+type Result struct {
+	Config  string            `json:"config"`
+	Schemas map[string]string `json:"schemas"`
+}
+
+// Cmd представляет команду register
+var Cmd = &cobra.Command{
+	Use:   "register [name] [schema.json]",
+	Short: "Регистрирует схему под именем в конфигурации проекта",
+	Long: `Добавляет схему в индекс проекта (` + project.ConfigFileName + `), после чего
+команды могут ссылаться на нее по имени вместо пути к файлу.
+Без аргументов выводит список зарегистрированных схем.
+
+Примеры использования:
+  register users schemas/users.schema.json
+  update users -i dump.json
+  register`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return fmt.Errorf("ожидается 0 или 2 аргумента, получено %d", len(args))
+		}
+		return nil
+	},
+	RunE: runRegister,
+}
+
+func runRegister(cmd *cobra.Command, args []string) error {
+	p, err := project.Current()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return fmt.Errorf("конфигурация проекта %s не найдена", project.ConfigFileName)
+	}
+
+	if len(args) == 2 {
+		name := args[0]
+		schemaFile := args[1]
+
+		// Проверяем существование файла схемы
+		if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+			return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+		}
+
+		if err := p.Register(name, schemaFile); err != nil {
+			return fmt.Errorf("ошибка регистрации схемы: %w", err)
+		}
+		output.Printf("✅ Схема %s зарегистрирована: %s\n", name, p.Config.Schemas[name])
+	}
+
+	schemas := map[string]string{}
+	if p.Config != nil && p.Config.Schemas != nil {
+		schemas = p.Config.Schemas
+	}
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output.Printf("📋 Зарегистрированные схемы (%s):\n", p.ConfigPath)
+	for _, name := range names {
+		output.Printf("  %s → %s\n", name, schemas[name])
+	}
+
+	return output.Result(Result{Config: p.ConfigPath, Schemas: schemas})
+}
@@ -0,0 +1,314 @@
+package relate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/relations"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+var (
+	remove     bool
+	exportAs   string
+	outputFile string
+)
+
+// SchemaRelations - связи одной схемы
+type SchemaRelations struct {
+	Schema    string               `json:"schema"`
+	Relations []relations.Relation `json:"relations"`
+}
+
+// Result представляет результат команды relate в режиме --json
+type Result struct {
+	// Relation - добавленная или удаленная связь
+	Relation  *relations.Relation `json:"relation,omitempty"`
+	Removed   bool                `json:"removed,omitempty"`
+	Changed   bool                `json:"changed"`
+	Signature string              `json:"signature,omitempty"`
+	// Schemas - связи схем проекта (без аргументов)
+	Schemas []SchemaRelations `json:"schemas,omitempty"`
+	// Model и Diagram - обзор модели данных (--export)
+	Model   *relations.Model `json:"model,omitempty"`
+	Diagram string           `json:"diagram,omitempty"`
+	Output  string           `json:"output,omitempty"`
+}
+
+// Cmd представляет команду relate
+var Cmd = &cobra.Command{
+	Use:   "relate [source] [target]",
+	Short: "Связывает схемы объявленными связями и строит обзор модели данных",
+	Long: `Объявляет, что поле одной схемы ссылается на поле другой (orders.items.productId → products.id).
+Связь сохраняется в расширении x-relations схемы-источника и переживает обновления схемы.
+
+Концы связи записываются как <схема>.<путь> (имя схемы до первой точки) или
+<файл схемы>:<путь>. Путь можно сокращать: индексы массивов и префикс записи
+ответа (data.0) подставляются автоматически, если поле определяется однозначно.
+
+Без аргументов выводит связи всех схем проекта. С флагом --export строит
+ER-диаграмму модели данных (mermaid или dot) по схемам проекта или указанным схемам.
+
+Примеры использования:
+  relate orders.items.productId products.id
+  relate orders.items.productId products.id --remove
+  relate
+  relate --export mermaid
+  relate --export dot -o model.dot orders products`,
+	RunE: runRelate,
+}
+
+func init() {
+	Cmd.Flags().BoolVar(&remove, "remove", false, "Удалить связь вместо добавления")
+	Cmd.Flags().StringVar(&exportAs, "export", "", "Вывести обзор модели данных: "+relations.FormatMermaid+" или "+relations.FormatDOT)
+	Cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл для диаграммы (по умолчанию stdout)")
+}
+
+func runRelate(cmd *cobra.Command, args []string) error {
+	if exportAs != "" {
+		return runExport(args)
+	}
+
+	switch len(args) {
+	case 0:
+		return runList()
+	case 2:
+		return runLink(args[0], args[1])
+	default:
+		return fmt.Errorf("укажите два конца связи: relate <схема>.<поле> <схема>.<поле>")
+	}
+}
+
+// endpoint - конец связи: схема и путь поля в ней
+type endpoint struct {
+	name string
+	file string
+	path string
+}
+
+// parseEndpoint разбирает конец связи вида schema.path или file.json:path
+func parseEndpoint(ref string) (endpoint, error) {
+	var schemaRef, path string
+	if i := strings.LastIndex(ref, ":"); i > 0 && !project.IsSchemaName(ref[:i]) {
+		schemaRef, path = ref[:i], ref[i+1:]
+	} else if i := strings.Index(ref, "."); i > 0 {
+		schemaRef, path = ref[:i], ref[i+1:]
+	}
+	if schemaRef == "" || path == "" {
+		return endpoint{}, fmt.Errorf("неверный конец связи %q: ожидается <схема>.<поле> или <файл схемы>:<поле>", ref)
+	}
+
+	schemaFile, err := project.ResolveSchema(schemaRef)
+	if err != nil {
+		return endpoint{}, err
+	}
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return endpoint{}, fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+
+	name := schemaRef
+	if !project.IsSchemaName(schemaRef) {
+		name = project.SchemaName(schemaFile)
+	}
+	return endpoint{name: name, file: schemaFile, path: path}, nil
+}
+
+func runLink(sourceRef, targetRef string) error {
+	source, err := parseEndpoint(sourceRef)
+	if err != nil {
+		return err
+	}
+	target, err := parseEndpoint(targetRef)
+	if err != nil {
+		return err
+	}
+
+	a := analyzer.New()
+	result, err := a.LoadSchema(source.file)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы %s: %w", source.file, err)
+	}
+	schema, targetSchema := result.Schema, result.Schema
+	if target.file != source.file {
+		targetResult, err := a.LoadSchema(target.file)
+		if err != nil {
+			return fmt.Errorf("ошибка загрузки схемы %s: %w", target.file, err)
+		}
+		targetSchema = targetResult.Schema
+	}
+
+	existing, err := relations.Load(schema)
+	if err != nil {
+		return err
+	}
+
+	var relation relations.Relation
+	if remove {
+		// Удаляемое поле могло уже исчезнуть из схемы, поэтому путь берется как есть,
+		// если он не разрешается
+		relation = relations.Relation{Field: source.path, Schema: target.name, Target: target.path}
+		if path, _, err := relations.ResolveField(schema, source.path); err == nil {
+			relation.Field = path
+		}
+		if path, _, err := relations.ResolveField(targetSchema, target.path); err == nil {
+			relation.Target = path
+		}
+	} else {
+		field, sourceProp, err := relations.ResolveField(schema, source.path)
+		if err != nil {
+			return fmt.Errorf("схема %s: %w", source.name, err)
+		}
+		targetField, targetProp, err := relations.ResolveField(targetSchema, target.path)
+		if err != nil {
+			return fmt.Errorf("схема %s: %w", target.name, err)
+		}
+		relation = relations.Relation{Field: field, Schema: target.name, Target: targetField}
+		if sourceProp.Type != targetProp.Type {
+			output.Printf("⚠️ Типы полей различаются: %s (%s) и %s (%s)\n", field, sourceProp.Type, targetField, targetProp.Type)
+		}
+	}
+
+	var changed bool
+	if remove {
+		existing, changed = relations.Remove(existing, relation)
+	} else {
+		existing, changed = relations.Add(existing, relation)
+	}
+
+	res := Result{Relation: &relation, Removed: remove, Changed: changed}
+	if !changed {
+		if remove {
+			output.Printf("ℹ️ Связь не найдена: %s.%s\n", source.name, relation)
+		} else {
+			output.Printf("ℹ️ Связь уже объявлена: %s.%s\n", source.name, relation)
+		}
+		return output.Result(res)
+	}
+
+	relations.Store(schema, existing)
+	if err := a.SaveSchema(result, source.file); err != nil {
+		return fmt.Errorf("ошибка сохранения схемы: %w", err)
+	}
+
+	signatureFile, err := signing.SignSchema(source.file)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+	res.Signature = signatureFile
+
+	if remove {
+		output.Printf("🗑️ Связь удалена: %s.%s\n", source.name, relation)
+	} else {
+		output.Printf("🔗 Связь добавлена: %s.%s\n", source.name, relation)
+	}
+	if signatureFile != "" {
+		output.Printf("🔏 Подпись: %s\n", signatureFile)
+	}
+
+	return output.Result(res)
+}
+
+// loadSchemas загружает схемы проекта или указанные схемы
+func loadSchemas(refs []string) (map[string]*types.JSONSchema, error) {
+	files, err := project.SelectSchemas(refs)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("схемы не найдены: укажите схемы аргументами или директорию схем флагом --schemas-dir")
+	}
+
+	a := analyzer.New()
+	schemas := make(map[string]*types.JSONSchema, len(files))
+	for name, file := range files {
+		result, err := a.LoadSchema(file)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки схемы %s: %w", file, err)
+		}
+		schemas[name] = result.Schema
+	}
+	return schemas, nil
+}
+
+func runList() error {
+	schemas, err := loadSchemas(nil)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := Result{Schemas: make([]SchemaRelations, 0)}
+	for _, name := range names {
+		list, err := relations.Load(schemas[name])
+		if err != nil {
+			return fmt.Errorf("схема %s: %w", name, err)
+		}
+		if len(list) == 0 {
+			continue
+		}
+		res.Schemas = append(res.Schemas, SchemaRelations{Schema: name, Relations: list})
+	}
+
+	if len(res.Schemas) == 0 {
+		output.Printf("ℹ️ Связи между схемами не объявлены\n")
+		return output.Result(res)
+	}
+
+	output.Printf("🔗 Связи схем:\n")
+	for _, s := range res.Schemas {
+		for _, r := range s.Relations {
+			marker := ""
+			if _, ok := schemas[r.Schema]; !ok {
+				marker = " (схема не найдена)"
+			}
+			output.Printf("  %s.%s%s\n", s.Schema, r, marker)
+		}
+	}
+
+	return output.Result(res)
+}
+
+func runExport(refs []string) error {
+	schemas, err := loadSchemas(refs)
+	if err != nil {
+		return err
+	}
+
+	model, err := relations.BuildModel(schemas)
+	if err != nil {
+		return err
+	}
+	diagram, err := model.Render(exportAs)
+	if err != nil {
+		return err
+	}
+
+	res := Result{Model: model, Output: outputFile}
+	if outputFile != "" {
+		if err := fileutil.WriteFile(outputFile, []byte(diagram), 0644); err != nil {
+			return fmt.Errorf("ошибка записи диаграммы: %w", err)
+		}
+		output.Printf("🗺️ Диаграмма модели данных (%d схем, %d связей) сохранена в %s\n", len(model.Entities), len(model.Links), outputFile)
+		return output.Result(res)
+	}
+
+	if output.JSON {
+		res.Diagram = diagram
+		return output.Result(res)
+	}
+	fmt.Print(diagram)
+	return nil
+}
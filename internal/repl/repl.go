@@ -0,0 +1,255 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
+)
+
+var verbose bool
+
+// Result представляет результат проверки одного фрагмента в режиме --json
+type Result struct {
+	Schema string `json:"schema"`
+	*validator.MatchResult
+}
+
+// Cmd представляет команду repl
+var Cmd = &cobra.Command{
+	Use:   "repl [schema.json]",
+	Short: "Песочница для проверки фрагментов JSON по схеме",
+	Long: `Читает фрагменты JSON из stdin и для каждого сразу показывает результат
+валидации и то, какие части схемы ему подошли: какой вариант oneOf/anyOf
+выбран (или почему не подошел ни один) и с каким значением enum совпало
+значение. Помогает отлаживать полиморфные схемы.
+
+Фрагмент может занимать несколько строк: он проверяется, как только JSON
+завершен. Команды:
+  :reload  перечитать схему после правки файла
+  :reset   сбросить недописанный фрагмент
+  :help    показать команды
+  :quit    выйти (также Ctrl+D)
+
+Без терминала фрагменты читаются из stdin без приглашений; с --json каждый
+результат выводится отдельной строкой JSON.
+
+Примеры использования:
+  repl users.schema.json
+  repl users < samples.ndjson`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRepl,
+}
+
+func init() {
+	Cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Показывать ошибки всех неподошедших вариантов")
+}
+
+// session хранит состояние песочницы
+type session struct {
+	schemaFile string
+	matcher    *validator.Matcher
+	pending    bytes.Buffer
+}
+
+func runRepl(cmd *cobra.Command, args []string) error {
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
+	matcher, err := validator.NewMatcher(schemaFile)
+	if err != nil {
+		return err
+	}
+	s := &session{schemaFile: schemaFile, matcher: matcher}
+
+	if output.Interactive() {
+		output.Printf("🧪 Песочница схемы %s\n", schemaFile)
+		output.Printf("Вставьте JSON для проверки; :help - команды, Ctrl+D - выход\n")
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if s.pending.Len() == 0 {
+			output.Prompt("> ")
+		} else {
+			output.Prompt("... ")
+		}
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if quit := s.line(line); quit {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка чтения ввода: %w", err)
+		}
+	}
+
+	if strings.TrimSpace(s.pending.String()) != "" {
+		s.fail(fmt.Errorf("ввод завершился на незаконченном JSON"))
+	}
+	return nil
+}
+
+// line обрабатывает строку ввода и сообщает, что пользователь вышел
+func (s *session) line(line string) bool {
+	switch strings.TrimSpace(line) {
+	case ":quit", ":q", ":exit":
+		return true
+	case ":help":
+		output.Printf(":reload - перечитать схему, :reset - сбросить ввод, :quit - выйти\n")
+		return false
+	case ":reset":
+		s.pending.Reset()
+		return false
+	case ":reload":
+		s.reload()
+		return false
+	}
+
+	s.pending.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		s.pending.WriteByte('\n')
+	}
+	s.flush()
+	return false
+}
+
+// reload перечитывает схему; при ошибке остается прежняя схема
+func (s *session) reload() {
+	matcher, err := validator.NewMatcher(s.schemaFile)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	s.matcher = matcher
+	output.Printf("🔄 Схема %s перечитана\n", s.schemaFile)
+}
+
+// flush проверяет завершенные фрагменты из накопленного ввода; незаконченный
+// JSON остается в буфере до следующих строк
+func (s *session) flush() {
+	data := s.pending.Bytes()
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var consumed int64
+	for {
+		var snippet json.RawMessage
+		err := decoder.Decode(&snippet)
+		if errors.Is(err, io.EOF) {
+			s.pending.Reset()
+			return
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			rest := append([]byte(nil), data[consumed:]...)
+			s.pending.Reset()
+			s.pending.Write(rest)
+			return
+		}
+		if err != nil {
+			s.pending.Reset()
+			s.fail(fmt.Errorf("ошибка парсинга JSON: %w", err))
+			return
+		}
+		consumed = decoder.InputOffset()
+		s.check(snippet)
+	}
+}
+
+// check проверяет фрагмент и выводит результат
+func (s *session) check(snippet []byte) {
+	result, err := s.matcher.Match(snippet)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	if output.JSON {
+		if err := output.Stream(Result{Schema: s.schemaFile, MatchResult: result}); err != nil {
+			s.fail(err)
+		}
+		return
+	}
+
+	if result.Valid {
+		output.Printf("✅ Соответствует схеме\n")
+	} else {
+		output.Printf("❌ Не соответствует схеме, ошибок: %d\n", len(result.Errors))
+		for i, e := range result.Errors {
+			output.Printf("  %d. %s: %s\n", i+1, e.Field, e.Description)
+		}
+	}
+	for _, match := range result.Matches {
+		printMatch(match)
+	}
+	output.Println()
+}
+
+// fail выводит ошибку обработки фрагмента, не прерывая сессию
+func (s *session) fail(err error) {
+	if output.JSON {
+		_ = output.Stream(output.ErrorResult{Error: err.Error()})
+		return
+	}
+	output.Printf("⚠️ %v\n", err)
+}
+
+// printMatch выводит выбор варианта oneOf/anyOf или значения enum
+func printMatch(match validator.Match) {
+	if match.Keyword == "enum" {
+		if len(match.Matched) == 0 {
+			output.Printf("🏷️ %s: enum %s - значение не входит в enum\n", match.Field, match.Schema)
+			return
+		}
+		value, _ := json.Marshal(match.Value)
+		output.Printf("🏷️ %s: enum %s → %s (значение %d)\n", match.Field, match.Schema, value, match.Matched[0])
+		return
+	}
+
+	switch {
+	case len(match.Matched) == 0:
+		output.Printf("🔀 %s: %s %s - не подошел ни один вариант\n", match.Field, match.Keyword, match.Schema)
+	case len(match.Matched) > 1 && match.Keyword == "oneOf":
+		output.Printf("🔀 %s: oneOf %s - подошло несколько вариантов: %s\n", match.Field, match.Schema, branchList(match, match.Matched))
+	default:
+		output.Printf("🔀 %s: %s %s → %s\n", match.Field, match.Keyword, match.Schema, branchList(match, match.Matched))
+	}
+
+	// Причины отказа вариантов показываются, когда выбор не состоялся
+	if !verbose && len(match.Matched) > 0 {
+		return
+	}
+	for _, branch := range match.Branches {
+		if branch.Valid {
+			continue
+		}
+		closest := ""
+		if branch.Closest {
+			closest = " - ближайший"
+		}
+		output.Printf("     вариант %s%s:\n", branch.Name(), closest)
+		for _, e := range branch.Errors {
+			output.Printf("       - %s: %s\n", e.Field, e.Description)
+		}
+	}
+}
+
+// branchList перечисляет варианты по номерам
+func branchList(match validator.Match, indexes []int) string {
+	names := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		names = append(names, "вариант "+match.Branches[i].Name())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,277 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
+)
+
+var (
+	staleDays     int
+	nullThreshold float64
+)
+
+// SchemaReport содержит показатели состояния одной схемы
+type SchemaReport struct {
+	File                string              `json:"file"`
+	Version             string              `json:"version,omitempty"`
+	UpdatedAt           time.Time           `json:"updated_at"`
+	Stale               bool                `json:"stale"`
+	Fields              int                 `json:"fields"`
+	DescribedFields     int                 `json:"described_fields"`
+	PendingEnums        []string            `json:"pending_enums,omitempty"`
+	NullFields          []analyzer.NullRate `json:"null_fields,omitempty"`
+	MetaSchemaErrors    []string            `json:"meta_schema_errors,omitempty"`
+	LoadError           string              `json:"load_error,omitempty"`
+	DescriptionCoverage float64             `json:"description_coverage"`
+}
+
+// Result представляет результат команды report в режиме --json
+type Result struct {
+	SchemasDir          string         `json:"schemas_dir"`
+	Schemas             []SchemaReport `json:"schemas"`
+	StaleSchemas        int            `json:"stale_schemas"`
+	InvalidSchemas      int            `json:"invalid_schemas"`
+	PendingEnums        int            `json:"pending_enums"`
+	NullFields          int            `json:"null_fields"`
+	DescriptionCoverage float64        `json:"description_coverage"`
+}
+
+// Cmd представляет команду report
+var Cmd = &cobra.Command{
+	Use:   "report [schemas-dir]",
+	Short: "Показывает сводку о состоянии схем проекта",
+	Long: `Сканирует директорию схем и выводит сводку для курирования:
+- схемы, которые давно не обновлялись
+- схемы, не проходящие проверку мета-схемой
+- покрытие полей описаниями
+- кандидаты в enum, ожидающие подтверждения
+- поля, которые часто приходят null (признак проблем с качеством данных)
+
+Отчет строится только по локальным файлам, никакие данные никуда не отправляются.
+Без аргумента используется директория схем проекта.
+
+Примеры использования:
+  report
+  report ./schemas --stale-days 30
+  report --null-threshold 80`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReport,
+}
+
+func init() {
+	Cmd.Flags().IntVar(&staleDays, "stale-days", 90, "Через сколько дней без обновлений схема считается устаревшей")
+	Cmd.Flags().Float64Var(&nullThreshold, "null-threshold", 50, "Доля null значений в процентах, начиная с которой поле попадает в отчет")
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	dir := ""
+	if len(args) == 1 {
+		dir = args[0]
+	} else {
+		resolved, err := project.ResolveSchemasDir()
+		if err != nil {
+			return err
+		}
+		dir = resolved
+	}
+	if dir == "" {
+		return fmt.Errorf("директория схем не задана: укажите ее аргументом, флагом --schemas-dir или в %s", project.ConfigFileName)
+	}
+
+	files, err := findSchemas(dir)
+	if err != nil {
+		return err
+	}
+
+	output.Printf("📊 Отчет по схемам: %s\n", dir)
+	output.Printf("📄 Найдено схем: %d\n", len(files))
+	output.Println()
+
+	res := Result{SchemasDir: dir, Schemas: make([]SchemaReport, 0, len(files))}
+	staleBefore := time.Now().AddDate(0, 0, -staleDays)
+	totalFields, describedFields := 0, 0
+
+	for _, file := range files {
+		r := inspectSchema(file, staleBefore)
+		res.Schemas = append(res.Schemas, r)
+
+		totalFields += r.Fields
+		describedFields += r.DescribedFields
+		if r.Stale {
+			res.StaleSchemas++
+		}
+		if r.LoadError != "" || len(r.MetaSchemaErrors) > 0 {
+			res.InvalidSchemas++
+		}
+		res.PendingEnums += len(r.PendingEnums)
+		res.NullFields += len(r.NullFields)
+
+		printSchemaReport(dir, r)
+	}
+	res.DescriptionCoverage = coverage(describedFields, totalFields)
+
+	output.Println()
+	output.Printf("🧭 Итого:\n")
+	output.Printf("   Устаревших схем (> %d дн.): %d\n", staleDays, res.StaleSchemas)
+	output.Printf("   Схем с ошибками: %d\n", res.InvalidSchemas)
+	output.Printf("   Покрытие описаниями: %.0f%% (%d из %d полей)\n", res.DescriptionCoverage*100, describedFields, totalFields)
+	output.Printf("   Кандидатов в enum без решения: %d\n", res.PendingEnums)
+	output.Printf("   Полей, часто равных null (>= %.0f%%): %d\n", nullThreshold, res.NullFields)
+
+	return output.Result(res)
+}
+
+// findSchemas находит файлы схем в директории и поддиректориях
+func findSchemas(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isSchemaFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения директории схем: %w", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isSchemaFile отбирает файлы схем: *.schema.json или JSON документы с ключом $schema
+func isSchemaFile(path string) bool {
+	if strings.HasSuffix(path, project.SchemaFileSuffix) {
+		return true
+	}
+	if !strings.HasSuffix(path, ".json") || filepath.Base(path) == project.ConfigFileName {
+		return false
+	}
+
+	data, err := fileutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(data, &header); err != nil {
+		return false
+	}
+	_, ok := header["$schema"]
+	return ok
+}
+
+// inspectSchema собирает показатели одной схемы
+func inspectSchema(file string, staleBefore time.Time) SchemaReport {
+	r := SchemaReport{File: file}
+
+	data, err := fileutil.ReadFile(file)
+	if err != nil {
+		r.LoadError = err.Error()
+		return r
+	}
+
+	result, err := analyzer.New().LoadSchemaBytes(data)
+	if err != nil {
+		r.LoadError = err.Error()
+		return r
+	}
+
+	if check, err := validator.New(false).ValidateSchemaBytes(data); err != nil {
+		r.MetaSchemaErrors = append(r.MetaSchemaErrors, err.Error())
+	} else {
+		for _, e := range check.Errors {
+			r.MetaSchemaErrors = append(r.MetaSchemaErrors, fmt.Sprintf("%s: %s", e.Field, e.Description))
+		}
+	}
+
+	// Время обновления известно только для схем с метаданными анализа
+	var header map[string]json.RawMessage
+	if json.Unmarshal(data, &header) == nil && header[types.ExtensionAnalysisMeta] != nil {
+		r.Version = result.Metadata.Version
+		r.UpdatedAt = result.Metadata.UpdatedAt
+		if r.UpdatedAt.IsZero() {
+			r.UpdatedAt = result.Metadata.GeneratedAt
+		}
+		r.Stale = r.UpdatedAt.Before(staleBefore)
+	}
+
+	index := fieldmanager.New().NewIndex(result.Schema)
+	for _, path := range index.Fields() {
+		field, err := index.Lookup(path)
+		if err != nil {
+			continue
+		}
+		r.Fields++
+		if field.Description != "" {
+			r.DescribedFields++
+		}
+	}
+	r.DescriptionCoverage = coverage(r.DescribedFields, r.Fields)
+
+	r.PendingEnums = analyzer.PendingEnums(result.Schema, result.Statistics)
+
+	r.NullFields = analyzer.NullRates(result.Statistics, nullThreshold)
+
+	return r
+}
+
+// printSchemaReport выводит строку отчета по схеме
+func printSchemaReport(dir string, r SchemaReport) {
+	name := r.File
+	if rel, err := filepath.Rel(dir, r.File); err == nil {
+		name = rel
+	}
+
+	if r.LoadError != "" {
+		output.Printf("❌ %s: ошибка загрузки: %s\n", name, r.LoadError)
+		return
+	}
+
+	marker := output.Marker("✅", "ok")
+	if r.Stale || len(r.MetaSchemaErrors) > 0 || len(r.PendingEnums) > 0 || len(r.NullFields) > 0 {
+		marker = output.Marker("⚠️", "warn")
+	}
+
+	if r.UpdatedAt.IsZero() {
+		output.Printf("%s %s (нет метаданных анализа, описания %.0f%%)\n", marker, name, r.DescriptionCoverage*100)
+	} else {
+		output.Printf("%s %s (v%s, обновлена %s, описания %.0f%%)\n",
+			marker, name, r.Version, r.UpdatedAt.Format("2006-01-02"), r.DescriptionCoverage*100)
+	}
+	if r.Stale {
+		output.Printf("     давно не обновлялась\n")
+	}
+	for _, e := range r.MetaSchemaErrors {
+		output.Printf("     мета-схема: %s\n", e)
+	}
+	if len(r.PendingEnums) > 0 {
+		output.Printf("     кандидаты в enum: %s\n", strings.Join(r.PendingEnums, ", "))
+	}
+	for _, n := range r.NullFields {
+		output.Printf("     часто null: %s (%.0f%%, %d из %d)\n", n.Field, n.Rate*100, n.Nulls, n.Presence)
+	}
+}
+
+// coverage вычисляет долю описанных полей
+func coverage(described, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(described) / float64(total)
+}
@@ -3,28 +3,117 @@ package root
 import (
 	"github.com/spf13/cobra"
 	"github.com/yanodincov/json-schema-detector/internal/analyze"
+	applypatch "github.com/yanodincov/json-schema-detector/internal/apply-patch"
+	"github.com/yanodincov/json-schema-detector/internal/buildinfo"
+	bundlecmd "github.com/yanodincov/json-schema-detector/internal/bundle"
+	checkcompat "github.com/yanodincov/json-schema-detector/internal/check-compat"
+	checkcontracts "github.com/yanodincov/json-schema-detector/internal/check-contracts"
+	"github.com/yanodincov/json-schema-detector/internal/coerce"
+	compareenv "github.com/yanodincov/json-schema-detector/internal/compare-env"
+	"github.com/yanodincov/json-schema-detector/internal/correlations"
+	"github.com/yanodincov/json-schema-detector/internal/exitcode"
+	"github.com/yanodincov/json-schema-detector/internal/export"
+	extracterrors "github.com/yanodincov/json-schema-detector/internal/extract-errors"
+	importcmd "github.com/yanodincov/json-schema-detector/internal/import"
+	initcmd "github.com/yanodincov/json-schema-detector/internal/init"
+	"github.com/yanodincov/json-schema-detector/internal/keygen"
 	listfields "github.com/yanodincov/json-schema-detector/internal/list-fields"
+	monitorcmd "github.com/yanodincov/json-schema-detector/internal/monitor"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/profiling"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/projection"
+	"github.com/yanodincov/json-schema-detector/internal/register"
+	"github.com/yanodincov/json-schema-detector/internal/relate"
+	"github.com/yanodincov/json-schema-detector/internal/repl"
+	"github.com/yanodincov/json-schema-detector/internal/report"
+	"github.com/yanodincov/json-schema-detector/internal/sample"
+	selfupdate "github.com/yanodincov/json-schema-detector/internal/self-update"
+	snapshotcmd "github.com/yanodincov/json-schema-detector/internal/snapshot"
+	tailcmd "github.com/yanodincov/json-schema-detector/internal/tail"
+	"github.com/yanodincov/json-schema-detector/internal/trend"
 	"github.com/yanodincov/json-schema-detector/internal/update"
 	updatefield "github.com/yanodincov/json-schema-detector/internal/update-field"
 	"github.com/yanodincov/json-schema-detector/internal/validate"
+	verifysignature "github.com/yanodincov/json-schema-detector/internal/verify-signature"
 )
 
+// stopProfiling завершает запись профилей, начатую перед выполнением команды
+var stopProfiling func() error
+
 var rootCmd = &cobra.Command{
 	Use:   "json-schema-detector",
 	Short: "Инструмент для анализа JSON структур и генерации схем",
 	Long: `JSON AI Schema Detector - инструмент для автоматического анализа JSON документов
 и генерации структурированных схем с поддержкой JSON Schema стандарта.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		stop, err := profiling.Start()
+		if err != nil {
+			return err
+		}
+		stopProfiling = stop
+		return nil
+	},
 }
 
 func init() {
+	rootCmd.Version = buildinfo.CurrentVersion()
+	rootCmd.PersistentFlags().StringVar(&project.SchemasDir, "schemas-dir", "", "Директория схем (по умолчанию из "+project.ConfigFileName+")")
+	rootCmd.PersistentFlags().StringVar(&project.SigningKey, "sign-key", "", "Закрытый ключ для подписи сохраняемых схем (по умолчанию из "+project.ConfigFileName+")")
+	rootCmd.PersistentFlags().BoolVar(&output.JSON, "json", false, "Машиночитаемый вывод: результат в формате JSON в stdout, текст в stderr")
+
+	// Флаги профилирования нужны для отчетов о производительности и скрыты из справки
+	rootCmd.PersistentFlags().StringVar(&profiling.CPUProfile, "cpuprofile", "", "Записать профиль CPU в файл")
+	rootCmd.PersistentFlags().StringVar(&profiling.MemProfile, "memprofile", "", "Записать профиль памяти в файл по завершении команды")
+	rootCmd.PersistentFlags().StringVar(&profiling.Trace, "trace", "", "Записать трассировку выполнения в файл")
+	for _, name := range []string{"cpuprofile", "memprofile", "trace"} {
+		_ = rootCmd.PersistentFlags().MarkHidden(name)
+	}
+
 	// Добавляем подкоманды
 	rootCmd.AddCommand(analyze.Cmd)
+	rootCmd.AddCommand(applypatch.Cmd)
+	rootCmd.AddCommand(bundlecmd.Cmd)
+	rootCmd.AddCommand(checkcompat.Cmd)
+	rootCmd.AddCommand(checkcontracts.Cmd)
+	rootCmd.AddCommand(coerce.Cmd)
+	rootCmd.AddCommand(compareenv.Cmd)
+	rootCmd.AddCommand(correlations.Cmd)
+	rootCmd.AddCommand(export.Cmd)
+	rootCmd.AddCommand(extracterrors.Cmd)
+	rootCmd.AddCommand(importcmd.Cmd)
+	rootCmd.AddCommand(initcmd.Cmd)
+	rootCmd.AddCommand(keygen.Cmd)
 	rootCmd.AddCommand(listfields.Cmd)
+	rootCmd.AddCommand(monitorcmd.Cmd)
+	rootCmd.AddCommand(projection.Cmd)
+	rootCmd.AddCommand(register.Cmd)
+	rootCmd.AddCommand(relate.Cmd)
+	rootCmd.AddCommand(repl.Cmd)
+	rootCmd.AddCommand(report.Cmd)
+	rootCmd.AddCommand(sample.Cmd)
+	rootCmd.AddCommand(selfupdate.Cmd)
+	rootCmd.AddCommand(snapshotcmd.Cmd)
+	rootCmd.AddCommand(tailcmd.Cmd)
+	rootCmd.AddCommand(trend.Cmd)
 	rootCmd.AddCommand(update.Cmd)
 	rootCmd.AddCommand(updatefield.Cmd)
 	rootCmd.AddCommand(validate.Cmd)
+	rootCmd.AddCommand(verifysignature.Cmd)
 }
 
+// Execute выполняет команду и завершает запись профилей. Код завершения
+// программы выбирает main уже после этого (см. exitcode.Error)
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if stopProfiling != nil {
+		if stopErr := stopProfiling(); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}
+	// Команда, завершившаяся кодом ошибки, уже вывела свой результат
+	if exitcode.As(err) == nil {
+		output.Error(err)
+	}
+	return err
 }
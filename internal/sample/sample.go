@@ -0,0 +1,240 @@
+package sample
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/analyzerflags"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/internal/summary"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/kafkasample"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+var (
+	analyzerFlags *analyzerflags.Flags
+	outputFile    string
+	brokers       []string
+	topic         string
+	count         int
+	start         string
+	wait          time.Duration
+	timeout       time.Duration
+	useTLS        bool
+	saslMechanism string
+	user          string
+	password      string
+)
+
+// Result представляет результат команды sample kafka в режиме --json
+type Result struct {
+	Output          string             `json:"output"`
+	Sample          *kafkasample.Stats `json:"sample"`
+	Created         bool               `json:"created"`
+	PreviousVersion string             `json:"previous_version,omitempty"`
+	Version         string             `json:"version"`
+	Bump            string             `json:"bump"`
+	Changes         []compat.Change    `json:"changes"`
+	Summary         *summary.Summary   `json:"summary"`
+	Signature       string             `json:"signature,omitempty"`
+}
+
+// Cmd представляет команду sample
+var Cmd = &cobra.Command{
+	Use:   "sample",
+	Short: "Строит схему по выборке сообщений из потока событий",
+	Long: `Читает выборку сообщений из потока событий и создает или обновляет
+схему их значений.
+
+Доступные источники:
+  kafka - топик Apache Kafka`,
+}
+
+// kafkaCmd представляет команду sample kafka
+var kafkaCmd = &cobra.Command{
+	Use:   "kafka",
+	Short: "Строит схему по сообщениям топика Kafka",
+	Long: `Читает --count сообщений топика Kafka, анализирует значение каждого как
+запись JSON и создает схему или, если файл схемы уже есть, объединяет
+выборку с ней так же, как команда update, повышая версию схемы.
+
+Топик читается без группы потребителей: сохраненные смещения групп не
+меняются. По умолчанию (--start latest) читаются последние сообщения,
+поровну из каждого раздела; --start earliest читает разделы с начала и
+ждет новых сообщений, если в топике их меньше --count. Выборка
+заканчивается раньше, если за --wait не пришло ни одного сообщения.
+
+Сообщения без значения (tombstone) и не в формате JSON пропускаются и
+учитываются в сводке. Заголовок Confluent Schema Registry перед JSON
+отбрасывается.
+
+Примеры использования:
+  sample kafka --brokers localhost:9092 --topic events --count 5000
+  sample kafka --brokers kafka-1:9093,kafka-2:9093 --topic orders -o orders \
+    --tls --sasl scram-sha-512 --user reader --password "$KAFKA_PASSWORD"`,
+	Args: cobra.NoArgs,
+	RunE: runKafka,
+}
+
+func init() {
+	kafkaCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Файл или имя схемы (по умолчанию <топик>.schema.json)")
+	kafkaCmd.Flags().StringSliceVar(&brokers, "brokers", nil, "Адреса брокеров Kafka через запятую")
+	kafkaCmd.Flags().StringVar(&topic, "topic", "", "Топик Kafka")
+	kafkaCmd.Flags().IntVar(&count, "count", 1000, "Сколько сообщений прочитать")
+	kafkaCmd.Flags().StringVar(&start, "start", kafkasample.StartLatest, "Откуда читать топик: "+kafkasample.StartLatest+" или "+kafkasample.StartEarliest)
+	kafkaCmd.Flags().DurationVar(&wait, "wait", 10*time.Second, "Сколько ждать следующего сообщения, прежде чем закончить выборку")
+	kafkaCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Таймаут подключения к брокерам")
+	kafkaCmd.Flags().BoolVar(&useTLS, "tls", false, "Подключаться к брокерам по TLS")
+	kafkaCmd.Flags().StringVar(&saslMechanism, "sasl", "", "Механизм аутентификации SASL: "+kafkasample.SASLPlain+", "+kafkasample.SASLScramSHA256+" или "+kafkasample.SASLScramSHA512)
+	kafkaCmd.Flags().StringVar(&user, "user", "", "Пользователь SASL")
+	kafkaCmd.Flags().StringVar(&password, "password", "", "Пароль SASL (по умолчанию переменная окружения KAFKA_PASSWORD)")
+	analyzerFlags = analyzerflags.Register(kafkaCmd)
+	kafkaCmd.MarkFlagRequired("brokers")
+	kafkaCmd.MarkFlagRequired("topic")
+	Cmd.AddCommand(kafkaCmd)
+}
+
+func runKafka(cmd *cobra.Command, args []string) error {
+	startAt, err := kafkasample.ParseStart(start)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return fmt.Errorf("число сообщений должно быть положительным")
+	}
+	if password == "" {
+		password = os.Getenv("KAFKA_PASSWORD")
+	}
+
+	if outputFile == "" {
+		outputFile = topic + project.SchemaFileSuffix
+	}
+	schemaFile, err := project.ResolveOutput(outputFile)
+	if err != nil {
+		return err
+	}
+	_, statErr := os.Stat(schemaFile)
+	exists := statErr == nil
+
+	output.Printf("📡 Выборка из топика %s: %d сообщений (%s)\n", topic, count, startAt)
+	output.Printf("Выходной файл: %s\n", schemaFile)
+
+	// Существующая схема задает настройки анализа, как в команде update
+	a := analyzerFlags.New()
+	var existing *types.AnalysisResult
+	if exists {
+		if existing, err = a.LoadSchema(schemaFile); err != nil {
+			return fmt.Errorf("ошибка загрузки схемы: %w", err)
+		}
+		for _, warning := range existing.Warnings {
+			output.Printf("⚠️ %s\n", warning)
+		}
+		a = analyzerFlags.ForSchema(existing.Metadata)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := kafkasample.Config{
+		Brokers:  brokers,
+		Topic:    topic,
+		Count:    count,
+		Start:    startAt,
+		Wait:     wait,
+		Timeout:  timeout,
+		TLS:      useTLS,
+		SASL:     saslMechanism,
+		User:     user,
+		Password: password,
+	}
+
+	// Сообщения передаются анализу NDJSON через канал, как строки таблицы CSV
+	pr, pw := io.Pipe()
+	read := make(chan error, 1)
+	var stats *kafkasample.Stats
+	go func() {
+		var err error
+		stats, err = kafkasample.Read(ctx, cfg, pw)
+		pw.CloseWithError(err)
+		read <- err
+	}()
+	result, err := a.AnalyzeNDJSON(pr)
+	pr.Close()
+
+	// Прерванная по Ctrl+C выборка анализируется как есть
+	readErr := <-read
+	if readErr != nil && (stats == nil || !errors.Is(readErr, io.ErrClosedPipe) && !errors.Is(readErr, context.Canceled)) {
+		return fmt.Errorf("ошибка чтения топика: %w", readErr)
+	}
+
+	output.Printf("📨 Прочитано сообщений: %d из разделов: %d\n", stats.Messages, stats.Partitions)
+	if stats.Tombstones > 0 || stats.Invalid > 0 {
+		output.Printf("⚠️ Пропущено: без значения - %d, не JSON - %d\n", stats.Tombstones, stats.Invalid)
+	}
+	if stats.Records == 0 {
+		return fmt.Errorf("в выборке нет сообщений JSON, схема не сохранена")
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка анализа сообщений: %w", analyzerflags.Explain(err))
+	}
+
+	res := Result{Output: schemaFile, Sample: stats, Created: !exists, Bump: compat.BumpNone.String(), Changes: make([]compat.Change, 0)}
+	var report *compat.Report
+	previousConflicts := make(map[string][]string)
+	if exists {
+		previous, err := existing.Schema.Clone()
+		if err != nil {
+			return fmt.Errorf("ошибка копирования схемы: %w", err)
+		}
+		if existing.Statistics != nil {
+			for field, kinds := range existing.Statistics.TypeConflicts {
+				previousConflicts[field] = kinds
+			}
+		}
+		if result, err = a.MergeResults(existing, result); err != nil {
+			return fmt.Errorf("ошибка объединения схем: %w", err)
+		}
+		if report, res.PreviousVersion, err = compat.StampVersion(previous, result); err != nil {
+			return fmt.Errorf("ошибка определения версии схемы: %w", err)
+		}
+		res.Bump = report.Bump.String()
+		res.Changes = report.Changes
+	}
+	res.Version = result.Metadata.Version
+
+	if err := a.SaveSchema(result, schemaFile); err != nil {
+		return fmt.Errorf("ошибка сохранения схемы: %w", err)
+	}
+	if res.Signature, err = signing.SignSchema(schemaFile); err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+
+	switch {
+	case !exists:
+		output.Printf("🆕 Схема создана: %s (версия %s)\n", schemaFile, res.Version)
+	case report.Bump == compat.BumpNone:
+		output.Printf("✅ Схема обновлена без изменений структуры: %s (версия %s)\n", schemaFile, res.Version)
+	default:
+		output.Printf("🔄 Схема обновлена: %s, версия %s → %s (%s, изменений: %d)\n", schemaFile, res.PreviousVersion, res.Version, report.Bump, len(report.Changes))
+	}
+
+	res.Summary = summary.Build(summary.Input{
+		Program:           cmd.Root().Name(),
+		Schema:            schemaFile,
+		Result:            result,
+		Report:            report,
+		PreviousConflicts: previousConflicts,
+	})
+	res.Summary.Print()
+	return output.Result(res)
+}
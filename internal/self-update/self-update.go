@@ -0,0 +1,425 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/buildinfo"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/pkg/signature"
+)
+
+var (
+	checkOnly bool
+	force     bool
+	timeout   time.Duration
+)
+
+// checksumsAsset - имя файла с SHA-256 суммами артефактов релиза. Суммы
+// доверенные, только если подпись checksumsSignatureAsset проверена ключом
+// buildinfo.ReleaseKey: файлы из релиза может подменить тот же, кто
+// подменит бинарный файл
+const checksumsAsset = "checksums.txt"
+
+// checksumsSignatureAsset - имя отсоединенной подписи файла контрольных сумм
+const checksumsSignatureAsset = checksumsAsset + signature.FileSuffix
+
+// release описывает релиз в GitHub API
+type release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []asset `json:"assets"`
+}
+
+// asset описывает артефакт релиза в GitHub API
+type asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// Result представляет результат команды self-update в режиме --json
+type Result struct {
+	CurrentVersion string `json:"current_version"`
+	LatestVersion  string `json:"latest_version"`
+	// UpdateNeeded - последний релиз новее текущей версии (см. updateNeeded)
+	UpdateNeeded bool   `json:"update_needed"`
+	Updated      bool   `json:"updated"`
+	Checksum     string `json:"checksum,omitempty"`
+	// KeyID - ключ, подписью которого подтверждены контрольные суммы
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// Cmd представляет команду self-update
+var Cmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Обновляет утилиту до последнего релиза",
+	Long: `Проверяет последний релиз на GitHub, скачивает бинарный файл для текущей
+платформы и заменяет им исполняемый файл. SHA-256 файла сверяется с
+` + checksumsAsset + ` релиза, а подпись ` + checksumsSignatureAsset + ` - с открытым ключом,
+встроенным в сборку; сборка без ключа не обновляется. Версии сравниваются по
+правилам семантического версионирования: более старый релиз не
+устанавливается без --force.
+
+Примеры использования:
+  self-update --check
+  self-update`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	Cmd.Flags().BoolVarP(&checkOnly, "check", "c", false, "Только проверить наличие новой версии")
+	Cmd.Flags().BoolVarP(&force, "force", "f", false, "Переустановить даже при совпадении версий")
+	Cmd.Flags().DurationVar(&timeout, "timeout", 60*time.Second, "Таймаут сетевых запросов")
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	client := &http.Client{Timeout: timeout}
+	current := buildinfo.CurrentVersion()
+
+	output.Printf("🔍 Проверка обновлений (текущая версия: %s)\n", current)
+
+	latest, err := fetchLatestRelease(client)
+	if err != nil {
+		return err
+	}
+
+	res := Result{
+		CurrentVersion: current,
+		LatestVersion:  latest.TagName,
+		UpdateNeeded:   updateNeeded(current, latest.TagName),
+	}
+
+	if !res.UpdateNeeded && !force {
+		output.Printf("✅ Установлена последняя версия: %s\n", current)
+		return output.Result(res)
+	}
+
+	output.Printf("🆕 Доступна версия: %s\n", latest.TagName)
+	if checkOnly {
+		return output.Result(res)
+	}
+
+	binaryName := assetName(runtime.GOOS, runtime.GOARCH)
+	binary, ok := findAsset(latest.Assets, binaryName)
+	if !ok {
+		return fmt.Errorf("в релизе %s нет сборки для %s/%s (%s)", latest.TagName, runtime.GOOS, runtime.GOARCH, binaryName)
+	}
+	key, err := releaseKey()
+	if err != nil {
+		return err
+	}
+	checksums, err := fetchChecksums(client, latest, key)
+	if err != nil {
+		return err
+	}
+	output.Printf("🔐 Подпись %s подтверждена ключом %s\n", checksumsAsset, key.KeyID())
+	expected, err := findChecksum(checksums, binaryName)
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("ошибка определения исполняемого файла: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	output.Printf("⬇️ Загрузка %s\n", binary.BrowserDownloadURL)
+	tmpFile, actual, err := download(client, binary.BrowserDownloadURL, filepath.Dir(executable))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("контрольная сумма не совпадает: ожидалась %s, получена %s", expected, actual)
+	}
+	output.Printf("🔐 Контрольная сумма SHA-256 подтверждена\n")
+
+	if err := replaceExecutable(executable, tmpFile); err != nil {
+		return err
+	}
+
+	res.Updated = true
+	res.Checksum = actual
+	res.KeyID = key.KeyID()
+	output.Printf("✅ Обновлено до версии %s: %s\n", latest.TagName, executable)
+
+	return output.Result(res)
+}
+
+// fetchLatestRelease запрашивает информацию о последнем релизе
+func fetchLatestRelease(client *http.Client) (*release, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", buildinfo.Repository)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса релизов: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка запроса релизов: %s", resp.Status)
+	}
+
+	var latest release
+	if err := json.NewDecoder(resp.Body).Decode(&latest); err != nil {
+		return nil, fmt.Errorf("ошибка разбора ответа GitHub: %w", err)
+	}
+	return &latest, nil
+}
+
+// releaseKey возвращает открытый ключ подписи релизов, встроенный в сборку
+func releaseKey() (*signature.PublicKey, error) {
+	if buildinfo.ReleaseKey == "" {
+		return nil, fmt.Errorf("сборка без ключа подписи релизов: подлинность релиза не проверить, установите его вручную")
+	}
+	key, err := signature.ParsePublicKey([]byte(buildinfo.ReleaseKey))
+	if err != nil {
+		return nil, fmt.Errorf("ключ подписи релизов: %w", err)
+	}
+	return key, nil
+}
+
+// fetchChecksums скачивает файл контрольных сумм релиза и проверяет его
+// подпись ключом key
+func fetchChecksums(client *http.Client, latest *release, key *signature.PublicKey) ([]byte, error) {
+	checksums, err := fetchAsset(client, latest, checksumsAsset)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := fetchAsset(client, latest, checksumsSignatureAsset)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Verify(checksums, sig); err != nil {
+		return nil, fmt.Errorf("подпись %s не подтверждена: %w", checksumsAsset, err)
+	}
+	return checksums, nil
+}
+
+// fetchAsset скачивает небольшой артефакт релиза целиком
+func fetchAsset(client *http.Client, latest *release, name string) ([]byte, error) {
+	a, ok := findAsset(latest.Assets, name)
+	if !ok {
+		return nil, fmt.Errorf("в релизе %s отсутствует %s, обновление без проверки невозможно", latest.TagName, name)
+	}
+
+	resp, err := client.Get(a.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка загрузки %s: %s", name, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// findChecksum находит SHA-256 артефакта в файле контрольных сумм
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("ошибка чтения %s: %w", checksumsAsset, err)
+	}
+
+	return "", fmt.Errorf("в %s нет контрольной суммы для %s", checksumsAsset, name)
+}
+
+// download скачивает файл во временный файл рядом с исполняемым и возвращает его SHA-256
+func download(client *http.Client, url, dir string) (string, string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка загрузки: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("ошибка загрузки: %s", resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".json-schema-detector-*")
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	defer tmp.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("ошибка загрузки: %w", err)
+	}
+
+	return tmp.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replaceExecutable атомарно подменяет исполняемый файл новым
+func replaceExecutable(executable, newFile string) error {
+	if err := os.Chmod(newFile, 0755); err != nil {
+		return fmt.Errorf("ошибка установки прав: %w", err)
+	}
+
+	// Windows не позволяет перезаписать запущенный файл, но позволяет его переименовать
+	backup := executable + ".old"
+	os.Remove(backup)
+	if err := os.Rename(executable, backup); err != nil {
+		return fmt.Errorf("ошибка замены исполняемого файла: %w", err)
+	}
+	if err := os.Rename(newFile, executable); err != nil {
+		_ = os.Rename(backup, executable)
+		return fmt.Errorf("ошибка замены исполняемого файла: %w", err)
+	}
+	os.Remove(backup)
+
+	return nil
+}
+
+// assetName возвращает имя артефакта релиза для платформы
+func assetName(goos, goarch string) string {
+	name := fmt.Sprintf("json-schema-detector_%s_%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// findAsset ищет артефакт релиза по имени
+func findAsset(assets []asset, name string) (asset, bool) {
+	for _, a := range assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return asset{}, false
+}
+
+// normalizeVersion приводит версию к виду без префикса v
+func normalizeVersion(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
+
+// updateNeeded сообщает, что версия latest новее current. Версии сравниваются
+// по семантическому версионированию; если одна из них не семантическая
+// (сборка "dev"), обновление нужно при любом различии версий
+func updateNeeded(current, latest string) bool {
+	currentVersion, ok := parseVersion(current)
+	latestVersion, latestOK := parseVersion(latest)
+	if !ok || !latestOK {
+		return normalizeVersion(current) != normalizeVersion(latest)
+	}
+	return compareVersions(latestVersion, currentVersion) > 0
+}
+
+// semver - разобранная семантическая версия без метаданных сборки
+type semver struct {
+	core       [3]int
+	prerelease []string
+}
+
+// parseVersion разбирает версию вида [v]MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]
+func parseVersion(version string) (semver, bool) {
+	var v semver
+	version, _, _ = strings.Cut(normalizeVersion(version), "+")
+	version, prerelease, hasPrerelease := strings.Cut(version, "-")
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	if hasPrerelease {
+		v.prerelease = strings.Split(prerelease, ".")
+	}
+	return v, true
+}
+
+// compareVersions сравнивает версии: -1, если a ниже b, 0 - равны, 1 - a
+// выше. Предварительная версия (1.2.0-rc.1) ниже выпуска 1.2.0
+func compareVersions(a, b semver) int {
+	for i := range a.core {
+		if a.core[i] != b.core[i] {
+			return compareInts(a.core[i], b.core[i])
+		}
+	}
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		if c := compareIdentifiers(a.prerelease[i], b.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a.prerelease), len(b.prerelease))
+}
+
+// compareIdentifiers сравнивает части предварительной версии: числовые -
+// как числа и ниже буквенных, буквенные - лексикографически
+func compareIdentifiers(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(x, y)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInts сравнивает числа: -1, 0 или 1
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package selfupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/internal/buildinfo"
+	"github.com/yanodincov/json-schema-detector/pkg/signature"
+)
+
+func TestUpdateNeeded(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.2.3", "v1.2.3", false},
+		{"1.2.3", "v1.2.3", false},
+		{"v1.2.3", "v1.2.4", true},
+		{"v1.2.3", "v1.10.0", true},
+		{"v1.10.0", "v1.9.9", false},
+		{"v2.0.0", "v1.9.9", false},
+		{"v1.3.0-rc.1", "v1.3.0", true},
+		{"v1.3.0", "v1.3.0-rc.1", false},
+		{"v1.3.0-rc.1", "v1.3.0-rc.2", true},
+		{"v1.3.0-rc.10", "v1.3.0-rc.9", false},
+		{"v1.3.0-alpha", "v1.3.0-alpha.1", true},
+		{"v1.3.0-1", "v1.3.0-alpha", true},
+		{"v1.2.3+dirty", "v1.2.3", false},
+		{"v0.0.0-20240101000000-abcdef123456", "v0.1.0", true},
+		{"dev", "v1.2.3", true},
+		{"dev", "dev", false},
+	}
+	for _, tt := range tests {
+		if got := updateNeeded(tt.current, tt.latest); got != tt.want {
+			t.Errorf("updateNeeded(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestFetchChecksums(t *testing.T) {
+	_, releasePriv, err := signature.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := signature.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksums := []byte("0123abcd  json-schema-detector_linux_amd64\n")
+
+	tests := []struct {
+		name   string
+		assets map[string][]byte
+		ok     bool
+	}{
+		{"signed", map[string][]byte{checksumsAsset: checksums, checksumsSignatureAsset: releasePriv.Sign(checksums)}, true},
+		{"replaced checksums", map[string][]byte{checksumsAsset: []byte("ffff  json-schema-detector_linux_amd64\n"), checksumsSignatureAsset: releasePriv.Sign(checksums)}, false},
+		{"other key", map[string][]byte{checksumsAsset: checksums, checksumsSignatureAsset: otherPriv.Sign(checksums)}, false},
+		{"no signature", map[string][]byte{checksumsAsset: checksums}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(tt.assets[strings.TrimPrefix(r.URL.Path, "/")])
+			}))
+			defer server.Close()
+
+			latest := &release{TagName: "v1.0.0"}
+			for name := range tt.assets {
+				latest.Assets = append(latest.Assets, asset{Name: name, BrowserDownloadURL: server.URL + "/" + name})
+			}
+			data, err := fetchChecksums(server.Client(), latest, releasePriv.Public())
+			if tt.ok != (err == nil) {
+				t.Fatalf("fetchChecksums: ошибка %v, want успех %v", err, tt.ok)
+			}
+			if tt.ok {
+				if sum, err := findChecksum(data, "json-schema-detector_linux_amd64"); err != nil || sum != "0123abcd" {
+					t.Errorf("findChecksum = %q, %v, want 0123abcd", sum, err)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseKeyRequired(t *testing.T) {
+	defer func(key string) { buildinfo.ReleaseKey = key }(buildinfo.ReleaseKey)
+
+	buildinfo.ReleaseKey = ""
+	if _, err := releaseKey(); err == nil {
+		t.Error("сборка без ключа подписи релизов принята")
+	}
+
+	pub, _, err := signature.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := pub.MarshalText()
+	lines := strings.Split(strings.TrimSpace(string(text)), "\n")
+	buildinfo.ReleaseKey = lines[len(lines)-1]
+	key, err := releaseKey()
+	if err != nil || key.KeyID() != pub.KeyID() {
+		t.Errorf("releaseKey = %v, %v, want ключ %s", key, err, pub.KeyID())
+	}
+}
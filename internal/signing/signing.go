@@ -0,0 +1,32 @@
+package signing
+
+import (
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/signature"
+)
+
+// SignSchema подписывает сохраненную схему, если в проекте настроен ключ подписи.
+// Возвращает путь к файлу подписи или пустую строку, если подпись не настроена.
+func SignSchema(schemaFile string) (string, error) {
+	keyFile, err := project.ResolveSigningKey()
+	if err != nil {
+		return "", err
+	}
+	if keyFile == "" {
+		return "", nil
+	}
+
+	key, err := signature.LoadPrivateKey(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := signature.SignFile(key, schemaFile)
+	if err != nil {
+		return "", err
+	}
+
+	output.Printf("🔏 Схема подписана: %s\n", path)
+	return path, nil
+}
@@ -0,0 +1,104 @@
+package snapshotcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/analyzerflags"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/snapshot"
+)
+
+var (
+	snapshotName string
+	snapshotsDir string
+)
+
+// Result представляет результат команды snapshot в режиме --json
+type Result struct {
+	Name      string    `json:"name"`
+	Input     string    `json:"input"`
+	File      string    `json:"file"`
+	Time      time.Time `json:"time"`
+	Snapshots int       `json:"snapshots"`
+}
+
+// Cmd представляет команду snapshot
+var Cmd = &cobra.Command{
+	Use:   "snapshot [input.json]",
+	Short: "Сохраняет датированный снимок схемы для анализа трендов",
+	Long: `Анализирует JSON файл и сохраняет выведенную схему как датированный снимок
+в директории снимков проекта (по умолчанию ` + project.DefaultSnapshotsDir + `/ в корне проекта
+или snapshots_dir в ` + project.ConfigFileName + `).
+
+Регулярные снимки (например, еженедельно из cron) позволяют командой trend
+отслеживать постепенную эволюцию API стороннего поставщика.
+
+Примеры использования:
+  snapshot provider-response.json --name provider
+  snapshot dump.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshot,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&snapshotName, "name", "n", "", "Имя серии снимков (по умолчанию имя входного файла)")
+	Cmd.Flags().StringVar(&snapshotsDir, "dir", "", "Директория снимков")
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	inputFile := args[0]
+	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
+		return fmt.Errorf("входной файл не найден: %s", inputFile)
+	}
+
+	name := snapshotName
+	if name == "" {
+		base := filepath.Base(inputFile)
+		name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if !project.IsSchemaName(name) {
+		return fmt.Errorf("некорректное имя серии снимков: %s", name)
+	}
+
+	dir := snapshotsDir
+	if dir == "" {
+		var err error
+		if dir, err = project.ResolveSnapshotsDir(); err != nil {
+			return err
+		}
+	}
+
+	result, err := analyzer.New().AnalyzeFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("ошибка анализа: %w", analyzerflags.Explain(err))
+	}
+
+	store := snapshot.NewStore(dir)
+	snap, err := store.Save(name, result, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения снимка: %w", err)
+	}
+
+	snapshots, err := store.List(name)
+	if err != nil {
+		return err
+	}
+
+	output.Printf("📸 Снимок сохранен: %s\n", snap.File)
+	output.Printf("🗂️ Снимков в серии %s: %d\n", name, len(snapshots))
+
+	return output.Result(Result{
+		Name:      name,
+		Input:     inputFile,
+		File:      snap.File,
+		Time:      snap.Time,
+		Snapshots: len(snapshots),
+	})
+}
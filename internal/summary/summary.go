@@ -0,0 +1,187 @@
+package summary
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// NullThreshold - доля null в процентах, начиная с которой поле попадает в предупреждения
+const NullThreshold = 50
+
+// maxListed - сколько полей перечислять в строке сводки
+const maxListed = 5
+
+// Conflict описывает поле, значения которого имели несовместимые типы
+type Conflict struct {
+	Field string   `json:"field"`
+	Types []string `json:"types"`
+}
+
+// Summary представляет сводку по результату analyze или update с
+// предлагаемыми следующими шагами
+type Summary struct {
+	Fields         int        `json:"fields"`
+	NewFields      []string   `json:"new_fields,omitempty"`
+	TypeConflicts  []Conflict `json:"type_conflicts,omitempty"`
+	EnumCandidates []string   `json:"enum_candidates,omitempty"`
+	Warnings       []string   `json:"warnings,omitempty"`
+	NextSteps      []string   `json:"next_steps,omitempty"`
+}
+
+// Input описывает результат команды, по которому строится сводка
+type Input struct {
+	// Program - имя исполняемого файла для предлагаемых команд
+	Program string
+	// Schema - файл схемы для предлагаемых команд
+	Schema string
+	// Result - сохраненный результат анализа
+	Result *types.AnalysisResult
+	// Report - изменения схемы при update; nil для analyze
+	Report *compat.Report
+	// PreviousConflicts - конфликты типов схемы до update; в сводку попадают
+	// только конфликты, появившиеся или расширившиеся в этом запуске
+	PreviousConflicts map[string][]string
+}
+
+// Build строит сводку по результату команды
+func Build(in Input) *Summary {
+	s := &Summary{}
+	if in.Result == nil || in.Result.Schema == nil {
+		return s
+	}
+
+	index := fieldmanager.New().NewIndex(in.Result.Schema)
+	fields := index.Fields()
+	s.Fields = len(fields)
+
+	if in.Report != nil {
+		for _, change := range in.Report.Changes {
+			if change.Kind == compat.ChangeFieldAdded {
+				s.NewFields = append(s.NewFields, change.Path)
+			}
+		}
+		sort.Strings(s.NewFields)
+	}
+
+	if stats := in.Result.Statistics; stats != nil {
+		for field, kinds := range stats.TypeConflicts {
+			if sameTypes(in.PreviousConflicts[field], kinds) {
+				continue
+			}
+			s.TypeConflicts = append(s.TypeConflicts, Conflict{Field: field, Types: kinds})
+		}
+		sort.Slice(s.TypeConflicts, func(i, j int) bool { return s.TypeConflicts[i].Field < s.TypeConflicts[j].Field })
+	}
+
+	s.EnumCandidates = analyzer.PendingEnums(in.Result.Schema, in.Result.Statistics)
+
+	// Поля, тип которых не удалось определить по выборке
+	unknown := false
+	for _, field := range fields {
+		prop, err := index.Lookup(field)
+		if err != nil {
+			continue
+		}
+		switch {
+		case prop.Type == string(types.TypeNull):
+			s.Warnings = append(s.Warnings, fmt.Sprintf("%s: всегда null, тип не определен", field))
+			unknown = true
+		case prop.Type == string(types.TypeArray) && prop.Items == nil && prop.PrefixItems == nil && prop.Ref == "":
+			s.Warnings = append(s.Warnings, fmt.Sprintf("%s: массив всегда пуст, тип элементов не определен", field))
+			unknown = true
+		}
+	}
+	for _, rate := range analyzer.NullRates(in.Result.Statistics, NullThreshold) {
+		if rate.Nulls == rate.Presence {
+			continue
+		}
+		s.Warnings = append(s.Warnings, fmt.Sprintf("%s: null в %.0f%% случаев (%d из %d)", rate.Field, rate.Rate*100, rate.Nulls, rate.Presence))
+	}
+
+	s.NextSteps = s.nextSteps(in, unknown)
+	return s
+}
+
+// nextSteps предлагает команды для разбора замечаний сводки
+func (s *Summary) nextSteps(in Input, unknown bool) []string {
+	var steps []string
+	if len(s.EnumCandidates) > 0 {
+		steps = append(steps, fmt.Sprintf("%s update-field %s %s enum", in.Program, in.Schema, s.EnumCandidates[0]))
+	}
+	if len(s.TypeConflicts) > 0 {
+		steps = append(steps, fmt.Sprintf("%s update-field %s %s polymorph", in.Program, in.Schema, s.TypeConflicts[0].Field))
+	}
+	if unknown {
+		steps = append(steps, fmt.Sprintf("%s update %s -i <more-data.json>", in.Program, in.Schema))
+	}
+	if len(s.NewFields) > 0 {
+		steps = append(steps, fmt.Sprintf("%s update-field %s %s description -d \"...\"", in.Program, in.Schema, s.NewFields[0]))
+	}
+	if len(s.EnumCandidates) > 1 || len(s.Warnings) > 0 {
+		steps = append(steps, fmt.Sprintf("%s report", in.Program))
+	}
+	return steps
+}
+
+// Print выводит сводку для человека
+func (s *Summary) Print() {
+	output.Println()
+	output.Printf("📋 Сводка\n")
+	output.Printf("   Полей в схеме: %d\n", s.Fields)
+	if len(s.NewFields) > 0 {
+		output.Printf("   🆕 Новые поля (%d): %s\n", len(s.NewFields), listed(s.NewFields))
+	}
+	if len(s.TypeConflicts) > 0 {
+		conflicts := make([]string, 0, len(s.TypeConflicts))
+		for _, c := range s.TypeConflicts {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", c.Field, strings.Join(c.Types, " / ")))
+		}
+		output.Printf("   ⚔️ Конфликты типов (%d): %s\n", len(conflicts), listed(conflicts))
+	}
+	if len(s.EnumCandidates) > 0 {
+		output.Printf("   🎯 Кандидаты в enum (%d): %s\n", len(s.EnumCandidates), listed(s.EnumCandidates))
+	}
+	if len(s.Warnings) > 0 {
+		output.Printf("   ⚠️ Предупреждения (%d):\n", len(s.Warnings))
+		for _, warning := range s.Warnings {
+			output.Printf("      • %s\n", warning)
+		}
+	}
+	if len(s.NextSteps) == 0 {
+		output.Printf("   ✅ Замечаний нет\n")
+		return
+	}
+
+	output.Printf("💡 Следующие шаги:\n")
+	for _, step := range s.NextSteps {
+		output.Printf("   %s\n", step)
+	}
+}
+
+// listed перечисляет первые значения через запятую
+func listed(values []string) string {
+	if len(values) <= maxListed {
+		return strings.Join(values, ", ")
+	}
+	return fmt.Sprintf("%s и еще %d", strings.Join(values[:maxListed], ", "), len(values)-maxListed)
+}
+
+// sameTypes сравнивает отсортированные списки типов
+func sameTypes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
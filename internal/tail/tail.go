@@ -0,0 +1,200 @@
+package tailcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/tail"
+)
+
+var (
+	fromStart      bool
+	poll           time.Duration
+	strict         bool
+	webhook        string
+	alertInterval  time.Duration
+	timeout        time.Duration
+	metricsAddr    string
+	reportInterval time.Duration
+	invalidOut     string
+)
+
+// Cmd представляет команду tail
+var Cmd = &cobra.Command{
+	Use:   "tail [events.ndjson] [schema]",
+	Short: "Следит за журналом NDJSON и проверяет новые записи по схеме",
+	Long: `Следит за растущим файлом NDJSON, как tail -F, и проверяет каждую новую
+запись по схеме, как validate --ndjson: легкий контроль контракта во время
+работы сервиса без прокси перед ним. Записи с ошибками выводятся по мере
+появления с номером записи от начала проверки, строки не в формате JSON
+учитываются отдельно и не прерывают проверку.
+
+По умолчанию проверяются только записи, дописанные после запуска;
+--from-start проверяет и уже записанные. Усеченный файл читается с начала,
+а после ротации журнала старый файл дочитывается и проверка продолжается
+по новому. Если файла еще нет, tail ждет его появления и проверяет его
+с начала.
+
+Оповещение - POST запрос на --webhook с JSON телом, поле text которого
+совместимо с входящими webhook Slack и Mattermost. Первая запись с ошибками
+отправляется сразу, последующие собираются в одно оповещение не чаще
+--alert-interval. С --metrics-addr счетчики записей доступны на /metrics
+в текстовом формате Prometheus. Флаг --invalid-out дописывает записи
+с ошибками в файл NDJSON в формате validate --invalid-out.
+
+В режиме --json каждая запись с ошибками выводится отдельной строкой JSON.
+
+Примеры использования:
+  tail /var/log/app/events.ndjson events
+  tail events.ndjson events.schema.json --webhook https://hooks.slack.com/services/... \
+    --alert-interval 5m --metrics-addr :9464
+  tail events.ndjson events --from-start --invalid-out bad.ndjson`,
+	Args: cobra.ExactArgs(2),
+	RunE: runTail,
+}
+
+func init() {
+	Cmd.Flags().BoolVar(&fromStart, "from-start", false, "Проверить и записи, уже записанные в файл")
+	Cmd.Flags().DurationVar(&poll, "poll", time.Second, "Как часто проверять файл на новые записи")
+	Cmd.Flags().BoolVar(&strict, "strict", false, "Строгая валидация")
+	Cmd.Flags().StringVar(&webhook, "webhook", "", "URL для оповещений о записях с ошибками")
+	Cmd.Flags().DurationVar(&alertInterval, "alert-interval", time.Minute, "Минимальный интервал между оповещениями")
+	Cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Таймаут отправки оповещения")
+	Cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Адрес для метрик Prometheus на /metrics (например, :9464)")
+	Cmd.Flags().DurationVar(&reportInterval, "report-interval", time.Minute, "Интервал вывода счетчиков записей (0 - не выводить)")
+	Cmd.Flags().StringVar(&invalidOut, "invalid-out", "", "Дописывать записи с ошибками в файл NDJSON")
+}
+
+func runTail(cmd *cobra.Command, args []string) error {
+	dataFile := args[0]
+	schemaFile, err := project.ResolveSchema(args[1])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+	if poll <= 0 || alertInterval <= 0 {
+		return fmt.Errorf("интервалы --poll и --alert-interval должны быть положительными")
+	}
+	if reportInterval < 0 {
+		return fmt.Errorf("интервал --report-interval не может быть отрицательным")
+	}
+
+	config := tail.Config{
+		DataFile:      dataFile,
+		SchemaFile:    schemaFile,
+		Follow:        tail.FollowOptions{FromStart: fromStart, Poll: poll},
+		Strict:        strict,
+		Webhook:       webhook,
+		AlertInterval: alertInterval,
+		Timeout:       timeout,
+	}
+	if invalidOut != "" {
+		file, err := os.OpenFile(invalidOut, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("ошибка открытия файла записей с ошибками: %w", err)
+		}
+		defer file.Close()
+		config.Invalid = file
+	}
+
+	t, err := tail.New(config)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	output.Printf("👀 Проверка записей: %s\n", dataFile)
+	output.Printf("📄 Схема: %s\n", schemaFile)
+	if metricsAddr != "" {
+		server, err := serveMetrics(t)
+		if err != nil {
+			return err
+		}
+		defer server.Shutdown(context.Background())
+		output.Printf("📈 Метрики: http://%s/metrics\n", server.Addr)
+	}
+	output.Println()
+
+	if reportInterval > 0 {
+		go report(ctx, t)
+	}
+
+	var streamErr error
+	err = t.Run(ctx, func(event *tail.Event) {
+		stamp := event.Time.Format("15:04:05")
+		if event.Malformed {
+			output.Printf("⚠️ %s запись %d: не JSON\n", stamp, event.Record)
+		} else {
+			output.Printf("❌ %s запись %d: %s\n", stamp, event.Record, tail.Describe(event))
+		}
+		if err := output.Stream(event); err != nil && streamErr == nil {
+			streamErr = err
+		}
+	}, func(alert *tail.Alert, err error) {
+		if err != nil {
+			output.Printf("⚠️ Ошибка отправки оповещения: %v\n", err)
+			return
+		}
+		output.Printf("📣 Оповещение отправлено: записей с ошибками %d\n", alert.Invalid)
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка проверки записей: %w", err)
+	}
+	if streamErr != nil {
+		return streamErr
+	}
+
+	counters := t.Counters()
+	output.Printf("👋 Проверка остановлена: записей %d, с ошибками %d, не JSON %d\n", counters.Records, counters.Invalid, counters.Malformed)
+	return nil
+}
+
+// report периодически выводит счетчики записей
+func report(ctx context.Context, t *tail.Tail) {
+	ticker := time.NewTicker(reportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			counters := t.Counters()
+			output.Printf("📊 %s записей %d, с ошибками %d, не JSON %d\n", now.Format("15:04:05"), counters.Records, counters.Invalid, counters.Malformed)
+		}
+	}
+}
+
+// serveMetrics запускает HTTP сервер с метриками на /metrics
+func serveMetrics(t *tail.Tail) (*http.Server, error) {
+	listener, err := net.Listen("tcp", metricsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запуска сервера метрик: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		t.WriteMetrics(w)
+	})
+	server := &http.Server{Addr: listener.Addr().String(), Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			output.Printf("⚠️ Ошибка сервера метрик: %v\n", err)
+		}
+	}()
+	return server, nil
+}
@@ -0,0 +1,160 @@
+package trend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/snapshot"
+)
+
+var (
+	snapshotsDir string
+	last         int
+)
+
+// dateLayout - формат даты в колонках таблицы присутствия полей
+const dateLayout = "01-02"
+
+// Result представляет результат команды trend в режиме --json
+type Result struct {
+	Name string `json:"name"`
+	*snapshot.Trend
+}
+
+// Cmd представляет команду trend
+var Cmd = &cobra.Command{
+	Use:   "trend [name]",
+	Short: "Показывает эволюцию схемы по датированным снимкам",
+	Long: `Строит по снимкам, сохраненным командой snapshot, таблицу присутствия полей
+и перечисляет изменения между соседними снимками: появившиеся и исчезнувшие
+поля, добавленные и удаленные значения enum.
+
+Без аргумента выводит список серий снимков.
+
+Примеры использования:
+  trend
+  trend provider
+  trend provider --last 8`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTrend,
+}
+
+func init() {
+	Cmd.Flags().StringVar(&snapshotsDir, "dir", "", "Директория снимков")
+	Cmd.Flags().IntVar(&last, "last", 0, "Учитывать только последние N снимков")
+}
+
+func runTrend(cmd *cobra.Command, args []string) error {
+	dir := snapshotsDir
+	if dir == "" {
+		var err error
+		if dir, err = project.ResolveSnapshotsDir(); err != nil {
+			return err
+		}
+	}
+	store := snapshot.NewStore(dir)
+
+	if len(args) == 0 {
+		names, err := store.Names()
+		if err != nil {
+			return err
+		}
+		output.Printf("🗂️ Серии снимков в %s: %d\n", dir, len(names))
+		for _, name := range names {
+			output.Printf("   • %s\n", name)
+		}
+		return output.Result(names)
+	}
+
+	name := args[0]
+	snapshots, err := store.List(name)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("снимки серии %s не найдены в %s", name, dir)
+	}
+	if last > 0 && len(snapshots) > last {
+		snapshots = snapshots[len(snapshots)-last:]
+	}
+
+	trend, err := snapshot.BuildTrend(snapshots)
+	if err != nil {
+		return err
+	}
+
+	first, final := snapshots[0].Time, snapshots[len(snapshots)-1].Time
+	output.Printf("📈 Тренд схемы %s: снимков %d (%s … %s)\n", name, len(snapshots), first.Format("2006-01-02"), final.Format("2006-01-02"))
+	output.Println()
+	printPresence(trend)
+
+	output.Println()
+	changed := 0
+	for _, t := range trend.Transitions {
+		if t.Empty() {
+			continue
+		}
+		changed++
+		output.Printf("🔄 %s → %s\n", t.From.Format("2006-01-02 15:04"), t.To.Format("2006-01-02 15:04"))
+		for _, path := range t.AddedFields {
+			output.Printf("   + %s\n", path)
+		}
+		for _, path := range t.RemovedFields {
+			output.Printf("   - %s\n", path)
+		}
+		for _, path := range sortedKeys(t.EnumAdded) {
+			output.Printf("   ~ %s: enum + %s\n", path, strings.Join(t.EnumAdded[path], ", "))
+		}
+		for _, path := range sortedKeys(t.EnumRemoved) {
+			output.Printf("   ~ %s: enum - %s\n", path, strings.Join(t.EnumRemoved[path], ", "))
+		}
+	}
+	if changed == 0 {
+		output.Printf("✅ Структура не менялась\n")
+	}
+
+	return output.Result(Result{Name: name, Trend: trend})
+}
+
+// printPresence выводит таблицу присутствия полей по снимкам
+func printPresence(trend *snapshot.Trend) {
+	width := utf8.RuneCountInString("Поле")
+	for _, field := range trend.Fields {
+		if n := utf8.RuneCountInString(field.Path); n > width {
+			width = n
+		}
+	}
+
+	header := fmt.Sprintf("%-*s", width, "Поле")
+	for _, snap := range trend.Snapshots {
+		header += " " + snap.Time.Format(dateLayout)
+	}
+	output.Println(header)
+
+	for _, field := range trend.Fields {
+		line := fmt.Sprintf("%-*s", width, field.Path)
+		for _, present := range field.Presence {
+			marker := "  ·  "
+			if present {
+				marker = "  ●  "
+			}
+			line += " " + marker
+		}
+		output.Println(line)
+	}
+}
+
+// sortedKeys возвращает отсортированные пути полей
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
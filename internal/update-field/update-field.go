@@ -2,15 +2,24 @@ package updatefield
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/autocommit"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
 	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/changelog"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/decisions"
 	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/jsonpatch"
 	"github.com/yanodincov/json-schema-detector/pkg/types"
 )
 
@@ -19,35 +28,65 @@ var (
 	fieldType   string
 	description string
 	autoCommit  bool
+	changeLog   bool
+	patchOut    string
+	remember    bool
 )
 
+// Result представляет результат команды update-field в режиме --json
+type Result struct {
+	Schema          string          `json:"schema"`
+	Path            string          `json:"path"`
+	Operation       string          `json:"operation"`
+	PreviousVersion string          `json:"previous_version"`
+	Version         string          `json:"version"`
+	Bump            string          `json:"bump"`
+	Changes         []compat.Change `json:"changes"`
+	Changelog       string          `json:"changelog,omitempty"`
+	Signature       string          `json:"signature,omitempty"`
+	Patch           string          `json:"patch,omitempty"`
+	Remembered      bool            `json:"remembered,omitempty"`
+	Committed       bool            `json:"committed"`
+}
+
 // Cmd представляет команду update-field
 var Cmd = &cobra.Command{
-	Use:   "update-field [schema.json] [json-path] [type]",
-	Short: "Обновляет поле в схеме (enum, polymorph, description)",
+	Use:   "update-field [schema.json] [json-path] [type] [value]",
+	Short: "Обновляет поле в схеме (enum, polymorph, description, type)",
 	Long: `Интерактивно обновляет поле в JSON Schema, позволяя:
 - Преобразовать поле в enum тип с выбором значений
 - Преобразовать поле в полиморфный тип с вариантами
 - Добавить или изменить описание поля
 - Изменить тип поля
 
+Решения по типу, enum и полиморфному типу запоминаются в конфигурации проекта
+(decisions) и применяются командой update повторно: поле сохраняет выбранный
+вид, а конфликт типов по нему больше не сообщается.
+
 Примеры использования:
   update-field schema.json "data.0.role" enum
   update-field schema.json "data.0.user" polymorph
-  update-field schema.json "data.0.id" description`,
+  update-field schema.json "data.0.id" description
+  update-field schema.json "data.0.id" type string`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: runUpdateField,
 }
 
 func init() {
 	Cmd.Flags().BoolVarP(&interactive, "interactive", "i", true, "Интерактивный режим")
-	Cmd.Flags().StringVarP(&fieldType, "type", "t", "", "Тип поля (enum, polymorph, description)")
+	Cmd.Flags().StringVarP(&fieldType, "type", "t", "", "Операция (enum, polymorph, description, preserve-default, type)")
 	Cmd.Flags().StringVarP(&description, "description", "d", "", "Описание поля")
 	Cmd.Flags().BoolVarP(&autoCommit, "auto-commit", "a", false, "Автоматический коммит изменений схемы")
+	Cmd.Flags().BoolVar(&changeLog, "changelog", false, "Дописать запись в файл истории изменений рядом со схемой")
+	Cmd.Flags().StringVar(&patchOut, "patch-out", "", "Записать изменения схемы в файл JSON Patch (RFC 6902)")
+	Cmd.Flags().BoolVar(&remember, "remember", true, "Запомнить решение (type, enum, polymorph) в конфигурации проекта для повторного применения командой update")
 }
 
 func runUpdateField(cmd *cobra.Command, args []string) error {
-	schemaFile := args[0]
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
 	jsonPath := args[1]
 
 	// Определяем тип операции
@@ -61,11 +100,11 @@ func runUpdateField(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
 	}
 
-	fmt.Printf("🔧 Обновление поля в схеме\n")
-	fmt.Printf("📄 Файл схемы: %s\n", schemaFile)
-	fmt.Printf("🎯 Путь к полю: %s\n", jsonPath)
-	fmt.Printf("🔄 Операция: %s\n", operation)
-	fmt.Println()
+	output.Printf("🔧 Обновление поля в схеме\n")
+	output.Printf("📄 Файл схемы: %s\n", schemaFile)
+	output.Printf("🎯 Путь к полю: %s\n", jsonPath)
+	output.Printf("🔄 Операция: %s\n", operation)
+	output.Println()
 
 	// Загружаем схему
 	analyzer := analyzer.New()
@@ -73,6 +112,15 @@ func runUpdateField(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("ошибка загрузки схемы: %w", err)
 	}
+	for _, warning := range schema.Warnings {
+		output.Printf("⚠️ %s\n", warning)
+	}
+
+	// Запоминаем исходную схему для определения уровня изменения версии
+	previousSchema, err := schema.Schema.Clone()
+	if err != nil {
+		return fmt.Errorf("ошибка копирования схемы: %w", err)
+	}
 
 	// Создаем менеджер полей
 	fieldManager := fieldmanager.New()
@@ -87,44 +135,137 @@ func runUpdateField(cmd *cobra.Command, args []string) error {
 		err = handleDescriptionUpdate(fieldManager, schema, jsonPath)
 	case "preserve-default", "preserve":
 		err = handlePreserveDefaultUpdate(fieldManager, schema, jsonPath)
+	case decisions.OperationType:
+		newType := ""
+		if len(args) >= 4 {
+			newType = args[3]
+		}
+		err = handleTypeChange(fieldManager, schema, jsonPath, newType)
 	default:
-		if interactive {
+		if interactive && output.Interactive() {
 			operation, err = promptOperation()
 			if err != nil {
 				return err
 			}
 			return runUpdateField(cmd, append(args[:2], operation))
 		}
-		return fmt.Errorf("неподдерживаемая операция: %s. Доступные: enum, polymorph, description, preserve-default", operation)
+		if operation == "" {
+			return output.InputRequired("укажите операцию аргументом: enum, polymorph, description, preserve-default, type")
+		}
+		return fmt.Errorf("неподдерживаемая операция: %s. Доступные: enum, polymorph, description, preserve-default, type", operation)
 	}
 
 	if err != nil {
 		return fmt.Errorf("ошибка обновления поля: %w", err)
 	}
 
+	// Повышаем версию схемы согласно характеру изменений
+	report, oldVersion, err := compat.StampVersion(previousSchema, schema)
+	if err != nil {
+		return fmt.Errorf("ошибка определения версии схемы: %w", err)
+	}
+
+	// Запоминаем содержимое файла до сохранения для построения JSON Patch
+	var previousContent []byte
+	if patchOut != "" {
+		if previousContent, err = os.ReadFile(schemaFile); err != nil {
+			return fmt.Errorf("ошибка чтения схемы: %w", err)
+		}
+	}
+
 	// Сохраняем обновленную схему
 	if err := analyzer.SaveSchema(schema, schemaFile); err != nil {
 		return fmt.Errorf("ошибка сохранения схемы: %w", err)
 	}
 
-	fmt.Printf("✅ Поле успешно обновлено: %s\n", jsonPath)
+	// Подписываем схему, если настроен ключ подписи
+	signatureFile, err := signing.SignSchema(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+
+	output.Printf("✅ Поле успешно обновлено: %s\n", jsonPath)
+	if report.Bump != compat.BumpNone {
+		output.Printf("🏷️ Версия схемы: %s → %s (%s)\n", oldVersion, schema.Metadata.Version, report.Bump)
+	}
+
+	// Запоминаем решение, чтобы update применял его повторно
+	var changedFiles []string
+	remembered := false
+	if remember {
+		configFile, err := rememberDecision(fieldManager, schema, schemaFile, jsonPath, operation)
+		if err != nil {
+			return err
+		}
+		if configFile != "" {
+			remembered = true
+			changedFiles = append(changedFiles, configFile)
+			output.Printf("🧠 Решение сохранено в конфигурации проекта: %s\n", configFile)
+		}
+	}
+
+	// Записываем историю изменений если флаг установлен
+	changelogFile := ""
+	if changeLog && len(report.Changes) > 0 {
+		path, err := changelog.Append(schemaFile, &changelog.Entry{
+			Date:    time.Now(),
+			Version: schema.Metadata.Version,
+			Bump:    report.Bump,
+			Source:  "update-field " + jsonPath,
+			Changes: report.Changes,
+		})
+		if err != nil {
+			return err
+		}
+		changelogFile = path
+		changedFiles = append(changedFiles, path)
+		output.Printf("📝 История изменений дополнена: %s\n", path)
+	}
+
+	// Записываем изменения в виде JSON Patch если флаг установлен
+	if patchOut != "" {
+		if err := writePatch(schemaFile, previousContent, patchOut); err != nil {
+			return err
+		}
+		output.Printf("🩹 JSON Patch записан: %s\n", patchOut)
+	}
+
+	if signatureFile != "" {
+		changedFiles = append(changedFiles, signatureFile)
+	}
 
 	// Автоматический коммит если флаг установлен
+	committed := false
 	if autoCommit {
-		if err := commitSchemaChanges(schemaFile, "update-field"); err != nil {
-			fmt.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
+		if err := autocommit.Commit(schemaFile, "update-field", changedFiles...); err != nil {
+			output.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
 		} else {
-			fmt.Printf("✅ Изменения схемы закоммичены\n")
+			committed = true
+			output.Printf("✅ Изменения схемы закоммичены\n")
 		}
 	}
 
-	return nil
+	res := Result{
+		Schema:          schemaFile,
+		Path:            jsonPath,
+		Operation:       operation,
+		PreviousVersion: oldVersion,
+		Version:         schema.Metadata.Version,
+		Bump:            report.Bump.String(),
+		Changes:         report.Changes,
+		Remembered:      remembered,
+		Committed:       committed,
+	}
+	res.Changelog = changelogFile
+	res.Patch = patchOut
+	res.Signature = signatureFile
+	return output.Result(res)
 }
 
 func handleEnumConversion(fm *fieldmanager.FieldManager, schema *types.AnalysisResult, jsonPath string) error {
-	fmt.Printf("🎯 Преобразование поля в enum тип\n")
-	fmt.Printf("Путь: %s\n", jsonPath)
-	fmt.Println()
+	output.Printf("🎯 Преобразование поля в enum тип\n")
+	output.Printf("Путь: %s\n", jsonPath)
+	output.Println()
 
 	// Находим поле по пути
 	field, err := fm.FindField(schema.Schema, jsonPath)
@@ -137,15 +278,15 @@ func handleEnumConversion(fm *fieldmanager.FieldManager, schema *types.AnalysisR
 	}
 
 	// Интерактивный ввод значений enum
-	fmt.Printf("📝 Введите возможные значения для enum (по одному на строку):\n")
-	fmt.Printf("💡 Закончите ввод пустой строкой\n")
-	fmt.Println()
+	output.Printf("📝 Введите возможные значения для enum (по одному на строку):\n")
+	output.Printf("💡 Закончите ввод пустой строкой\n")
+	output.Println()
 
 	scanner := bufio.NewScanner(os.Stdin)
 	var enumValues []interface{}
 
 	for {
-		fmt.Print("Значение: ")
+		output.Prompt("Значение: ")
 		if !scanner.Scan() {
 			break
 		}
@@ -159,6 +300,9 @@ func handleEnumConversion(fm *fieldmanager.FieldManager, schema *types.AnalysisR
 	}
 
 	if len(enumValues) == 0 {
+		if !output.Interactive() {
+			return output.InputRequired("передайте значения enum через stdin по одному на строку")
+		}
 		return fmt.Errorf("не введено ни одного значения для enum")
 	}
 
@@ -166,8 +310,10 @@ func handleEnumConversion(fm *fieldmanager.FieldManager, schema *types.AnalysisR
 	field.Enum = enumValues
 
 	// Добавляем описание
-	if interactive {
-		fmt.Print("📝 Описание поля (опционально): ")
+	if description != "" {
+		field.Description = description
+	} else if interactive && output.Interactive() {
+		output.Print("📝 Описание поля (опционально): ")
 		if scanner.Scan() {
 			desc := strings.TrimSpace(scanner.Text())
 			if desc != "" {
@@ -176,16 +322,16 @@ func handleEnumConversion(fm *fieldmanager.FieldManager, schema *types.AnalysisR
 		}
 	}
 
-	fmt.Printf("✅ Поле преобразовано в enum с %d значениями\n", len(enumValues))
-	fmt.Printf("🎯 Значения: %v\n", enumValues)
+	output.Printf("✅ Поле преобразовано в enum с %d значениями\n", len(enumValues))
+	output.Printf("🎯 Значения: %v\n", enumValues)
 
 	return nil
 }
 
 func handlePolymorphicConversion(fm *fieldmanager.FieldManager, schema *types.AnalysisResult, jsonPath string) error {
-	fmt.Printf("🎯 Преобразование поля в полиморфный тип\n")
-	fmt.Printf("Путь: %s\n", jsonPath)
-	fmt.Println()
+	output.Printf("🎯 Преобразование поля в полиморфный тип\n")
+	output.Printf("Путь: %s\n", jsonPath)
+	output.Println()
 
 	// Находим поле по пути
 	field, err := fm.FindField(schema.Schema, jsonPath)
@@ -197,15 +343,15 @@ func handlePolymorphicConversion(fm *fieldmanager.FieldManager, schema *types.An
 		return fmt.Errorf("преобразование в полиморфный тип поддерживается только для object полей, текущий тип: %s", field.Type)
 	}
 
-	fmt.Printf("📝 Создание полиморфного типа\n")
-	fmt.Printf("💡 Введите варианты полиморфного типа\n")
-	fmt.Println()
+	output.Printf("📝 Создание полиморфного типа\n")
+	output.Printf("💡 Введите варианты полиморфного типа\n")
+	output.Println()
 
 	scanner := bufio.NewScanner(os.Stdin)
 	var variants []*types.JSONSchema
 
 	for {
-		fmt.Print("Название варианта (или пустая строка для завершения): ")
+		output.Prompt("Название варианта (или пустая строка для завершения): ")
 		if !scanner.Scan() {
 			break
 		}
@@ -215,24 +361,15 @@ func handlePolymorphicConversion(fm *fieldmanager.FieldManager, schema *types.An
 			break
 		}
 
-		// Создаем базовый вариант
-		variant := &types.JSONSchema{
-			Type:        "object",
-			Properties:  make(map[string]*types.Property),
-			Description: fmt.Sprintf("Вариант %s", variantName),
-		}
-
-		// Добавляем дискриминатор
-		variant.Properties["type"] = &types.Property{
-			Type: "string",
-			Enum: []interface{}{variantName},
-		}
-
-		variants = append(variants, variant)
-		fmt.Printf("✅ Добавлен вариант: %s\n", variantName)
+		// Создаем базовый вариант с дискриминатором type
+		variants = append(variants, decisions.Variant(variantName))
+		output.Printf("✅ Добавлен вариант: %s\n", variantName)
 	}
 
 	if len(variants) == 0 {
+		if !output.Interactive() {
+			return output.InputRequired("передайте названия вариантов через stdin по одному на строку")
+		}
 		return fmt.Errorf("не создано ни одного варианта")
 	}
 
@@ -240,15 +377,15 @@ func handlePolymorphicConversion(fm *fieldmanager.FieldManager, schema *types.An
 	field.OneOf = variants
 	field.Type = "" // Убираем базовый тип
 
-	fmt.Printf("✅ Поле преобразовано в полиморфный тип с %d вариантами\n", len(variants))
+	output.Printf("✅ Поле преобразовано в полиморфный тип с %d вариантами\n", len(variants))
 
 	return nil
 }
 
 func handlePreserveDefaultUpdate(fm *fieldmanager.FieldManager, schema *types.AnalysisResult, jsonPath string) error {
-	fmt.Printf("🔒 Защита default значения от перезатирания\n")
-	fmt.Printf("Путь: %s\n", jsonPath)
-	fmt.Println()
+	output.Printf("🔒 Защита default значения от перезатирания\n")
+	output.Printf("Путь: %s\n", jsonPath)
+	output.Println()
 
 	// Находим поле по пути
 	field, err := fm.FindField(schema.Schema, jsonPath)
@@ -260,20 +397,20 @@ func handlePreserveDefaultUpdate(fm *fieldmanager.FieldManager, schema *types.An
 	field.PreserveDefault = true
 
 	if field.Default != nil {
-		fmt.Printf("✅ Default значение защищено: %v\n", field.Default)
+		output.Printf("✅ Default значение защищено: %v\n", field.Default)
 	} else {
-		fmt.Printf("⚠️ Default значение отсутствует, но защита установлена\n")
-		fmt.Printf("💡 При следующем анализе default будет заполнен и защищен\n")
+		output.Printf("⚠️ Default значение отсутствует, но защита установлена\n")
+		output.Printf("💡 При следующем анализе default будет заполнен и защищен\n")
 	}
 
-	fmt.Printf("✅ Поле защищено от перезатирания default: %s\n", jsonPath)
+	output.Printf("✅ Поле защищено от перезатирания default: %s\n", jsonPath)
 	return nil
 }
 
 func handleDescriptionUpdate(fm *fieldmanager.FieldManager, schema *types.AnalysisResult, jsonPath string) error {
-	fmt.Printf("🎯 Обновление описания поля\n")
-	fmt.Printf("Путь: %s\n", jsonPath)
-	fmt.Println()
+	output.Printf("🎯 Обновление описания поля\n")
+	output.Printf("Путь: %s\n", jsonPath)
+	output.Println()
 
 	// Находим поле по пути
 	field, err := fm.FindField(schema.Schema, jsonPath)
@@ -283,34 +420,108 @@ func handleDescriptionUpdate(fm *fieldmanager.FieldManager, schema *types.Analys
 
 	// Показываем текущее описание
 	if field.Description != "" {
-		fmt.Printf("📄 Текущее описание: %s\n", field.Description)
+		output.Printf("📄 Текущее описание: %s\n", field.Description)
 	} else {
-		fmt.Printf("📄 Текущее описание: отсутствует\n")
+		output.Printf("📄 Текущее описание: отсутствует\n")
+	}
+
+	// Описание из флага -d применяется без диалога
+	if description != "" {
+		field.Description = description
+		output.Printf("✅ Описание обновлено: %s\n", description)
+		return nil
 	}
 
 	// Интерактивный ввод нового описания
-	fmt.Print("📝 Новое описание: ")
+	output.Prompt("📝 Новое описание: ")
 	scanner := bufio.NewScanner(os.Stdin)
-	if scanner.Scan() {
-		newDesc := strings.TrimSpace(scanner.Text())
-		if newDesc != "" {
-			field.Description = newDesc
-			fmt.Printf("✅ Описание обновлено: %s\n", newDesc)
-		} else {
-			fmt.Printf("⚠️ Пустое описание, изменения не внесены\n")
+	if !scanner.Scan() && !output.Interactive() {
+		return output.InputRequired("передайте описание флагом -d")
+	}
+	newDesc := strings.TrimSpace(scanner.Text())
+	if newDesc != "" {
+		field.Description = newDesc
+		output.Printf("✅ Описание обновлено: %s\n", newDesc)
+	} else {
+		output.Printf("⚠️ Пустое описание, изменения не внесены\n")
+	}
+
+	return nil
+}
+
+func handleTypeChange(fm *fieldmanager.FieldManager, schema *types.AnalysisResult, jsonPath, newType string) error {
+	output.Printf("🎯 Изменение типа поля\n")
+	output.Printf("Путь: %s\n", jsonPath)
+	output.Println()
+
+	field, err := fm.FindField(schema.Schema, jsonPath)
+	if err != nil {
+		return fmt.Errorf("поле не найдено: %w", err)
+	}
+	output.Printf("📄 Текущий тип: %s\n", field.Type)
+
+	if newType == "" {
+		output.Prompt("📝 Новый тип (string, number, integer, boolean, object, array, null): ")
+		scanner := bufio.NewScanner(os.Stdin)
+		if !scanner.Scan() && !output.Interactive() {
+			return output.InputRequired("укажите тип четвертым аргументом: update-field <схема> <поле> type <тип>")
 		}
+		newType = strings.TrimSpace(scanner.Text())
+	}
+	if !analyzer.IsOverrideType(newType) {
+		return fmt.Errorf("неизвестный тип: %q", newType)
 	}
 
+	// Тип задается так же, как переопределением: ограничения прежнего типа
+	// убираются, конфликт типов поля снимается
+	analyzer.ApplyOverrides(schema.Schema, map[string]types.Override{jsonPath: {Type: newType}}, schema.Statistics)
+	output.Printf("✅ Тип поля изменен: %s\n", newType)
 	return nil
 }
 
+// rememberDecision сохраняет решение по полю в конфигурации проекта и
+// возвращает путь к файлу конфигурации. Вне проекта и для операций без
+// повторного применения решение не сохраняется
+func rememberDecision(fm *fieldmanager.FieldManager, schema *types.AnalysisResult, schemaFile, jsonPath, operation string) (string, error) {
+	decision := decisions.Decision{Description: description, DecidedAt: time.Now()}
+	field, err := fm.FindField(schema.Schema, jsonPath)
+	if err != nil {
+		return "", nil
+	}
+	switch operation {
+	case decisions.OperationType:
+		decision.Operation, decision.Type = decisions.OperationType, field.Type
+	case "enum":
+		decision.Operation, decision.Enum = decisions.OperationEnum, field.Enum
+	case "polymorph", "polymorphic":
+		decision.Operation = decisions.OperationPolymorph
+		for _, variant := range field.OneOf {
+			if discriminator := variant.Properties["type"]; discriminator != nil && len(discriminator.Enum) == 1 {
+				decision.Variants = append(decision.Variants, fmt.Sprint(discriminator.Enum[0]))
+			}
+		}
+	default:
+		return "", nil
+	}
+
+	p, err := project.Current()
+	if err != nil || p == nil {
+		return "", err
+	}
+	if err := p.RememberDecision(schemaFile, jsonPath, decision); err != nil {
+		return "", fmt.Errorf("ошибка сохранения решения: %w", err)
+	}
+	return p.ConfigPath, nil
+}
+
 func promptOperation() (string, error) {
-	fmt.Printf("🎯 Выберите операцию:\n")
-	fmt.Printf("1. enum - преобразовать в enum тип\n")
-	fmt.Printf("2. polymorph - преобразовать в полиморфный тип\n")
-	fmt.Printf("3. description - обновить описание\n")
-	fmt.Printf("4. preserve-default - защитить default от перезатирания\n")
-	fmt.Print("Ваш выбор (1-4): ")
+	output.Printf("🎯 Выберите операцию:\n")
+	output.Printf("1. enum - преобразовать в enum тип\n")
+	output.Printf("2. polymorph - преобразовать в полиморфный тип\n")
+	output.Printf("3. description - обновить описание\n")
+	output.Printf("4. preserve-default - защитить default от перезатирания\n")
+	output.Printf("5. type - изменить тип поля\n")
+	output.Print("Ваш выбор (1-5): ")
 
 	scanner := bufio.NewScanner(os.Stdin)
 	if scanner.Scan() {
@@ -324,6 +535,8 @@ func promptOperation() (string, error) {
 			return "description", nil
 		case "4":
 			return "preserve-default", nil
+		case "5":
+			return decisions.OperationType, nil
 		default:
 			return "", fmt.Errorf("неверный выбор: %s", choice)
 		}
@@ -332,25 +545,25 @@ func promptOperation() (string, error) {
 	return "", fmt.Errorf("ошибка ввода")
 }
 
-// commitSchemaChanges выполняет автоматический коммит изменений схемы
-func commitSchemaChanges(schemaFile, operation string) error {
-	// Проверяем, что мы в git репозитории
-	if _, err := exec.LookPath("git"); err != nil {
-		return fmt.Errorf("git не найден")
+// writePatch сохраняет разницу между прежним и текущим содержимым схемы как JSON Patch
+func writePatch(schemaFile string, previousContent []byte, patchFile string) error {
+	currentContent, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения схемы: %w", err)
 	}
 
-	// Добавляем файл схемы в git
-	cmd := exec.Command("git", "add", schemaFile)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ошибка git add: %w", err)
+	patch, err := jsonpatch.DiffBytes(previousContent, currentContent)
+	if err != nil {
+		return fmt.Errorf("ошибка построения JSON Patch: %w", err)
 	}
 
-	// Создаем коммит
-	commitMessage := fmt.Sprintf("schema: %s %s", operation, filepath.Base(schemaFile))
-	cmd = exec.Command("git", "commit", "-m", commitMessage)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ошибка git commit: %w", err)
+	data, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации JSON Patch: %w", err)
 	}
 
+	if err := fileutil.WriteFile(patchFile, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи JSON Patch: %w", err)
+	}
 	return nil
 }
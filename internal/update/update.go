@@ -1,20 +1,52 @@
 package update
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/internal/analyzerflags"
+	"github.com/yanodincov/json-schema-detector/internal/autocommit"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/internal/signing"
+	"github.com/yanodincov/json-schema-detector/internal/summary"
+	"github.com/yanodincov/json-schema-detector/pkg/changelog"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/decisions"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/jsonpatch"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
 )
 
 var (
-	inputFile  string
-	autoCommit bool
+	analyzerFlags *analyzerflags.Flags
+	inputFile     string
+	autoCommit    bool
+	changeLog     bool
+	patchOut      string
 )
 
+// Result представляет результат команды update в режиме --json
+type Result struct {
+	Schema          string              `json:"schema"`
+	Input           string              `json:"input"`
+	NewObjects      int                 `json:"new_objects"`
+	PreviousVersion string              `json:"previous_version"`
+	Version         string              `json:"version"`
+	Bump            string              `json:"bump"`
+	Changes         []compat.Change     `json:"changes"`
+	Summary         *summary.Summary    `json:"summary"`
+	Changelog       string              `json:"changelog,omitempty"`
+	Signature       string              `json:"signature,omitempty"`
+	Patch           string              `json:"patch,omitempty"`
+	Decisions       []decisions.Applied `json:"decisions,omitempty"`
+	Committed       bool                `json:"committed"`
+}
+
 // Cmd представляет команду update
 var Cmd = &cobra.Command{
 	Use:   "update [schema.json]",
@@ -28,11 +60,17 @@ var Cmd = &cobra.Command{
 func init() {
 	Cmd.Flags().StringVarP(&inputFile, "input", "i", "", "JSON файл с новыми данными")
 	Cmd.Flags().BoolVarP(&autoCommit, "auto-commit", "a", false, "Автоматический коммит изменений схемы")
+	Cmd.Flags().BoolVar(&changeLog, "changelog", false, "Дописать запись в файл истории изменений рядом со схемой")
+	Cmd.Flags().StringVar(&patchOut, "patch-out", "", "Записать изменения схемы в файл JSON Patch (RFC 6902)")
+	analyzerFlags = analyzerflags.Register(Cmd)
 	Cmd.MarkFlagRequired("input")
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
-	schemaFile := args[0]
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
 
 	// Проверяем существование файлов
 	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
@@ -43,23 +81,44 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("входной файл не найден: %s", inputFile)
 	}
 
-	fmt.Printf("Обновление схемы: %s\n", schemaFile)
-	fmt.Printf("Новые данные: %s\n", inputFile)
+	output.Printf("Обновление схемы: %s\n", schemaFile)
+	output.Printf("Новые данные: %s\n", inputFile)
 
 	// Создаем анализатор
-	analyzer := analyzer.New()
+	analyzer := analyzerFlags.New()
 
 	// Загружаем существующую схему
 	existingSchema, err := analyzer.LoadSchema(schemaFile)
 	if err != nil {
 		return fmt.Errorf("ошибка загрузки схемы: %w", err)
 	}
+	for _, warning := range existingSchema.Warnings {
+		output.Printf("⚠️ %s\n", warning)
+	}
+
+	// Запоминаем исходную схему для определения уровня изменения версии
+	previousSchema, err := existingSchema.Schema.Clone()
+	if err != nil {
+		return fmt.Errorf("ошибка копирования схемы: %w", err)
+	}
+
+	// Запоминаем конфликты типов, чтобы в сводке показать только новые
+	previousConflicts := make(map[string][]string)
+	if existingSchema.Statistics != nil {
+		for field, kinds := range existingSchema.Statistics.TypeConflicts {
+			previousConflicts[field] = kinds
+		}
+	}
+
+	// Новые данные анализируются с настройками, сохраненными в схеме
+	analyzer = analyzerFlags.ForSchema(existingSchema.Metadata)
 
 	// Анализируем новые данные
 	newResult, err := analyzer.AnalyzeFile(inputFile)
 	if err != nil {
-		return fmt.Errorf("ошибка анализа новых данных: %w", err)
+		return fmt.Errorf("ошибка анализа новых данных: %w", analyzerflags.Explain(err))
 	}
+	analyzerflags.PrintWarnings(newResult.Warnings)
 
 	// Объединяем схемы
 	mergedResult, err := analyzer.MergeResults(existingSchema, newResult)
@@ -67,45 +126,160 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("ошибка объединения схем: %w", err)
 	}
 
+	// Применяем сохраненные решения по полям, чтобы результат совпадал с ручным выбором
+	applied, err := applyDecisions(schemaFile, mergedResult)
+	if err != nil {
+		return err
+	}
+
+	// Повышаем версию схемы согласно характеру изменений
+	report, oldVersion, err := compat.StampVersion(previousSchema, mergedResult)
+	if err != nil {
+		return fmt.Errorf("ошибка определения версии схемы: %w", err)
+	}
+
+	// Запоминаем содержимое файла до сохранения для построения JSON Patch
+	var previousContent []byte
+	if patchOut != "" {
+		if previousContent, err = os.ReadFile(schemaFile); err != nil {
+			return fmt.Errorf("ошибка чтения схемы: %w", err)
+		}
+	}
+
 	// Сохраняем обновленную схему
 	if err := analyzer.SaveSchema(mergedResult, schemaFile); err != nil {
 		return fmt.Errorf("ошибка сохранения схемы: %w", err)
 	}
 
-	fmt.Printf("Схема успешно обновлена: %s\n", schemaFile)
-	fmt.Printf("Добавлено новых объектов: %d\n", newResult.Statistics.TotalObjects)
+	// Подписываем схему, если настроен ключ подписи
+	signatureFile, err := signing.SignSchema(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи схемы: %w", err)
+	}
+
+	output.Printf("Схема успешно обновлена: %s\n", schemaFile)
+	output.Printf("Добавлено новых объектов: %d\n", newResult.Statistics.TotalObjects)
+	if report.Bump == compat.BumpNone {
+		output.Printf("Версия схемы: %s (без изменений)\n", oldVersion)
+	} else {
+		output.Printf("Версия схемы: %s → %s (%s, изменений: %d)\n", oldVersion, mergedResult.Metadata.Version, report.Bump, len(report.Changes))
+	}
+	if len(applied) > 0 {
+		restored := 0
+		for _, a := range applied {
+			if a.Changed {
+				restored++
+			}
+		}
+		output.Printf("🧠 Применены сохраненные решения: %d (восстановлено: %d)\n", len(applied), restored)
+	}
+
+	// Записываем историю изменений если флаг установлен
+	var changedFiles []string
+	changelogFile := ""
+	if changeLog && len(report.Changes) > 0 {
+		path, err := changelog.Append(schemaFile, &changelog.Entry{
+			Date:    time.Now(),
+			Version: mergedResult.Metadata.Version,
+			Bump:    report.Bump,
+			Source:  filepath.Base(inputFile),
+			Changes: report.Changes,
+		})
+		if err != nil {
+			return err
+		}
+		changelogFile = path
+		changedFiles = append(changedFiles, path)
+		output.Printf("История изменений дополнена: %s\n", path)
+	}
+
+	// Записываем изменения в виде JSON Patch если флаг установлен
+	if patchOut != "" {
+		if err := writePatch(schemaFile, previousContent, patchOut); err != nil {
+			return err
+		}
+		output.Printf("🩹 JSON Patch записан: %s\n", patchOut)
+	}
+
+	if signatureFile != "" {
+		changedFiles = append(changedFiles, signatureFile)
+	}
 
 	// Автоматический коммит если флаг установлен
+	committed := false
 	if autoCommit {
-		if err := commitSchemaChanges(schemaFile, "update"); err != nil {
-			fmt.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
+		if err := autocommit.Commit(schemaFile, "update", changedFiles...); err != nil {
+			output.Printf("⚠️ Ошибка автоматического коммита: %v\n", err)
 		} else {
-			fmt.Printf("✅ Изменения схемы закоммичены\n")
+			committed = true
+			output.Printf("✅ Изменения схемы закоммичены\n")
 		}
 	}
 
-	return nil
+	sum := summary.Build(summary.Input{
+		Program:           cmd.Root().Name(),
+		Schema:            schemaFile,
+		Result:            mergedResult,
+		Report:            report,
+		PreviousConflicts: previousConflicts,
+	})
+	sum.Print()
+
+	res := Result{
+		Schema:          schemaFile,
+		Input:           inputFile,
+		NewObjects:      newResult.Statistics.TotalObjects,
+		PreviousVersion: oldVersion,
+		Version:         mergedResult.Metadata.Version,
+		Bump:            report.Bump.String(),
+		Changes:         report.Changes,
+		Summary:         sum,
+		Decisions:       applied,
+		Committed:       committed,
+	}
+	res.Changelog = changelogFile
+	res.Patch = patchOut
+	res.Signature = signatureFile
+	return output.Result(res)
+}
+
+// applyDecisions применяет решения по полям схемы, сохраненные в конфигурации проекта
+func applyDecisions(schemaFile string, result *types.AnalysisResult) ([]decisions.Applied, error) {
+	p, err := project.Current()
+	if err != nil || p == nil {
+		return nil, err
+	}
+	saved := p.Decisions(schemaFile)
+	if len(saved) == 0 {
+		return nil, nil
+	}
+
+	applied, err := decisions.Apply(result, saved)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка применения сохраненных решений: %w", err)
+	}
+	return applied, nil
 }
 
-// commitSchemaChanges выполняет автоматический коммит изменений схемы
-func commitSchemaChanges(schemaFile, operation string) error {
-	// Проверяем, что мы в git репозитории
-	if _, err := exec.LookPath("git"); err != nil {
-		return fmt.Errorf("git не найден")
+// writePatch сохраняет разницу между прежним и текущим содержимым схемы как JSON Patch
+func writePatch(schemaFile string, previousContent []byte, patchFile string) error {
+	currentContent, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения схемы: %w", err)
 	}
 
-	// Добавляем файл схемы в git
-	cmd := exec.Command("git", "add", schemaFile)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ошибка git add: %w", err)
+	patch, err := jsonpatch.DiffBytes(previousContent, currentContent)
+	if err != nil {
+		return fmt.Errorf("ошибка построения JSON Patch: %w", err)
 	}
 
-	// Создаем коммит
-	commitMessage := fmt.Sprintf("schema: %s %s", operation, filepath.Base(schemaFile))
-	cmd = exec.Command("git", "commit", "-m", commitMessage)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ошибка git commit: %w", err)
+	data, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации JSON Patch: %w", err)
 	}
 
+	if err := fileutil.WriteFile(patchFile, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи JSON Patch: %w", err)
+	}
 	return nil
 }
@@ -1,24 +1,67 @@
 package validate
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/exitcode"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
 	"github.com/yanodincov/json-schema-detector/pkg/validator"
 )
 
 var (
-	verbose bool
-	strict  bool
+	verbose    bool
+	strict     bool
+	suggest    bool
+	patchOut   string
+	ndjson     bool
+	workers    int
+	invalidOut string
 )
 
+// Result представляет результат команды validate в режиме --json
+type Result struct {
+	Data   string `json:"data"`
+	Schema string `json:"schema"`
+	*validator.ValidationResult
+	Fixes      []validator.Fix `json:"fixes,omitempty"`
+	Patch      string          `json:"patch,omitempty"`
+	InvalidOut string          `json:"invalid_out,omitempty"`
+}
+
 // Cmd представляет команду validate
 var Cmd = &cobra.Command{
 	Use:   "validate [data.json] [schema.json]",
 	Short: "Валидирует JSON файл против схемы",
 	Long: `Валидирует JSON файл против JSON Schema и выводит результат валидации 
-с подробным описанием ошибок.`,
+с подробным описанием ошибок.
+
+С флагом --suggest для частых ошибок предлагаются исправления данных:
+приведение значений, тип которых однозначно приводится к ожидаемому ("42" →
+42), значения по умолчанию для отсутствующих обязательных полей и ближайшие
+значения enum для опечаток. Флаг --patch-out записывает исправления в файл
+JSON Patch (RFC 6902), который применяется к файлу данных.
+
+Файл NDJSON (.ndjson, в том числе сжатый, или --ndjson) проверяется
+по записям: каждая запись - отдельный документ для схемы. Записи
+проверяются параллельно в --workers обработчиках, а ошибки выводятся
+в порядке записей с номером записи. Флаг --invalid-out записывает
+записи с ошибками в отдельный файл NDJSON, чтобы отложить их и обработать
+повторно: по строке {"record", "errors", "data"} на запись, где data -
+исходная запись. Исправления для NDJSON не предлагаются.
+
+Примеры использования:
+  validate data.json users.schema.json
+  validate data.json users.schema.json --suggest
+  validate data.json users.schema.json --patch-out fixes.json
+  validate events.ndjson.gz events.schema.json --workers 8 -v
+  validate events.ndjson events.schema.json --invalid-out bad.ndjson`,
 	Args: cobra.ExactArgs(2),
 	RunE: runValidate,
 }
@@ -26,11 +69,19 @@ var Cmd = &cobra.Command{
 func init() {
 	Cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Подробный вывод")
 	Cmd.Flags().BoolVarP(&strict, "strict", "s", false, "Строгая валидация")
+	Cmd.Flags().BoolVar(&suggest, "suggest", false, "Предложить исправления данных для частых ошибок: приведение типа, default, опечатки в enum")
+	Cmd.Flags().StringVar(&patchOut, "patch-out", "", "Записать предложенные исправления в файл JSON Patch (RFC 6902); включает --suggest")
+	Cmd.Flags().BoolVar(&ndjson, "ndjson", false, "Проверять каждую запись NDJSON отдельно (для файлов .ndjson включено всегда)")
+	Cmd.Flags().IntVar(&workers, "workers", 0, "Сколько обработчиков проверяют записи NDJSON параллельно (0 - по числу процессоров)")
+	Cmd.Flags().StringVar(&invalidOut, "invalid-out", "", "Записать записи NDJSON с ошибками и описанием ошибок в отдельный файл NDJSON")
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
 	dataFile := args[0]
-	schemaFile := args[1]
+	schemaFile, err := project.ResolveSchema(args[1])
+	if err != nil {
+		return err
+	}
 
 	// Проверяем существование файлов
 	if _, err := os.Stat(dataFile); os.IsNotExist(err) {
@@ -41,40 +92,185 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
 	}
 
-	fmt.Printf("Валидация данных: %s\n", dataFile)
-	fmt.Printf("Против схемы: %s\n", schemaFile)
+	records := ndjson || analyzer.IsNDJSON(dataFile)
+	if records && (suggest || patchOut != "") {
+		return fmt.Errorf("исправления (--suggest, --patch-out) для NDJSON не предлагаются")
+	}
+	if !records && invalidOut != "" {
+		return fmt.Errorf("--invalid-out записывает записи NDJSON; для других файлов укажите --ndjson")
+	}
+	if workers < 0 {
+		return fmt.Errorf("число обработчиков не может быть отрицательным")
+	}
+
+	output.Printf("Валидация данных: %s\n", dataFile)
+	output.Printf("Против схемы: %s\n", schemaFile)
 
 	// Создаем валидатор
+	var result *validator.ValidationResult
 	validator := validator.New(strict)
 
 	// Выполняем валидацию
-	result, err := validator.ValidateFile(dataFile, schemaFile)
+	if records {
+		result, err = validateRecords(validator, dataFile, schemaFile)
+	} else {
+		result, err = validator.ValidateFile(dataFile, schemaFile)
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка валидации: %w", err)
 	}
 
 	// Выводим результат
 	if result.Valid {
-		fmt.Printf("✅ Валидация прошла успешно\n")
+		output.Printf("✅ Валидация прошла успешно\n")
+		printInvalidOut(result)
 		if verbose {
-			fmt.Printf("Проверено полей: %d\n", result.ValidatedFields)
-			fmt.Printf("Время валидации: %s\n", result.Duration)
+			printRecords(result)
+			output.Printf("Проверено полей: %d\n", result.ValidatedFields)
+			output.Printf("Время валидации: %s\n", result.Duration)
 		}
 	} else {
-		fmt.Printf("❌ Валидация не пройдена\n")
-		fmt.Printf("Найдено ошибок: %d\n", len(result.Errors))
+		output.Printf("❌ Валидация не пройдена\n")
+		output.Printf("Найдено ошибок: %d\n", len(result.Errors))
+		if records {
+			output.Printf("Записей с ошибками: %d из %d\n", result.InvalidRecords, result.Records)
+			printInvalidOut(result)
+		}
 
 		for i, err := range result.Errors {
-			fmt.Printf("  %d. %s\n", i+1, err.Description)
+			if err.Record > 0 {
+				output.Printf("  %d. запись %d: %s\n", i+1, err.Record, err.Description)
+			} else {
+				output.Printf("  %d. %s\n", i+1, err.Description)
+			}
 			if verbose {
-				fmt.Printf("     Путь: %s\n", err.Field)
-				fmt.Printf("     Тип: %s\n", err.Type)
+				output.Printf("     Путь: %s\n", err.Field)
+				output.Printf("     Тип: %s\n", err.Type)
+			}
+			printBranches(err.Branches)
+		}
+
+		res := Result{Data: dataFile, Schema: schemaFile, ValidationResult: result, InvalidOut: invalidOut}
+		if suggest || patchOut != "" {
+			fixes, err := validator.SuggestFile(dataFile, schemaFile, result)
+			if err != nil {
+				return fmt.Errorf("ошибка подбора исправлений: %w", err)
 			}
+			printFixes(fixes)
+			res.Fixes = fixes
+			if patchOut != "" {
+				if err := writePatch(fixes, patchOut); err != nil {
+					return err
+				}
+				output.Printf("🩹 JSON Patch записан: %s\n", patchOut)
+				res.Patch = patchOut
+			}
+		}
+
+		if err := output.Result(res); err != nil {
+			return err
 		}
 
 		// Возвращаем код ошибки для CI/CD
-		os.Exit(1)
+		return exitcode.Fail(cmd, 1)
+	}
+
+	return output.Result(Result{Data: dataFile, Schema: schemaFile, ValidationResult: result, InvalidOut: invalidOut})
+}
+
+// validateRecords проверяет записи NDJSON и, если задан --invalid-out,
+// записывает записи с ошибками в файл; файл создается и без таких записей
+func validateRecords(v *validator.Validator, dataFile, schemaFile string) (*validator.ValidationResult, error) {
+	opts := validator.RecordsOptions{Workers: workers}
+	if invalidOut == "" {
+		return v.ValidateRecordsFile(dataFile, schemaFile, opts)
+	}
+
+	file, err := os.Create(invalidOut)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания файла записей с ошибками: %w", err)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	opts.Invalid = writer
+
+	result, err := v.ValidateRecordsFile(dataFile, schemaFile, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("ошибка записи записей с ошибками: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка записи записей с ошибками: %w", err)
+	}
+	return result, nil
+}
+
+// printInvalidOut сообщает, куда записаны записи с ошибками
+func printInvalidOut(result *validator.ValidationResult) {
+	if invalidOut != "" {
+		output.Printf("🚧 Записи с ошибками (%d) записаны: %s\n", result.InvalidRecords, invalidOut)
 	}
+}
 
+// printRecords выводит число проверенных записей NDJSON и скорость проверки
+func printRecords(result *validator.ValidationResult) {
+	if result.Records == 0 {
+		return
+	}
+	rate := float64(result.Records) / result.Duration.Seconds()
+	output.Printf("Проверено записей: %d (%.0f записей/с)\n", result.Records, rate)
+}
+
+// printBranches выводит проверку значения по вариантам oneOf: ошибки
+// ближайшего варианта целиком, остальных - первую ошибку; с --verbose -
+// все ошибки всех вариантов
+func printBranches(branches []validator.Branch) {
+	for _, branch := range branches {
+		switch {
+		case branch.Valid:
+			output.Printf("     ✓ вариант %s\n", branch.Name())
+		case branch.Closest || verbose || len(branch.Errors) == 0:
+			closest := ""
+			if branch.Closest {
+				closest = " - ближайший"
+			}
+			output.Printf("     ✗ вариант %s%s:\n", branch.Name(), closest)
+			for _, e := range branch.Errors {
+				output.Printf("         - %s: %s\n", e.Field, e.Description)
+			}
+		default:
+			more := ""
+			if len(branch.Errors) > 1 {
+				more = fmt.Sprintf(" (и еще ошибок: %d)", len(branch.Errors)-1)
+			}
+			first := branch.Errors[0]
+			output.Printf("     ✗ вариант %s: %s: %s%s\n", branch.Name(), first.Field, first.Description, more)
+		}
+	}
+}
+
+// printFixes выводит предложенные исправления данных
+func printFixes(fixes []validator.Fix) {
+	if len(fixes) == 0 {
+		output.Printf("💡 Исправлений для найденных ошибок не предложено\n")
+		return
+	}
+	output.Printf("💡 Предлагаемые исправления (%d):\n", len(fixes))
+	for _, fix := range fixes {
+		output.Printf("   %s: %s\n", fix.Field, fix.Description())
+	}
+}
+
+// writePatch сохраняет исправления в файл JSON Patch
+func writePatch(fixes []validator.Fix, patchFile string) error {
+	data, err := json.MarshalIndent(validator.Patch(fixes), "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации JSON Patch: %w", err)
+	}
+	if err := fileutil.WriteFile(patchFile, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи JSON Patch: %w", err)
+	}
 	return nil
 }
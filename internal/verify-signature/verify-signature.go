@@ -0,0 +1,94 @@
+package verifysignature
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yanodincov/json-schema-detector/internal/exitcode"
+	"github.com/yanodincov/json-schema-detector/internal/output"
+	"github.com/yanodincov/json-schema-detector/internal/project"
+	"github.com/yanodincov/json-schema-detector/pkg/signature"
+)
+
+var (
+	keyFile       string
+	signatureFile string
+)
+
+// Result представляет результат команды verify-signature в режиме --json
+type Result struct {
+	Schema    string `json:"schema"`
+	Signature string `json:"signature"`
+	KeyID     string `json:"key_id"`
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Cmd представляет команду verify-signature
+var Cmd = &cobra.Command{
+	Use:   "verify-signature [schema.json]",
+	Short: "Проверяет подпись схемы открытым ключом",
+	Long: `Проверяет, что схема не изменялась после подписи закрытым ключом
+команды-владельца. По умолчанию подпись читается из файла <schema>.sig.
+При неверной подписи команда завершается с кодом 1.
+
+Примеры использования:
+  verify-signature users.schema.json --key team.pub
+  verify-signature users --key team.pub --signature dist/users.sig`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerifySignature,
+}
+
+func init() {
+	Cmd.Flags().StringVarP(&keyFile, "key", "k", "", "Файл открытого ключа")
+	Cmd.Flags().StringVarP(&signatureFile, "signature", "s", "", "Файл подписи (по умолчанию <schema>"+signature.FileSuffix+")")
+	Cmd.MarkFlagRequired("key")
+}
+
+func runVerifySignature(cmd *cobra.Command, args []string) error {
+	schemaFile, err := project.ResolveSchema(args[0])
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(schemaFile); os.IsNotExist(err) {
+		return fmt.Errorf("файл схемы не найден: %s", schemaFile)
+	}
+
+	sigFile := signatureFile
+	if sigFile == "" {
+		sigFile = signature.Path(schemaFile)
+	}
+
+	key, err := signature.LoadPublicKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	output.Printf("🔍 Проверка подписи схемы: %s\n", schemaFile)
+	output.Printf("🔏 Файл подписи: %s\n", sigFile)
+	output.Printf("🔑 Ключ: %s\n", key.KeyID())
+
+	res := Result{
+		Schema:    schemaFile,
+		Signature: sigFile,
+		KeyID:     key.KeyID(),
+		Valid:     true,
+	}
+
+	if err := signature.VerifyFile(key, schemaFile, sigFile); err != nil {
+		output.Printf("❌ Подпись недействительна: %v\n", err)
+		res.Valid = false
+		res.Error = err.Error()
+		if err := output.Result(res); err != nil {
+			return err
+		}
+
+		// Возвращаем код ошибки для CI/CD
+		return exitcode.Fail(cmd, 1)
+	}
+
+	output.Printf("✅ Подпись действительна\n")
+	return output.Result(res)
+}
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/yanodincov/json-schema-detector/internal/exitcode"
 	"github.com/yanodincov/json-schema-detector/internal/root"
 )
 
 func main() {
 	if err := root.Execute(); err != nil {
+		if exitErr := exitcode.As(err); exitErr != nil {
+			os.Exit(exitErr.Code)
+		}
 		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,226 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// keyPatterns - шаблоны ключей-идентификаторов, по которым объект выглядит
+// словарем. Ключи словаря описываются первым шаблоном, подходящим для всех
+var keyPatterns = []string{
+	`^\d+$`,
+	`^\d{4}-\d{2}-\d{2}$`,
+	`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?$`,
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+	`^[0-9a-fA-F]{8,}$`,
+}
+
+// keyMatchers - скомпилированные keyPatterns
+var keyMatchers = compileKeyPatterns()
+
+func compileKeyPatterns() []*regexp.Regexp {
+	matchers := make([]*regexp.Regexp, len(keyPatterns))
+	for i, pattern := range keyPatterns {
+		matchers[i] = regexp.MustCompile(pattern)
+	}
+	return matchers
+}
+
+// applyAdditional описывает объекты, похожие на словари, схемой значений
+// (patternProperties для ключей-идентификаторов, additionalProperties для
+// прочих) и в режиме ClosedObjects закрывает объекты (additionalProperties:
+// false). Вложенные узлы обрабатываются раньше родителя, чтобы словари внутри
+// словарей тоже были найдены
+func (a *Analyzer) applyAdditional(prop *types.Property, path string, st *state) {
+	if prop == nil {
+		return
+	}
+
+	for key, child := range prop.Properties {
+		a.applyAdditional(child, path+"."+key, st)
+	}
+	a.applyAdditional(prop.Items, path+"[0]", st)
+	for i, position := range prop.PrefixItems {
+		a.applyAdditional(position, positionPath(path, i), st)
+	}
+
+	// Поля обрезанного объекта неизвестны: его нельзя ни закрыть, ни описать словарем
+	if prop.Type != "object" || prop.Ref != "" || prop.Truncated {
+		return
+	}
+	if a.config.DetectMaps {
+		if values, pattern := a.mapValues(prop, path, st); values != nil {
+			prop.Properties = nil
+			prop.Required = nil
+			if pattern == "" {
+				prop.AdditionalProperties = &types.AdditionalProperties{Schema: values}
+				return
+			}
+			prop.PatternProperties = map[string]*types.Property{pattern: values}
+		}
+	}
+	if a.config.ClosedObjects {
+		prop.AdditionalProperties = &types.AdditionalProperties{Allowed: false}
+	}
+}
+
+// mapValues объединяет схемы значений объекта, похожего на словарь, и
+// возвращает шаблон его ключей. Словарь - объект, все ключи которого
+// подходят под один из keyPatterns, либо объект, у которого различных ключей
+// не меньше MapMinKeys и каждый экземпляр содержит лишь часть из них
+// (тогда шаблон пуст). Значения должны быть одного типа. Для обычного
+// объекта возвращает nil
+func (a *Analyzer) mapValues(prop *types.Property, path string, st *state) (*types.Property, string) {
+	keys := make([]string, 0, len(prop.Properties))
+	for key := range prop.Properties {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, ""
+	}
+	sort.Strings(keys)
+
+	pattern := keyPattern(keys)
+	if pattern == "" && !a.sparseKeys(keys, path, st) {
+		return nil, ""
+	}
+
+	kind := ""
+	for _, key := range keys {
+		child := prop.Properties[key]
+		if child == nil || child.Ref != "" || len(child.OneOf) > 0 || len(child.AnyOf) > 0 {
+			return nil, ""
+		}
+		switch {
+		case child.Type == "null" || child.Type == kind || types.SameKind(child.Type, kind):
+		case kind == "":
+			kind = child.Type
+		default:
+			return nil, ""
+		}
+	}
+
+	// Значения объединяются отдельно от статистики анализа: конфликты между
+	// ключами словаря не относятся к полям схемы
+	scratch := newState(&types.AnalysisStatistics{})
+	values := prop.Properties[keys[0]]
+	for _, key := range keys[1:] {
+		a.mergeProperty(values, prop.Properties[key], path+".*", scratch)
+	}
+	if values.Type == "null" && kind != "" {
+		return nil, ""
+	}
+	return values, pattern
+}
+
+// sparseKeys сообщает, что различных ключей не меньше MapMinKeys, а в
+// среднем объект содержит не больше половины из них
+func (a *Analyzer) sparseKeys(keys []string, path string, st *state) bool {
+	if a.config.MapMinKeys <= 0 || len(keys) < a.config.MapMinKeys {
+		return false
+	}
+	objects := st.objects[path]
+	if objects < 2 {
+		return false
+	}
+
+	present := 0
+	for _, key := range keys {
+		present += st.fields[path][key]
+	}
+	return present*2 <= objects*len(keys)
+}
+
+// keyPattern возвращает первый из keyPatterns, которому соответствуют все
+// ключи, или пустую строку
+func keyPattern(keys []string) string {
+	for i, matcher := range keyMatchers {
+		if allMatch(matcher, keys) {
+			return keyPatterns[i]
+		}
+	}
+	return ""
+}
+
+// allMatch сообщает, что все ключи соответствуют шаблону
+func allMatch(matcher *regexp.Regexp, keys []string) bool {
+	for _, key := range keys {
+		if !matcher.MatchString(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeMap объединяет схемы значений словарей при обновлении схемы и
+// сообщает, что поля новых данных уже учтены в схеме значений. Поля обычного
+// объекта, объединяемого со словарем, дополняют схему значений; ключи, не
+// подходящие под шаблон patternProperties, переводят словарь на
+// additionalProperties
+func (a *Analyzer) mergeMap(existing, new *types.Property, path string, st *state) bool {
+	values := types.MapValues(existing.PatternProperties, existing.AdditionalProperties)
+	newValues := types.MapValues(new.PatternProperties, new.AdditionalProperties)
+
+	if values == nil {
+		// Объект, в новых данных распознанный как словарь, становится словарем:
+		// его прежние поля дополняют схему значений
+		if newValues != nil {
+			existing.PatternProperties = new.PatternProperties
+			existing.AdditionalProperties = new.AdditionalProperties
+			if pattern := types.KeyPattern(new.PatternProperties); pattern != "" && !keysFit(pattern, existing) {
+				existing.PatternProperties = nil
+				existing.AdditionalProperties = &types.AdditionalProperties{Schema: newValues}
+			}
+			for _, prop := range existing.Properties {
+				if prop != nil {
+					a.mergeProperty(newValues, prop, path+".*", st)
+				}
+			}
+			existing.Properties, existing.Required = nil, nil
+			return true
+		}
+		// Режим закрытых объектов применяется и к сохраненной схеме
+		if existing.AdditionalProperties == nil && new.AdditionalProperties.Closed() {
+			existing.AdditionalProperties = new.AdditionalProperties
+		}
+		return false
+	}
+
+	pattern := types.KeyPattern(existing.PatternProperties)
+	if pattern != "" && !keysFit(pattern, new) {
+		existing.PatternProperties = nil
+		existing.AdditionalProperties = &types.AdditionalProperties{Schema: values}
+	}
+
+	if newValues != nil {
+		a.mergeProperty(values, newValues, path+".*", st)
+	}
+	for _, prop := range new.Properties {
+		if prop != nil {
+			a.mergeProperty(values, prop, path+".*", st)
+		}
+	}
+	return true
+}
+
+// keysFit сообщает, что ключи нового объекта подходят под шаблон словаря
+func keysFit(pattern string, new *types.Property) bool {
+	if newPattern := types.KeyPattern(new.PatternProperties); newPattern != "" && newPattern != pattern {
+		return false
+	}
+	if new.AdditionalProperties.Values() != nil {
+		return false
+	}
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return true
+	}
+	for key := range new.Properties {
+		if !matcher.MatchString(key) {
+			return false
+		}
+	}
+	return true
+}
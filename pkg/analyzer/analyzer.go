@@ -1,54 +1,394 @@
 package analyzer
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
 	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
 )
 
+// Config содержит настройки анализатора
+type Config struct {
+	// MaxArraySamples ограничивает число элементов вложенного массива, по
+	// которым выводится схема items; 0 - анализировать все элементы. Записи
+	// верхнего уровня (корневой массив, массив data, записи NDJSON) им не
+	// ограничиваются, для них есть MaxRecords и выборка записей
+	MaxArraySamples int
+
+	// RequiredPercent - доля объектов в процентах, в которой должно встречаться
+	// поле, чтобы считаться обязательным
+	RequiredPercent float64
+
+	// DetectIntegers выводит "integer" для чисел без дробной части;
+	// при false все числа описываются как "number"
+	DetectIntegers bool
+
+	// DetectFormats выставляет format строкам, все значения которых
+	// распознаны одним из детекторов форматов
+	DetectFormats bool
+
+	// FormatDetectors задает детекторы форматов; nil - встроенные
+	// (date-time, date, time, uuid, ipv4, ipv6, email, uri, hostname)
+	FormatDetectors []FormatDetector
+
+	// Examples - сколько примеров значений записывать в examples строковых
+	// полей; 0 - не записывать
+	Examples int
+
+	// ExampleCardinality - число различных значений поля, начиная с которого
+	// примеры заменяются синтетическими значениями той же формы
+	ExampleCardinality int
+
+	// EnumThreshold - максимальное число различных значений строкового поля,
+	// при котором для него автоматически выводится enum; 0 - не выводить
+	EnumThreshold int
+	// EnumOrder - порядок значений выведенных enum: EnumAlphabetical (по
+	// умолчанию), EnumFirstSeen или EnumFrequency
+	EnumOrder string
+	// PropertyOrder - порядок полей объектов в схеме: PropertyAlphabetical
+	// (по умолчанию) или PropertyFirstSeen
+	PropertyOrder string
+
+	// LengthMode включает вывод minLength/maxLength строк и задает способ
+	// подсчета длины: LengthCodePoints или LengthGraphemes; "" - не выводить
+	LengthMode string
+
+	// RangeMode включает вывод диапазона числовых полей: RangeConstraint -
+	// minimum/maximum, RangeObserved - расширение x-observed-range; "" - не выводить
+	RangeMode string
+
+	// ArrayLimits включает вывод minItems/maxItems по наблюдаемой длине массивов
+	// и uniqueItems для массивов, элементы которых не повторяются
+	ArrayLimits bool
+
+	// Patterns включает вывод pattern для строковых полей, значения которых
+	// имеют общую структуру (ORD-1234, хеши, slug)
+	Patterns bool
+	// PatternMinSamples - минимальное число различных значений поля для вывода pattern
+	PatternMinSamples int
+
+	// DetectMaps описывает объекты, похожие на словари, схемой значений вместо
+	// перечисления ключей: patternProperties для ключей-идентификаторов (числа,
+	// даты, UUID, хеши), additionalProperties для множества редких ключей
+	DetectMaps bool
+	// MapMinKeys - минимальное число различных ключей объекта с произвольными
+	// ключами, начиная с которого он может считаться словарем; 0 - только по
+	// ключам-идентификаторам
+	MapMinKeys int
+	// ClosedObjects выставляет объектам additionalProperties: false
+	ClosedObjects bool
+
+	// MixedItems описывает элементы массива несовместимых типов вариантами
+	// items.anyOf вместо схемы первого элемента с конфликтом типов
+	MixedItems bool
+
+	// DetectPolymorphic описывает элементы массива объектов, которые
+	// различаются полем-дискриминатором (type, kind) и имеют разные наборы
+	// полей, вариантами oneOf с enum дискриминатора в каждом варианте
+	DetectPolymorphic bool
+
+	// DetectTuples описывает короткие массивы, позиции которых во всех выборках
+	// содержат значения разных типов ([name, count]), как кортежи: prefixItems
+	// и items: false вместо общей схемы элементов
+	DetectTuples bool
+
+	// DetectRecursion заменяет объекты, повторяющие форму одного из предков
+	// (деревья с children, вложенные ответы), ссылкой $ref на общее
+	// определение в $defs вместо вложенности на глубину выборки
+	DetectRecursion bool
+
+	// Dedupe выносит объекты одной формы, встреченные по разным путям, в общие
+	// определения $defs и заменяет их ссылками $ref
+	Dedupe bool
+	// DedupeSimilarity - доля общих полей (0..1), начиная с которой объекты
+	// считаются одной формой; 1 - только одинаковые наборы полей
+	DedupeSimilarity float64
+
+	// Confidence записывает полям оценки уверенности в выведенных типе,
+	// обязательности, enum и формате в расширение x-confidence
+	Confidence bool
+
+	// MinSamples - сколько значений поля нужно, чтобы записать в схему
+	// выведенные required, enum, format, pattern и границы; ограничения по
+	// меньшей выборке откладываются в x-pending до накопления данных
+	// обновлениями. 0 - записывать всегда
+	MinSamples int
+
+	// Overrides задает полям тип и формат по шаблону пути независимо от
+	// выборки (см. LoadOverrides); применяется последним проходом анализа
+	Overrides map[string]types.Override
+
+	// Stream анализирует файлы потоком: записи корневого массива или массива
+	// data читаются и объединяются по одной, память не зависит от размера
+	// файла, а MaxInputSize не применяется
+	Stream bool
+	// Tokenize при потоковом анализе выводит схемы записей прямо из токенов
+	// JSON, не строя для них дерево значений; на плоских записях это
+	// примерно вдвое быстрее и экономнее по памяти
+	Tokenize bool
+
+	// Auto выбирает настройки производительности по размеру и форме входного
+	// файла (см. ChooseProfile) вместо MaxArraySamples, Stream и Workers
+	Auto bool
+
+	// MaxInputSize - предел размера входных данных в байтах; больший файл не
+	// читается, а анализ завершается LimitError. 0 - без ограничения
+	MaxInputSize int64
+	// MaxRecords - предел числа записей верхнего уровня; 0 - без ограничения
+	MaxRecords int
+	// MaxDepth - предел вложенности объектов и массивов: корень имеет
+	// глубину 0, и анализируются объекты и массивы на глубине меньше
+	// MaxDepth. Более глубокие значения обрабатываются по DepthPolicy;
+	// 0 - без ограничения
+	MaxDepth int
+	// DepthPolicy - поведение при вложенности глубже MaxDepth: DepthError
+	// (по умолчанию) или DepthTruncate
+	DepthPolicy string
+
+	// SampleRecords - сколько записей верхнего уровня анализировать: после
+	// них чтение корневого массива прекращается, остальные записи массива
+	// data пропускаются; 0 - все записи
+	SampleRecords int
+	// SampleRate - доля записей верхнего уровня, отбираемых случайно (0..1];
+	// 0 - все записи
+	SampleRate float64
+	// SampleSeed инициализирует генератор случайной выборки
+	SampleSeed int64
+
+	// Workers - число параллельных обработчиков для нескольких входных
+	// файлов и записей NDJSON; 0 (по умолчанию) - GOMAXPROCS, 1 -
+	// последовательный анализ
+	Workers int
+
+	// Format - формат входных файлов: FormatJSON, FormatCSV, FormatYAML,
+	// FormatXML или FormatLog; пустое значение - по расширению файла (см.
+	// IsConverted)
+	Format string
+	// CSVDelimiter - разделитель значений CSV; 0 - запятая
+	CSVDelimiter rune
+	// LogPrefix - текст в строке журнала, после которого начинается JSON;
+	// пустая строка - первый объект {...} строки (см. AnalyzeLog)
+	LogPrefix string
+}
+
+// DefaultConfig возвращает настройки анализатора по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		MaxArraySamples:    1000,
+		RequiredPercent:    100,
+		DetectIntegers:     true,
+		DetectFormats:      true,
+		ExampleCardinality: 10,
+		PatternMinSamples:  5,
+		DetectMaps:         true,
+		MapMinKeys:         20,
+		MixedItems:         true,
+		DetectPolymorphic:  true,
+		DetectTuples:       true,
+		DetectRecursion:    true,
+		DedupeSimilarity:   DefaultDedupeSimilarity,
+		MaxInputSize:       DefaultMaxInputSize,
+		MaxDepth:           DefaultMaxDepth,
+	}
+}
+
 // Analyzer представляет анализатор JSON структур
 type Analyzer struct {
+	config Config
+	// ctx отменяет анализ (см. AnalyzeFileContext); nil - без отмены
+	ctx context.Context
+	// inParallel - анализатор одного из файлов, которые AnalyzeFiles
+	// анализирует параллельно: автоматический режим не добавляет ему
+	// обработчиков (см. ChooseProfile)
+	inParallel bool
+}
+
+// New создает новый анализатор с настройками по умолчанию, к которым
+// применяются opts
+func New(opts ...Option) *Analyzer {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return NewWithConfig(config)
 }
 
-// New создает новый анализатор
-func New() *Analyzer {
-	return &Analyzer{}
+// NewWithConfig создает анализатор с указанными настройками
+func NewWithConfig(config Config) *Analyzer {
+	return &Analyzer{config: config}
 }
 
-// AnalyzeFile анализирует JSON файл и возвращает результат
+// AnalyzeFile анализирует JSON файл и возвращает результат. Файл .ndjson
+// анализируется как массив своих записей (см. AnalyzeNDJSON), таблица CSV -
+// как массив строк (см. AnalyzeCSV), журнал .log - как массив JSON из его
+// строк (см. AnalyzeLog), файлы YAML и XML - после преобразования в JSON
+// (см. AnalyzeYAML, AnalyzeXML). Файлы, сжатые gzip или zstd,
+// распаковываются при чтении
 func (a *Analyzer) AnalyzeFile(filename string) (*types.AnalysisResult, error) {
-	// Читаем файл
-	data, err := os.ReadFile(filename)
+	if a.IsCSV(filename) {
+		return a.analyzeFileCSV(filename)
+	}
+	if a.IsYAML(filename) {
+		return a.analyzeFileYAML(filename)
+	}
+	if a.IsXML(filename) {
+		return a.analyzeFileXML(filename)
+	}
+	if a.IsLog(filename) {
+		return a.analyzeFileLog(filename)
+	}
+	if a.config.Auto {
+		profile, err := a.ChooseProfile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return a.withProfile(profile).AnalyzeFile(filename)
+	}
+	if IsNDJSON(filename) {
+		return a.analyzeFileNDJSON(filename)
+	}
+	if a.config.Stream {
+		return a.analyzeFileStream(filename)
+	}
+
+	data, err := a.ReadInput(filename)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+		return nil, err
 	}
 
-	// Парсим JSON
+	return a.AnalyzeBytes(data)
+}
+
+// AnalyzeFiles анализирует несколько JSON файлов и объединяет их в одну
+// схему так же, как последовательные обновления схемы первого файла. При
+// Workers больше 1 файлы анализируются параллельно, а их схемы
+// объединяются в порядке файлов, поэтому результат тот же
+func (a *Analyzer) AnalyzeFiles(filenames []string) (*types.AnalysisResult, error) {
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("не указаны входные файлы")
+	}
+
+	// Обработчики уже заняты файлами; записи каждого файла читаются
+	// последовательно
+	file := a
+	if len(filenames) > 1 && a.workers() > 1 {
+		config := a.config
+		config.Workers = 1
+		file = a.withConfig(config)
+		file.inParallel = true
+	}
+
+	var merged *types.AnalysisResult
+	index := 0
+	produce := func(emit func(task) bool) {
+		for _, filename := range filenames {
+			ok := emit(func() (*types.AnalysisResult, error) {
+				result, err := file.AnalyzeFile(filename)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", filename, err)
+				}
+				return result, nil
+			})
+			if !ok {
+				return
+			}
+		}
+	}
+	err := parallel(a.workers(), produce, func(result *types.AnalysisResult) error {
+		filename := filenames[index]
+		index++
+		if merged == nil {
+			merged = result
+			return nil
+		}
+		sampling := addSampling(merged.Metadata.Sampling, result.Metadata.Sampling)
+		var err error
+		if merged, err = a.MergeResults(merged, result); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		merged.Metadata.Sampling = sampling
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// AnalyzeBytes анализирует JSON данные из памяти и возвращает результат
+func (a *Analyzer) AnalyzeBytes(data []byte) (*types.AnalysisResult, error) {
+	if err := a.checkSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+	return a.analyzeBytes(data)
+}
+
+// analyzeBytes анализирует JSON данные без проверки их размера
+func (a *Analyzer) analyzeBytes(data []byte) (*types.AnalysisResult, error) {
+	if a.truncates() {
+		return a.analyzeScanned(data)
+	}
+
+	// Парсим JSON; несколько документов подряд анализируются как NDJSON
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
 	var jsonData interface{}
-	if err := json.Unmarshal(data, &jsonData); err != nil {
+	if err := dec.Decode(&jsonData); err != nil {
 		return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
 	}
+	if dec.More() {
+		return a.AnalyzeNDJSON(bytes.NewReader(data))
+	}
+	if err := checkEnd(dec); err != nil {
+		return nil, err
+	}
 
 	// Анализируем структуру
-	return a.analyzeData(jsonData)
+	return a.analyzeDocument(jsonData, a.orderBytes(data, ""))
+}
+
+// analyzeScanned анализирует JSON данные, разобранные сканером: объекты и
+// массивы глубже MaxDepth не разбираются (см. truncates)
+func (a *Analyzer) analyzeScanned(data []byte) (*types.AnalysisResult, error) {
+	sc := newScanner(newStreamReader(bytes.NewReader(data)), a.config.MaxDepth)
+	jsonData, err := sc.value()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+	}
+	if _, err := sc.peek(); err == nil {
+		return a.AnalyzeNDJSON(bytes.NewReader(data))
+	}
+	return a.analyzeDocument(jsonData, a.orderBytes(data, ""))
+}
+
+// AnalyzeValue анализирует уже декодированное JSON значение
+func (a *Analyzer) AnalyzeValue(value interface{}) (*types.AnalysisResult, error) {
+	return a.analyzeData(value)
 }
 
 // analyzeData анализирует JSON данные
 func (a *Analyzer) analyzeData(data interface{}) (*types.AnalysisResult, error) {
-	// Создаем результат
-	result := &types.AnalysisResult{
-		Metadata: &types.AnalysisMetadata{
-			GeneratedAt: time.Now(),
-			Version:     "1.0.0",
-		},
-		Statistics: &types.AnalysisStatistics{
-			FieldFrequency:   make(map[string]int),
-			TypeDistribution: make(map[string]int),
-			EnumCandidates:   make(map[string][]interface{}),
-		},
+	return a.analyzeDocument(data, nil)
+}
+
+// analyzeDocument анализирует JSON данные, порядок полей которых
+// возвращает order; nil - поля упорядочиваются по алфавиту
+func (a *Analyzer) analyzeDocument(data interface{}, order func() *keyOrder) (*types.AnalysisResult, error) {
+	result := a.newResult()
+	st := newState(result.Statistics).withArena().withSampler(a.newSampler()).withOrder(order)
+
+	// Лимит записей относится к анализируемой выборке
+	data = st.sampler.records(data)
+	if err := a.checkRecords(data); err != nil {
+		return nil, err
 	}
 
 	// Определяем тип корневого элемента
@@ -61,23 +401,104 @@ func (a *Analyzer) analyzeData(data interface{}) (*types.AnalysisResult, error)
 		if dataField, exists := v["data"]; exists {
 			if _, ok := dataField.([]interface{}); ok {
 				// Это структура с массивом данных
-				schema, err = a.analyzeValue(data, "", result.Statistics)
+				schema, err = a.analyzeValue(data, "", st)
 			} else {
 				// Поле 'data' существует, но не массив - анализируем как обычный объект
-				schema, err = a.analyzeValue(data, "", result.Statistics)
+				schema, err = a.analyzeValue(data, "", st)
 			}
 		} else {
 			// Нет поля 'data' - считаем за один объект
-			schema, err = a.analyzeValue(data, "", result.Statistics)
+			schema, err = a.analyzeValue(data, "", st)
 		}
 	default:
 		// Анализируем как есть
-		schema, err = a.analyzeValue(data, "", result.Statistics)
+		schema, err = a.analyzeValue(data, "", st)
 	}
 
 	if err != nil {
 		return nil, err
 	}
+	return a.buildResult(result, schema, st)
+}
+
+// newResult создает пустой результат анализа с метаданными настроек
+func (a *Analyzer) newResult() *types.AnalysisResult {
+	now := time.Now()
+	return &types.AnalysisResult{
+		Metadata: &types.AnalysisMetadata{
+			GeneratedAt:   now,
+			UpdatedAt:     now,
+			Version:       "1.0.0",
+			LengthMode:    a.config.LengthMode,
+			RangeMode:     a.config.RangeMode,
+			ArrayLimits:   a.config.ArrayLimits,
+			MinSamples:    a.config.MinSamples,
+			EnumOrder:     a.config.EnumOrder,
+			PropertyOrder: a.config.PropertyOrder,
+			MaxDepth:      a.truncateDepth(),
+			Overrides:     a.config.Overrides,
+		},
+		Statistics: &types.AnalysisStatistics{
+			FieldFrequency:   make(map[string]int),
+			TypeDistribution: make(map[string]int),
+			EnumCandidates:   make(map[string][]interface{}),
+			FieldPresence:    make(map[string]int),
+			FieldNulls:       make(map[string]int),
+		},
+	}
+
+}
+
+// buildResult завершает анализ: применяет к схеме корня проходы, которым
+// нужна статистика всей выборки, и строит JSON Schema
+func (a *Analyzer) buildResult(result *types.AnalysisResult, schema *types.Property, st *state) (*types.AnalysisResult, error) {
+	result.Metadata.Sampling = st.sampler.metadata()
+	result.Warnings = append(result.Warnings, a.sampledWarnings(st)...)
+	if schema == nil {
+		return nil, fmt.Errorf("не удалось определить структуру данных")
+	}
+	// Проходы ниже сохраняют узлы в схеме результата, поэтому схема
+	// копируется из арены до них
+	schema = st.finalize(schema)
+
+	if a.config.DetectTuples {
+		applyTuples(schema, "", st)
+	}
+	if st.order != nil {
+		st.order().apply(schema, "")
+	}
+
+	// Обязательными остаются только поля, присутствующие в достаточной доле объектов
+	st.applyRequired(schema, "", a.config.RequiredPercent)
+	if a.config.EnumThreshold > 0 {
+		st.applyEnums(schema, "", a.config.EnumThreshold, a.config.EnumOrder)
+	}
+	if a.config.Patterns {
+		st.applyPatterns(schema, "", a.config.PatternMinSamples)
+	}
+	if a.config.Examples > 0 {
+		st.applyExamples(schema, "", a.config.Examples, a.config.ExampleCardinality)
+	}
+	if a.config.Confidence {
+		st.applyConfidence(schema, "")
+	}
+	if a.config.MinSamples > 0 {
+		st.applyMinSamples(schema, "", a.config.MinSamples)
+	}
+	if a.config.DetectMaps || a.config.ClosedObjects {
+		a.applyAdditional(schema, "", st)
+	}
+	var defs map[string]*types.Property
+	if a.config.DetectRecursion {
+		defs = a.applyRecursion(schema)
+	}
+	if a.config.Dedupe {
+		defs = a.applyDedupe(schema, defs, a.config.DedupeSimilarity)
+	}
+	sortRequired(schema)
+	for _, def := range defs {
+		sortRequired(def)
+	}
 
 	// Создаем JSON Schema
 	result.Schema = &types.JSONSchema{
@@ -88,64 +509,101 @@ func (a *Analyzer) analyzeData(data interface{}) (*types.AnalysisResult, error)
 		Required:    schema.Required,
 		Default:     schema.Default,
 		Description: "Generated JSON Schema",
+		MinItems:    schema.MinItems,
+		MaxItems:    schema.MaxItems,
+		UniqueItems: schema.UniqueItems,
+
+		PropertyOrder:        schema.PropertyOrder,
+		PatternProperties:    schema.PatternProperties,
+		AdditionalProperties: schema.AdditionalProperties,
+
+		Defs: defs,
 	}
+	applyOverrides(result.Schema, a.config.Overrides, result.Statistics)
+	result.Metadata.OptionalFields = optionalFields(result.Schema)
 
 	return result, nil
 }
 
 // analyzeValue анализирует JSON значение
-func (a *Analyzer) analyzeValue(value interface{}, path string, stats *types.AnalysisStatistics) (*types.Property, error) {
+func (a *Analyzer) analyzeValue(value interface{}, path string, st *state) (*types.Property, error) {
+	if a.config.Confidence || a.config.MinSamples > 0 {
+		st.recordKind(path, a.kindOf(value))
+	}
+
 	switch v := value.(type) {
 	case map[string]interface{}:
-		return a.analyzeObject(v, path, stats)
+		if a.tooDeep(st) {
+			return a.truncated(types.TypeObject, st)
+		}
+		return a.analyzeObject(v, path, st)
 	case []interface{}:
-		return a.analyzeArray(v, path, stats)
+		if a.tooDeep(st) {
+			return a.truncated(types.TypeArray, st)
+		}
+		return a.analyzeArray(v, path, st)
+	case truncatedValue:
+		// Сканер потока не разбирал значение глубже MaxDepth
+		return a.truncated(types.JSONType(v), st)
 	case string:
-		stats.TypeDistribution["string"]++
-		property := &types.Property{Type: "string"}
+		st.stats.TypeDistribution["string"]++
+		property := st.property("string")
 		if v != "" { // Заполняем default только если строка не пустая
 			property.Default = v
 		}
-		return property, nil
-	case float64:
-		stats.TypeDistribution["number"]++
-		property := &types.Property{Type: "number"}
-		if v != 0 { // Заполняем default только если число не равно 0
-			property.Default = v
+		if a.config.DetectFormats {
+			property.Format = a.scanFormat(path, v, st)
+		}
+		if a.config.LengthMode != "" {
+			length := StringLength(v, a.config.LengthMode)
+			minLength, maxLength := length, length
+			property.MinLength, property.MaxLength = &minLength, &maxLength
+		}
+		if a.config.Patterns {
+			st.recordPattern(path, v, a.config.PatternMinSamples)
+		}
+		if a.config.Examples > 0 || a.config.EnumThreshold > 0 {
+			st.recordString(path, v, max(a.config.ExampleCardinality, a.config.EnumThreshold))
 		}
 		return property, nil
+	case float64:
+		return a.analyzeNumber(types.Number(v), st), nil
+	case json.Number:
+		return a.analyzeNumber(v, st), nil
 	case bool:
-		stats.TypeDistribution["boolean"]++
-		property := &types.Property{Type: "boolean"}
+		st.stats.TypeDistribution["boolean"]++
+		property := st.property("boolean")
 		// Для boolean всегда заполняем default
 		property.Default = v
 		return property, nil
 	case nil:
-		stats.TypeDistribution["null"]++
+		st.stats.TypeDistribution["null"]++
 		// Для null не заполняем default
-		return &types.Property{Type: "null"}, nil
+		return st.property("null"), nil
 	default:
 		return nil, fmt.Errorf("неподдерживаемый тип данных: %T", v)
 	}
 }
 
 // analyzeObject анализирует объект
-func (a *Analyzer) analyzeObject(obj map[string]interface{}, path string, stats *types.AnalysisStatistics) (*types.Property, error) {
-	stats.TypeDistribution["object"]++
-	stats.TotalObjects++
+func (a *Analyzer) analyzeObject(obj map[string]interface{}, path string, st *state) (*types.Property, error) {
+	st.stats.TypeDistribution["object"]++
+	st.stats.TotalObjects++
+	st.recordObject(path, obj)
+	a.recordStructure(path, obj, st)
+	st.depth++
+	defer func() { st.depth-- }()
 
-	property := &types.Property{
-		Type:       "object",
-		Properties: make(map[string]*types.Property),
-		Required:   make([]string, 0),
-	}
+	property := st.property("object")
+	property.Properties = make(map[string]*types.Property, len(obj))
+	property.Required = make([]string, 0, len(obj))
 
 	// Анализируем каждое поле
 	for key, value := range obj {
 		fieldPath := path + "." + key
-		stats.FieldFrequency[key]++
+		st.stats.FieldFrequency[key]++
 
-		fieldProperty, err := a.analyzeValue(value, fieldPath, stats)
+		fieldProperty, err := a.analyzeValue(value, fieldPath, st)
 		if err != nil {
 			return nil, err
 		}
@@ -158,35 +616,85 @@ func (a *Analyzer) analyzeObject(obj map[string]interface{}, path string, stats
 }
 
 // analyzeArray анализирует массив
-func (a *Analyzer) analyzeArray(arr []interface{}, path string, stats *types.AnalysisStatistics) (*types.Property, error) {
-	stats.TypeDistribution["array"]++
+func (a *Analyzer) analyzeArray(arr []interface{}, path string, st *state) (*types.Property, error) {
+	st.stats.TypeDistribution["array"]++
+	st.depth++
+	defer func() { st.depth-- }()
 
-	property := &types.Property{
-		Type: "array",
+	property := st.property("array")
+	if a.config.ArrayLimits {
+		setArrayLimits(property, arr)
 	}
 
 	if len(arr) == 0 {
 		return property, nil
 	}
 
-	// Анализируем первый элемент для определения типа элементов
-	itemProperty, err := a.analyzeValue(arr[0], path+"[0]", stats)
-	if err != nil {
-		return nil, err
+	// Объединяем структуры элементов, чтобы поля, встречающиеся только
+	// в последующих элементах, тоже попали в items
+	samples := arr
+	if a.config.MaxArraySamples > 0 && len(samples) > a.config.MaxArraySamples && !recordsPath(path) {
+		samples = samples[:a.config.MaxArraySamples]
+		st.recordSampled(path)
+	}
+
+	itemPath := path + "[0]"
+	start := st.mark()
+	for i, element := range samples {
+		itemProperty, err := a.analyzeValue(element, itemPath, st)
+		if err != nil {
+			return nil, err
+		}
+		a.mergeItems(property, itemProperty, itemPath, st)
+		// Схемы объединенных элементов больше не нужны; на узлы после
+		// start ссылается только items
+		if (i+1)%compactInterval == 0 {
+			property.Items = st.compact(property.Items, start)
+			if err := a.canceled(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Объекты с разными значениями дискриминатора описываются вариантами
+	if a.config.DetectPolymorphic {
+		variants, err := a.polymorphicItems(samples, itemPath, st)
+		if err != nil {
+			return nil, err
+		}
+		if variants != nil {
+			property.Items = variants
+		}
+	}
+
+	// Короткий массив может оказаться кортежем; подтверждается это при
+	// объединении с другими массивами по тому же пути
+	if a.config.DetectTuples && tupleCandidate(arr) {
+		positions, err := a.analyzePositions(arr, path, st.depth)
+		if err != nil {
+			return nil, err
+		}
+		property.PrefixItems = positions
 	}
 
-	property.Items = itemProperty
 	return property, nil
 }
 
 // SaveSchema сохраняет схему в файл
 func (a *Analyzer) SaveSchema(result *types.AnalysisResult, filename string) error {
+	if result == nil || result.Schema == nil {
+		return fmt.Errorf("пустая схема")
+	}
+
 	// Создаем JSON Schema с метаданными
 	schema := result.Schema
 	if schema.Extensions == nil {
 		schema.Extensions = make(map[string]interface{})
 	}
-	schema.Extensions["x-analysis-meta"] = result.Metadata
+	schema.Extensions[types.ExtensionAnalysisMeta] = result.Metadata
+	if result.Statistics != nil {
+		schema.Extensions[types.ExtensionAnalysisStats] = result.Statistics
+	}
 
 	// Сериализуем в JSON
 	data, err := json.MarshalIndent(schema, "", "  ")
@@ -194,49 +702,156 @@ func (a *Analyzer) SaveSchema(result *types.AnalysisResult, filename string) err
 		return fmt.Errorf("ошибка сериализации схемы: %w", err)
 	}
 
+	// Проверяем результат на соответствие мета-схеме до записи на диск
+	if err := checkSchema(data); err != nil {
+		return err
+	}
+
 	// Записываем в файл
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := fileutil.WriteFile(filename, data, 0644); err != nil {
 		return fmt.Errorf("ошибка записи файла: %w", err)
 	}
 
 	return nil
 }
 
+// checkSchema проверяет сериализованную схему против мета-схемы ее draft версии
+func checkSchema(data []byte) error {
+	check, err := validator.New(false).ValidateSchemaBytes(data)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки схемы: %w", err)
+	}
+	if check.Valid {
+		return nil
+	}
+
+	problems := make([]string, 0, len(check.Errors))
+	for _, e := range check.Errors {
+		problems = append(problems, fmt.Sprintf("%s: %s", e.Field, e.Description))
+	}
+	return fmt.Errorf("схема не соответствует мета-схеме, сохранение отменено: %s", strings.Join(problems, "; "))
+}
+
 // LoadSchema загружает схему из файла
 func (a *Analyzer) LoadSchema(filename string) (*types.AnalysisResult, error) {
 	// Читаем файл
-	data, err := os.ReadFile(filename)
+	data, err := fileutil.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
 	}
 
+	return a.LoadSchemaBytes(data)
+}
+
+// LoadSchemaBytes загружает схему из JSON данных в памяти. Схемы других
+// генераторов (quicktype, genson) приводятся к внутренней модели, см.
+// normalizeForeign
+func (a *Analyzer) LoadSchemaBytes(data []byte) (*types.AnalysisResult, error) {
+	data, warnings, err := normalizeForeign(data)
+	if err != nil {
+		return nil, err
+	}
+
 	// Парсим JSON Schema
 	var schema types.JSONSchema
 	if err := json.Unmarshal(data, &schema); err != nil {
 		return nil, fmt.Errorf("ошибка парсинга схемы: %w", err)
 	}
 
+	// Убираем пустые узлы, чтобы дальнейшая обработка не натыкалась на nil
+	sanitizeSchema(&schema)
+
 	// Извлекаем метаданные
 	result := &types.AnalysisResult{
-		Schema: &schema,
+		Schema:   &schema,
+		Warnings: warnings,
+	}
+
+	metadata, err := extractMetadata(&schema)
+	if err != nil {
+		return nil, err
 	}
+	result.Metadata = metadata
 
-	// TODO: Извлечь метаданные из extensions
-	result.Metadata = &types.AnalysisMetadata{
-		GeneratedAt: time.Now(),
-		Version:     "1.0.0",
+	// Порядок полей файла сохраняется, только если схема построена с ним;
+	// иначе добавленные поля встают по алфавиту
+	if metadata.PropertyOrder != PropertyFirstSeen {
+		clearOrder(&schema)
 	}
 
+	statistics, err := extractStatistics(&schema)
+	if err != nil {
+		return nil, err
+	}
+	result.Statistics = statistics
+
 	return result, nil
 }
 
+// extractMetadata извлекает метаданные анализа из расширений схемы
+func extractMetadata(schema *types.JSONSchema) (*types.AnalysisMetadata, error) {
+	metadata := &types.AnalysisMetadata{}
+	found, err := types.DecodeExtension(schema.Extensions, types.ExtensionAnalysisMeta, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", types.ExtensionAnalysisMeta, err)
+	}
+	delete(schema.Extensions, types.ExtensionAnalysisMeta)
+
+	// Схема без метаданных (создана вручную или старой версией) - начинаем историю заново
+	if !found {
+		metadata.GeneratedAt = time.Now()
+	}
+	if metadata.Version == "" {
+		metadata.Version = "1.0.0"
+	}
+
+	return metadata, nil
+}
+
+// extractStatistics извлекает статистику анализа из расширений схемы
+func extractStatistics(schema *types.JSONSchema) (*types.AnalysisStatistics, error) {
+	statistics := &types.AnalysisStatistics{}
+	if _, err := types.DecodeExtension(schema.Extensions, types.ExtensionAnalysisStats, statistics); err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", types.ExtensionAnalysisStats, err)
+	}
+	delete(schema.Extensions, types.ExtensionAnalysisStats)
+
+	if statistics.FieldFrequency == nil {
+		statistics.FieldFrequency = make(map[string]int)
+	}
+	if statistics.TypeDistribution == nil {
+		statistics.TypeDistribution = make(map[string]int)
+	}
+	if statistics.EnumCandidates == nil {
+		statistics.EnumCandidates = make(map[string][]interface{})
+	}
+
+	return statistics, nil
+}
+
 // MergeResults объединяет результаты анализа
 func (a *Analyzer) MergeResults(existing, new *types.AnalysisResult) (*types.AnalysisResult, error) {
-	// Обновляем схему с учетом новых данных
-	a.mergeProperties(existing.Schema.Properties, new.Schema.Properties, "")
+	if existing == nil || existing.Schema == nil {
+		return nil, fmt.Errorf("отсутствует исходная схема")
+	}
+	if new == nil || new.Schema == nil {
+		return nil, fmt.Errorf("отсутствует схема новых данных")
+	}
+
+	// Предупреждения анализа частей данных относятся ко всему результату
+	for _, warning := range new.Warnings {
+		if !slices.Contains(existing.Warnings, warning) {
+			existing.Warnings = append(existing.Warnings, warning)
+		}
+	}
 
 	// Обновляем статистики
-	if existing.Statistics != nil && new.Statistics != nil {
+	switch {
+	case existing.Statistics == nil && new.Statistics != nil:
+		existing.Statistics = new.Statistics
+	case existing.Statistics == nil:
+		existing.Statistics = &types.AnalysisStatistics{}
+	case new.Statistics != nil:
 		for key, count := range new.Statistics.FieldFrequency {
 			existing.Statistics.FieldFrequency[key] += count
 		}
@@ -244,22 +859,124 @@ func (a *Analyzer) MergeResults(existing, new *types.AnalysisResult) (*types.Ana
 			existing.Statistics.TypeDistribution[key] += count
 		}
 		existing.Statistics.TotalObjects += new.Statistics.TotalObjects
+		if existing.Statistics.EnumCandidates == nil {
+			existing.Statistics.EnumCandidates = make(map[string][]interface{})
+		}
+		for field, values := range new.Statistics.EnumCandidates {
+			existing.Statistics.EnumCandidates[field] = mergeEnum(existing.Statistics.EnumCandidates[field], values, a.config.EnumOrder)
+		}
+		existing.Statistics.FieldPresence = addCounts(existing.Statistics.FieldPresence, new.Statistics.FieldPresence)
+		existing.Statistics.FieldNulls = addCounts(existing.Statistics.FieldNulls, new.Statistics.FieldNulls)
+		for field, conflict := range new.Statistics.TypeConflicts {
+			existing.Statistics.TypeConflicts = addConflict(existing.Statistics.TypeConflicts, field, conflict...)
+		}
+		mergeStructures(existing.Statistics, new.Statistics)
+	}
+
+	// Поля по алфавиту не хранят порядок сохраненной схемы
+	if a.config.PropertyOrder != PropertyFirstSeen {
+		clearOrder(existing.Schema)
+	}
+
+	// Обновляем схему с учетом новых данных; схема-ссылка задается общим определением
+	if existing.Schema.Ref == "" {
+		a.mergeRoot(existing.Schema, new.Schema, newState(existing.Statistics))
+		if new.Statistics != nil {
+			extendEnums(existing.Schema, new.Statistics.EnumCandidates, a.config.EnumOrder)
+		}
+	}
+
+	// Фиксируем время обновления, сохраняя исходные метаданные
+	if existing.Metadata == nil {
+		existing.Metadata = new.Metadata
+	}
+	if existing.Metadata != nil {
+		// Переопределения сохраненной схемы действуют и на новые данные
+		if new.Metadata != nil {
+			existing.Metadata.Overrides = mergeOverrides(existing.Metadata.Overrides, new.Metadata.Overrides)
+			existing.Metadata.Sampling = new.Metadata.Sampling
+		}
+		applyOverrides(existing.Schema, existing.Metadata.Overrides, existing.Statistics)
+		existing.Metadata.MinSamples = a.config.MinSamples
+		existing.Metadata.EnumOrder = a.config.EnumOrder
+		existing.Metadata.PropertyOrder = a.config.PropertyOrder
+		existing.Metadata.MaxDepth = a.truncateDepth()
+		existing.Metadata.UpdatedAt = time.Now()
+		existing.Metadata.OptionalFields = optionalFields(existing.Schema)
 	}
 
 	return existing, nil
 }
 
+// mergeRoot объединяет корневые узлы схем
+func (a *Analyzer) mergeRoot(existing, new *types.JSONSchema, st *state) {
+	if existing.Type == "object" && new.Type == "object" {
+		root := &types.Property{Properties: existing.Properties, Required: existing.Required, PatternProperties: existing.PatternProperties, AdditionalProperties: existing.AdditionalProperties}
+		incoming := &types.Property{Properties: new.Properties, PatternProperties: new.PatternProperties, AdditionalProperties: new.AdditionalProperties}
+		folded := a.mergeMap(root, incoming, "", st)
+		existing.Properties, existing.Required = root.Properties, root.Required
+		existing.PatternProperties, existing.AdditionalProperties = root.PatternProperties, root.AdditionalProperties
+		if folded {
+			return
+		}
+	}
+	if existing.Properties == nil && new.Properties != nil {
+		existing.Properties = make(map[string]*types.Property)
+	}
+	existingRequired := withPendingRequired(&types.Property{Properties: existing.Properties, Required: existing.Required})
+	newRequired := withPendingRequired(&types.Property{Properties: new.Properties, Required: new.Required})
+	a.mergeProperties(existing.Properties, new.Properties, "", st)
+	existing.PropertyOrder = mergeOrder(existing.PropertyOrder, new.PropertyOrder)
+	if existing.Type == "object" && new.Type == "object" {
+		root := &types.Property{Properties: existing.Properties, Required: intersectRequired(existingRequired, newRequired)}
+		settleRequired(root)
+		trimConfidence(root)
+		existing.Required = root.Required
+	}
+	if existing.Items != nil && new.Items != nil {
+		root := &types.Property{Items: existing.Items, MinItems: existing.MinItems, MaxItems: existing.MaxItems, UniqueItems: existing.UniqueItems}
+		a.mergeItems(root, new.Items, "[0]", st)
+		mergeArrayLimits(root, &types.Property{MinItems: new.MinItems, MaxItems: new.MaxItems, UniqueItems: new.UniqueItems})
+		existing.Items = root.Items
+		existing.MinItems, existing.MaxItems, existing.UniqueItems = root.MinItems, root.MaxItems, root.UniqueItems
+	} else if existing.Items == nil && new.Items != nil && existing.Type == new.Type {
+		existing.Items = new.Items
+	}
+	a.mergeDefs(existing, new, st)
+}
+
+// mergeDefs объединяет общие определения схем по именам
+func (a *Analyzer) mergeDefs(existing, new *types.JSONSchema, st *state) {
+	for name, def := range new.Defs {
+		if def == nil {
+			continue
+		}
+		if current := existing.Defs[name]; current != nil {
+			a.mergeProperty(current, def, types.DefsSegment+"."+name, st)
+			continue
+		}
+		if existing.Defs == nil {
+			existing.Defs = make(map[string]*types.Property)
+		}
+		existing.Defs[name] = def
+	}
+}
+
 // mergeProperties рекурсивно объединяет свойства схем
-func (a *Analyzer) mergeProperties(existing, new map[string]*types.Property, path string) {
+func (a *Analyzer) mergeProperties(existing, new map[string]*types.Property, path string, st *state) {
 	for key, newProp := range new {
+		if newProp == nil {
+			continue
+		}
+
 		currentPath := path + "." + key
 		if currentPath[0] == '.' {
 			currentPath = currentPath[1:]
 		}
 
-		if existingProp, exists := existing[key]; exists {
+		if existingProp, exists := existing[key]; exists && existingProp != nil {
 			// Поле уже существует - обновляем
-			a.mergeProperty(existingProp, newProp, currentPath)
+			a.mergeProperty(existingProp, newProp, currentPath, st)
 		} else {
 			// Новое поле - добавляем
 			existing[key] = newProp
@@ -268,32 +985,157 @@ func (a *Analyzer) mergeProperties(existing, new map[string]*types.Property, pat
 }
 
 // mergeProperty объединяет два свойства
-func (a *Analyzer) mergeProperty(existing, new *types.Property, path string) {
+func (a *Analyzer) mergeProperty(existing, new *types.Property, path string, st *state) {
+	// Структура узла со ссылкой задана общим определением; null в другой
+	// выборке допускается вариантом anyOf
+	if existing.Ref != "" {
+		if new.Type == string(types.TypeNull) || new.Nullable {
+			*existing = refProperty(existing.Ref, true)
+		}
+		return
+	}
+
+	// Варианты полиморфного поля объединяются по значению дискриминатора
+	if existing.Type == "" && len(existing.OneOf) > 0 {
+		a.mergeVariants(existing, new, path, st)
+		return
+	}
+
+	// Отложенные ограничения объединяются по общим правилам и снова
+	// откладываются, если суммарной выборки недостаточно
+	settle := a.mergeEvidence(existing, new)
+	defer settle()
+
+	// null в одной из выборок делает поле nullable
+	if a.mergeNullable(existing, new) {
+		return
+	}
+
 	// Обновляем default значения
 	if !existing.PreserveDefault {
 		a.updateDefaultValue(existing, new)
 	}
 
+	// Значения enum из обеих выборок объединяются; enum без пары в новых
+	// данных сохраняется как решение пользователя
+	if len(existing.Enum) > 0 && len(new.Enum) > 0 {
+		existing.Enum = mergeEnum(existing.Enum, new.Enum, a.config.EnumOrder)
+	}
+
+	// Границы длины расширяются до наблюдаемых в обеих выборках
+	if existing.MinLength != nil && new.MinLength != nil && *new.MinLength < *existing.MinLength {
+		existing.MinLength = new.MinLength
+	}
+	if existing.MaxLength != nil && new.MaxLength != nil && *new.MaxLength > *existing.MaxLength {
+		existing.MaxLength = new.MaxLength
+	}
+
+	existing.PropertyOrder = mergeOrder(existing.PropertyOrder, new.PropertyOrder)
+	// Структура, обрезанная в одной из выборок, описана неполно
+	existing.Truncated = existing.Truncated || new.Truncated
+	mergeRange(existing, new)
+	mergeArrayLimits(existing, new)
+	mergeConfidence(existing, new)
+	if a.config.Patterns {
+		mergePattern(existing, new)
+	}
+
+	// Примеры из новых данных нужны, только если их еще нет
+	if len(existing.Examples) == 0 && len(new.Examples) > 0 {
+		existing.Examples = new.Examples
+	}
+
+	// Выведенный формат сохраняется, только если ему соответствуют все значения
+	if existing.Type == "string" && new.Type == "string" && existing.Format != new.Format && a.detectable(existing.Format) {
+		existing.Format = ""
+	}
+
+	// Тип расширяется до наименьшего общего типа решетки (integer и number -
+	// number); несовместимый тип не меняет схему, но запоминается как конфликт
+	if existing.Type != "" && new.Type != "" {
+		if joined, ok := types.JoinTypes(existing.Type, new.Type); ok {
+			existing.Type = joined
+		} else {
+			st.recordConflict(path, existing.Type, new.Type)
+		}
+	}
+
 	// Рекурсивно обновляем вложенные свойства
 	if existing.Type == "object" && new.Type == "object" {
+		if a.mergeMap(existing, new, path, st) {
+			return
+		}
 		if existing.Properties == nil {
 			existing.Properties = make(map[string]*types.Property)
 		}
+		existingRequired, newRequired := withPendingRequired(existing), withPendingRequired(new)
 		if new.Properties != nil {
-			a.mergeProperties(existing.Properties, new.Properties, path)
+			a.mergeProperties(existing.Properties, new.Properties, path, st)
 		}
+		// Поле остается обязательным, только если оно обязательно в обеих выборках
+		existing.Required = intersectRequired(existingRequired, newRequired)
+		settleRequired(existing)
+		trimConfidence(existing)
 	}
 
-	// Для массивов обновляем items
+	// Для массивов обновляем позиции кортежа и items
 	if existing.Type == "array" && new.Type == "array" {
-		if existing.Items != nil && new.Items != nil {
-			a.mergeProperty(existing.Items, new.Items, path+"[0]")
+		a.mergeTuple(existing, new, path, st)
+		switch {
+		case existing.ClosedItems:
+			// Элементы кортежа описаны позициями
+		case existing.Items != nil && new.Items != nil:
+			a.mergeItems(existing, new.Items, path+"[0]", st)
+		case existing.Items == nil && new.Items != nil:
+			// Ранее массив был пустым - берем структуру элементов из новых данных
+			existing.Items = new.Items
 		}
 	}
 }
 
+// mergeNullable объединяет поле, которое в одной из выборок было null, и
+// сообщает, что дальнейшее объединение не требуется
+func (a *Analyzer) mergeNullable(existing, new *types.Property) bool {
+	if new.Nullable && existing.Type != "" {
+		existing.Nullable = true
+	}
+
+	switch {
+	case existing.Type == "null" && new.Type != "null" && new.Type != "":
+		// Структуру поля берем из выборки с непустым значением
+		merged := *new
+		merged.Nullable = true
+		if existing.Description != "" {
+			merged.Description = existing.Description
+		}
+		if existing.Title != "" {
+			merged.Title = existing.Title
+		}
+		if existing.Extensions != nil {
+			merged.Extensions = existing.Extensions
+		}
+		merged.PreserveDefault = existing.PreserveDefault
+		*existing = merged
+		return true
+	case new.Type == "null" && existing.Type != "null":
+		if existing.Type != "" {
+			existing.Nullable = true
+		}
+		return true
+	}
+	return false
+}
+
 // updateDefaultValue обновляет default значение согласно правилам
 func (a *Analyzer) updateDefaultValue(existing, new *types.Property) {
+	// Разные значения уже встречались, или типы выборок различаются: общего
+	// default нет, и третье значение его не восстанавливает
+	if existing.DefaultConflict || new.DefaultConflict || existing.Type != "" && new.Type != "" && existing.Type != new.Type {
+		existing.Default = nil
+		existing.DefaultConflict = true
+		return
+	}
+
 	// Если у существующего свойства нет default, устанавливаем из нового
 	if existing.Default == nil && new.Default != nil {
 		existing.Default = new.Default
@@ -304,12 +1146,100 @@ func (a *Analyzer) updateDefaultValue(existing, new *types.Property) {
 	if existing.Default != nil && new.Default != nil {
 		if !a.isEqualValue(existing.Default, new.Default) {
 			existing.Default = nil
+			existing.DefaultConflict = true
 		}
 	}
 }
 
+// analyzeNumber анализирует число, сохраняя его исходную запись в default
+func (a *Analyzer) analyzeNumber(v json.Number, st *state) *types.Property {
+	numberType := "number"
+	if a.config.DetectIntegers && isInteger(v) {
+		numberType = "integer"
+	}
+	st.stats.TypeDistribution[numberType]++
+
+	property := st.property(numberType)
+	setRange(property, v, a.config.RangeMode)
+	if f, err := v.Float64(); err != nil || f != 0 { // Заполняем default только если число не равно 0
+		property.Default = v
+	}
+	return property
+}
+
+// isInteger сообщает, что число не имеет дробной части
+func isInteger(v json.Number) bool {
+	if !strings.ContainsAny(v.String(), ".eE") {
+		return true
+	}
+	f, err := v.Float64()
+	return err == nil && f == math.Trunc(f) && math.Abs(f) <= 1<<53
+}
+
 // isEqualValue сравнивает два значения
 func (a *Analyzer) isEqualValue(a1, a2 interface{}) bool {
 	// Простое сравнение значений
-	return fmt.Sprintf("%v", a1) == fmt.Sprintf("%v", a2)
+	return formatKey(a1) == formatKey(a2)
+}
+
+// formatKey возвращает строковое представление значения для сравнения
+func formatKey(value interface{}) string {
+	return fmt.Sprintf("%v", value)
+}
+
+// sanitizeSchema удаляет nil узлы из загруженной схемы
+func sanitizeSchema(schema *types.JSONSchema) {
+	if schema == nil {
+		return
+	}
+	sanitizeProperties(schema.Properties)
+	sanitizeProperty(schema.Items)
+	sanitizeProperties(schema.Defs)
+	schema.OneOf = sanitizeVariants(schema.OneOf)
+	schema.AnyOf = sanitizeVariants(schema.AnyOf)
+}
+
+// sanitizeProperty удаляет nil узлы из свойства и его потомков
+func sanitizeProperty(prop *types.Property) {
+	if prop == nil {
+		return
+	}
+	sanitizeProperties(prop.Properties)
+	sanitizeProperty(prop.Items)
+	for i, position := range prop.PrefixItems {
+		if position == nil {
+			prop.PrefixItems[i] = &types.Property{}
+			continue
+		}
+		sanitizeProperty(position)
+	}
+	prop.OneOf = sanitizeVariants(prop.OneOf)
+	prop.AnyOf = sanitizeVariants(prop.AnyOf)
+}
+
+// sanitizeProperties удаляет свойства со значением null
+func sanitizeProperties(props map[string]*types.Property) {
+	for key, prop := range props {
+		if prop == nil {
+			delete(props, key)
+			continue
+		}
+		sanitizeProperty(prop)
+	}
+}
+
+// sanitizeVariants удаляет пустые варианты oneOf/anyOf
+func sanitizeVariants(variants []*types.JSONSchema) []*types.JSONSchema {
+	if variants == nil {
+		return nil
+	}
+	clean := variants[:0]
+	for _, variant := range variants {
+		if variant == nil {
+			continue
+		}
+		sanitizeSchema(variant)
+		clean = append(clean, variant)
+	}
+	return clean
 }
@@ -0,0 +1,186 @@
+package analyzer
+
+import (
+	"sync"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// chunkSize - сколько узлов схемы выделяется одним блоком арены
+const chunkSize = 128
+
+// compactInterval - через сколько элементов массива его схема копируется из
+// арены, а узлы уже объединенных элементов освобождаются
+const compactInterval = 4096
+
+// chunk - блок узлов схемы
+type chunk [chunkSize]types.Property
+
+// chunkPool переиспользует освобожденные блоки между анализами
+var chunkPool = sync.Pool{New: func() interface{} { return new(chunk) }}
+
+// arena выделяет узлы схемы блоками вместо отдельного объекта на каждое
+// значение. Схема каждого значения живет, только пока не объединена с
+// общей схемой, поэтому сборщику мусора достаются не миллионы мелких
+// объектов, а несколько переиспользуемых блоков. Узлы арены нельзя
+// использовать после освобождения: итоговая схема копируется из арены
+type arena struct {
+	chunks []*chunk
+	used   int
+}
+
+// mark - позиция арены, до которой узлы остаются после освобождения
+type mark struct {
+	chunks int
+	used   int
+}
+
+// property выделяет пустой узел схемы
+func (a *arena) property() *types.Property {
+	if len(a.chunks) == 0 || a.used == chunkSize {
+		a.chunks = append(a.chunks, chunkPool.Get().(*chunk))
+		a.used = 0
+	}
+	prop := &a.chunks[len(a.chunks)-1][a.used]
+	a.used++
+	return prop
+}
+
+// mark запоминает текущую позицию арены
+func (a *arena) mark() mark {
+	return mark{chunks: len(a.chunks), used: a.used}
+}
+
+// release освобождает узлы, выделенные после позиции m
+func (a *arena) release(m mark) {
+	if m.chunks == len(a.chunks) && m.used == a.used {
+		return
+	}
+	for i := len(a.chunks) - 1; i >= m.chunks; i-- {
+		clear(a.chunks[i][:])
+		chunkPool.Put(a.chunks[i])
+		a.chunks[i] = nil
+	}
+	a.chunks = a.chunks[:m.chunks]
+	a.used = m.used
+	if m.chunks > 0 {
+		clear(a.chunks[m.chunks-1][m.used:])
+	}
+}
+
+// withArena включает выделение узлов схемы состояния в арене. Вспомогательные
+// состояния арену не используют: их схемы встраиваются в итоговую как есть
+func (s *state) withArena() *state {
+	s.arena = new(arena)
+	return s
+}
+
+// property выделяет узел схемы заданного типа: в арене состояния, если она
+// есть, иначе обычным объектом
+func (s *state) property(kind string) *types.Property {
+	if s.arena == nil {
+		return &types.Property{Type: kind}
+	}
+	prop := s.arena.property()
+	prop.Type = kind
+	return prop
+}
+
+// mark запоминает позицию арены состояния
+func (s *state) mark() mark {
+	if s.arena == nil {
+		return mark{}
+	}
+	return s.arena.mark()
+}
+
+// compact копирует схему из арены и освобождает узлы, выделенные после
+// позиции m. Вызывающий гарантирует, что на эти узлы больше никто не ссылается
+func (s *state) compact(prop *types.Property, m mark) *types.Property {
+	if s.arena == nil {
+		return prop
+	}
+	prop = copyProperty(prop, make(map[*types.Property]*types.Property))
+	s.arena.release(m)
+	return prop
+}
+
+// finalize копирует итоговую схему из арены и освобождает арену целиком
+func (s *state) finalize(prop *types.Property) *types.Property {
+	if s.arena == nil {
+		return prop
+	}
+	return s.compact(prop, mark{})
+}
+
+// copyProperty копирует дерево схемы в обычные объекты. Общие узлы остаются
+// общими и в копии
+func copyProperty(prop *types.Property, seen map[*types.Property]*types.Property) *types.Property {
+	if prop == nil {
+		return nil
+	}
+	if copied, ok := seen[prop]; ok {
+		return copied
+	}
+	copied := new(types.Property)
+	*copied = *prop
+	seen[prop] = copied
+
+	copied.Properties = copyProperties(prop.Properties, seen)
+	copied.PatternProperties = copyProperties(prop.PatternProperties, seen)
+	copied.Items = copyProperty(prop.Items, seen)
+	copied.AdditionalProperties = copyAdditional(prop.AdditionalProperties, seen)
+	copied.OneOf = copyVariants(prop.OneOf, seen)
+	copied.AnyOf = copyVariants(prop.AnyOf, seen)
+	if prop.PrefixItems != nil {
+		copied.PrefixItems = make([]*types.Property, len(prop.PrefixItems))
+		for i, item := range prop.PrefixItems {
+			copied.PrefixItems[i] = copyProperty(item, seen)
+		}
+	}
+	return copied
+}
+
+// copyProperties копирует узлы схемы по именам
+func copyProperties(props map[string]*types.Property, seen map[*types.Property]*types.Property) map[string]*types.Property {
+	if props == nil {
+		return nil
+	}
+	copied := make(map[string]*types.Property, len(props))
+	for key, prop := range props {
+		copied[key] = copyProperty(prop, seen)
+	}
+	return copied
+}
+
+// copyAdditional копирует additionalProperties со схемой значений
+func copyAdditional(additional *types.AdditionalProperties, seen map[*types.Property]*types.Property) *types.AdditionalProperties {
+	if additional == nil {
+		return nil
+	}
+	return &types.AdditionalProperties{Allowed: additional.Allowed, Schema: copyProperty(additional.Schema, seen)}
+}
+
+// copyVariants копирует варианты oneOf и anyOf
+func copyVariants(variants []*types.JSONSchema, seen map[*types.Property]*types.Property) []*types.JSONSchema {
+	if variants == nil {
+		return nil
+	}
+	copied := make([]*types.JSONSchema, len(variants))
+	for i, variant := range variants {
+		if variant == nil {
+			continue
+		}
+		schema := new(types.JSONSchema)
+		*schema = *variant
+		schema.Properties = copyProperties(variant.Properties, seen)
+		schema.PatternProperties = copyProperties(variant.PatternProperties, seen)
+		schema.Defs = copyProperties(variant.Defs, seen)
+		schema.Items = copyProperty(variant.Items, seen)
+		schema.AdditionalProperties = copyAdditional(variant.AdditionalProperties, seen)
+		schema.OneOf = copyVariants(variant.OneOf, seen)
+		schema.AnyOf = copyVariants(variant.AnyOf, seen)
+		copied[i] = schema
+	}
+	return copied
+}
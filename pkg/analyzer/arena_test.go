@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// benchRecordCount - число записей во входах бенчмарков: несколько интервалов
+// уплотнения арены (compactInterval)
+const benchRecordCount = 3 * compactInterval
+
+// benchRecords строит JSON-массив из n однотипных записей с вложенным
+// объектом, массивом и строками с распознаваемыми форматами
+func benchRecords(n int) []byte {
+	records := make([]map[string]interface{}, n)
+	for i := range records {
+		record := map[string]interface{}{
+			"id":      i,
+			"email":   fmt.Sprintf("user%d@example.com", i),
+			"created": fmt.Sprintf("2024-%02d-%02dT10:%02d:00Z", i%12+1, i%28+1, i%60),
+			"uuid":    fmt.Sprintf("%08x-1234-4abc-8def-%012x", i, i),
+			"status":  []string{"new", "paid", "shipped"}[i%3],
+			"amount":  float64(i%1000) + 0.25,
+			"owner":   map[string]interface{}{"name": fmt.Sprintf("user %d", i), "ip": fmt.Sprintf("10.0.%d.%d", i/256%256, i%256)},
+			"tags":    []string{"a", "b"}[:1+i%2],
+		}
+		if i%5 == 0 {
+			record["note"] = nil
+		}
+		records[i] = record
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// benchAnalyzer создает анализатор, который разбирает все элементы массива
+func benchAnalyzer() *Analyzer {
+	config := DefaultConfig()
+	config.MaxArraySamples = 0
+	return NewWithConfig(config)
+}
+
+// BenchmarkArena сравнивает выделение узлов схемы в арене с обычными
+// объектами на одном и том же разобранном массиве записей
+func BenchmarkArena(b *testing.B) {
+	var records interface{}
+	if err := json.Unmarshal(benchRecords(benchRecordCount), &records); err != nil {
+		b.Fatal(err)
+	}
+	a := benchAnalyzer()
+
+	for _, bench := range []struct {
+		name  string
+		arena bool
+	}{{"arena", true}, {"heap", false}} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				st := newState(a.newResult().Statistics)
+				if bench.arena {
+					st.withArena()
+				}
+				schema, err := a.analyzeValue(records, "", st)
+				if err != nil {
+					b.Fatal(err)
+				}
+				st.finalize(schema)
+			}
+		})
+	}
+}
+
+// BenchmarkArenaProperty измеряет выделение и освобождение узлов арены
+// блоками по compactInterval, как при анализе длинного массива
+func BenchmarkArenaProperty(b *testing.B) {
+	b.ReportAllocs()
+	arena := new(arena)
+	start := arena.mark()
+	for i := 0; i < b.N; i++ {
+		arena.property()
+		if (i+1)%compactInterval == 0 {
+			arena.release(start)
+		}
+	}
+}
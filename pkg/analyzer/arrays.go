@@ -0,0 +1,49 @@
+package analyzer
+
+import "github.com/yanodincov/json-schema-detector/pkg/types"
+
+// setArrayLimits записывает длину массива в minItems/maxItems и отмечает
+// uniqueItems, если в массиве из двух и более элементов нет повторов
+func setArrayLimits(property *types.Property, arr []interface{}) {
+	n := len(arr)
+	property.MinItems, property.MaxItems = &n, &n
+	property.UniqueItems = n >= 2 && distinctValues(arr)
+}
+
+// distinctValues сообщает, что элементы массива попарно различны
+func distinctValues(arr []interface{}) bool {
+	seen := make(map[string]bool, len(arr))
+	for _, element := range arr {
+		key := valueKind(element) + ":" + formatKey(element)
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+	}
+	return true
+}
+
+// mergeArrayLimits расширяет minItems/maxItems existing до наблюдаемых в new.
+// Как и диапазоны чисел, границы объединяются, только если они есть у обеих
+// схем. Уникальность элементов подтверждают лишь массивы из двух и более
+// элементов, поэтому выборка из пустых и одноэлементных массивов ее не меняет
+func mergeArrayLimits(existing, new *types.Property) {
+	if existing.MaxItems == nil || new.MaxItems == nil {
+		return
+	}
+
+	switch {
+	case *new.MaxItems < 2:
+	case *existing.MaxItems < 2:
+		existing.UniqueItems = new.UniqueItems
+	default:
+		existing.UniqueItems = existing.UniqueItems && new.UniqueItems
+	}
+
+	if *new.MaxItems > *existing.MaxItems {
+		existing.MaxItems = new.MaxItems
+	}
+	if existing.MinItems != nil && new.MinItems != nil && *new.MinItems < *existing.MinItems {
+		existing.MinItems = new.MinItems
+	}
+}
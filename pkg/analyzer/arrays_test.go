@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+func TestRootArrayLimits(t *testing.T) {
+	config := DefaultConfig()
+	config.ArrayLimits = true
+	a := NewWithConfig(config)
+
+	existing, err := a.AnalyzeBytes([]byte(`[[1, 2], [3, 4, 5]]`))
+	if err != nil {
+		t.Fatalf("AnalyzeBytes: %v", err)
+	}
+	root := existing.Schema
+	if root.MinItems == nil || *root.MinItems != 2 || root.MaxItems == nil || *root.MaxItems != 2 || !root.UniqueItems {
+		t.Fatalf("корень = {minItems %v, maxItems %v, uniqueItems %v}, want 2, 2, true", root.MinItems, root.MaxItems, root.UniqueItems)
+	}
+
+	// Обновление расширяет границы корня, а повтор снимает uniqueItems
+	update, err := a.AnalyzeBytes([]byte(`[[1], [1], [2], [3], [4]]`))
+	if err != nil {
+		t.Fatalf("AnalyzeBytes: %v", err)
+	}
+	merged, err := a.MergeResults(existing, update)
+	if err != nil {
+		t.Fatalf("MergeResults: %v", err)
+	}
+	root = merged.Schema
+	if *root.MinItems != 2 || *root.MaxItems != 5 || root.UniqueItems {
+		t.Errorf("корень после обновления = {minItems %d, maxItems %d, uniqueItems %v}, want 2, 5, false", *root.MinItems, *root.MaxItems, root.UniqueItems)
+	}
+	if items := root.Items; *items.MinItems != 1 || *items.MaxItems != 3 {
+		t.Errorf("items = {minItems %d, maxItems %d}, want 1, 3", *items.MinItems, *items.MaxItems)
+	}
+}
+
+func TestMaxArraySamplesSkipsRecords(t *testing.T) {
+	// Поле late появляется только в записи за пределами выборки элементов
+	var b strings.Builder
+	for i := 0; i < 30; i++ {
+		// Длина tags меняется, чтобы массив не считался кортежем
+		tags := `[1, 2, 3, 4, "x"]`
+		if i%2 == 1 {
+			tags = `[1, 2, 3, 4, 5, "x"]`
+		}
+		late := ""
+		if i == 25 {
+			late = `"late": true, `
+		}
+		fmt.Fprintf(&b, `{"id": 1, %s"tags": %s}`+"\n", late, tags)
+	}
+	records := b.String()
+	array := "[" + strings.ReplaceAll(strings.TrimSpace(records), "\n", ",") + "]"
+
+	tests := []struct {
+		name    string
+		config  func(c *Config)
+		analyze func(a *Analyzer) (*types.AnalysisResult, error)
+	}{
+		{"bytes", nil, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeBytes([]byte(array)) }},
+		{"data", nil, func(a *Analyzer) (*types.AnalysisResult, error) {
+			return a.AnalyzeBytes([]byte(`{"data": ` + array + `}`))
+		}},
+		{"stream", nil, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeStream(strings.NewReader(array)) }},
+		{"tokenize", func(c *Config) { c.Tokenize = true }, func(a *Analyzer) (*types.AnalysisResult, error) {
+			return a.AnalyzeStream(strings.NewReader(array))
+		}},
+		{"ndjson", nil, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeNDJSON(strings.NewReader(records)) }},
+		{"ndjson/workers=2", func(c *Config) { c.Workers = 2 }, func(a *Analyzer) (*types.AnalysisResult, error) {
+			return a.AnalyzeNDJSON(strings.NewReader(records))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.MaxArraySamples = 4
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			result, err := tt.analyze(NewWithConfig(config))
+			if err != nil {
+				t.Fatalf("анализ: %v", err)
+			}
+			items := result.Schema.Items
+			if tt.name == "data" {
+				items = result.Schema.Properties["data"].Items
+			}
+			if items.Properties["late"] == nil {
+				t.Errorf("нет поля late из записи 26: записи верхнего уровня не должны ограничиваться MaxArraySamples")
+			}
+
+			// Вложенный массив ограничен выборкой: строка из пятого элемента
+			// не учтена, и об этом есть предупреждение
+			if tags := items.Properties["tags"]; tags.Items.Type != "integer" {
+				t.Errorf("tags.items.type = %q, want integer", tags.Items.Type)
+			}
+			if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "tags") {
+				t.Errorf("предупреждения = %q, want одно о tags", result.Warnings)
+			}
+		})
+	}
+}
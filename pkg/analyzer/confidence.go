@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// evidenceWeight - число значений, при котором доверие к выводу по выборке
+// достигает половины: одно значение дает 1/3, десять - 5/6
+const evidenceWeight = 2
+
+// recordKind учитывает тип значения по пути для оценки уверенности
+func (s *state) recordKind(path, kind string) {
+	counts, ok := s.kinds[path]
+	if !ok {
+		counts = make(map[string]int)
+		s.kinds[path] = counts
+	}
+	counts[kind]++
+}
+
+// kindOf возвращает тип JSON значения так же, как его выводит analyzeValue
+func (a *Analyzer) kindOf(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return string(types.TypeObject)
+	case []interface{}:
+		return string(types.TypeArray)
+	case truncatedValue:
+		return string(v)
+	case string:
+		return string(types.TypeString)
+	case bool:
+		return string(types.TypeBoolean)
+	case nil:
+		return string(types.TypeNull)
+	case float64:
+		return a.numberKind(types.Number(v))
+	case json.Number:
+		return a.numberKind(v)
+	}
+	return ""
+}
+
+// numberKind возвращает integer или number для числа
+func (a *Analyzer) numberKind(v json.Number) string {
+	if a.config.DetectIntegers && isInteger(v) {
+		return string(types.TypeInteger)
+	}
+	return string(types.TypeNumber)
+}
+
+// applyConfidence оценивает уверенность в типе, обязательности, enum и
+// формате полей по числу значений и доле значений, согласных с выводом
+func (s *state) applyConfidence(prop *types.Property, path string) {
+	if prop == nil || prop.Ref != "" {
+		return
+	}
+
+	if kinds := s.kinds[path]; len(kinds) > 0 {
+		samples, typed, strs := 0, 0, kinds[string(types.TypeString)]
+		for kind, count := range kinds {
+			if kind == string(types.TypeNull) {
+				continue
+			}
+			samples += count
+			if prop.Type != "" && types.IsSubtype(kind, prop.Type) {
+				typed += count
+			}
+		}
+
+		confidence := &types.Confidence{Samples: samples}
+		if samples > 0 && prop.Type != "" {
+			confidence.Type = score(float64(typed)/float64(samples), samples)
+		}
+		if len(prop.Enum) > 0 && strs > 0 {
+			// Набор значений тем надежнее, чем чаще повторяются его значения
+			confidence.Enum = score(math.Max(0, 1-float64(len(prop.Enum))/float64(strs)), strs)
+		}
+		if prop.Format != "" && strs > 0 {
+			confidence.Format = score(1, strs)
+		}
+		if samples > 0 {
+			prop.Confidence = confidence
+		}
+	}
+
+	total := s.objects[path]
+	for key, child := range prop.Properties {
+		s.applyConfidence(child, path+"."+key)
+		// Обязательность оценивается по доле объектов, в которых поле есть
+		if total > 0 && child != nil && child.Confidence != nil && containsString(prop.Required, key) {
+			child.Confidence.Required = score(float64(s.fields[path][key])/float64(total), total)
+		}
+	}
+	s.applyConfidence(prop.Items, path+"[0]")
+}
+
+// score объединяет долю согласных значений с весом выборки из samples значений
+func score(agreement float64, samples int) float64 {
+	return round(agreement * evidence(samples))
+}
+
+// evidence возвращает вес выборки: растет от 0 к 1 с числом значений
+func evidence(samples int) float64 {
+	return float64(samples) / float64(samples+evidenceWeight)
+}
+
+// round округляет оценку до сотых
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// mergeConfidence объединяет оценки двух выборок: доли согласных значений
+// усредняются с весами по числу значений, а вес выборки считается по их сумме
+func mergeConfidence(existing, new *types.Property) {
+	switch {
+	case new.Confidence == nil:
+		return
+	case existing.Confidence == nil:
+		existing.Confidence = new.Confidence
+		return
+	}
+
+	old, add := existing.Confidence, new.Confidence
+	samples := old.Samples + add.Samples
+	existing.Confidence = &types.Confidence{
+		Samples:  samples,
+		Type:     mergeScore(old.Type, old.Samples, add.Type, add.Samples),
+		Required: mergeScore(old.Required, old.Samples, add.Required, add.Samples),
+		Enum:     mergeScore(old.Enum, old.Samples, add.Enum, add.Samples),
+		Format:   mergeScore(old.Format, old.Samples, add.Format, add.Samples),
+	}
+}
+
+// mergeScore объединяет оценку одного свойства; свойство, не оцененное в
+// одной из выборок, сохраняет оценку другой
+func mergeScore(a float64, na int, b float64, nb int) float64 {
+	switch {
+	case a == 0 || na == 0:
+		return b
+	case b == 0 || nb == 0:
+		return a
+	}
+	agreement := (a/evidence(na)*float64(na) + b/evidence(nb)*float64(nb)) / float64(na+nb)
+	return score(math.Min(agreement, 1), na+nb)
+}
+
+// trimConfidence снимает оценки свойств, которые поле потеряло при объединении
+func trimConfidence(prop *types.Property) {
+	for key, child := range prop.Properties {
+		if child == nil || child.Confidence == nil {
+			continue
+		}
+		if !containsString(prop.Required, key) {
+			child.Confidence.Required = 0
+		}
+		if len(child.Enum) == 0 {
+			child.Confidence.Enum = 0
+		}
+		if child.Format == "" {
+			child.Confidence.Format = 0
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"context"
+	"io"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// AnalyzeFileContext анализирует файл, как AnalyzeFile, и прерывает анализ
+// с ошибкой ctx.Err(), если ctx отменен. Отмена проверяется при чтении
+// входа и между элементами больших массивов, поэтому анализ потока или
+// файла останавливается вскоре после отмены
+func (a *Analyzer) AnalyzeFileContext(ctx context.Context, filename string) (*types.AnalysisResult, error) {
+	result, err := a.withContext(ctx).AnalyzeFile(filename)
+	return result, interrupted(ctx, err)
+}
+
+// AnalyzeFilesContext анализирует несколько файлов, как AnalyzeFiles, с
+// отменой по ctx (см. AnalyzeFileContext)
+func (a *Analyzer) AnalyzeFilesContext(ctx context.Context, filenames []string) (*types.AnalysisResult, error) {
+	result, err := a.withContext(ctx).AnalyzeFiles(filenames)
+	return result, interrupted(ctx, err)
+}
+
+// AnalyzeStreamContext анализирует поток JSON, как AnalyzeStream, с
+// отменой по ctx (см. AnalyzeFileContext)
+func (a *Analyzer) AnalyzeStreamContext(ctx context.Context, r io.Reader) (*types.AnalysisResult, error) {
+	result, err := a.withContext(ctx).AnalyzeStream(r)
+	return result, interrupted(ctx, err)
+}
+
+// AnalyzeNDJSONContext анализирует поток NDJSON, как AnalyzeNDJSON, с
+// отменой по ctx (см. AnalyzeFileContext)
+func (a *Analyzer) AnalyzeNDJSONContext(ctx context.Context, r io.Reader) (*types.AnalysisResult, error) {
+	result, err := a.withContext(ctx).AnalyzeNDJSON(r)
+	return result, interrupted(ctx, err)
+}
+
+// interrupted заменяет ошибку анализа ошибкой ctx, если анализ прерван
+// отменой: ошибки чтения и разбора оборванного входа в этом случае ничего
+// не сообщают
+func interrupted(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// withContext возвращает копию анализатора, анализ которой отменяется по ctx
+func (a *Analyzer) withContext(ctx context.Context) *Analyzer {
+	copy := *a
+	copy.ctx = ctx
+	return &copy
+}
+
+// withConfig возвращает анализатор с настройками config и тем же контекстом
+func (a *Analyzer) withConfig(config Config) *Analyzer {
+	return &Analyzer{config: config, ctx: a.ctx, inParallel: a.inParallel}
+}
+
+// canceled возвращает ошибку контекста, если анализ отменен
+func (a *Analyzer) canceled() error {
+	if a.ctx == nil {
+		return nil
+	}
+	return a.ctx.Err()
+}
+
+// reader возвращает поток, чтение которого прекращается с ошибкой
+// контекста после отмены анализа
+func (a *Analyzer) reader(r io.Reader) io.Reader {
+	if a.ctx == nil {
+		return r
+	}
+	if _, ok := r.(contextReader); ok {
+		return r
+	}
+	return contextReader{ctx: a.ctx, r: r}
+}
+
+// contextReader - поток, прерываемый отменой контекста
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
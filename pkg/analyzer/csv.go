@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yanodincov/json-schema-detector/pkg/csvinput"
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// AnalyzeCSV анализирует таблицу CSV с заголовком как массив объектов -
+// по одному на строку. Таблица читается дважды: сначала определяются типы
+// колонок (см. csvinput.Infer), затем строки с этими типами анализируются
+// как записи NDJSON, поэтому выборка, лимит записей и Workers действуют
+// так же, а MaxInputSize не применяется
+func (a *Analyzer) AnalyzeCSV(r io.ReadSeeker) (*types.AnalysisResult, error) {
+	return a.analyzeCSV(func() (io.ReadCloser, error) {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	})
+}
+
+// analyzeFileCSV анализирует файл CSV, при необходимости распаковывая его
+func (a *Analyzer) analyzeFileCSV(filename string) (*types.AnalysisResult, error) {
+	return a.analyzeCSV(func() (io.ReadCloser, error) {
+		return decompress.Open(filename)
+	})
+}
+
+// analyzeCSV выполняет оба прохода по таблице, которую открывает open
+func (a *Analyzer) analyzeCSV(open func() (io.ReadCloser, error)) (*types.AnalysisResult, error) {
+	opts := csvinput.Options{Delimiter: a.config.CSVDelimiter}
+
+	table, err := open()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	columns, err := csvinput.Infer(a.reader(table), opts)
+	table.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if table, err = open(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	defer table.Close()
+
+	// Строки передаются анализу NDJSON через канал; если анализ прочитал
+	// не все записи (выборка, ошибка), запись в канал прерывается
+	pr, pw := io.Pipe()
+	converted := make(chan error, 1)
+	go func() {
+		err := csvinput.Convert(table, columns, opts, pw)
+		pw.CloseWithError(err)
+		converted <- err
+	}()
+	result, err := a.AnalyzeNDJSON(pr)
+	pr.Close()
+
+	// Ошибка чтения таблицы важнее ошибки разбора оборванных записей
+	if convertErr := <-converted; convertErr != nil && !errors.Is(convertErr, io.ErrClosedPipe) {
+		return nil, convertErr
+	}
+	return result, err
+}
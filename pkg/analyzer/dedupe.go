@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// DefaultDedupeSimilarity - доля общих полей, начиная с которой объекты
+// считаются одной формой при --dedupe
+const DefaultDedupeSimilarity = 0.7
+
+// shapeNode - объект схемы, который может быть вынесен в общее определение
+type shapeNode struct {
+	prop *types.Property
+	path string
+	key  string
+}
+
+// applyDedupe выносит объекты одной формы, встреченные по разным путям
+// (author и editor с одинаковыми полями), в общие определения $defs и
+// заменяет их ссылками. Формы сравниваются по доле общих полей (similarity от
+// 0 до 1); поля общих ключей должны иметь совместимые типы. Вложенные формы
+// выносятся раньше содержащих их объектов, поэтому определение может
+// ссылаться на другие определения
+func (a *Analyzer) applyDedupe(root *types.Property, defs map[string]*types.Property, similarity float64) map[string]*types.Property {
+	var nodes []shapeNode
+	collectShapes(root, "", "", &nodes)
+
+	// Группируем формы: объект попадает в первую группу с похожим представителем
+	var groups [][]shapeNode
+	for _, node := range nodes {
+		placed := false
+		for i, group := range groups {
+			if related(group, node.path) || shapeSimilarity(group[0].prop, node.prop) < similarity {
+				continue
+			}
+			groups[i] = append(group, node)
+			placed = true
+			break
+		}
+		if !placed {
+			groups = append(groups, []shapeNode{node})
+		}
+	}
+
+	taken := make(map[string]bool, len(defs))
+	for name := range defs {
+		taken[name] = true
+	}
+
+	// nodes собраны в обратном порядке обхода, поэтому группа вложенных форм
+	// встречается раньше группы объектов, которые их содержат
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		def := *group[0].prop
+		def.Nullable = false
+		for _, node := range group[1:] {
+			a.mergeProperty(&def, node.prop, "", newState(scratchStats()))
+		}
+
+		name := uniqueName(defName(group[0].key), taken)
+		taken[name] = true
+		if defs == nil {
+			defs = make(map[string]*types.Property)
+		}
+		defs[name] = &def
+
+		for _, node := range group {
+			*node.prop = refProperty(types.DefsRefPrefix+name, node.prop.Nullable)
+		}
+	}
+	return defs
+}
+
+// collectShapes собирает объекты с перечисленными полями в обратном порядке
+// обхода: потомки раньше предков. Корень и ссылки не собираются
+func collectShapes(prop *types.Property, key, path string, nodes *[]shapeNode) {
+	if prop == nil || prop.Ref != "" {
+		return
+	}
+
+	for _, name := range sortedKeys(prop.Properties) {
+		collectShapes(prop.Properties[name], name, path+"."+name, nodes)
+	}
+	collectShapes(prop.Items, key, path+"[0]", nodes)
+	for i, position := range prop.PrefixItems {
+		collectShapes(position, key, positionPath(path, i), nodes)
+	}
+	collectShapes(types.MapValues(prop.PatternProperties, prop.AdditionalProperties), key, path+".*", nodes)
+
+	if path != "" && prop.Type == string(types.TypeObject) && len(prop.Properties) >= 2 {
+		*nodes = append(*nodes, shapeNode{prop: prop, path: path, key: key})
+	}
+}
+
+// related сообщает, что путь вложен в путь одного из объектов группы или
+// содержит его: объект не объединяется со своим предком или потомком
+func related(group []shapeNode, path string) bool {
+	for _, node := range group {
+		if nested(node.path, path) || nested(path, node.path) {
+			return true
+		}
+	}
+	return false
+}
+
+// nested сообщает, что путь path лежит внутри пути parent
+func nested(parent, path string) bool {
+	return strings.HasPrefix(path, parent) && len(path) > len(parent) && strings.ContainsRune(".[", rune(path[len(parent)]))
+}
+
+// shapeSimilarity возвращает долю общих полей двух объектов (коэффициент
+// Жаккара); объекты с несовместимыми типами общих полей не похожи
+func shapeSimilarity(a, b *types.Property) float64 {
+	shared := 0
+	for key, prop := range a.Properties {
+		other, ok := b.Properties[key]
+		if !ok {
+			continue
+		}
+		if prop == nil || other == nil || !types.CompatibleTypes(prop.Type, other.Type) {
+			return 0
+		}
+		shared++
+	}
+	return float64(shared) / float64(len(a.Properties)+len(b.Properties)-shared)
+}
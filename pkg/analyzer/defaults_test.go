@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultStaysClearedAfterConflict(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{name: "integers", input: `[1, 2, 999]`},
+		{name: "strings", input: `["a", "b", "c"]`},
+		{name: "booleans", input: `[true, false, true]`},
+		{name: "cleared then repeated", input: `[1, 2, 1, 1]`},
+		{name: "same value", input: `[7, 7, 7]`, want: "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().AnalyzeBytes([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("AnalyzeBytes: %v", err)
+			}
+			got := result.Schema.Items.Default
+			if tt.want == nil && got != nil || tt.want != nil && formatKey(got) != tt.want {
+				t.Errorf("items.default = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultDroppedOnTypeConflict(t *testing.T) {
+	// Третья запись меняет тип поля: default не должен противоречить типу
+	input := strings.Join([]string{`{"a": 1}`, `{"a": 2}`, `{"a": "}"}`, `{"a": 3}`}, "\n")
+	for _, workers := range []int{1, 2} {
+		result, err := New(WithWorkers(workers)).AnalyzeNDJSON(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("workers=%d: AnalyzeNDJSON: %v", workers, err)
+		}
+		field := result.Schema.Items.Properties["a"]
+		if field.Default != nil {
+			t.Errorf("workers=%d: a = {type: %s, default: %v}, want no default", workers, field.Type, field.Default)
+		}
+	}
+
+	// Порядок выборок не важен: строка первой
+	result, err := New().AnalyzeBytes([]byte(`[{"a": "x"}, {"a": 1}, {"a": 1}]`))
+	if err != nil {
+		t.Fatalf("AnalyzeBytes: %v", err)
+	}
+	if field := result.Schema.Items.Properties["a"]; field.Default != nil {
+		t.Errorf("a = {type: %s, default: %v}, want no default", field.Type, field.Default)
+	}
+}
+
+func TestDefaultClearedOnCSVColumn(t *testing.T) {
+	result, err := New().AnalyzeCSV(strings.NewReader("n\n1\n2\n3\n"))
+	if err != nil {
+		t.Fatalf("AnalyzeCSV: %v", err)
+	}
+	if field := result.Schema.Items.Properties["n"]; field.Default != nil {
+		t.Errorf("n.default = %v, want none", field.Default)
+	}
+}
+
+func TestDefaultConflictSurvivesSave(t *testing.T) {
+	a := New()
+	existing, err := a.AnalyzeBytes([]byte(`[{"id": 1}, {"id": 2}]`))
+	if err != nil {
+		t.Fatalf("AnalyzeBytes: %v", err)
+	}
+
+	// update читает сохраненную схему, а не результат анализа
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := a.SaveSchema(existing, path); err != nil {
+		t.Fatalf("SaveSchema: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := a.LoadSchemaBytes(data)
+	if err != nil {
+		t.Fatalf("LoadSchemaBytes: %v", err)
+	}
+
+	update, err := a.AnalyzeBytes([]byte(`[{"id": 3}]`))
+	if err != nil {
+		t.Fatalf("AnalyzeBytes: %v", err)
+	}
+	merged, err := a.MergeResults(loaded, update)
+	if err != nil {
+		t.Fatalf("MergeResults: %v", err)
+	}
+	if field := merged.Schema.Items.Properties["id"]; field.Default != nil {
+		t.Errorf("id.default = %v, want none", field.Default)
+	}
+}
@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// Порядок значений enum
+const (
+	// EnumAlphabetical упорядочивает значения по алфавиту (по умолчанию)
+	EnumAlphabetical = "alphabetical"
+	// EnumFirstSeen сохраняет порядок, в котором значения встретились в данных
+	EnumFirstSeen = "first-seen"
+	// EnumFrequency упорядочивает значения по убыванию частоты, равные по
+	// частоте - в порядке появления
+	EnumFrequency = "frequency"
+)
+
+// ParseEnumOrder проверяет название порядка значений enum
+func ParseEnumOrder(order string) (string, error) {
+	switch order {
+	case "", EnumAlphabetical, EnumFirstSeen, EnumFrequency:
+		return order, nil
+	default:
+		return "", fmt.Errorf("неизвестный порядок значений enum: %s. Доступные: %s, %s, %s", order, EnumAlphabetical, EnumFirstSeen, EnumFrequency)
+	}
+}
+
+// applyEnums записывает в кандидаты enum статистики строковые поля, у которых
+// не больше threshold различных значений, и выставляет им enum, если хотя бы
+// одно значение повторяется: по одному вхождению каждого значения нельзя судить
+// о закрытом наборе
+func (s *state) applyEnums(prop *types.Property, path string, threshold int, order string) {
+	if prop == nil {
+		return
+	}
+
+	if values, ok := s.strings[path]; ok && prop.Type == "string" && len(prop.Enum) == 0 {
+		if distinct := len(values.distinct); distinct > 0 && distinct <= threshold {
+			enum := values.ordered(order)
+			candidates := make([]interface{}, 0, len(enum))
+			for _, value := range enum {
+				candidates = append(candidates, value)
+			}
+			s.stats.EnumCandidates[fieldPath(path)] = candidates
+			if values.count > distinct {
+				prop.Enum = candidates
+			}
+		}
+	}
+
+	for key, child := range prop.Properties {
+		s.applyEnums(child, path+"."+key, threshold, order)
+	}
+	s.applyEnums(prop.Items, path+"[0]", threshold, order)
+}
+
+// ordered возвращает различные значения поля в заданном порядке
+func (v *stringValues) ordered(order string) []string {
+	values := append([]string(nil), v.order...)
+	switch order {
+	case EnumFirstSeen:
+	case EnumFrequency:
+		sort.SliceStable(values, func(i, j int) bool {
+			return v.distinct[values[i]] > v.distinct[values[j]]
+		})
+	default:
+		sort.Strings(values)
+	}
+	return values
+}
+
+// extendEnums дополняет существующие enum схемы значениями-кандидатами из новых данных
+func extendEnums(schema *types.JSONSchema, candidates map[string][]interface{}, order string) {
+	if len(candidates) == 0 {
+		return
+	}
+	walk.Walk(schema, func(path string, p *types.Property) error {
+		if values, ok := candidates[path]; ok && len(p.Enum) > 0 {
+			p.Enum = mergeEnum(p.Enum, values, order)
+		}
+		return nil
+	})
+}
+
+// mergeEnum объединяет списки допустимых значений. Порядок существующего
+// списка не меняется, чтобы правки выверенных вручную enum оставались
+// читаемыми в diff: новые значения добавляются в конец, а в упорядоченный по
+// алфавиту список - на свои места
+func mergeEnum(existing, new []interface{}, order string) []interface{} {
+	seen := make(map[string]bool, len(existing))
+	for _, value := range existing {
+		seen[formatKey(value)] = true
+	}
+
+	var added []interface{}
+	for _, value := range new {
+		if key := formatKey(value); !seen[key] {
+			seen[key] = true
+			added = append(added, value)
+		}
+	}
+	if len(added) == 0 {
+		return existing
+	}
+	if (order == "" || order == EnumAlphabetical) && alphabetical(existing) && alphabetical(added) {
+		return insertSorted(existing, added)
+	}
+	return append(existing, added...)
+}
+
+// alphabetical сообщает, что список состоит из строк, упорядоченных по алфавиту
+func alphabetical(values []interface{}) bool {
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		if i > 0 && values[i-1].(string) > s {
+			return false
+		}
+	}
+	return true
+}
+
+// insertSorted вставляет упорядоченные строки added в упорядоченный список
+// existing, сохраняя порядок
+func insertSorted(existing, added []interface{}) []interface{} {
+	merged := make([]interface{}, 0, len(existing)+len(added))
+	i, j := 0, 0
+	for i < len(existing) && j < len(added) {
+		if existing[i].(string) <= added[j].(string) {
+			merged = append(merged, existing[i])
+			i++
+		} else {
+			merged = append(merged, added[j])
+			j++
+		}
+	}
+	merged = append(merged, existing[i:]...)
+	return append(merged, added[j:]...)
+}
+
+// PendingEnums возвращает поля-кандидаты в enum, для которых enum еще не
+// выставлен и требуется решение пользователя
+func PendingEnums(schema *types.JSONSchema, stats *types.AnalysisStatistics) []string {
+	if schema == nil || stats == nil {
+		return nil
+	}
+
+	index := fieldmanager.New().NewIndex(schema)
+	var fields []string
+	for field, candidates := range stats.EnumCandidates {
+		// Кандидат, для которого enum уже выставлен, решения не ждет
+		if prop, err := index.Lookup(field); err == nil && len(prop.Enum) > 0 {
+			continue
+		}
+		if len(candidates) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
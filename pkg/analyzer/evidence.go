@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// samples возвращает число значений по пути, включая null
+func (s *state) samples(path string) int {
+	total := 0
+	for _, count := range s.kinds[path] {
+		total += count
+	}
+	return total
+}
+
+// applyMinSamples откладывает ограничения полей, у которых меньше minimum
+// значений: enum, format, pattern, границы длины, диапазона и размера массива
+// переносятся в x-pending, а поля объектов, встреченных меньше minimum раз,
+// не считаются обязательными. Схема сохраняет только типы и структуру
+func (s *state) applyMinSamples(prop *types.Property, path string, minimum int) {
+	if prop == nil || prop.Ref != "" {
+		return
+	}
+
+	for key, child := range prop.Properties {
+		s.applyMinSamples(child, path+"."+key, minimum)
+	}
+	s.applyMinSamples(prop.Items, path+"[0]", minimum)
+
+	if samples := s.samples(path); samples > 0 && samples < minimum {
+		deferConstraints(prop, samples)
+	}
+
+	if total := s.objects[path]; total > 0 && total < minimum {
+		for _, key := range prop.Required {
+			if child := prop.Properties[key]; child != nil && child.Pending != nil {
+				child.Pending.Required = true
+			}
+		}
+		prop.Required = pendingRequired(prop.Required, prop.Properties)
+	}
+}
+
+// deferConstraints переносит ограничения поля в x-pending
+func deferConstraints(prop *types.Property, samples int) {
+	prop.Pending = &types.Pending{
+		Samples:     samples,
+		Enum:        prop.Enum,
+		Format:      prop.Format,
+		Pattern:     prop.Pattern,
+		MinLength:   prop.MinLength,
+		MaxLength:   prop.MaxLength,
+		Minimum:     prop.Minimum,
+		Maximum:     prop.Maximum,
+		MinItems:    prop.MinItems,
+		MaxItems:    prop.MaxItems,
+		UniqueItems: prop.UniqueItems,
+	}
+	prop.Enum, prop.Format, prop.Pattern = nil, "", ""
+	prop.MinLength, prop.MaxLength = nil, nil
+	prop.Minimum, prop.Maximum = "", ""
+	prop.MinItems, prop.MaxItems, prop.UniqueItems = nil, nil, false
+}
+
+// restoreConstraints возвращает отложенные ограничения поля в схему
+func restoreConstraints(prop *types.Property) {
+	pending := prop.Pending
+	if pending == nil {
+		return
+	}
+	prop.Pending = nil
+
+	if len(prop.Enum) == 0 {
+		prop.Enum = pending.Enum
+	}
+	if prop.Format == "" {
+		prop.Format = pending.Format
+	}
+	if prop.Pattern == "" {
+		prop.Pattern = pending.Pattern
+	}
+	if prop.MinLength == nil && prop.MaxLength == nil {
+		prop.MinLength, prop.MaxLength = pending.MinLength, pending.MaxLength
+	}
+	if prop.Minimum == "" && prop.Maximum == "" {
+		prop.Minimum, prop.Maximum = pending.Minimum, pending.Maximum
+	}
+	if prop.MinItems == nil && prop.MaxItems == nil {
+		prop.MinItems, prop.MaxItems = pending.MinItems, pending.MaxItems
+		prop.UniqueItems = prop.UniqueItems || pending.UniqueItems
+	}
+}
+
+// pendingRequired убирает из списка обязательных поля с отложенной обязательностью
+func pendingRequired(required []string, props map[string]*types.Property) []string {
+	kept := make([]string, 0, len(required))
+	for _, key := range required {
+		if child := props[key]; child == nil || child.Pending == nil || !child.Pending.Required {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
+
+// withPendingRequired возвращает обязательные поля объекта вместе с полями,
+// обязательность которых отложена
+func withPendingRequired(prop *types.Property) []string {
+	pending := false
+	for _, child := range prop.Properties {
+		if child != nil && child.Pending != nil && child.Pending.Required {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		return prop.Required
+	}
+
+	required := append([]string{}, prop.Required...)
+	for _, key := range sortedKeys(prop.Properties) {
+		child := prop.Properties[key]
+		if child != nil && child.Pending != nil && child.Pending.Required && !containsString(required, key) {
+			required = append(required, key)
+		}
+	}
+	return required
+}
+
+// mergeEvidence готовит объединение полей с отложенными ограничениями:
+// ограничения возвращаются в схему, чтобы объединиться по обычным правилам,
+// а возвращенная функция снова откладывает их, если суммарной выборки
+// недостаточно. Поле без x-pending подтверждено выборкой не меньше порога
+func (a *Analyzer) mergeEvidence(existing, new *types.Property) func() {
+	if existing.Pending == nil && new.Pending == nil {
+		return func() {}
+	}
+
+	enough := existing.Pending == nil || new.Pending == nil
+	samples := 0
+	if !enough {
+		samples = existing.Pending.Samples + new.Pending.Samples
+		enough = samples >= a.config.MinSamples
+	}
+	restoreConstraints(existing)
+	restoreConstraints(new)
+
+	return func() {
+		if !enough {
+			deferConstraints(existing, samples)
+		}
+	}
+}
+
+// settleRequired откладывает обязательность полей объединенного объекта,
+// выборка которых все еще меньше порога
+func settleRequired(prop *types.Property) {
+	for key, child := range prop.Properties {
+		if child != nil && child.Pending != nil {
+			child.Pending.Required = containsString(prop.Required, key)
+		}
+	}
+	prop.Required = pendingRequired(prop.Required, prop.Properties)
+}
@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"unicode"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// syntheticExamples задает обезличенные примеры для строк известных форматов,
+// в которых посимвольная замена дала бы некорректное значение
+var syntheticExamples = map[string]string{
+	FormatDateTime: "2024-01-01T12:00:00Z",
+	FormatDate:     "2024-01-01",
+	FormatTime:     "12:00:00",
+	FormatUUID:     "123e4567-e89b-12d3-a456-426614174000",
+	FormatIPv4:     "192.0.2.1",
+	FormatIPv6:     "2001:db8::1",
+}
+
+// stringValues хранит различные значения строкового поля: число вхождений
+// каждого и порядок, в котором они встретились впервые
+type stringValues struct {
+	distinct map[string]int
+	order    []string
+	count    int
+}
+
+// recordString запоминает значение строкового поля для примеров и enum.
+// Различные значения считаются до limit, после чего поле считается
+// высококардинальным.
+func (s *state) recordString(path, value string, limit int) {
+	values, ok := s.strings[path]
+	if !ok {
+		values = &stringValues{distinct: make(map[string]int)}
+		s.strings[path] = values
+	}
+	values.count++
+	if n, ok := values.distinct[value]; ok {
+		values.distinct[value] = n + 1
+		return
+	}
+	if len(values.distinct) > limit {
+		return
+	}
+
+	values.distinct[value] = 1
+	values.order = append(values.order, value)
+}
+
+// applyExamples заполняет examples строковых полей первыми limit различными
+// значениями. Значения полей, у которых различных значений больше cardinality,
+// заменяются синтетическими той же формы.
+func (s *state) applyExamples(prop *types.Property, path string, limit, cardinality int) {
+	if prop == nil {
+		return
+	}
+
+	if values, ok := s.strings[path]; ok && prop.Type == "string" && len(values.order) > 0 {
+		first := values.order[:min(limit, len(values.order))]
+		anonymize := len(values.distinct) > cardinality
+		seen := make(map[string]bool, len(first))
+		prop.Examples = make([]interface{}, 0, len(first))
+		for _, value := range first {
+			if anonymize {
+				value = anonymizeValue(value, prop.Format)
+			}
+			if !seen[value] {
+				seen[value] = true
+				prop.Examples = append(prop.Examples, value)
+			}
+		}
+	}
+
+	for key, child := range prop.Properties {
+		s.applyExamples(child, path+"."+key, limit, cardinality)
+	}
+	s.applyExamples(prop.Items, path+"[0]", limit, cardinality)
+}
+
+// anonymizeValue возвращает синтетическое значение той же длины и формы:
+// цифры заменяются цифрами, буквы - буквами того же регистра, остальные символы
+// сохраняются. Замена детерминирована, чтобы схема не менялась между запусками.
+func anonymizeValue(value, format string) string {
+	if example, ok := syntheticExamples[format]; ok {
+		return example
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(value))
+	random := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	runes := []rune(value)
+	for i, r := range runes {
+		switch {
+		case r >= '0' && r <= '9':
+			runes[i] = rune('0' + random.Intn(10))
+		case r >= 'a' && r <= 'z':
+			runes[i] = rune('a' + random.Intn(26))
+		case r >= 'A' && r <= 'Z':
+			runes[i] = rune('A' + random.Intn(26))
+		case unicode.IsUpper(r):
+			runes[i] = 'X'
+		case unicode.IsLetter(r):
+			runes[i] = 'x'
+		case unicode.IsDigit(r):
+			runes[i] = '0'
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,345 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// draft07 - версия JSON Schema, в которой сохраняются схемы
+const draft07 = "http://json-schema.org/draft-07/schema#"
+
+// subschemaKeys - ключевые слова, значения которых - одна схема или
+// словарь/список схем; по ним обходятся вложенные схемы
+var subschemaKeys = []string{
+	"properties", "patternProperties", "additionalProperties",
+	"items", "additionalItems", "prefixItems",
+	"anyOf", "oneOf", "$defs",
+}
+
+// typeKeywords - ключевые слова, которые относятся к одному типу значения;
+// при разборе списка типов они переходят в вариант этого типа
+var typeKeywords = map[string][]string{
+	"object":  {"properties", "patternProperties", "additionalProperties", "required", "minProperties", "maxProperties"},
+	"array":   {"items", "additionalItems", "prefixItems", "minItems", "maxItems", "uniqueItems"},
+	"string":  {"minLength", "maxLength", "pattern", "format"},
+	"number":  {"minimum", "maximum", "multipleOf"},
+	"integer": {"minimum", "maximum", "multipleOf"},
+}
+
+// normalizeForeign приводит схему другого генератора (quicktype, genson и
+// им подобных) к записи, которую понимает внутренняя модель:
+//   - definitions на любом уровне переносятся в корневой $defs, ссылки на
+//     них переписываются;
+//   - список из нескольких типов ("type": ["string", "integer"])
+//     становится вариантами anyOf, integer вместе с number - типом number;
+//   - корень-ссылка на определение ("$ref": "#/definitions/Welcome" у
+//     quicktype) заменяется самим определением, иначе обновление схемы не
+//     дошло бы до ее структуры;
+//   - $schema другой версии заменяется на draft-07; для версий новее
+//     draft-07 (2019-09, 2020-12) возвращается предупреждение: их ключевые
+//     слова, которых нет в модели, при сохранении теряются.
+//
+// Схемы, сохраненные этим инструментом, не меняются
+func normalizeForeign(data []byte) ([]byte, []string, error) {
+	var root map[string]interface{}
+	if err := types.Unmarshal(data, &root); err != nil || root == nil {
+		// Ошибку разбора сообщит основной разбор схемы
+		return data, nil, nil
+	}
+
+	n := &foreignSchema{defs: map[string]interface{}{}, pointers: map[string]string{}}
+	if defs, ok := root["$defs"].(map[string]interface{}); ok {
+		n.defs = defs
+	}
+	n.walk(root, "#", true)
+	if n.changed && len(n.defs) > 0 {
+		root["$defs"] = n.defs
+	}
+	if len(n.pointers) > 0 {
+		n.rewriteRefs(root)
+	}
+	if ref, ok := root["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+		if err := n.inlineRoot(root, ref); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var warnings []string
+	if version, ok := root["$schema"].(string); ok && version != draft07 {
+		if !olderDrafts[dialect(version)] {
+			warnings = append(warnings, fmt.Sprintf("версия схемы %s заменена на draft-07: ключевые слова, которых нет в draft-07, при сохранении будут потеряны", version))
+		}
+		root["$schema"] = draft07
+		n.changed = true
+	}
+
+	if !n.changed {
+		return data, warnings, nil
+	}
+	normalized, err := json.Marshal(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка преобразования схемы: %w", err)
+	}
+	return normalized, warnings, nil
+}
+
+// olderDrafts - версии JSON Schema, которые draft-07 только расширяет
+var olderDrafts = map[string]bool{
+	"json-schema.org/draft-04/schema": true,
+	"json-schema.org/draft-06/schema": true,
+	"json-schema.org/draft-07/schema": true,
+}
+
+// dialect приводит URI версии схемы к виду без схемы URI и пустого фрагмента
+func dialect(version string) string {
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "http://"), "https://")
+	return strings.TrimSuffix(version, "#")
+}
+
+// inlineRoot заменяет корень-ссылку ref определением из $defs. Заголовок и
+// описание корня имеют приоритет над заголовком определения; определение
+// остается в $defs, только если на него ссылаются другие узлы
+func (n *foreignSchema) inlineRoot(root map[string]interface{}, ref string) error {
+	name, ok := strings.CutPrefix(ref, types.DefsRefPrefix)
+	if !ok || strings.Contains(name, "/") {
+		return fmt.Errorf("корень схемы - ссылка %q не на определение $defs: такую схему нельзя обновить", ref)
+	}
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	def, ok := n.defs[name].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("корень схемы ссылается на отсутствующее определение %q", ref)
+	}
+
+	delete(root, "$ref")
+	for key, value := range def {
+		if _, exists := root[key]; !exists {
+			root[key] = value
+		}
+	}
+	if !referenced(root, ref) {
+		delete(n.defs, name)
+		if len(n.defs) == 0 {
+			delete(root, "$defs")
+		}
+	}
+	n.changed = true
+	return nil
+}
+
+// referenced сообщает, что в схеме есть ссылка на узел ref или внутрь него
+func referenced(value interface{}, ref string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if target, ok := v["$ref"].(string); ok && (target == ref || strings.HasPrefix(target, ref+"/")) {
+			return true
+		}
+		for _, child := range v {
+			if referenced(child, ref) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if referenced(child, ref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// foreignSchema накапливает изменения схемы другого генератора
+type foreignSchema struct {
+	// defs - определения корневого $defs, pointers - новые ссылки на
+	// определения по их прежним JSON Pointer
+	defs     map[string]interface{}
+	pointers map[string]string
+	changed  bool
+}
+
+// walk нормализует узел схемы с указателем pointer и его вложенные схемы.
+// Корень и варианты anyOf/oneOf (variant) не бывают nullable: null из их
+// списка типов убирается, и walk сообщает об этом, чтобы вызывающий добавил
+// вариант null
+func (n *foreignSchema) walk(node map[string]interface{}, pointer string, variant bool) bool {
+	if defs, ok := node["definitions"].(map[string]interface{}); ok {
+		delete(node, "definitions")
+		n.changed = true
+		for _, name := range slices.Sorted(maps.Keys(defs)) {
+			def, ok := defs[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			hoisted := n.defName(name)
+			n.defs[hoisted] = def
+			n.pointers[pointer+"/definitions/"+escapePointer(name)] = types.DefsRefPrefix + hoisted
+			n.walk(def, pointer+"/definitions/"+escapePointer(name), false)
+		}
+	}
+
+	nullable := false
+	if list, ok := node["type"].([]interface{}); ok {
+		names := nonNullTypes(list)
+		withNull := slices.Contains(list, interface{}(string(types.TypeNull)))
+		switch {
+		case len(names) > 1:
+			n.changed = true
+			n.splitTypes(node, names, withNull)
+		case len(names) == 0:
+			n.changed = true
+			node["type"] = string(types.TypeNull)
+		case variant:
+			n.changed = true
+			node["type"] = names[0]
+			nullable = withNull
+		case !withNull || len(list) != 2 || list[0] != names[0]:
+			// Свойство описывается одним типом или парой [тип, null]
+			n.changed = true
+			node["type"] = names[0]
+			if withNull {
+				node["type"] = []interface{}{names[0], string(types.TypeNull)}
+			}
+		}
+	}
+
+	for _, key := range subschemaKeys {
+		switch value := node[key].(type) {
+		case map[string]interface{}:
+			if key == "properties" || key == "patternProperties" || key == "$defs" {
+				for _, name := range slices.Sorted(maps.Keys(value)) {
+					if child, ok := value[name].(map[string]interface{}); ok {
+						n.walk(child, pointer+"/"+key+"/"+escapePointer(name), false)
+					}
+				}
+				continue
+			}
+			n.walk(value, pointer+"/"+key, false)
+		case []interface{}:
+			variants := key == "anyOf" || key == "oneOf"
+			withNull := false
+			for i, item := range value {
+				if child, ok := item.(map[string]interface{}); ok {
+					withNull = n.walk(child, pointer+"/"+key+"/"+strconv.Itoa(i), variants) || withNull
+				}
+			}
+			if withNull && !hasNullVariant(value) {
+				node[key] = append(value, map[string]interface{}{"type": string(types.TypeNull)})
+			}
+		}
+	}
+	return nullable
+}
+
+// splitTypes заменяет список типов names узла вариантами anyOf: ключевые
+// слова каждого типа переходят в его вариант, null становится отдельным
+// вариантом
+func (n *foreignSchema) splitTypes(node map[string]interface{}, names []string, nullable bool) {
+	delete(node, "type")
+	variants := make([]interface{}, 0, len(names)+1)
+	moved := map[string]bool{}
+	for _, name := range names {
+		variant := map[string]interface{}{"type": name}
+		for _, key := range typeKeywords[name] {
+			if value, ok := node[key]; ok {
+				variant[key] = value
+				moved[key] = true
+			}
+		}
+		variants = append(variants, variant)
+	}
+	for key := range moved {
+		delete(node, key)
+	}
+	if nullable {
+		variants = append(variants, map[string]interface{}{"type": string(types.TypeNull)})
+	}
+	node["anyOf"] = variants
+}
+
+// defName возвращает свободное имя определения в корневом $defs
+func (n *foreignSchema) defName(name string) string {
+	hoisted := name
+	for i := 2; n.defs[hoisted] != nil; i++ {
+		hoisted = name + strconv.Itoa(i)
+	}
+	return hoisted
+}
+
+// rewriteRefs заменяет ссылки на перенесенные определения и на узлы
+// внутри них
+func (n *foreignSchema) rewriteRefs(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			if rewritten, ok := n.rewriteRef(ref); ok {
+				v["$ref"] = rewritten
+				n.changed = true
+			}
+		}
+		for key, child := range v {
+			if !strings.HasPrefix(key, "x-") {
+				n.rewriteRefs(child)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			n.rewriteRefs(child)
+		}
+	}
+}
+
+// rewriteRef возвращает новую запись ссылки по самому длинному прежнему
+// указателю определения, с которого она начинается
+func (n *foreignSchema) rewriteRef(ref string) (string, bool) {
+	best := ""
+	for pointer := range n.pointers {
+		if (ref == pointer || strings.HasPrefix(ref, pointer+"/")) && len(pointer) > len(best) {
+			best = pointer
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	rewritten := n.pointers[best] + strings.TrimPrefix(ref, best)
+	return rewritten, rewritten != ref
+}
+
+// escapePointer экранирует сегмент JSON Pointer
+func escapePointer(segment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+}
+
+// nonNullTypes возвращает типы списка без null; integer вместе с number
+// описывается типом number
+func nonNullTypes(list []interface{}) []string {
+	names := make([]string, 0, len(list))
+	number := false
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok || name == string(types.TypeNull) || slices.Contains(names, name) {
+			continue
+		}
+		number = number || name == string(types.TypeNumber)
+		names = append(names, name)
+	}
+	if number {
+		names = slices.DeleteFunc(names, func(name string) bool { return name == string(types.TypeInteger) })
+	}
+	return names
+}
+
+// hasNullVariant сообщает, что среди вариантов есть null
+func hasNullVariant(variants []interface{}) bool {
+	for _, item := range variants {
+		if variant, ok := item.(map[string]interface{}); ok && variant["type"] == string(types.TypeNull) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+// quicktypeSchema - схема в записи quicktype: корень - ссылка на определение
+const quicktypeSchema = `{
+	"$schema": "http://json-schema.org/draft-06/schema#",
+	"$ref": "#/definitions/Welcome",
+	"definitions": {
+		"Welcome": {
+			"type": "object",
+			"properties": {"id": {"type": "integer"}, "user": {"$ref": "#/definitions/User"}},
+			"required": ["id", "user"],
+			"title": "Welcome"
+		},
+		"User": {
+			"type": "object",
+			"properties": {"name": {"type": "string"}},
+			"required": ["name"],
+			"title": "User"
+		}
+	}
+}`
+
+func TestUpdateForeignRootRef(t *testing.T) {
+	a := New()
+	existing, err := a.LoadSchemaBytes([]byte(quicktypeSchema))
+	if err != nil {
+		t.Fatalf("LoadSchemaBytes: %v", err)
+	}
+	if existing.Schema.Ref != "" || existing.Schema.Type != "object" {
+		t.Fatalf("корень = {$ref: %q, type: %q}, want определение Welcome", existing.Schema.Ref, existing.Schema.Type)
+	}
+	if existing.Schema.Title != "Welcome" {
+		t.Errorf("title = %q, want Welcome", existing.Schema.Title)
+	}
+	if _, ok := existing.Schema.Defs["Welcome"]; ok {
+		t.Error("определение Welcome без других ссылок осталось в $defs")
+	}
+	if user := existing.Schema.Defs["User"]; user == nil || user.Title != "User" {
+		t.Errorf("$defs.User = %+v, want определение с title User", user)
+	}
+	if len(existing.Warnings) != 0 {
+		t.Errorf("warnings = %v, want none для draft-06", existing.Warnings)
+	}
+
+	update, err := a.AnalyzeBytes([]byte(`{"id": 2, "user": {"name": "b"}, "extra": true}`))
+	if err != nil {
+		t.Fatalf("AnalyzeBytes: %v", err)
+	}
+	merged, err := a.MergeResults(existing, update)
+	if err != nil {
+		t.Fatalf("MergeResults: %v", err)
+	}
+	if merged.Schema.Properties["extra"] == nil {
+		t.Error("новое поле extra не попало в схему")
+	}
+	if merged.Schema.Title != "Welcome" {
+		t.Errorf("title после обновления = %q, want Welcome", merged.Schema.Title)
+	}
+}
+
+func TestForeignRootRefKeptWhenReferenced(t *testing.T) {
+	// Дерево ссылается на свое определение: оно остается в $defs
+	schema := `{
+		"$ref": "#/definitions/Node",
+		"definitions": {"Node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/definitions/Node"}}}}}
+	}`
+	result, err := New().LoadSchemaBytes([]byte(schema))
+	if err != nil {
+		t.Fatalf("LoadSchemaBytes: %v", err)
+	}
+	if result.Schema.Type != "object" || result.Schema.Defs["Node"] == nil {
+		t.Errorf("корень = %q, $defs = %v, want объект и определение Node", result.Schema.Type, result.Schema.Defs)
+	}
+}
+
+func TestForeignRootRefRejected(t *testing.T) {
+	for _, schema := range []string{
+		`{"$ref": "#/properties/a", "properties": {"a": {"type": "string"}}}`,
+		`{"$ref": "#/definitions/Missing", "definitions": {"Other": {"type": "string"}}}`,
+	} {
+		if _, err := New().LoadSchemaBytes([]byte(schema)); err == nil || !strings.Contains(err.Error(), "корень схемы") {
+			t.Errorf("LoadSchemaBytes(%s) error = %v, want ошибку корня-ссылки", schema, err)
+		}
+	}
+}
+
+func TestForeignDialectWarning(t *testing.T) {
+	tests := []struct {
+		version string
+		warn    bool
+	}{
+		{"http://json-schema.org/draft-04/schema#", false},
+		{"https://json-schema.org/draft-07/schema", false},
+		{"https://json-schema.org/draft/2019-09/schema", true},
+		{"https://json-schema.org/draft/2020-12/schema", true},
+	}
+	for _, tt := range tests {
+		result, err := New().LoadSchemaBytes([]byte(`{"$schema": "` + tt.version + `", "type": "object"}`))
+		if err != nil {
+			t.Fatalf("%s: LoadSchemaBytes: %v", tt.version, err)
+		}
+		if got := len(result.Warnings) > 0; got != tt.warn {
+			t.Errorf("%s: warnings = %v, want warning: %v", tt.version, result.Warnings, tt.warn)
+		}
+		if result.Schema.Schema != draft07 {
+			t.Errorf("%s: $schema = %q, want draft-07", tt.version, result.Schema.Schema)
+		}
+	}
+}
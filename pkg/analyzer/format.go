@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// Форматы входных файлов
+const (
+	// FormatJSON - JSON или NDJSON (по расширению .ndjson)
+	FormatJSON = "json"
+	// FormatCSV - таблица CSV с заголовком (см. AnalyzeCSV)
+	FormatCSV = "csv"
+	// FormatYAML - документы YAML (см. AnalyzeYAML)
+	FormatYAML = "yaml"
+	// FormatXML - документ XML (см. AnalyzeXML)
+	FormatXML = "xml"
+	// FormatLog - текстовый журнал со строками, содержащими JSON (см. AnalyzeLog)
+	FormatLog = "log"
+)
+
+// ParseFormat проверяет название формата входных данных; пустое значение -
+// формат по расширению файла
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatJSON, FormatCSV, FormatYAML, FormatXML, FormatLog:
+		return format, nil
+	case "yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("неизвестный формат входных данных: %s. Доступные: %s, %s, %s, %s, %s", format, FormatJSON, FormatCSV, FormatYAML, FormatXML, FormatLog)
+	}
+}
+
+// ParseCSVDelimiter разбирает разделитель значений CSV: один символ, кроме
+// кавычки и перевода строки; "tab" и "\t" - табуляция
+func ParseCSVDelimiter(value string) (rune, error) {
+	switch value {
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	runes := []rune(value)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' || runes[0] == utf8.RuneError {
+		return 0, fmt.Errorf("недопустимый разделитель CSV: %q. Ожидается один символ, например ';' или tab", value)
+	}
+	return runes[0], nil
+}
+
+// IsCSV сообщает, что файл анализируется как таблица CSV: так задано
+// Config.Format или, если формат не задан, у файла расширение .csv (в том
+// числе сжатого: .csv.gz)
+func (a *Analyzer) IsCSV(filename string) bool {
+	if a.config.Format != "" {
+		return a.config.Format == FormatCSV
+	}
+	return strings.EqualFold(filepath.Ext(decompress.TrimExt(filename)), ".csv")
+}
+
+// IsYAML сообщает, что файл анализируется как YAML: так задано
+// Config.Format или, если формат не задан, у файла расширение .yaml или
+// .yml (в том числе сжатого: .yaml.gz)
+func (a *Analyzer) IsYAML(filename string) bool {
+	if a.config.Format != "" {
+		return a.config.Format == FormatYAML
+	}
+	ext := strings.ToLower(filepath.Ext(decompress.TrimExt(filename)))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// IsXML сообщает, что файл анализируется как XML: так задано Config.Format
+// или, если формат не задан, у файла расширение .xml (в том числе сжатого:
+// .xml.gz)
+func (a *Analyzer) IsXML(filename string) bool {
+	if a.config.Format != "" {
+		return a.config.Format == FormatXML
+	}
+	return strings.EqualFold(filepath.Ext(decompress.TrimExt(filename)), ".xml")
+}
+
+// IsLog сообщает, что файл анализируется как текстовый журнал: так задано
+// Config.Format или, если формат не задан, у файла расширение .log (в том
+// числе сжатого: .log.gz)
+func (a *Analyzer) IsLog(filename string) bool {
+	if a.config.Format != "" {
+		return a.config.Format == FormatLog
+	}
+	return strings.EqualFold(filepath.Ext(decompress.TrimExt(filename)), ".log")
+}
+
+// IsConverted сообщает, что файл не является JSON и преобразуется перед
+// анализом: CSV, YAML, XML или журнал. К таким файлам не применяются
+// потоковый анализ и автоматический режим
+func (a *Analyzer) IsConverted(filename string) bool {
+	return a.IsCSV(filename) || a.IsYAML(filename) || a.IsXML(filename) || a.IsLog(filename)
+}
+
+// analyzeConverted анализирует данные, преобразованные в JSON функцией
+// convert. MaxInputSize ограничивает размер исходных данных, а не JSON
+func (a *Analyzer) analyzeConverted(data []byte, convert func([]byte) ([]byte, error)) (*types.AnalysisResult, error) {
+	if err := a.checkSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+	converted, err := convert(data)
+	if err != nil {
+		return nil, err
+	}
+	return a.analyzeBytes(converted)
+}
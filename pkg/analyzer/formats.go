@@ -0,0 +1,309 @@
+package analyzer
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Форматы строк, которые анализатор умеет распознавать
+const (
+	FormatDateTime = "date-time"
+	FormatDate     = "date"
+	FormatTime     = "time"
+	FormatUUID     = "uuid"
+	FormatEmail    = "email"
+	FormatURI      = "uri"
+	FormatIPv4     = "ipv4"
+	FormatIPv6     = "ipv6"
+	FormatHostname = "hostname"
+)
+
+// FormatDetector распознает формат строкового значения. Match должен принимать
+// только значения, которые проходят проверку format при валидации.
+type FormatDetector struct {
+	Format string
+	Match  func(value string) bool
+
+	// accepts - быстрая проверка встроенного детектора по составу символов:
+	// значение, которое она отклоняет, Match не принимает
+	accepts func(value string, chars charSet) bool
+}
+
+// DefaultFormatDetectors возвращает встроенные детекторы форматов в порядке
+// проверки: более специфичные форматы проверяются раньше
+func DefaultFormatDetectors() []FormatDetector {
+	return []FormatDetector{
+		{Format: FormatDateTime, Match: layoutMatcher(time.RFC3339, time.RFC3339Nano), accepts: maybeDateTime},
+		{Format: FormatDate, Match: layoutMatcher("2006-01-02"), accepts: maybeDate},
+		{Format: FormatTime, Match: layoutMatcher("15:04:05", "15:04:05Z07:00"), accepts: maybeTime},
+		{Format: FormatUUID, Match: isUUID},
+		{Format: FormatIPv4, Match: checkerMatcher(FormatIPv4), accepts: maybeIP(charDot)},
+		{Format: FormatIPv6, Match: checkerMatcher(FormatIPv6), accepts: maybeIP(charColon)},
+		{Format: FormatEmail, Match: isEmail, accepts: containsChars(charAt)},
+		{Format: FormatURI, Match: isURI, accepts: containsChars(charColon)},
+		{Format: FormatHostname, Match: isHostname, accepts: maybeHostname},
+	}
+}
+
+// defaultDetectors - встроенные детекторы, общие для всех анализаторов
+var defaultDetectors = DefaultFormatDetectors()
+
+// detectors возвращает детекторы форматов из настроек или встроенные
+func (a *Analyzer) detectors() []FormatDetector {
+	if a.config.FormatDetectors != nil {
+		return a.config.FormatDetectors
+	}
+	return defaultDetectors
+}
+
+// detectFormat возвращает формат строкового значения или пустую строку
+func (a *Analyzer) detectFormat(value string) string {
+	format, _ := a.matchFormat(value, "")
+	return format
+}
+
+// matchFormat возвращает формат значения, проверяя детекторы по порядку,
+// но не дальше детектора формата stop: значение, не подходящее к stop и
+// предшествующим ему форматам, уже не может получить формат stop. Второй
+// результат сообщает, что проверка остановилась раньше, чем формат определен
+func (a *Analyzer) matchFormat(value, stop string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+
+	// Состав символов вычисляется одним проходом и отсекает детекторы без
+	// разбора значения
+	chars := scanChars(value)
+	for _, detector := range a.detectors() {
+		if (detector.accepts == nil || detector.accepts(value, chars)) && detector.Match(value) {
+			return detector.Format, false
+		}
+		if detector.Format == stop {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// scanFormat возвращает формат строкового значения по пути. Формат поля
+// сохраняется, только если ему соответствуют все значения, поэтому значения
+// по одному пути проверяются вместе: после первого значения без формата или
+// другого формата остальные не проверяются, а следующие значения
+// проверяются только до детектора уже найденного формата. Результат
+// объединения схем тот же, что при проверке каждого значения всеми детекторами
+func (a *Analyzer) scanFormat(path, value string, st *state) string {
+	scan, ok := st.formats[path]
+	if !ok {
+		format := a.detectFormat(value)
+		st.formats[path] = &formatScan{format: format, last: value}
+		return format
+	}
+	if scan.format == "" || value == scan.last {
+		return scan.format
+	}
+
+	if format, _ := a.matchFormat(value, scan.format); format != scan.format {
+		scan.format = ""
+		return format
+	}
+	scan.last = value
+	return scan.format
+}
+
+// formatScan - формат, общий для значений по пути, и последнее значение,
+// для которого он подтвержден
+type formatScan struct {
+	format string
+	last   string
+}
+
+// charSet - классы символов, встречающихся в значении
+type charSet uint8
+
+const (
+	charDigit  charSet = 1 << iota // 0-9
+	charHex                        // a-f, A-F
+	charLetter                     // прочие латинские буквы
+	charDot                        // .
+	charColon                      // :
+	charHyphen                     // -
+	charAt                         // @
+	charOther                      // остальные символы, включая не-ASCII
+)
+
+// scanChars определяет классы символов значения за один проход по байтам
+func scanChars(value string) charSet {
+	var chars charSet
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c >= '0' && c <= '9':
+			chars |= charDigit
+		case c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F':
+			chars |= charHex
+		case c >= 'g' && c <= 'z' || c >= 'G' && c <= 'Z':
+			chars |= charLetter
+		case c == '.':
+			chars |= charDot
+		case c == ':':
+			chars |= charColon
+		case c == '-':
+			chars |= charHyphen
+		case c == '@':
+			chars |= charAt
+		default:
+			chars |= charOther
+		}
+	}
+	return chars
+}
+
+// containsChars принимает значения, в которых есть все классы символов want
+func containsChars(want charSet) func(string, charSet) bool {
+	return func(_ string, chars charSet) bool {
+		return chars&want == want
+	}
+}
+
+// maybeDateTime принимает значения с датой 2006-01-02 и разделителем T в
+// начале: год, месяц и день в RFC 3339 имеют фиксированную длину
+func maybeDateTime(value string, _ charSet) bool {
+	return len(value) >= len("2006-01-02T0:0:0Z") && maybeDate(value[:10], 0) && (value[10] == 'T' || value[10] == 't')
+}
+
+// maybeDate принимает значения вида 2006-01-02
+func maybeDate(value string, _ charSet) bool {
+	return len(value) == len("2006-01-02") && value[4] == '-' && value[7] == '-'
+}
+
+// maybeTime принимает значения, начинающиеся с часа из одной или двух цифр
+// и двоеточия
+func maybeTime(value string, _ charSet) bool {
+	return len(value) >= len("0:0:0") && (value[1] == ':' || value[2] == ':')
+}
+
+// maybeIP принимает значения из шестнадцатеричных цифр, точек и двоеточий,
+// в которых есть разделитель sep: IPv4 записывается через точки (в том
+// числе в IPv6-отображении ::ffff:192.0.2.1), IPv6 - через двоеточия
+func maybeIP(sep charSet) func(string, charSet) bool {
+	return func(_ string, chars charSet) bool {
+		return chars&sep != 0 && chars&^(charDigit|charHex|charDot|charColon) == 0
+	}
+}
+
+// maybeHostname принимает значения из букв, цифр, дефисов и точек, в которых
+// есть точка
+func maybeHostname(_ string, chars charSet) bool {
+	return chars&charDot != 0 && chars&^(charDigit|charHex|charLetter|charDot|charHyphen) == 0
+}
+
+// isUUID проверяет UUID в нижнем регистре так же, как проверка формата
+// gojsonschema, но без регулярного выражения
+func isUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// detectable сообщает, что формат выводится из значений. Только такие
+// форматы снимаются при объединении, если новое значение им не соответствует.
+func (a *Analyzer) detectable(format string) bool {
+	for _, detector := range a.detectors() {
+		if detector.Format == format {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutMatcher проверяет значение раскладками time.Parse, совпадающими с
+// проверками gojsonschema
+func layoutMatcher(layouts ...string) func(string) bool {
+	return func(value string) bool {
+		for _, layout := range layouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// checkerMatcher использует проверку формата gojsonschema
+func checkerMatcher(format string) func(string) bool {
+	return func(value string) bool {
+		return gojsonschema.FormatCheckers.IsFormat(format, value)
+	}
+}
+
+// isEmail принимает только голый адрес без имени и угловых скобок
+func isEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value && address.Name == ""
+}
+
+// isURI принимает абсолютные URI с хостом, а также mailto:, tel: и urn:,
+// чтобы строки вида "key:value" не считались URI
+func isURI(value string) bool {
+	if !gojsonschema.FormatCheckers.IsFormat(FormatURI, value) {
+		return false
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "mailto", "tel", "urn":
+		return u.Opaque != ""
+	}
+	return u.Host != ""
+}
+
+// fileExtensions содержит частые расширения файлов, из-за которых имена файлов
+// выглядят как доменные имена
+var fileExtensions = map[string]bool{
+	"json": true, "yaml": true, "yml": true, "xml": true, "csv": true, "txt": true,
+	"pdf": true, "doc": true, "docx": true, "xls": true, "xlsx": true, "html": true,
+	"htm": true, "png": true, "jpg": true, "jpeg": true, "gif": true, "svg": true,
+	"zip": true, "gz": true, "tar": true, "log": true, "js": true, "ts": true,
+	"go": true, "py": true, "md": true, "exe": true,
+}
+
+// isHostname принимает доменные имена из нескольких меток с буквенной зоной,
+// которая не похожа на расширение файла
+func isHostname(value string) bool {
+	if !gojsonschema.FormatCheckers.IsFormat(FormatHostname, value) {
+		return false
+	}
+	labels := strings.Split(value, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	tld := labels[len(labels)-1]
+	if len(tld) < 2 || fileExtensions[strings.ToLower(tld)] {
+		return false
+	}
+	for _, r := range tld {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
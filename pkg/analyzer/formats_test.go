@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+)
+
+// formatColumns - значения одного поля по форматам: каждый столбец
+// проверяется так же, как строки одного пути при анализе
+var formatColumns = []struct {
+	name  string
+	value func(i int) string
+}{
+	{"date-time", func(i int) string { return fmt.Sprintf("2024-%02d-%02dT10:%02d:00Z", i%12+1, i%28+1, i%60) }},
+	{"email", func(i int) string { return fmt.Sprintf("user%d@example.com", i) }},
+	{"uuid", func(i int) string { return fmt.Sprintf("%08x-1234-4abc-8def-%012x", i, i) }},
+	{"ipv4", func(i int) string { return fmt.Sprintf("10.0.%d.%d", i/256%256, i%256) }},
+	{"uri", func(i int) string { return fmt.Sprintf("https://example.com/orders/%d", i) }},
+	{"text", func(i int) string { return fmt.Sprintf("order %d is ready", i) }},
+}
+
+// formatColumnSize - число значений в столбце
+const formatColumnSize = 1024
+
+// BenchmarkDetectFormat измеряет проверку каждого значения всеми детекторами
+// с отсечением по составу символов
+func BenchmarkDetectFormat(b *testing.B) {
+	a := New()
+	for _, column := range formatColumns {
+		values := formatColumn(column.value)
+		b.Run(column.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				a.detectFormat(values[i%len(values)])
+			}
+		})
+	}
+}
+
+// BenchmarkScanFormat измеряет проверку значений по пути (см. scanFormat):
+// после первого значения остальные проверяются только до найденного формата
+func BenchmarkScanFormat(b *testing.B) {
+	a := New()
+	for _, column := range formatColumns {
+		values := formatColumn(column.value)
+		b.Run(column.name, func(b *testing.B) {
+			b.ReportAllocs()
+			st := newState(a.newResult().Statistics)
+			for i := 0; i < b.N; i++ {
+				a.scanFormat("field", values[i%len(values)], st)
+			}
+		})
+	}
+}
+
+// formatColumn строит столбец значений
+func formatColumn(value func(i int) string) []string {
+	values := make([]string, formatColumnSize)
+	for i := range values {
+		values[i] = value(i)
+	}
+	return values
+}
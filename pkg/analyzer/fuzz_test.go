@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// fuzzInputs - начальный корпус для целей по входным данным: формы, которые
+// анализ различает, и граничные случаи разбора
+var fuzzInputs = []string{
+	`{"id": 1, "name": "a", "tags": ["x", "y"], "active": true}`,
+	`[{"id": 1}, {"id": "2", "extra": null}, {}]`,
+	`{"data": [{"id": 1, "created": "2024-01-01T00:00:00Z"}], "meta": {"total": 1}}`,
+	`[[1, "a"], [2, "b"]]`,
+	`[{"kind": "circle", "r": 1}, {"kind": "square", "side": 2}]`,
+	`{"children": [{"children": [{"children": []}]}]}`,
+	"{\"a\":1}\n{\"a\":\"}\"}\n",
+	`[1, 2.5, -0, 1e400, 12345678901234567890]`,
+	`[]`, `{}`, `null`, `"x"`, `0`,
+	"\xef\xbb\xbf[1]",
+	`[[[[[[[[[[[[[[[[[[[[[[[[[[[[[[1]]]]]]]]]]]]]]]]]]]]]]]]]]]]]`,
+	`{"": {"": [""]}, "a.b": {"[0]": 1}}`,
+}
+
+// FuzzAnalyzeBytes проверяет, что анализ произвольного входа не паникует,
+// а успешный результат сериализуется в схему, которая проходит мета-схему
+// и загружается обратно
+func FuzzAnalyzeBytes(f *testing.F) {
+	for _, input := range fuzzInputs {
+		f.Add([]byte(input))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		config := DefaultConfig()
+		config.MaxDepth = 64
+		a := NewWithConfig(config)
+		result, err := a.AnalyzeBytes(data)
+		if err != nil {
+			return
+		}
+		checkResult(t, a, result)
+	})
+}
+
+// FuzzAnalyzeStream проверяет потоковый анализ и анализ по токенам на тех
+// же входах
+func FuzzAnalyzeStream(f *testing.F) {
+	for _, input := range fuzzInputs {
+		f.Add([]byte(input), false)
+		f.Add([]byte(input), true)
+	}
+	f.Fuzz(func(t *testing.T, data []byte, tokenize bool) {
+		config := DefaultConfig()
+		config.MaxDepth = 64
+		config.Tokenize = tokenize
+		a := NewWithConfig(config)
+		result, err := a.AnalyzeStream(bytes.NewReader(data))
+		if errors.Is(err, ErrConcatenated) {
+			result, err = a.AnalyzeNDJSON(bytes.NewReader(data))
+		}
+		if err != nil {
+			return
+		}
+		checkResult(t, a, result)
+	})
+}
+
+// FuzzLoadSchemaBytes проверяет загрузку произвольных схем, в том числе
+// схем других генераторов
+func FuzzLoadSchemaBytes(f *testing.F) {
+	seeds := []string{
+		`{"$schema": "http://json-schema.org/draft-07/schema#", "type": "object", "properties": {"id": {"type": "integer"}}}`,
+		`{"type": ["string", "null"]}`,
+		`{"$ref": "#/definitions/Root", "definitions": {"Root": {"type": "object", "properties": {"a": {"$ref": "#/definitions/A"}}}, "A": {"type": "string"}}}`,
+		`{"type": "array", "items": [{"type": "number"}, {"type": "string"}], "additionalItems": false}`,
+		`{"oneOf": [{"type": "object"}, {"type": "null"}]}`,
+		`{"x-analysis-meta": {"version": 1}}`,
+		`{"properties": null, "items": true}`,
+		`[]`, `null`, `{}`,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		result, err := New().LoadSchemaBytes(data)
+		if err != nil {
+			return
+		}
+		if result.Schema == nil {
+			t.Fatalf("LoadSchemaBytes вернул результат без схемы")
+		}
+		if _, err := json.Marshal(result.Schema); err != nil {
+			t.Fatalf("загруженная схема не сериализуется: %v", err)
+		}
+	})
+}
+
+// checkResult проверяет инварианты успешного анализа
+func checkResult(t *testing.T, a *Analyzer, result *types.AnalysisResult) {
+	t.Helper()
+	if result.Schema == nil {
+		t.Fatalf("анализ вернул результат без схемы")
+	}
+	data, err := json.Marshal(result.Schema)
+	if err != nil {
+		t.Fatalf("схема не сериализуется: %v", err)
+	}
+	if err := checkSchema(data); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	if _, err := a.LoadSchemaBytes(data); err != nil {
+		t.Fatalf("схема не загружается обратно: %v\n%s", err, data)
+	}
+}
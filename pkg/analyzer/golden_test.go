@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/internal/golden"
+)
+
+// TestGoldenAnalyze сравнивает схемы, выведенные из testdata/golden/analyze/<случай>/input.*,
+// с эталонами schema.golden.json рядом с входом
+func TestGoldenAnalyze(t *testing.T) {
+	for _, input := range golden.Inputs(t, "testdata/golden/analyze/*/input.*") {
+		dir := filepath.Dir(input)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			result, err := New().AnalyzeFile(input)
+			if err != nil {
+				t.Fatalf("AnalyzeFile: %v", err)
+			}
+			golden.AssertJSON(t, filepath.Join(dir, "schema.golden.json"), result.Schema)
+		})
+	}
+}
+
+// TestGoldenMerge сравнивает объединение схем base.json и update.json из
+// testdata/golden/merge/<случай> с эталоном merged.golden.json, как в update
+func TestGoldenMerge(t *testing.T) {
+	for _, dir := range golden.Inputs(t, "testdata/golden/merge/*") {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			a := New()
+			base, err := a.AnalyzeFile(filepath.Join(dir, "base.json"))
+			if err != nil {
+				t.Fatalf("AnalyzeFile base: %v", err)
+			}
+			update, err := a.AnalyzeFile(filepath.Join(dir, "update.json"))
+			if err != nil {
+				t.Fatalf("AnalyzeFile update: %v", err)
+			}
+			merged, err := a.MergeResults(base, update)
+			if err != nil {
+				t.Fatalf("MergeResults: %v", err)
+			}
+			golden.AssertJSON(t, filepath.Join(dir, "merged.golden.json"), merged.Schema)
+		})
+	}
+}
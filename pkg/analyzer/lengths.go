@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Режимы подсчета длины строк для minLength/maxLength
+const (
+	// LengthCodePoints считает кодовые точки Unicode, как валидаторы JSON Schema
+	LengthCodePoints = "codepoints"
+	// LengthGraphemes считает видимые символы: комбинируемые знаки, селекторы
+	// вариантов, модификаторы эмодзи и последовательности с ZWJ не увеличивают длину
+	LengthGraphemes = "graphemes"
+)
+
+// ParseLengthMode проверяет название режима подсчета длины строк
+func ParseLengthMode(mode string) (string, error) {
+	switch mode {
+	case "", LengthCodePoints, LengthGraphemes:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("неизвестный режим подсчета длины: %s. Доступные: %s, %s", mode, LengthCodePoints, LengthGraphemes)
+	}
+}
+
+// StringLength возвращает длину строки в указанном режиме
+func StringLength(value, mode string) int {
+	if mode != LengthGraphemes {
+		return utf8.RuneCountInString(value)
+	}
+	return graphemeCount(value)
+}
+
+// graphemeCount приближенно считает кластеры графем (UAX #29) без внешних
+// таблиц: покрывает комбинируемые диакритики, эмодзи с модификаторами и ZWJ,
+// флаги из региональных индикаторов и перевод строки CRLF
+func graphemeCount(value string) int {
+	count := 0
+	joined := false   // предыдущий символ - ZWJ
+	openFlag := false // начат флаг из одного регионального индикатора
+	var prev rune
+	for _, r := range value {
+		switch {
+		case prev == '\r' && r == '\n', joined:
+			// CRLF и символ после ZWJ продолжают текущую графему
+		case isExtending(r):
+			if count == 0 {
+				count++
+			}
+		case isRegionalIndicator(r) && openFlag:
+			// Второй региональный индикатор завершает флаг
+			openFlag = false
+		default:
+			count++
+			openFlag = isRegionalIndicator(r)
+		}
+
+		joined = r == '\u200d'
+		prev = r
+	}
+	return count
+}
+
+// isExtending сообщает, что символ присоединяется к предыдущей графеме
+func isExtending(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == '\u200d' || r == '\u200c' ||
+		(r >= '\ufe00' && r <= '\ufe0f') || // селекторы вариантов
+		(r >= 0x1f3fb && r <= 0x1f3ff) || // модификаторы оттенка кожи
+		(r >= 0xe0020 && r <= 0xe007f) // теги эмодзи
+}
+
+// isRegionalIndicator сообщает, что символ - региональный индикатор флага
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
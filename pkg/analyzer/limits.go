@@ -0,0 +1,244 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// DefaultMaxInputSize - предел размера входных данных по умолчанию. Разобранный
+// JSON занимает в памяти в несколько раз больше исходного текста, поэтому
+// файлы крупнее анализируются только по явному разрешению
+const DefaultMaxInputSize = 512 << 20
+
+// DefaultMaxDepth - предел вложенности объектов и массивов по умолчанию.
+// Реальные документы вложены на десятки уровней, а рекурсивный разбор и
+// анализ искусственно глубоких входных данных может исчерпать стек
+const DefaultMaxDepth = 1000
+
+// Поведение анализа при вложенности глубже MaxDepth
+const (
+	// DepthError завершает анализ ошибкой LimitError (по умолчанию)
+	DepthError = "error"
+	// DepthTruncate описывает объекты и массивы глубже MaxDepth только типом,
+	// без вложенной структуры, и отмечает их расширением x-truncated
+	DepthTruncate = "truncate"
+)
+
+// ParseDepthPolicy проверяет название поведения при превышении вложенности
+func ParseDepthPolicy(policy string) (string, error) {
+	switch policy {
+	case "", DepthError, DepthTruncate:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("неизвестное поведение при превышении вложенности: %s. Доступные: %s, %s", policy, DepthError, DepthTruncate)
+	}
+}
+
+// Виды лимитов входных данных
+const (
+	// LimitSize - размер входных данных в байтах
+	LimitSize = "size"
+	// LimitRecords - число записей верхнего уровня: элементов корневого
+	// массива или массива data
+	LimitRecords = "records"
+	// LimitDepth - вложенность объектов и массивов
+	LimitDepth = "depth"
+)
+
+// LimitError сообщает, что входные данные превышают лимит анализатора.
+// Анализ при этом не выполняется, чтобы не исчерпать память
+type LimitError struct {
+	// Kind - вид лимита: LimitSize, LimitRecords или LimitDepth
+	Kind string
+	// Actual - размер, число записей или вложенность входных данных; для
+	// вложенности - первая глубина сверх лимита, дальше вход не читается
+	Actual int64
+	// Limit - установленный лимит
+	Limit int64
+}
+
+func (e *LimitError) Error() string {
+	switch e.Kind {
+	case LimitRecords:
+		return fmt.Sprintf("входные данные содержат %d записей, лимит - %d", e.Actual, e.Limit)
+	case LimitDepth:
+		return fmt.Sprintf("вложенность входных данных превышает лимит %d уровней", e.Limit)
+	}
+	if e.Actual <= 0 {
+		// Распакованные данные читаются только до лимита
+		return fmt.Sprintf("размер распакованных входных данных превышает лимит %s", FormatSize(e.Limit))
+	}
+	return fmt.Sprintf("размер входных данных %s превышает лимит %s", FormatSize(e.Actual), FormatSize(e.Limit))
+}
+
+// CheckFileSize проверяет размер файла до чтения, чтобы не загружать в
+// память файлы крупнее MaxInputSize
+func (a *Analyzer) CheckFileSize(filename string) error {
+	if a.config.MaxInputSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	return a.checkSize(info.Size())
+}
+
+// ReadInput читает входной файл в память с учетом MaxInputSize. Размер
+// несжатого файла проверяется до чтения; сжатый файл (gzip, zstd)
+// распаковывается, и лимит применяется к распакованным данным, которые
+// читаются не дальше лимита
+func (a *Analyzer) ReadInput(filename string) ([]byte, error) {
+	if err := a.canceled(); err != nil {
+		return nil, err
+	}
+	format, err := decompress.DetectFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	if format == "" {
+		// Слишком большой файл не читаем вовсе
+		if err := a.CheckFileSize(filename); err != nil {
+			return nil, err
+		}
+		data, err := fileutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+		}
+		return data, nil
+	}
+
+	reader, err := decompress.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := decompress.ReadAll(a.reader(reader), a.config.MaxInputSize)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка распаковки %s: %w", format, err)
+	}
+	if a.config.MaxInputSize > 0 && int64(len(data)) > a.config.MaxInputSize {
+		return nil, &LimitError{Kind: LimitSize, Limit: a.config.MaxInputSize}
+	}
+	return data, nil
+}
+
+// checkSize сравнивает размер входных данных с MaxInputSize
+func (a *Analyzer) checkSize(size int64) error {
+	if a.config.MaxInputSize > 0 && size > a.config.MaxInputSize {
+		return &LimitError{Kind: LimitSize, Actual: size, Limit: a.config.MaxInputSize}
+	}
+	return nil
+}
+
+// checkRecords сравнивает число записей верхнего уровня с MaxRecords
+func (a *Analyzer) checkRecords(data interface{}) error {
+	if a.config.MaxRecords <= 0 {
+		return nil
+	}
+	if count := countRecords(data); count > a.config.MaxRecords {
+		return &LimitError{Kind: LimitRecords, Actual: int64(count), Limit: int64(a.config.MaxRecords)}
+	}
+	return nil
+}
+
+// tooDeep сообщает, что объект или массив на глубине текущего значения
+// состояния не анализируется: его вложенность превышает MaxDepth
+func (a *Analyzer) tooDeep(st *state) bool {
+	return a.config.MaxDepth > 0 && st.depth >= a.config.MaxDepth
+}
+
+// truncated возвращает схему объекта или массива kind глубже MaxDepth:
+// только тип с отметкой x-truncated или, без DepthTruncate, LimitError
+func (a *Analyzer) truncated(kind types.JSONType, st *state) (*types.Property, error) {
+	if a.config.DepthPolicy != DepthTruncate {
+		return nil, &LimitError{Kind: LimitDepth, Actual: int64(a.config.MaxDepth) + 1, Limit: int64(a.config.MaxDepth)}
+	}
+	st.stats.TypeDistribution[string(kind)]++
+	property := st.property(string(kind))
+	property.Truncated = true
+	return property, nil
+}
+
+// truncates сообщает, что объекты и массивы глубже MaxDepth описываются
+// только типом. json.Decoder разбирает значение целиком и отказывает на
+// вложенности глубже 10000 уровней, поэтому такой вход читается сканером,
+// который не разбирает значения глубже MaxDepth (см. scanner.value)
+func (a *Analyzer) truncates() bool {
+	return a.config.DepthPolicy == DepthTruncate && a.config.MaxDepth > 0
+}
+
+// truncateDepth возвращает глубину обрезки для метаданных схемы: MaxDepth
+// при DepthTruncate, иначе 0
+func (a *Analyzer) truncateDepth() int {
+	if a.config.DepthPolicy != DepthTruncate {
+		return 0
+	}
+	return a.config.MaxDepth
+}
+
+// countRecords считает записи верхнего уровня: элементы корневого массива
+// или массива data; одиночный объект - одна запись
+func countRecords(data interface{}) int {
+	switch v := data.(type) {
+	case []interface{}:
+		return len(v)
+	case map[string]interface{}:
+		if records, ok := v["data"].([]interface{}); ok {
+			return len(records)
+		}
+	}
+	return 1
+}
+
+// sizeUnits - суффиксы размеров в порядке проверки: сначала длинные
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize разбирает размер вида 512MB, 2GiB или 1048576; суффиксы
+// двоичные (1MB = 1024 KB). 0 отключает лимит
+func ParseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	factor := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, factor = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("неверный размер: %s (пример: 512MB, 2GB)", value)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+// FormatSize выводит размер в наиболее подходящих единицах: 512MB, 1.5GB
+func FormatSize(size int64) string {
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}} {
+		if size >= unit.factor {
+			value := math.Round(float64(size)/float64(unit.factor)*10) / 10
+			return strconv.FormatFloat(value, 'f', -1, 64) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
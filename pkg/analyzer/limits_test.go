@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// decoderDepth - вложенность, которую json.Decoder уже не разбирает
+const decoderDepth = 20000
+
+func TestDepthTruncateBeyondDecoder(t *testing.T) {
+	deep := []byte(strings.Repeat(`{"a":`, decoderDepth) + "1" + strings.Repeat("}", decoderDepth))
+	records := append(append([]byte{}, deep...), "\n{\"b\": 2}\n"...)
+
+	tests := []struct {
+		name    string
+		workers int
+		analyze func(a *Analyzer) (*types.AnalysisResult, error)
+		records bool
+	}{
+		{"bytes", 1, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeBytes(deep) }, false},
+		{"stream", 1, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeStream(bytes.NewReader(deep)) }, false},
+		{"ndjson", 1, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeNDJSON(bytes.NewReader(records)) }, true},
+		{"ndjson/workers=4", 4, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeNDJSON(bytes.NewReader(records)) }, true},
+		{"concatenated", 1, func(a *Analyzer) (*types.AnalysisResult, error) { return a.AnalyzeBytes(records) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.MaxDepth, config.DepthPolicy, config.Workers = 3, DepthTruncate, tt.workers
+			result, err := tt.analyze(NewWithConfig(config))
+			if err != nil {
+				t.Fatalf("анализ: %v", err)
+			}
+
+			// Корень на глубине 0, записи NDJSON - на глубине 1
+			prop := &types.Property{Type: result.Schema.Type, Properties: result.Schema.Properties, Items: result.Schema.Items}
+			first := 1
+			if tt.records {
+				prop, first = prop.Items, 2
+			}
+			for depth := first; depth <= config.MaxDepth; depth++ {
+				if prop = prop.Properties["a"]; prop == nil {
+					t.Fatalf("нет поля a на глубине %d", depth)
+				}
+			}
+			if !prop.Truncated || prop.Type != "object" {
+				t.Errorf("поле на глубине %d = {type %q, truncated %v}, want обрезанный объект", config.MaxDepth, prop.Type, prop.Truncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/loginput"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// AnalyzeLog анализирует JSON, встроенный в строки текстового журнала, как
+// записи NDJSON - по одной на строку с JSON (см. loginput.Extract). Строки
+// без JSON пропускаются; журнал без единой такой строки - ошибка. Выборка,
+// лимит записей и Workers действуют так же, как для NDJSON, а MaxInputSize
+// не применяется
+func (a *Analyzer) AnalyzeLog(r io.Reader) (*types.AnalysisResult, error) {
+	opts := loginput.Options{Prefix: a.config.LogPrefix}
+
+	// Записи передаются анализу NDJSON через канал, как строки таблицы CSV
+	pr, pw := io.Pipe()
+	type extracted struct {
+		stats *loginput.Stats
+		err   error
+	}
+	done := make(chan extracted, 1)
+	go func() {
+		stats, err := loginput.Extract(r, opts, pw)
+		pw.CloseWithError(err)
+		done <- extracted{stats: stats, err: err}
+	}()
+	result, err := a.AnalyzeNDJSON(pr)
+	pr.Close()
+
+	// Ошибка чтения журнала важнее ошибки разбора оборванных записей
+	out := <-done
+	if out.err != nil && !errors.Is(out.err, io.ErrClosedPipe) {
+		return nil, out.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if out.stats.Records == 0 {
+		if opts.Prefix != "" {
+			return nil, fmt.Errorf("в журнале нет строк с JSON после %q (строк: %d)", opts.Prefix, out.stats.Lines)
+		}
+		return nil, fmt.Errorf("в журнале нет строк с JSON (строк: %d)", out.stats.Lines)
+	}
+	return result, nil
+}
+
+// analyzeFileLog анализирует файл журнала, при необходимости распаковывая его
+func (a *Analyzer) analyzeFileLog(filename string) (*types.AnalysisResult, error) {
+	file, err := decompress.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	defer file.Close()
+
+	return a.AnalyzeLog(file)
+}
@@ -0,0 +1,97 @@
+package analyzer
+
+import "github.com/yanodincov/json-schema-detector/pkg/types"
+
+// mergeItems добавляет схему элемента к items массива. Элементы несовместимых
+// типов (строки вместе с объектами) в режиме MixedItems описываются вариантами
+// items.anyOf - по одному на каждый наблюдаемый тип; иначе несовместимый тип
+// записывается как конфликт, а в схеме остается первый
+func (a *Analyzer) mergeItems(array, item *types.Property, path string, st *state) {
+	switch {
+	case array.Items == nil:
+		array.Items = item
+	case mixedItems(item):
+		for _, variant := range item.AnyOf {
+			if variant != nil {
+				a.mergeItems(array, variantProperty(variant), path, st)
+			}
+		}
+	case mixedItems(array.Items):
+		a.addVariant(array.Items, item, path, st)
+	case !a.config.MixedItems || types.CompatibleTypes(array.Items.Type, item.Type):
+		a.mergeProperty(array.Items, item, path, st)
+	default:
+		array.Items = &types.Property{AnyOf: []*types.JSONSchema{variantSchema(array.Items), variantSchema(item)}}
+	}
+}
+
+// addVariant объединяет элемент с вариантом того же типа или добавляет новый вариант
+func (a *Analyzer) addVariant(mixed, item *types.Property, path string, st *state) {
+	for i, variant := range mixed.AnyOf {
+		if variant == nil || variant.Ref != "" || !types.SameKind(variant.Type, item.Type) {
+			continue
+		}
+		merged := variantProperty(variant)
+		a.mergeProperty(merged, item, path, st)
+		mixed.AnyOf[i] = variantSchema(merged)
+		return
+	}
+	mixed.AnyOf = append(mixed.AnyOf, variantSchema(item))
+}
+
+// mixedItems сообщает, что схема элементов - набор вариантов anyOf без общего типа
+func mixedItems(prop *types.Property) bool {
+	return prop != nil && prop.Type == "" && prop.Ref == "" && len(prop.AnyOf) > 0
+}
+
+// variantSchema представляет схему элемента как вариант anyOf
+func variantSchema(prop *types.Property) *types.JSONSchema {
+	return &types.JSONSchema{
+		Ref:         prop.Ref,
+		Type:        prop.Type,
+		Properties:  prop.Properties,
+		Items:       prop.Items,
+		Required:    prop.Required,
+		Enum:        prop.Enum,
+		Format:      prop.Format,
+		OneOf:       prop.OneOf,
+		AnyOf:       prop.AnyOf,
+		Title:       prop.Title,
+		Description: prop.Description,
+		MinItems:    prop.MinItems,
+		MaxItems:    prop.MaxItems,
+		UniqueItems: prop.UniqueItems,
+		Default:     prop.Default,
+		Extensions:  prop.Extensions,
+
+		PropertyOrder:        prop.PropertyOrder,
+		PatternProperties:    prop.PatternProperties,
+		AdditionalProperties: prop.AdditionalProperties,
+	}
+}
+
+// variantProperty представляет вариант anyOf как схему элемента
+func variantProperty(schema *types.JSONSchema) *types.Property {
+	return &types.Property{
+		Ref:         schema.Ref,
+		Type:        schema.Type,
+		Properties:  schema.Properties,
+		Items:       schema.Items,
+		Required:    schema.Required,
+		Enum:        schema.Enum,
+		Format:      schema.Format,
+		OneOf:       schema.OneOf,
+		AnyOf:       schema.AnyOf,
+		Title:       schema.Title,
+		Description: schema.Description,
+		MinItems:    schema.MinItems,
+		MaxItems:    schema.MaxItems,
+		UniqueItems: schema.UniqueItems,
+		Default:     schema.Default,
+		Extensions:  schema.Extensions,
+
+		PropertyOrder:        schema.PropertyOrder,
+		PatternProperties:    schema.PatternProperties,
+		AdditionalProperties: schema.AdditionalProperties,
+	}
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// NullRate описывает долю null значений поля
+type NullRate struct {
+	Field    string  `json:"field"`
+	Nulls    int     `json:"nulls"`
+	Presence int     `json:"presence"`
+	Rate     float64 `json:"rate"`
+}
+
+// NullRates возвращает поля, которые были null не реже чем в minPercent
+// процентах случаев присутствия, по убыванию доли null
+func NullRates(stats *types.AnalysisStatistics, minPercent float64) []NullRate {
+	if stats == nil {
+		return nil
+	}
+
+	var rates []NullRate
+	for field, nulls := range stats.FieldNulls {
+		presence := stats.FieldPresence[field]
+		if presence == 0 || nulls == 0 {
+			continue
+		}
+		rate := float64(nulls) / float64(presence)
+		if rate*100 < minPercent {
+			continue
+		}
+		rates = append(rates, NullRate{Field: field, Nulls: nulls, Presence: presence, Rate: rate})
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Rate != rates[j].Rate {
+			return rates[i].Rate > rates[j].Rate
+		}
+		return rates[i].Field < rates[j].Field
+	})
+	return rates
+}
@@ -0,0 +1,57 @@
+package analyzer
+
+// Option изменяет настройки анализатора, создаваемого New. Это функция
+// над Config, поэтому любую настройку без готовой опции можно передать
+// своей функцией
+type Option func(*Config)
+
+// WithConfig заменяет настройки целиком; следующие опции применяются
+// поверх них
+func WithConfig(config Config) Option {
+	return func(c *Config) { *c = config }
+}
+
+// WithSampleRecords анализирует только первые n записей верхнего уровня
+// (см. Config.SampleRecords)
+func WithSampleRecords(n int) Option {
+	return func(c *Config) { c.SampleRecords = n }
+}
+
+// WithSampleRate анализирует случайную долю rate записей верхнего уровня;
+// seed инициализирует генератор выборки (см. Config.SampleRate)
+func WithSampleRate(rate float64, seed int64) Option {
+	return func(c *Config) { c.SampleRate, c.SampleSeed = rate, seed }
+}
+
+// WithMaxArraySamples ограничивает число элементов массива, по которым
+// выводится схема items; 0 - все элементы
+func WithMaxArraySamples(n int) Option {
+	return func(c *Config) { c.MaxArraySamples = n }
+}
+
+// WithFormatDetection включает или выключает вывод format строк
+func WithFormatDetection(enabled bool) Option {
+	return func(c *Config) { c.DetectFormats = enabled }
+}
+
+// WithFormatDetectors задает детекторы форматов строк и включает вывод
+// format (см. DefaultFormatDetectors)
+func WithFormatDetectors(detectors ...FormatDetector) Option {
+	return func(c *Config) { c.DetectFormats, c.FormatDetectors = true, detectors }
+}
+
+// WithInputFormat задает формат входных файлов: FormatJSON, FormatCSV,
+// FormatYAML, FormatXML или FormatLog; пустая строка - по расширению
+func WithInputFormat(format string) Option {
+	return func(c *Config) { c.Format = format }
+}
+
+// WithStream включает потоковый анализ файлов (см. Config.Stream)
+func WithStream(enabled bool) Option {
+	return func(c *Config) { c.Stream = enabled }
+}
+
+// WithWorkers задает число параллельных обработчиков; 0 - GOMAXPROCS
+func WithWorkers(n int) Option {
+	return func(c *Config) { c.Workers = n }
+}
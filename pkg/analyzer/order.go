@@ -0,0 +1,240 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// Порядок полей объектов в сохраняемой схеме
+const (
+	// PropertyAlphabetical упорядочивает поля по алфавиту (по умолчанию)
+	PropertyAlphabetical = "alphabetical"
+	// PropertyFirstSeen сохраняет порядок, в котором поля встретились в
+	// данных; поля, добавленные обновлениями, следуют за прежними
+	PropertyFirstSeen = "first-seen"
+)
+
+// ParsePropertyOrder проверяет название порядка полей
+func ParsePropertyOrder(order string) (string, error) {
+	switch order {
+	case "", PropertyAlphabetical, PropertyFirstSeen:
+		return order, nil
+	default:
+		return "", fmt.Errorf("неизвестный порядок полей: %s. Доступные: %s, %s", order, PropertyAlphabetical, PropertyFirstSeen)
+	}
+}
+
+// keyOrder - порядок, в котором поля объектов встретились во входных
+// данных, по пути объекта. Разобранные значения JSON хранят объекты в
+// словарях, поэтому порядок собирается отдельным проходом по токенам входа
+type keyOrder struct {
+	keys map[string][]string
+	seen map[[2]string]bool
+}
+
+func newKeyOrder() *keyOrder {
+	return &keyOrder{keys: make(map[string][]string), seen: make(map[[2]string]bool)}
+}
+
+// add запоминает поле key объекта по пути path, если оно встретилось впервые.
+// Пути объектов-словарей с произвольными ключами не должны раздувать порядок,
+// поэтому после maxInternedKeys путей новые пути не запоминаются
+func (o *keyOrder) add(path, key string) {
+	if o.seen[[2]string{path, key}] {
+		return
+	}
+	if _, ok := o.keys[path]; !ok && len(o.keys) >= maxInternedKeys {
+		return
+	}
+	o.seen[[2]string{path, key}] = true
+	o.keys[path] = append(o.keys[path], key)
+}
+
+// scan читает значения из потока до конца и запоминает порядок полей их
+// объектов не глубже maxDepth; root - путь каждого значения верхнего уровня
+// ("" для документа JSON, "[0]" для записей NDJSON)
+func (o *keyOrder) scan(r io.Reader, root string, maxDepth int) {
+	sc := newScanner(newStreamReader(r), maxDepth)
+	for {
+		if _, err := sc.peek(); err != nil {
+			return
+		}
+		if err := o.value(sc, root); err != nil {
+			return
+		}
+	}
+}
+
+// value читает значение по пути path
+func (o *keyOrder) value(sc *scanner, path string) error {
+	c, err := sc.peek()
+	if err != nil {
+		return err
+	}
+	if sc.tooDeep() {
+		return sc.skip()
+	}
+	switch c {
+	case '{':
+		return sc.object(func(key string) error {
+			o.add(path, key)
+			return o.value(sc, path+"."+key)
+		})
+	case '[':
+		return sc.array(func() error {
+			return o.value(sc, path+"[0]")
+		})
+	}
+	return sc.skip()
+}
+
+// orderBytes возвращает порядок полей документа data или nil, если поля
+// упорядочиваются по алфавиту
+func (a *Analyzer) orderBytes(data []byte, root string) func() *keyOrder {
+	if a.config.PropertyOrder != PropertyFirstSeen {
+		return nil
+	}
+	order := newKeyOrder()
+	order.scan(bytes.NewReader(data), root, a.config.MaxDepth)
+	return func() *keyOrder { return order }
+}
+
+// orderReader возвращает поток, при чтении которого параллельно собирается
+// порядок полей, и функцию, которая дожидается конца сбора и возвращает
+// порядок. При упорядочивании по алфавиту поток возвращается как есть, а
+// функция равна nil
+func (a *Analyzer) orderReader(r io.Reader, root string) (io.Reader, func() *keyOrder) {
+	if a.config.PropertyOrder != PropertyFirstSeen {
+		return r, nil
+	}
+
+	order := newKeyOrder()
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		order.scan(pr, root, a.config.MaxDepth)
+		// Анализ может прочитать больше, чем разобрал сбор порядка
+		io.Copy(io.Discard, pr)
+	}()
+	return io.TeeReader(r, pw), func() *keyOrder {
+		pw.Close()
+		<-done
+		return order
+	}
+}
+
+// withOrder задает источник порядка полей состояния; nil - поля по алфавиту
+func (s *state) withOrder(order func() *keyOrder) *state {
+	s.order = order
+	return s
+}
+
+// apply записывает узлам схемы порядок полей из входных данных
+func (o *keyOrder) apply(prop *types.Property, path string) {
+	if prop == nil {
+		return
+	}
+	if len(prop.Properties) > 0 {
+		prop.PropertyOrder = o.ordered(path, prop.Properties)
+	}
+	for key, child := range prop.Properties {
+		o.apply(child, path+"."+key)
+	}
+	o.apply(prop.Items, path+"[0]")
+	for _, position := range prop.PrefixItems {
+		o.apply(position, path+"[0]")
+	}
+	o.applyVariants(prop.OneOf, path)
+	o.applyVariants(prop.AnyOf, path)
+}
+
+// applySchema записывает порядок полей корню схемы и его потомкам
+func (o *keyOrder) applySchema(schema *types.JSONSchema) {
+	root := &types.Property{Properties: schema.Properties, Items: schema.Items, OneOf: schema.OneOf, AnyOf: schema.AnyOf}
+	o.apply(root, "")
+	schema.PropertyOrder = root.PropertyOrder
+}
+
+// applyVariants записывает порядок полей вариантам oneOf и anyOf
+func (o *keyOrder) applyVariants(variants []*types.JSONSchema, path string) {
+	for _, variant := range variants {
+		if variant == nil {
+			continue
+		}
+		if len(variant.Properties) > 0 {
+			variant.PropertyOrder = o.ordered(path, variant.Properties)
+		}
+		for key, child := range variant.Properties {
+			o.apply(child, path+"."+key)
+		}
+		o.apply(variant.Items, path+"[0]")
+		o.applyVariants(variant.OneOf, path)
+		o.applyVariants(variant.AnyOf, path)
+	}
+}
+
+// ordered возвращает поля props, встреченные по пути path, в порядке
+// появления
+func (o *keyOrder) ordered(path string, props map[string]*types.Property) []string {
+	var order []string
+	for _, key := range o.keys[path] {
+		if _, ok := props[key]; ok {
+			order = append(order, key)
+		}
+	}
+	return order
+}
+
+// mergeOrder дополняет порядок полей existing полями new, которых в нем
+// еще нет, в порядке new
+func mergeOrder(existing, new []string) []string {
+	if len(new) == 0 {
+		return existing
+	}
+	existing = slices.Clip(existing)
+	listed := make(map[string]bool, len(existing))
+	for _, key := range existing {
+		listed[key] = true
+	}
+	for _, key := range new {
+		if !listed[key] {
+			listed[key] = true
+			existing = append(existing, key)
+		}
+	}
+	return existing
+}
+
+// clearOrder убирает порядок полей из всех узлов схемы, чтобы поля
+// сохранялись по алфавиту
+func clearOrder(schema *types.JSONSchema) {
+	if schema == nil {
+		return
+	}
+	schema.PropertyOrder = nil
+	clearVariantsOrder(schema.OneOf)
+	clearVariantsOrder(schema.AnyOf)
+	_ = walk.Walk(schema, func(_ string, prop *types.Property) error {
+		prop.PropertyOrder = nil
+		clearVariantsOrder(prop.OneOf)
+		clearVariantsOrder(prop.AnyOf)
+		return nil
+	})
+}
+
+// clearVariantsOrder убирает порядок полей вариантов oneOf и anyOf
+func clearVariantsOrder(variants []*types.JSONSchema) {
+	for _, variant := range variants {
+		if variant != nil {
+			variant.PropertyOrder = nil
+			clearVariantsOrder(variant.OneOf)
+			clearVariantsOrder(variant.AnyOf)
+		}
+	}
+}
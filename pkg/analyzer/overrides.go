@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// overrideTypes - типы, которые можно задать в файле переопределений
+var overrideTypes = map[string]bool{
+	string(types.TypeString):  true,
+	string(types.TypeNumber):  true,
+	string(types.TypeInteger): true,
+	string(types.TypeBoolean): true,
+	string(types.TypeObject):  true,
+	string(types.TypeArray):   true,
+	string(types.TypeNull):    true,
+}
+
+// IsOverrideType сообщает, что тип можно задать полю принудительно
+func IsOverrideType(name string) bool {
+	return overrideTypes[name]
+}
+
+// LoadOverrides читает файл переопределений: JSON объект, сопоставляющий
+// шаблону пути тип и/или формат поля, например
+//
+//	{"data.*.id": {"type": "string"}, "data.*.created": {"format": "date-time"}}
+//
+// Пути записываются в формате fieldmanager; сегмент "*" соответствует любому
+// одному сегменту: индексу массива, имени поля или значению словаря
+func LoadOverrides(filename string) (map[string]types.Override, error) {
+	data, err := fileutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла переопределений: %w", err)
+	}
+
+	var overrides map[string]types.Override
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга файла переопределений %s: %w", filename, err)
+	}
+
+	for path, override := range overrides {
+		if strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("файл переопределений %s: пустой путь", filename)
+		}
+		if override.Type == "" && override.Format == "" {
+			return nil, fmt.Errorf("файл переопределений %s: для %s не задан ни type, ни format", filename, path)
+		}
+		if override.Type != "" && !overrideTypes[override.Type] {
+			return nil, fmt.Errorf("файл переопределений %s: неизвестный тип %q для %s", filename, override.Type, path)
+		}
+	}
+	return overrides, nil
+}
+
+// applyOverrides применяет переопределения к полям схемы последним проходом
+// анализа. Конфликты типов переопределенных полей снимаются: тип задан явно
+func applyOverrides(schema *types.JSONSchema, overrides map[string]types.Override, stats *types.AnalysisStatistics) {
+	if len(overrides) == 0 {
+		return
+	}
+
+	_ = walk.Walk(schema, func(path string, prop *types.Property) error {
+		for pattern, override := range overrides {
+			if !matchPath(pattern, path) {
+				continue
+			}
+			overrideApply(override, prop)
+			if override.Type != "" && stats != nil {
+				delete(stats.TypeConflicts, path)
+			}
+		}
+		return nil
+	})
+}
+
+// ApplyOverrides применяет переопределения к полям готовой схемы и снимает
+// конфликты типов переопределенных полей в статистике
+func ApplyOverrides(schema *types.JSONSchema, overrides map[string]types.Override, stats *types.AnalysisStatistics) {
+	applyOverrides(schema, overrides, stats)
+}
+
+// mergeOverrides объединяет переопределения схемы и новых данных; при
+// совпадении шаблона пути побеждают новые
+func mergeOverrides(existing, new map[string]types.Override) map[string]types.Override {
+	if len(new) == 0 {
+		return existing
+	}
+	if existing == nil {
+		existing = make(map[string]types.Override, len(new))
+	}
+	for path, override := range new {
+		existing[path] = override
+	}
+	return existing
+}
+
+// matchPath сопоставляет путь поля с шаблоном, в котором "*" - любой сегмент
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.TrimPrefix(pattern, "."), ".")
+	pathSegments := strings.Split(path, ".")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// overrideApply задает полю тип и формат. При смене типа ограничения и примеры,
+// выведенные из значений прежнего типа, убираются: они ему уже не соответствуют
+func overrideApply(o types.Override, prop *types.Property) {
+	if o.Type != "" && o.Type != prop.Type {
+		if !types.SameKind(o.Type, prop.Type) {
+			prop.Default = nil
+			prop.Examples = nil
+			prop.Enum = nil
+			prop.Format = ""
+			prop.Pattern = ""
+			prop.MinLength, prop.MaxLength = nil, nil
+			prop.Minimum, prop.Maximum = "", ""
+			prop.ObservedRange = nil
+		}
+		if o.Type != string(types.TypeObject) {
+			prop.Properties, prop.Required, prop.AdditionalProperties = nil, nil, nil
+		}
+		if o.Type != string(types.TypeArray) {
+			prop.Items, prop.PrefixItems, prop.ClosedItems = nil, nil, false
+			prop.MinItems, prop.MaxItems, prop.UniqueItems = nil, nil, false
+		}
+		prop.Type = o.Type
+	}
+	if o.Type != "" {
+		// При обновлении значения прежнего типа могли снова попасть в default и примеры
+		if prop.Default != nil && !fitsType(prop.Type, prop.Default) {
+			prop.Default = nil
+		}
+		prop.Examples = filterValues(prop.Type, prop.Examples)
+		prop.Enum = filterValues(prop.Type, prop.Enum)
+	}
+	if o.Format != "" {
+		prop.Format = o.Format
+	}
+}
+
+// filterValues оставляет значения, соответствующие типу
+func filterValues(kind string, values []interface{}) []interface{} {
+	var result []interface{}
+	for _, value := range values {
+		if fitsType(kind, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// fitsType сообщает, что декодированное JSON значение соответствует типу схемы
+func fitsType(kind string, value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return kind == string(types.TypeString)
+	case bool:
+		return kind == string(types.TypeBoolean)
+	case json.Number:
+		return kind == string(types.TypeNumber) || kind == string(types.TypeInteger) && isInteger(v)
+	case float64:
+		return kind == string(types.TypeNumber) || kind == string(types.TypeInteger) && v == float64(int64(v))
+	case map[string]interface{}:
+		return kind == string(types.TypeObject)
+	case []interface{}:
+		return kind == string(types.TypeArray)
+	case nil:
+		return kind == string(types.TypeNull)
+	}
+	return false
+}
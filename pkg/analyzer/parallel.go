@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// ndjsonBatch - сколько записей NDJSON анализирует один обработчик при
+// параллельном анализе
+const ndjsonBatch = 2048
+
+// task - анализ одной части входных данных: файла или пакета записей
+type task func() (*types.AnalysisResult, error)
+
+// outcome - результат задачи
+type outcome struct {
+	result *types.AnalysisResult
+	err    error
+}
+
+// workers возвращает число параллельных обработчиков: Config.Workers или,
+// если он не задан, GOMAXPROCS
+func (a *Analyzer) workers() int {
+	if a.config.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return a.config.Workers
+}
+
+// parallel выполняет задачи, которые produce передает в emit, не более чем
+// в workers горутинах, и передает их результаты в merge в порядке задач,
+// поэтому итоговая схема не зависит от того, какая задача завершится
+// раньше. Задачи, ожидающие объединения, тоже занимают обработчик, так что
+// в памяти одновременно не больше workers частей входа. После первой ошибки
+// emit возвращает false, и produce должен завершиться
+func parallel(workers int, produce func(emit func(task) bool), merge func(*types.AnalysisResult) error) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	slots := make(chan chan outcome, max(workers-1, 0))
+	go func() {
+		defer close(slots)
+		produce(func(t task) bool {
+			slot := make(chan outcome, 1)
+			select {
+			case slots <- slot:
+			case <-done:
+				return false
+			}
+			go func() {
+				result, err := t()
+				slot <- outcome{result: result, err: err}
+			}()
+			return true
+		})
+	}()
+
+	for slot := range slots {
+		out := <-slot
+		if out.err != nil {
+			return out.err
+		}
+		if err := merge(out.result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analyzeNDJSONParallel анализирует поток NDJSON пакетами по ndjsonBatch
+// записей в нескольких обработчиках и объединяет схемы пакетов в порядке
+// записей, как при обновлении схемы. Поток читается одной горутиной без
+// разбора значений, разбор и анализ записей выполняют обработчики. Выборка
+// и лимиты записей применяются ко всему потоку, как при последовательном
+// анализе. Порядок полей, если он нужен, возвращает order
+func (a *Analyzer) analyzeNDJSONParallel(r io.Reader, order func() *keyOrder) (*types.AnalysisResult, error) {
+	dec := json.NewDecoder(newStreamReader(r))
+	sampler := a.newSampler()
+
+	// Пакет анализируется как массив в памяти; выборку и лимиты записей
+	// уже применило чтение потока
+	config := a.config
+	config.Workers, config.SampleRecords, config.SampleRate = 1, 0, 0
+	config.MaxRecords = 0
+	config.PropertyOrder = ""
+	batch := a.withConfig(config)
+
+	count, batches := 0, 0
+	produce := func(emit func(task) bool) {
+		var records []json.RawMessage
+		flush := func() bool {
+			if len(records) == 0 {
+				return true
+			}
+			pending := records
+			records = nil
+			batches++
+			return emit(func() (*types.AnalysisResult, error) {
+				return batch.analyzeRaw(pending)
+			})
+		}
+
+		for dec.More() {
+			if sampler.full() {
+				sampler.stop()
+				break
+			}
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				emit(func() (*types.AnalysisResult, error) {
+					return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+				})
+				return
+			}
+			if !sampler.take() {
+				continue
+			}
+
+			// Сверх лимитов записи только считаются
+			count++
+			if a.config.MaxRecords > 0 && count > a.config.MaxRecords {
+				continue
+			}
+			records = append(records, raw)
+			if len(records) == ndjsonBatch && !flush() {
+				return
+			}
+		}
+		if !sampler.stopped() {
+			if err := checkEnd(dec); err != nil {
+				emit(func() (*types.AnalysisResult, error) { return nil, err })
+				return
+			}
+		}
+		flush()
+	}
+
+	var merged *types.AnalysisResult
+	err := parallel(a.workers(), produce, func(result *types.AnalysisResult) error {
+		if merged == nil {
+			merged = result
+			return nil
+		}
+		var err error
+		merged, err = batch.MergeResults(merged, result)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if a.config.MaxRecords > 0 && count > a.config.MaxRecords {
+		return nil, &LimitError{Kind: LimitRecords, Actual: int64(count), Limit: int64(a.config.MaxRecords)}
+	}
+
+	if merged == nil {
+		if merged, err = batch.analyzeData([]interface{}{}); err != nil {
+			return nil, err
+		}
+	}
+	// Каждый пакет учел корневой массив отдельно
+	if batches > 1 {
+		merged.Statistics.TypeDistribution["array"] -= batches - 1
+	}
+	merged.Metadata.Sampling = sampler.metadata()
+	if order != nil {
+		order().applySchema(merged.Schema)
+		merged.Metadata.PropertyOrder = a.config.PropertyOrder
+	}
+	return merged, nil
+}
+
+// analyzeRaw разбирает записи пакета и анализирует их как корневой массив
+func (a *Analyzer) analyzeRaw(records []json.RawMessage) (*types.AnalysisResult, error) {
+	values := make([]interface{}, len(records))
+	for i, raw := range records {
+		if err := types.Unmarshal(raw, &values[i]); err != nil {
+			return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+		}
+	}
+	return a.analyzeData(values)
+}
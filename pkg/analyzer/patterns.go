@@ -0,0 +1,487 @@
+package analyzer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// maxPatternLength - строки длиннее считаются текстом и не получают pattern
+const maxPatternLength = 128
+
+// Классы символов, из которых складываются шаблоны строк. Шестнадцатеричные
+// буквы выделены отдельно, чтобы хеши получали [0-9a-f], а не [0-9a-z]
+const (
+	classDigit uint8 = 1 << iota
+	classUpperHex
+	classUpperOther
+	classLowerHex
+	classLowerOther
+)
+
+// patternRun - элемент шаблона: литерал или последовательность символов классов
+// class длиной от min до max (max < 0 - без ограничения)
+type patternRun struct {
+	literal byte
+	class   uint8
+	min     int
+	max     int
+}
+
+// patternShape - общая форма значений поля. Без разделителя sep это
+// последовательность runs; с разделителем - список элементов runs[0],
+// разделенных sep (slug, идентификатор с точками)
+type patternShape struct {
+	runs []patternRun
+	sep  byte
+}
+
+// patternMiner накапливает общую форму значений строкового поля
+type patternMiner struct {
+	started  bool
+	fixed    []patternRun
+	fixedOK  bool
+	item     patternRun
+	sep      byte
+	listOK   bool
+	distinct map[string]bool
+}
+
+// recordPattern учитывает значение строкового поля при поиске шаблона.
+// Различные значения считаются до limit - этого достаточно для порога уверенности
+func (s *state) recordPattern(path, value string, limit int) {
+	miner, ok := s.patterns[path]
+	if !ok {
+		miner = &patternMiner{distinct: make(map[string]bool)}
+		s.patterns[path] = miner
+	}
+	miner.add(value)
+	if len(miner.distinct) < limit {
+		miner.distinct[value] = true
+	}
+}
+
+// add объединяет форму значения с накопленной
+func (m *patternMiner) add(value string) {
+	runs, ok := tokenizePattern(value)
+	if !ok {
+		m.started, m.fixedOK, m.listOK = true, false, false
+		return
+	}
+	item, sep, listOK := listRuns(runs)
+
+	if !m.started {
+		m.started = true
+		m.fixed, m.fixedOK = runs, true
+		m.item, m.sep, m.listOK = item, sep, listOK
+		return
+	}
+
+	if m.fixedOK {
+		m.fixed, m.fixedOK = mergeRuns(m.fixed, runs)
+	}
+	if m.listOK && listOK {
+		m.item, m.listOK = mergeRun(m.item, item)
+		switch {
+		case sep == 0:
+		case m.sep == 0:
+			m.sep = sep
+		case m.sep != sep:
+			m.listOK = false
+		}
+	} else {
+		m.listOK = false
+	}
+}
+
+// shape возвращает общую форму всех значений, если она есть
+func (m *patternMiner) shape() (patternShape, bool) {
+	switch {
+	case !m.started:
+		return patternShape{}, false
+	case m.fixedOK:
+		return patternShape{runs: m.fixed}, true
+	case m.listOK && m.sep != 0:
+		return patternShape{runs: []patternRun{m.item}, sep: m.sep}, true
+	}
+	return patternShape{}, false
+}
+
+// applyPatterns выставляет pattern строковым полям, все значения которых имеют
+// общую форму. Шаблон выводится, только если различных значений не меньше
+// minSamples и форма структурирована: содержит разделители или имеет
+// фиксированную длину. Наблюдаемый шаблон без учета порога сохраняется в
+// ObservedPattern для объединения схем при update
+func (s *state) applyPatterns(prop *types.Property, path string, minSamples int) {
+	if prop == nil {
+		return
+	}
+
+	if miner, ok := s.patterns[path]; ok && prop.Type == "string" {
+		if shape, ok := miner.shape(); ok {
+			prop.ObservedPattern = shape.String()
+			if len(miner.distinct) >= minSamples && prop.Format == "" && len(prop.Enum) == 0 && shape.structured() {
+				prop.Pattern = prop.ObservedPattern
+			}
+		}
+	}
+
+	for key, child := range prop.Properties {
+		s.applyPatterns(child, path+"."+key, minSamples)
+	}
+	s.applyPatterns(prop.Items, path+"[0]", minSamples)
+}
+
+// mergePattern сохраняет выведенный шаблон, только если ему соответствуют
+// новые значения. Шаблон, который не удается разобрать, задан вручную и
+// остается без изменений
+func mergePattern(existing, new *types.Property) {
+	if existing.Pattern == "" || new.Type != "string" {
+		return
+	}
+	current, ok := parsePattern(existing.Pattern)
+	if !ok {
+		return
+	}
+	observed, ok := parsePattern(new.ObservedPattern)
+	if !ok {
+		existing.Pattern = ""
+		return
+	}
+	// Значения одинаковой структуры могут оказаться частным случаем списка
+	if current.sep != 0 && observed.sep == 0 {
+		if item, sep, ok := listRuns(observed.runs); ok && (sep == 0 || sep == current.sep) {
+			observed = patternShape{runs: []patternRun{item}, sep: current.sep}
+		}
+	}
+	if merged, ok := mergeShapes(current, observed); !ok || merged.String() != existing.Pattern {
+		existing.Pattern = ""
+	}
+}
+
+// tokenizePattern разбивает строку на последовательности букв и цифр и
+// литералы между ними. Строки с символами вне ASCII не разбираются
+func tokenizePattern(value string) ([]patternRun, bool) {
+	if value == "" || len(value) > maxPatternLength {
+		return nil, false
+	}
+
+	var runs []patternRun
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 0x20 || c >= 0x7f {
+			return nil, false
+		}
+
+		class := charClass(c)
+		last := len(runs) - 1
+		switch {
+		case class != 0 && last >= 0 && runs[last].class != 0:
+			runs[last].class |= class
+			runs[last].min++
+			runs[last].max++
+		case class == 0 && last >= 0 && runs[last].literal == c:
+			runs[last].min++
+			runs[last].max++
+		default:
+			runs = append(runs, patternRun{literal: literalOf(c, class), class: class, min: 1, max: 1})
+		}
+	}
+	return runs, true
+}
+
+// charClass возвращает класс буквы или цифры; 0 для остальных символов
+func charClass(c byte) uint8 {
+	switch {
+	case c >= '0' && c <= '9':
+		return classDigit
+	case c >= 'A' && c <= 'F':
+		return classUpperHex
+	case c >= 'G' && c <= 'Z':
+		return classUpperOther
+	case c >= 'a' && c <= 'f':
+		return classLowerHex
+	case c >= 'g' && c <= 'z':
+		return classLowerOther
+	}
+	return 0
+}
+
+// literalOf возвращает символ литерала для символа без класса
+func literalOf(c byte, class uint8) byte {
+	if class != 0 {
+		return 0
+	}
+	return c
+}
+
+// listRuns представляет форму как список элементов через одинаковый
+// однобуквенный разделитель. Для формы из одного элемента разделитель неизвестен
+func listRuns(runs []patternRun) (patternRun, byte, bool) {
+	if len(runs)%2 == 0 {
+		return patternRun{}, 0, false
+	}
+
+	item := runs[0]
+	var sep byte
+	for i, run := range runs {
+		if i%2 == 0 {
+			if run.class == 0 {
+				return patternRun{}, 0, false
+			}
+			item, _ = mergeRun(item, run)
+			continue
+		}
+		if run.class != 0 || run.min != 1 || run.max != 1 || (sep != 0 && run.literal != sep) {
+			return patternRun{}, 0, false
+		}
+		sep = run.literal
+	}
+	return item, sep, true
+}
+
+// mergeRuns объединяет две последовательности одинаковой структуры
+func mergeRuns(a, b []patternRun) ([]patternRun, bool) {
+	if len(a) != len(b) {
+		return nil, false
+	}
+	merged := make([]patternRun, len(a))
+	for i := range a {
+		run, ok := mergeRun(a[i], b[i])
+		if !ok {
+			return nil, false
+		}
+		merged[i] = run
+	}
+	return merged, true
+}
+
+// mergeRun объединяет два элемента: литералы должны совпадать, классы объединяются
+func mergeRun(a, b patternRun) (patternRun, bool) {
+	if (a.class == 0) != (b.class == 0) || a.literal != b.literal {
+		return patternRun{}, false
+	}
+	merged := patternRun{literal: a.literal, class: a.class | b.class, min: min(a.min, b.min), max: a.max}
+	if a.max >= 0 && (b.max < 0 || b.max > a.max) {
+		merged.max = b.max
+	}
+	return merged, true
+}
+
+// mergeShapes объединяет две формы одного вида
+func mergeShapes(a, b patternShape) (patternShape, bool) {
+	if a.sep != b.sep {
+		return patternShape{}, false
+	}
+	runs, ok := mergeRuns(a.runs, b.runs)
+	return patternShape{runs: runs, sep: a.sep}, ok
+}
+
+// structured сообщает, что форма достаточно конкретна для pattern: не
+// содержит пробелов и либо имеет разделители, либо фиксированную длину
+func (s patternShape) structured() bool {
+	if s.sep != 0 {
+		return s.sep != ' '
+	}
+
+	literals, fixed, length := false, true, 0
+	for _, run := range s.runs {
+		if run.class == 0 {
+			if run.literal == ' ' {
+				return false
+			}
+			literals = true
+		}
+		if run.min != run.max {
+			fixed = false
+		}
+		length += run.min
+	}
+	return length >= 2 && (literals || fixed)
+}
+
+// String возвращает форму в виде регулярного выражения ECMA-262
+func (s patternShape) String() string {
+	var b strings.Builder
+	b.WriteString("^")
+	if s.sep != 0 {
+		item := renderRun(patternRun{class: s.runs[0].class, min: 1, max: -1})
+		sep := regexp.QuoteMeta(string(s.sep))
+		b.WriteString(item + "(?:" + sep + item + ")*")
+	} else {
+		for _, run := range s.runs {
+			b.WriteString(renderRun(run))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// renderRun записывает элемент шаблона с квантификатором
+func renderRun(run patternRun) string {
+	atom := regexp.QuoteMeta(string(run.literal))
+	if run.class != 0 {
+		atom = renderClass(run.class)
+	}
+	switch {
+	case run.min != run.max:
+		return atom + "+"
+	case run.min == 1:
+		return atom
+	default:
+		return atom + "{" + strconv.Itoa(run.min) + "}"
+	}
+}
+
+// renderClass записывает класс символов. Шестнадцатеричные буквы вместе с
+// цифрами дают диапазон A-F/a-f, в остальных случаях используется весь алфавит
+func renderClass(class uint8) string {
+	if class == classDigit {
+		return `\d`
+	}
+
+	hex := class&classDigit != 0
+	var b strings.Builder
+	b.WriteString("[")
+	if class&classDigit != 0 {
+		b.WriteString("0-9")
+	}
+	switch {
+	case class&classUpperOther != 0 || (class&classUpperHex != 0 && !hex):
+		b.WriteString("A-Z")
+	case class&classUpperHex != 0:
+		b.WriteString("A-F")
+	}
+	switch {
+	case class&classLowerOther != 0 || (class&classLowerHex != 0 && !hex):
+		b.WriteString("a-z")
+	case class&classLowerHex != 0:
+		b.WriteString("a-f")
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// parsePattern разбирает шаблон, записанный renderRun/String. Для шаблонов
+// другого вида возвращает false
+func parsePattern(pattern string) (patternShape, bool) {
+	if !strings.HasPrefix(pattern, "^") || !strings.HasSuffix(pattern, "$") {
+		return patternShape{}, false
+	}
+	body := pattern[1 : len(pattern)-1]
+
+	// Список элементов: X+(?:sX+)*
+	if strings.HasSuffix(body, ")*") {
+		open := strings.Index(body, "(?:")
+		if open < 0 {
+			return patternShape{}, false
+		}
+		item, rest := body[:open], strings.TrimSuffix(body[open+3:], ")*")
+		runs, ok := parseRuns(item)
+		if !ok || len(runs) != 1 || runs[0].class == 0 || runs[0].max >= 0 {
+			return patternShape{}, false
+		}
+		sep, n := parseLiteral(rest)
+		if n == 0 || rest[n:] != item {
+			return patternShape{}, false
+		}
+		return patternShape{runs: runs, sep: sep}, true
+	}
+
+	runs, ok := parseRuns(body)
+	if !ok || len(runs) == 0 {
+		return patternShape{}, false
+	}
+	return patternShape{runs: runs}, true
+}
+
+// parseRuns разбирает последовательность элементов шаблона
+func parseRuns(body string) ([]patternRun, bool) {
+	var runs []patternRun
+	for body != "" {
+		var run patternRun
+		switch {
+		case strings.HasPrefix(body, `\d`):
+			run.class, body = classDigit, body[2:]
+		case body[0] == '[':
+			end := strings.IndexByte(body, ']')
+			if end < 0 {
+				return nil, false
+			}
+			class, ok := parseClass(body[1:end])
+			if !ok {
+				return nil, false
+			}
+			run.class, body = class, body[end+1:]
+		default:
+			c, n := parseLiteral(body)
+			if n == 0 {
+				return nil, false
+			}
+			run.literal, body = c, body[n:]
+		}
+
+		run.min, run.max = 1, 1
+		switch {
+		case strings.HasPrefix(body, "+"):
+			run.max, body = -1, body[1:]
+		case strings.HasPrefix(body, "{"):
+			end := strings.IndexByte(body, '}')
+			if end < 0 {
+				return nil, false
+			}
+			n, err := strconv.Atoi(body[1:end])
+			if err != nil || n < 1 {
+				return nil, false
+			}
+			run.min, run.max, body = n, n, body[end+1:]
+		}
+		runs = append(runs, run)
+	}
+	return runs, true
+}
+
+// parseClass разбирает содержимое класса символов, записанного renderClass
+func parseClass(ranges string) (uint8, bool) {
+	var class uint8
+	for ranges != "" {
+		if len(ranges) < 3 {
+			return 0, false
+		}
+		switch ranges[:3] {
+		case "0-9":
+			class |= classDigit
+		case "A-Z":
+			class |= classUpperHex | classUpperOther
+		case "A-F":
+			class |= classUpperHex
+		case "a-z":
+			class |= classLowerHex | classLowerOther
+		case "a-f":
+			class |= classLowerHex
+		default:
+			return 0, false
+		}
+		ranges = ranges[3:]
+	}
+	return class, class != 0
+}
+
+// parseLiteral разбирает один литерал шаблона и возвращает число прочитанных байт
+func parseLiteral(body string) (byte, int) {
+	if body == "" {
+		return 0, 0
+	}
+	if body[0] == '\\' {
+		if len(body) < 2 || charClass(body[1]) != 0 {
+			return 0, 0
+		}
+		return body[1], 2
+	}
+	if strings.IndexByte(`^$()[]{}*+?|.\`, body[0]) >= 0 || charClass(body[0]) != 0 {
+		return 0, 0
+	}
+	return body[0], 1
+}
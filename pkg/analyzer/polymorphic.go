@@ -0,0 +1,237 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// discriminatorKeys - имена полей, которые обычно различают варианты объектов
+var discriminatorKeys = []string{"type", "kind", "__typename", "event", "event_type", "eventType", "object", "object_type", "objectType"}
+
+// maxVariants - наибольшее число вариантов полиморфного типа
+const maxVariants = 10
+
+// variantSimilarity - доля общих полей, начиная с которой группы объектов
+// считаются одной формой, а не разными вариантами
+const variantSimilarity = 0.5
+
+// polymorphicItems описывает элементы массива объектов вариантами oneOf, если
+// объекты различаются полем-дискриминатором (type, kind) и группы с разными
+// значениями дискриминатора имеют явно разные наборы полей. Каждый вариант
+// объединяет объекты своей группы, а дискриминатор получает enum из одного
+// значения. Конфликты типов полей, которые различаются только между
+// вариантами, снимаются. Возвращает nil, если элементы не полиморфны
+func (a *Analyzer) polymorphicItems(samples []interface{}, itemPath string, st *state) (*types.Property, error) {
+	objects := make([]map[string]interface{}, 0, len(samples))
+	for _, element := range samples {
+		obj, ok := element.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		objects = append(objects, obj)
+	}
+
+	key, values, groups := discriminate(objects)
+	if key == "" {
+		return nil, nil
+	}
+
+	// Конфликты внутри элементов пересчитываются по вариантам
+	prefix := fieldPath(itemPath) + "."
+	for field := range st.stats.TypeConflicts {
+		if strings.HasPrefix(field, prefix) {
+			delete(st.stats.TypeConflicts, field)
+		}
+	}
+
+	variants := make([]*types.JSONSchema, 0, len(values))
+	for _, value := range values {
+		// Обязательность полей варианта считается по объектам его группы
+		scratch := newState(scratchStats())
+		scratch.depth = st.depth
+		var variant *types.Property
+		for _, obj := range groups[value] {
+			prop, err := a.analyzeValue(obj, itemPath, scratch)
+			if err != nil {
+				return nil, err
+			}
+			if variant == nil {
+				variant = prop
+				continue
+			}
+			a.mergeProperty(variant, prop, itemPath, scratch)
+		}
+		scratch.applyRequired(variant, itemPath, a.config.RequiredPercent)
+		setDiscriminator(variant, key, value)
+		variants = append(variants, variantSchema(variant))
+
+		for field, kinds := range scratch.stats.TypeConflicts {
+			st.stats.TypeConflicts = addConflict(st.stats.TypeConflicts, field, kinds...)
+		}
+	}
+
+	return &types.Property{OneOf: variants}, nil
+}
+
+// discriminate ищет поле-дискриминатор: строковое поле из discriminatorKeys,
+// присутствующее во всех объектах, с 2..maxVariants значениями, группы по
+// которым имеют разные наборы полей. Возвращает поле, значения в порядке
+// появления и объекты по значениям
+func discriminate(objects []map[string]interface{}) (string, []string, map[string][]map[string]interface{}) {
+	if len(objects) < 2 {
+		return "", nil, nil
+	}
+
+	for _, key := range discriminatorKeys {
+		var values []string
+		groups := make(map[string][]map[string]interface{})
+		for _, obj := range objects {
+			value, ok := obj[key].(string)
+			if !ok {
+				values = nil
+				break
+			}
+			if _, seen := groups[value]; !seen {
+				values = append(values, value)
+			}
+			groups[value] = append(groups[value], obj)
+		}
+		if len(values) < 2 || len(values) > maxVariants {
+			continue
+		}
+		if distinctShapes(key, values, groups) {
+			return key, values, groups
+		}
+	}
+	return "", nil, nil
+}
+
+// distinctShapes сообщает, что наборы полей групп попарно различаются: доля
+// общих полей (без дискриминатора) меньше variantSimilarity
+func distinctShapes(key string, values []string, groups map[string][]map[string]interface{}) bool {
+	shapes := make([]map[string]bool, len(values))
+	for i, value := range values {
+		shapes[i] = make(map[string]bool)
+		for _, obj := range groups[value] {
+			for field := range obj {
+				if field != key {
+					shapes[i][field] = true
+				}
+			}
+		}
+	}
+
+	for i := range shapes {
+		for j := i + 1; j < len(shapes); j++ {
+			if keySimilarity(shapes[i], shapes[j]) >= variantSimilarity {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// keySimilarity возвращает долю общих ключей двух наборов (коэффициент Жаккара)
+func keySimilarity(a, b map[string]bool) float64 {
+	shared := 0
+	for key := range a {
+		if b[key] {
+			shared++
+		}
+	}
+	total := len(a) + len(b) - shared
+	if total == 0 {
+		return 1
+	}
+	return float64(shared) / float64(total)
+}
+
+// setDiscriminator закрепляет значение дискриминатора варианта
+func setDiscriminator(variant *types.Property, key, value string) {
+	prop := variant.Properties[key]
+	if prop == nil {
+		return
+	}
+	prop.Enum = []interface{}{value}
+	prop.Default = nil
+	prop.Examples = nil
+}
+
+// variantDiscriminator находит дискриминатор вариантов oneOf: поле, которое
+// есть в каждом варианте и имеет в нем enum из одного значения
+func variantDiscriminator(variants []*types.JSONSchema) string {
+	if len(variants) == 0 || variants[0] == nil {
+		return ""
+	}
+	for _, key := range sortedKeys(variants[0].Properties) {
+		found := true
+		for _, variant := range variants {
+			if variantValue(variant.Properties, key) == nil {
+				found = false
+				break
+			}
+		}
+		if found {
+			return key
+		}
+	}
+	return ""
+}
+
+// variantValue возвращает значение дискриминатора в полях варианта или объекта:
+// enum из одного значения или default
+func variantValue(props map[string]*types.Property, key string) interface{} {
+	prop := props[key]
+	switch {
+	case prop == nil:
+		return nil
+	case len(prop.Enum) == 1:
+		return prop.Enum[0]
+	case len(prop.Enum) == 0 && prop.Default != nil:
+		return prop.Default
+	}
+	return nil
+}
+
+// mergeVariants объединяет полиморфное поле с вариантами или объектом из новых
+// данных: вариант с тем же значением дискриминатора дополняется, вариант с
+// новым значением добавляется
+func (a *Analyzer) mergeVariants(existing, new *types.Property, path string, st *state) {
+	key := variantDiscriminator(existing.OneOf)
+	if key == "" {
+		return
+	}
+
+	incoming := new.OneOf
+	if len(incoming) == 0 {
+		if new.Type != string(types.TypeObject) {
+			return
+		}
+		incoming = []*types.JSONSchema{variantSchema(new)}
+	}
+
+	for _, variant := range incoming {
+		value := variantValue(variant.Properties, key)
+		if value == nil {
+			continue
+		}
+		matched := false
+		for i, current := range existing.OneOf {
+			if formatKey(variantValue(current.Properties, key)) != formatKey(value) {
+				continue
+			}
+			merged := variantProperty(current)
+			a.mergeProperty(merged, variantProperty(variant), path, st)
+			setDiscriminator(merged, key, formatKey(value))
+			existing.OneOf[i] = variantSchema(merged)
+			matched = true
+			break
+		}
+		if !matched && len(existing.OneOf) < maxVariants {
+			added := variantProperty(variant)
+			setDiscriminator(added, key, formatKey(value))
+			existing.OneOf = append(existing.OneOf, variantSchema(added))
+		}
+	}
+}
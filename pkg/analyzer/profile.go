@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"unicode"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+)
+
+// Границы размеров входных данных, по которым выбирается профиль анализа
+const (
+	// smallInput - данные до этого размера анализируются целиком: каждый
+	// элемент каждого массива попадает в схему. Они разбираются быстрее,
+	// чем окупается запуск обработчиков, поэтому анализируются одним
+	smallInput = 16 << 20
+	// largeInput - начиная с этого размера файл анализируется потоком, а
+	// вложенные массивы - по уменьшенной выборке элементов
+	largeInput = 256 << 20
+	// largeInputSamples - выборка элементов вложенного массива для крупных данных
+	largeInputSamples = 200
+)
+
+// Формы корня входных данных
+const (
+	RootObject = "object"
+	RootArray  = "array"
+	RootScalar = "scalar"
+	// RootRecords - файл NDJSON: записи анализируются как корневой массив
+	RootRecords = "records"
+)
+
+// Profile - настройки производительности, выбранные по размеру и форме
+// входных данных в автоматическом режиме
+type Profile struct {
+	// Size - размер входного файла в байтах
+	Size int64
+	// Root - форма корня: RootObject, RootArray или RootScalar
+	Root string
+	// MaxArraySamples - выборка элементов вложенных массивов; 0 - все
+	// элементы. Записи верхнего уровня анализируются все
+	MaxArraySamples int
+	// Stream - анализ потоком вместо чтения файла в память
+	Stream bool
+	// Workers - число обработчиков записей NDJSON и документов, записанных
+	// подряд (см. Config.Workers)
+	Workers int
+	// Compressed - формат сжатия файла (gzip, zstd); Size тогда - размер
+	// сжатого файла
+	Compressed string
+}
+
+// String описывает профиль для вывода пользователю
+func (p Profile) String() string {
+	samples := "все элементы массивов"
+	if p.MaxArraySamples > 0 {
+		samples = fmt.Sprintf("выборка %d элементов вложенных массивов", p.MaxArraySamples)
+	}
+	mode := "в памяти"
+	if p.Stream {
+		mode = "потоком"
+	}
+	size := FormatSize(p.Size)
+	if p.Compressed != "" {
+		size += " " + p.Compressed
+	}
+	return fmt.Sprintf("%s, корень %s: %s, %s, обработчиков %d", size, p.Root, mode, samples, p.Workers)
+}
+
+// ChooseProfile выбирает настройки производительности для файла по его
+// размеру и форме корня, не читая файл целиком. Данные крупнее smallInput
+// анализируются в runtime.NumCPU() обработчиках
+func (a *Analyzer) ChooseProfile(filename string) (Profile, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return Profile{}, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	root := RootRecords
+	if !IsNDJSON(filename) {
+		if root, err = rootKind(filename); err != nil {
+			return Profile{}, err
+		}
+	}
+	format, err := decompress.DetectFile(filename)
+	if err != nil {
+		return Profile{}, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	profile := Profile{Size: info.Size(), Root: root, MaxArraySamples: a.config.MaxArraySamples, Compressed: format, Workers: 1}
+	switch {
+	case profile.Size <= smallInput:
+		profile.MaxArraySamples = 0
+	case profile.Size >= largeInput:
+		profile.MaxArraySamples = largeInputSamples
+	}
+	// Файлы, анализируемые параллельно, уже заняли обработчики
+	if profile.Size > smallInput && !a.inParallel {
+		profile.Workers = runtime.NumCPU()
+	}
+	// Записи крупного массива выгоднее объединять по мере чтения; файл
+	// сверх MaxInputSize иначе не проанализировать вовсе. Размер
+	// распакованных данных заранее не известен, поэтому сжатый файл тоже
+	// читается потоком
+	exceeds := a.config.MaxInputSize > 0 && profile.Size > a.config.MaxInputSize
+	profile.Stream = a.config.Stream || root == RootRecords || root != RootScalar && (profile.Size >= largeInput || exceeds || format != "")
+	return profile, nil
+}
+
+// withProfile возвращает анализатор с настройками профиля
+func (a *Analyzer) withProfile(profile Profile) *Analyzer {
+	config := a.config
+	config.Auto = false
+	config.MaxArraySamples = profile.MaxArraySamples
+	config.Stream = profile.Stream
+	config.Workers = profile.Workers
+	return a.withConfig(config)
+}
+
+// rootKind определяет форму корня JSON по первому значащему символу файла
+func rootKind(filename string) (string, error) {
+	file, err := decompress.Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return RootScalar, nil
+		}
+		switch {
+		case unicode.IsSpace(r), r == '\uFEFF':
+			continue
+		case r == '{':
+			return RootObject, nil
+		case r == '[':
+			return RootArray, nil
+		}
+		return RootScalar, nil
+	}
+}
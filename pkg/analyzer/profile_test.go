@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// sizedFile создает файл name размера size, начинающийся с prefix; остаток
+// файла не записывается на диск
+func sizedFile(t *testing.T, name, prefix string, size int64) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(prefix), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, size); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestChooseProfileWorkers(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		prefix     string
+		size       int64
+		inParallel bool
+		root       string
+		workers    int
+	}{
+		{"small", "small.json", "[", 1 << 10, false, RootArray, 1},
+		{"medium", "medium.json", "[", smallInput + 1, false, RootArray, runtime.NumCPU()},
+		{"ndjson", "events.ndjson", "{", largeInput, false, RootRecords, runtime.NumCPU()},
+		{"in parallel", "medium.json", "[", smallInput + 1, true, RootArray, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New()
+			a.inParallel = tt.inParallel
+			profile, err := a.ChooseProfile(sizedFile(t, tt.file, tt.prefix, tt.size))
+			if err != nil {
+				t.Fatalf("ChooseProfile: %v", err)
+			}
+			if profile.Root != tt.root || profile.Workers != tt.workers {
+				t.Errorf("профиль = {root %s, workers %d}, want {%s, %d}", profile.Root, profile.Workers, tt.root, tt.workers)
+			}
+			if config := a.withProfile(profile).config; config.Workers != tt.workers {
+				t.Errorf("Config.Workers = %d, want %d", config.Workers, tt.workers)
+			}
+		})
+	}
+}
+
+func TestDefaultWorkers(t *testing.T) {
+	if got := New().workers(); got != runtime.GOMAXPROCS(0) {
+		t.Errorf("обработчиков по умолчанию = %d, want GOMAXPROCS %d", got, runtime.GOMAXPROCS(0))
+	}
+	if got := New(WithWorkers(1)).workers(); got != 1 {
+		t.Errorf("WithWorkers(1): обработчиков = %d, want 1", got)
+	}
+}
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// Режимы вывода диапазона числовых полей
+const (
+	// RangeConstraint записывает диапазон как ограничения minimum/maximum
+	RangeConstraint = "constraint"
+	// RangeObserved записывает диапазон в расширение x-observed-range, не
+	// ограничивая допустимые значения
+	RangeObserved = "observed"
+)
+
+// ParseRangeMode проверяет название режима вывода диапазона чисел
+func ParseRangeMode(mode string) (string, error) {
+	switch mode {
+	case "", RangeConstraint, RangeObserved:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("неизвестный режим диапазона чисел: %s. Доступные: %s, %s", mode, RangeConstraint, RangeObserved)
+	}
+}
+
+// setRange записывает диапазон из одного наблюдаемого значения
+func setRange(property *types.Property, v json.Number, mode string) {
+	switch mode {
+	case RangeConstraint:
+		property.Minimum, property.Maximum = v, v
+	case RangeObserved:
+		property.ObservedRange = &types.Range{Minimum: v, Maximum: v}
+	}
+}
+
+// mergeRange расширяет диапазон existing до наблюдаемого в new. Диапазон
+// расширяется, только если он есть у обеих схем: отсутствие диапазона у
+// существующей схемы означает, что он не выводился или был снят вручную
+func mergeRange(existing, new *types.Property) {
+	if existing.Minimum != "" && new.Minimum != "" && numberLess(new.Minimum, existing.Minimum) {
+		existing.Minimum = new.Minimum
+	}
+	if existing.Maximum != "" && new.Maximum != "" && numberLess(existing.Maximum, new.Maximum) {
+		existing.Maximum = new.Maximum
+	}
+
+	if existing.ObservedRange != nil && new.ObservedRange != nil {
+		if numberLess(new.ObservedRange.Minimum, existing.ObservedRange.Minimum) {
+			existing.ObservedRange.Minimum = new.ObservedRange.Minimum
+		}
+		if numberLess(existing.ObservedRange.Maximum, new.ObservedRange.Maximum) {
+			existing.ObservedRange.Maximum = new.ObservedRange.Maximum
+		}
+	}
+}
+
+// numberLess сравнивает числа, сохраненные в исходной записи
+func numberLess(a, b json.Number) bool {
+	fa, errA := a.Float64()
+	fb, errB := b.Float64()
+	return errA == nil && errB == nil && fa < fb
+}
@@ -0,0 +1,229 @@
+package analyzer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// rootRef - ссылка на корень схемы
+const rootRef = "#"
+
+// recursionFrame - объект на пути от корня к текущему узлу
+type recursionFrame struct {
+	prop *types.Property
+	// name - ключ, под которым объект встречается в родителе
+	name string
+	// edge - ключ, через который обход спустился из объекта к текущему узлу
+	edge string
+}
+
+// recursion находит рекурсивные структуры: объект, повторяющий форму одного
+// из своих предков (дерево с children, ответы на комментарии), заменяется
+// ссылкой на предка, а предок выносится в $defs
+type recursion struct {
+	stack []recursionFrame
+	// refs - предки, на которые найдены ссылки: предок → ссылка
+	refs map[*types.Property]string
+	// order - предки в порядке обнаружения
+	order []*types.Property
+	// folded - отделенные копии повторений, которые объединяются с предком
+	folded []foldedNode
+	defs   map[string]*types.Property
+}
+
+// foldedNode - повторение формы предка, отделенное от дерева
+type foldedNode struct {
+	ancestor *types.Property
+	node     *types.Property
+}
+
+// applyRecursion заменяет повторения формы предков ссылками $ref и возвращает
+// вынесенные определения. Повторение объединяется с предком, поэтому поля,
+// встреченные только на глубоких уровнях, попадают в общее определение.
+// Предок-корень в $defs не выносится: на него ссылается "#"
+func (a *Analyzer) applyRecursion(root *types.Property) map[string]*types.Property {
+	r := &recursion{refs: make(map[*types.Property]string), defs: make(map[string]*types.Property)}
+	r.visit(root, "", "", root)
+
+	for _, f := range r.folded {
+		a.mergeProperty(f.ancestor, f.node, "", newState(scratchStats()))
+	}
+	for _, ancestor := range r.order {
+		ref := r.refs[ancestor]
+		if ref == rootRef {
+			continue
+		}
+		def := *ancestor
+		def.Nullable = false
+		r.defs[strings.TrimPrefix(ref, types.DefsRefPrefix)] = &def
+		*ancestor = refProperty(ref, ancestor.Nullable)
+	}
+
+	if len(r.defs) == 0 {
+		return nil
+	}
+	return r.defs
+}
+
+// visit обходит узел; path - внутренний путь узла для имени определения
+func (r *recursion) visit(prop *types.Property, name, path string, root *types.Property) {
+	if prop == nil || prop.Ref != "" {
+		return
+	}
+
+	if prop.Type == string(types.TypeObject) && len(prop.Properties) > 0 {
+		for i := len(r.stack) - 1; i >= 0; i-- {
+			frame := r.stack[i]
+			if !sameShape(frame.prop, prop, frame.edge) {
+				continue
+			}
+			ref := r.reference(frame.prop, frame.name, root)
+			node := *prop
+			*prop = refProperty(ref, prop.Nullable)
+			// Глубже отделенной копии встречаются те же повторения того же предка
+			r.children(&node, path, root)
+			r.folded = append(r.folded, foldedNode{ancestor: frame.prop, node: &node})
+			return
+		}
+
+		r.stack = append(r.stack, recursionFrame{prop: prop, name: name})
+		r.children(prop, path, root)
+		r.stack = r.stack[:len(r.stack)-1]
+		return
+	}
+
+	r.children(prop, path, root)
+}
+
+// children обходит потомков узла, запоминая ключ спуска из ближайшего объекта
+func (r *recursion) children(prop *types.Property, path string, root *types.Property) {
+	for _, key := range sortedKeys(prop.Properties) {
+		if len(r.stack) > 0 && r.stack[len(r.stack)-1].prop == prop {
+			r.stack[len(r.stack)-1].edge = key
+		}
+		r.visit(prop.Properties[key], key, path+"."+key, root)
+	}
+	r.visit(prop.Items, lastKey(path), path+"[0]", root)
+	for i, position := range prop.PrefixItems {
+		r.visit(position, lastKey(path), positionPath(path, i), root)
+	}
+	r.visit(types.MapValues(prop.PatternProperties, prop.AdditionalProperties), lastKey(path), path+".*", root)
+}
+
+// reference возвращает ссылку на предка, при первом обращении выбирая имя определения
+func (r *recursion) reference(ancestor *types.Property, name string, root *types.Property) string {
+	if ref, ok := r.refs[ancestor]; ok {
+		return ref
+	}
+
+	ref := rootRef
+	if ancestor != root {
+		taken := make(map[string]bool, len(r.refs))
+		for _, ref := range r.refs {
+			taken[strings.TrimPrefix(ref, types.DefsRefPrefix)] = true
+		}
+		ref = types.DefsRefPrefix + uniqueName(defName(name), taken)
+	}
+	r.refs[ancestor] = ref
+	r.order = append(r.order, ancestor)
+	return ref
+}
+
+// sortedKeys возвращает ключи свойств по порядку
+func sortedKeys(props map[string]*types.Property) []string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// uniqueName добавляет к имени определения номер, если имя уже занято
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	return unique
+}
+
+// sameShape сообщает, что объект повторяет форму предка: все его поля есть у
+// предка с совместимыми типами, а недостающие поля предка ограничены ключом
+// спуска edge (лист дерева без children) или составляют меньшую часть полей
+// при наличии ключа спуска (узел с пустым списком потомков)
+func sameShape(ancestor, node *types.Property, edge string) bool {
+	if edge == "" || len(node.Properties) < 2 {
+		return false
+	}
+	for key, prop := range node.Properties {
+		other, ok := ancestor.Properties[key]
+		if !ok || other == nil || prop == nil || !types.CompatibleTypes(other.Type, prop.Type) {
+			return false
+		}
+	}
+
+	if _, ok := node.Properties[edge]; ok {
+		return len(node.Properties)*2 >= len(ancestor.Properties)
+	}
+	return len(node.Properties) == len(ancestor.Properties)-1
+}
+
+// refProperty создает ссылку; nullable-ссылка описывается вариантами anyOf
+func refProperty(ref string, nullable bool) types.Property {
+	if !nullable {
+		return types.Property{Ref: ref}
+	}
+	return types.Property{AnyOf: []*types.JSONSchema{{Ref: ref}, {Type: string(types.TypeNull)}}}
+}
+
+// lastKey возвращает последний ключ внутреннего пути без индексов массивов
+func lastKey(path string) string {
+	path = positionSegment.ReplaceAllString(path, "")
+	path = strings.TrimSuffix(path, ".*")
+	return path[strings.LastIndex(path, ".")+1:]
+}
+
+// defName строит имя определения по ключу поля: единственное число в
+// PascalCase (children → Child, replies → Reply, order_items → OrderItem)
+func defName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "Node"
+	}
+
+	words[len(words)-1] = singular(words[len(words)-1])
+	var b strings.Builder
+	for _, word := range words {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	name := b.String()
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "Node" + name
+	}
+	return name
+}
+
+// singular приводит английское слово к единственному числу по простым правилам
+func singular(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case lower == "children":
+		return word[:len(word)-3]
+	case strings.HasSuffix(lower, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "ches"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/schema"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
+)
+
+// roundTripSeeds - число случайных схем в TestRoundTrip; каждая схема
+// воспроизводится подтестом со своим seed
+const roundTripSeeds = 200
+
+// fieldNames - имена полей случайных объектов
+var fieldNames = []string{"id", "name", "status", "amount", "owner", "items", "meta", "created", "tags", "score"}
+
+// TestRoundTrip проверяет свойство вывода схемы: для случайной схемы S и
+// данных, сгенерированных по ней, выведенная схема S' принимает эти данные,
+// а переход от S' к S совместим (pkg/compat) - анализ не выдумывает типов,
+// обязательных полей и ограничений, которых нет в S. Упавший случай
+// воспроизводится запуском go test -run 'TestRoundTrip/seed=N'
+func TestRoundTrip(t *testing.T) {
+	for seed := int64(1); seed <= roundTripSeeds; seed++ {
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			g := &generator{rng: rand.New(rand.NewSource(seed)), visits: make(map[*types.Property]int)}
+			original := schema.Array(g.object(0)).Schema()
+
+			records := make([]interface{}, 2+g.rng.Intn(20))
+			for i := range records {
+				records[i] = g.value(original.Items)
+			}
+			data, err := json.Marshal(records)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := New().AnalyzeBytes(data)
+			if err != nil {
+				t.Fatalf("AnalyzeBytes: %v\nданные: %s", err, data)
+			}
+			inferred, err := json.Marshal(result.Schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validation, err := validator.New(false).ValidateBytes(data, inferred)
+			if err != nil {
+				t.Fatalf("ValidateBytes: %v", err)
+			}
+			if !validation.Valid {
+				t.Errorf("данные не проходят выведенную схему: %v\nсхема: %s\nданные: %s", validation.Errors, inferred, data)
+			}
+
+			if breaking := compat.Compare(result.Schema, original).Breaking(); len(breaking) > 0 {
+				expected, _ := json.Marshal(original)
+				t.Errorf("выведенная схема строже исходной: %+v\nисходная: %s\nвыведенная: %s\nданные: %s", breaking, expected, inferred, data)
+			}
+		})
+	}
+}
+
+// generator строит случайные схемы и данные по ним
+type generator struct {
+	rng *rand.Rand
+	// visits считает значения, сгенерированные по каждому объекту схемы
+	visits  map[*types.Property]int
+	strings int
+}
+
+// object строит случайный объект из 1-5 полей; depth ограничивает вложенность
+func (g *generator) object(depth int) *schema.Builder {
+	object := schema.NewObject()
+	for _, i := range g.rng.Perm(len(fieldNames))[:1+g.rng.Intn(5)] {
+		if g.rng.Intn(5) < 3 {
+			object.Prop(fieldNames[i], g.field(depth+1))
+		} else {
+			object.Optional(fieldNames[i], g.field(depth+1))
+		}
+	}
+	return object
+}
+
+// field строит схему поля: скаляр, возможно nullable, объект или массив
+func (g *generator) field(depth int) *schema.Builder {
+	kinds := 4
+	if depth < 3 {
+		kinds = 6
+	}
+	var field *schema.Builder
+	switch g.rng.Intn(kinds) {
+	case 0:
+		field = schema.String()
+	case 1:
+		field = schema.Integer()
+	case 2:
+		field = schema.Number()
+	case 3:
+		field = schema.Boolean()
+	case 4:
+		return g.object(depth)
+	default:
+		return schema.Array(g.field(depth + 1))
+	}
+	// null допускается только у скаляров: поле, в выборке которого были
+	// только null, не описывает структуру объекта
+	if g.rng.Intn(5) == 0 {
+		field.Nullable()
+	}
+	return field
+}
+
+// value генерирует значение по схеме. Необязательные поля объекта
+// пропускаются в первом его значении и присутствуют во втором, чтобы
+// выборка не делала их обязательными; дальше - случайно
+func (g *generator) value(prop *types.Property) interface{} {
+	if prop.Nullable && g.rng.Intn(4) == 0 {
+		return nil
+	}
+	switch prop.Type {
+	case string(types.TypeString):
+		g.strings++
+		return fmt.Sprintf("value %d-%d", g.strings, g.rng.Intn(1000))
+	case string(types.TypeInteger):
+		return g.rng.Intn(2000) - 1000
+	case string(types.TypeNumber):
+		return float64(g.rng.Intn(100000))/100 + 0.5
+	case string(types.TypeBoolean):
+		return g.rng.Intn(2) == 0
+	case string(types.TypeArray):
+		items := make([]interface{}, g.rng.Intn(4))
+		for i := range items {
+			items[i] = g.value(prop.Items)
+		}
+		return items
+	case string(types.TypeObject):
+		visit := g.visits[prop]
+		g.visits[prop]++
+		required := make(map[string]bool, len(prop.Required))
+		for _, name := range prop.Required {
+			required[name] = true
+		}
+		object := make(map[string]interface{}, len(prop.Properties))
+		// Поля обходятся по порядку имен, чтобы seed воспроизводил данные
+		names := make([]string, 0, len(prop.Properties))
+		for name := range prop.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !required[name] && (visit == 0 || visit > 1 && g.rng.Intn(2) == 0) {
+				continue
+			}
+			object[name] = g.value(prop.Properties[name])
+		}
+		return object
+	}
+	panic("неизвестный тип схемы: " + prop.Type)
+}
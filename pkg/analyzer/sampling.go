@@ -0,0 +1,197 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// errSampled прерывает чтение корневого массива, когда выборка набрана
+var errSampled = errors.New("выборка записей набрана")
+
+// ParseSampleRate разбирает долю случайной выборки записей: число в (0, 1]
+func ParseSampleRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 || rate > 1 {
+		return 0, fmt.Errorf("некорректная доля выборки: %s, ожидается число больше 0 и не больше 1", value)
+	}
+	return rate, nil
+}
+
+// sampler отбирает записи верхнего уровня для анализа: случайную долю
+// SampleRate и не больше SampleRecords записей. Генератор инициализируется
+// SampleSeed, поэтому повторный анализ тех же данных дает ту же выборку
+type sampler struct {
+	limit int
+	rate  float64
+	seed  int64
+	rng   *rand.Rand
+
+	// seen - сколько записей прочитано, taken - сколько из них отобрано;
+	// halted - вход не дочитан, потому что выборка набрана
+	seen   int
+	taken  int
+	halted bool
+}
+
+// newSampler создает выборку по настройкам анализатора; nil - анализируются
+// все записи
+func (a *Analyzer) newSampler() *sampler {
+	rate := a.config.SampleRate
+	if rate >= 1 {
+		rate = 0
+	}
+	if a.config.SampleRecords <= 0 && rate <= 0 {
+		return nil
+	}
+	s := &sampler{limit: a.config.SampleRecords, rate: rate, seed: a.config.SampleSeed}
+	if rate > 0 {
+		s.rng = rand.New(rand.NewSource(s.seed))
+	}
+	return s
+}
+
+// take сообщает, анализировать ли очередную запись
+func (s *sampler) take() bool {
+	if s == nil {
+		return true
+	}
+	s.seen++
+	if s.full() {
+		return false
+	}
+	if s.rng != nil && s.rng.Float64() >= s.rate {
+		return false
+	}
+	s.taken++
+	return true
+}
+
+// full сообщает, что набрано SampleRecords записей
+func (s *sampler) full() bool {
+	return s != nil && s.limit > 0 && s.taken >= s.limit
+}
+
+// stop отмечает, что остаток входа не читается
+func (s *sampler) stop() {
+	if s != nil {
+		s.halted = true
+	}
+}
+
+// stopped сообщает, что вход не дочитан
+func (s *sampler) stopped() bool {
+	return s != nil && s.halted
+}
+
+// withSampler задает выборку записей верхнего уровня состояния
+func (s *state) withSampler(sampler *sampler) *state {
+	s.sampler = sampler
+	return s
+}
+
+// records оставляет в данных только отобранные записи корневого массива
+// или массива data
+func (s *sampler) records(data interface{}) interface{} {
+	if s == nil {
+		return data
+	}
+	switch v := data.(type) {
+	case []interface{}:
+		return s.filter(v)
+	case map[string]interface{}:
+		records, ok := v["data"].([]interface{})
+		if !ok {
+			return data
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			copied[key] = value
+		}
+		copied["data"] = s.filter(records)
+		return copied
+	}
+	return data
+}
+
+// filter возвращает отобранные записи массива
+func (s *sampler) filter(records []interface{}) []interface{} {
+	kept := make([]interface{}, 0)
+	for i, record := range records {
+		if s.full() {
+			// Остаток массива уже в памяти, поэтому считается прочитанным
+			s.seen += len(records) - i
+			break
+		}
+		if s.take() {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// metadata описывает выборку для метаданных анализа
+func (s *sampler) metadata() *types.Sampling {
+	if s == nil {
+		return nil
+	}
+	sampling := &types.Sampling{MaxRecords: s.limit, Rate: s.rate, Sampled: s.taken}
+	if s.rng != nil {
+		sampling.Seed = s.seed
+	}
+	if !s.halted {
+		sampling.Total = s.seen
+	}
+	return sampling
+}
+
+// addSampling суммирует выборки нескольких входных файлов одного анализа
+func addSampling(total, add *types.Sampling) *types.Sampling {
+	if total == nil || add == nil {
+		return nil
+	}
+	sum := *total
+	sum.Sampled += add.Sampled
+	if total.Total > 0 && add.Total > 0 {
+		sum.Total += add.Total
+	} else {
+		sum.Total = 0
+	}
+	return &sum
+}
+
+// recordsPath сообщает, что массив по пути path содержит записи верхнего
+// уровня: это корневой массив или массив data корневого объекта
+func recordsPath(path string) bool {
+	return path == "" || path == ".data"
+}
+
+// recordSampled запоминает вложенный массив, схема элементов которого
+// выведена по первым MaxArraySamples элементам
+func (s *state) recordSampled(path string) {
+	if s.sampled == nil {
+		s.sampled = make(map[string]bool)
+	}
+	s.sampled[fieldPath(path)] = true
+}
+
+// sampledWarnings возвращает предупреждения о массивах, элементы которых
+// проанализированы не все: поля, которые есть только в последующих
+// элементах, в схему не попали
+func (a *Analyzer) sampledWarnings(st *state) []string {
+	paths := make([]string, 0, len(st.sampled))
+	for path := range st.sampled {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	warnings := make([]string, 0, len(paths))
+	for _, path := range paths {
+		warnings = append(warnings, fmt.Sprintf("%s: схема элементов выведена по первым %d элементам массива", path, a.config.MaxArraySamples))
+	}
+	return warnings
+}
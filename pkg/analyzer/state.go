@@ -0,0 +1,297 @@
+package analyzer
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// state накапливает данные одного прохода анализа
+type state struct {
+	stats *types.AnalysisStatistics
+
+	// sampler отбирает анализируемые записи верхнего уровня; nil - все записи
+	sampler *sampler
+
+	// sampled - пути вложенных массивов, элементы которых проанализированы
+	// не все, а первые MaxArraySamples (см. recordSampled)
+	sampled map[string]bool
+
+	// objects - сколько объектов встретилось по пути, fields - сколько раз
+	// в них присутствовало каждое поле
+	objects map[string]int
+	fields  map[string]map[string]int
+
+	// strings - значения строковых полей по пути для примеров
+	strings map[string]*stringValues
+
+	// patterns - общая форма значений строковых полей по пути
+	patterns map[string]*patternMiner
+
+	// kinds - сколько значений каждого типа встретилось по пути
+	kinds map[string]map[string]int
+
+	// formats - распознавание формата строк по пути (см. scanFormat)
+	formats map[string]*formatScan
+
+	// paths - пути полей объектов, разобранных по токенам (см. childPath)
+	paths map[[2]string]childPath
+
+	// arena - блоки, из которых выделяются узлы схемы при анализе; nil -
+	// узлы выделяются обычными объектами
+	arena *arena
+
+	// order возвращает порядок полей во входных данных (см. keyOrder);
+	// nil - поля упорядочиваются по алфавиту
+	order func() *keyOrder
+
+	// depth - глубина анализируемого значения: число объектов и массивов,
+	// внутри которых оно находится
+	depth int
+}
+
+// newState создает состояние анализа, пишущее статистику в stats
+func newState(stats *types.AnalysisStatistics) *state {
+	return &state{
+		stats:    stats,
+		objects:  make(map[string]int),
+		fields:   make(map[string]map[string]int),
+		strings:  make(map[string]*stringValues),
+		patterns: make(map[string]*patternMiner),
+		kinds:    make(map[string]map[string]int),
+		formats:  make(map[string]*formatScan),
+	}
+}
+
+// recordObject учитывает присутствие полей объекта по пути
+func (s *state) recordObject(path string, obj map[string]interface{}) {
+	counts := s.objectFields(path)
+	for key, value := range obj {
+		s.recordField(counts, key, fieldPath(path+"."+key), value == nil)
+	}
+}
+
+// objectFields учитывает объект по пути и возвращает счетчики его полей
+func (s *state) objectFields(path string) map[string]int {
+	s.objects[path]++
+	counts, ok := s.fields[path]
+	if !ok {
+		counts = make(map[string]int)
+		s.fields[path] = counts
+	}
+	return counts
+}
+
+// recordField учитывает присутствие поля key в объекте; field - путь поля
+// в формате fieldmanager
+func (s *state) recordField(counts map[string]int, key, field string, null bool) {
+	counts[key]++
+
+	s.stats.FieldPresence[field]++
+	if null {
+		s.stats.FieldNulls[field]++
+	}
+}
+
+// recordConflict запоминает несовместимые типы значений поля
+func (s *state) recordConflict(path string, kinds ...string) {
+	s.stats.TypeConflicts = addConflict(s.stats.TypeConflicts, fieldPath(path), kinds...)
+}
+
+// applyRequired выставляет required по частоте присутствия полей: поле
+// обязательно, если встречается не реже чем в percent процентах объектов
+func (s *state) applyRequired(prop *types.Property, path string, percent float64) {
+	if prop == nil {
+		return
+	}
+
+	if prop.Type == "object" && prop.Properties != nil {
+		if total := s.objects[path]; total > 0 {
+			required := make([]string, 0, len(prop.Properties))
+			for key := range prop.Properties {
+				if float64(s.fields[path][key])*100 >= percent*float64(total) {
+					required = append(required, key)
+				}
+			}
+			sort.Strings(required)
+			prop.Required = required
+		}
+
+		for key, child := range prop.Properties {
+			s.applyRequired(child, path+"."+key, percent)
+		}
+	}
+
+	s.applyRequired(prop.Items, path+"[0]", percent)
+}
+
+// sortRequired упорядочивает required по алфавиту и убирает повторы во всем
+// дереве схемы. Списки объектов, не прошедших applyRequired (позиции
+// кортежей, варианты anyOf, значения словарей), иначе сохранили бы порядок
+// обхода map и менялись бы от запуска к запуску
+func sortRequired(prop *types.Property) {
+	if prop == nil {
+		return
+	}
+	prop.Required = uniqueSorted(prop.Required)
+	for _, child := range prop.Properties {
+		sortRequired(child)
+	}
+	for _, child := range prop.PatternProperties {
+		sortRequired(child)
+	}
+	for _, item := range prop.PrefixItems {
+		sortRequired(item)
+	}
+	sortRequired(prop.Items)
+	if prop.AdditionalProperties != nil {
+		sortRequired(prop.AdditionalProperties.Schema)
+	}
+	sortVariantsRequired(prop.OneOf)
+	sortVariantsRequired(prop.AnyOf)
+}
+
+// sortVariantsRequired упорядочивает required вариантов oneOf и anyOf
+func sortVariantsRequired(variants []*types.JSONSchema) {
+	for _, variant := range variants {
+		if variant == nil {
+			continue
+		}
+		root := &types.Property{
+			Required:             variant.Required,
+			Properties:           variant.Properties,
+			PatternProperties:    variant.PatternProperties,
+			Items:                variant.Items,
+			AdditionalProperties: variant.AdditionalProperties,
+			OneOf:                variant.OneOf,
+			AnyOf:                variant.AnyOf,
+		}
+		sortRequired(root)
+		variant.Required = root.Required
+	}
+}
+
+// uniqueSorted возвращает строки по алфавиту без повторов
+func uniqueSorted(values []string) []string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+// optionalFields возвращает пути необязательных полей схемы в формате fieldmanager
+func optionalFields(schema *types.JSONSchema) []string {
+	if schema == nil {
+		return nil
+	}
+
+	var fields []string
+	collectOptional(&fields, "", schema.Properties, schema.Required, schema.Items)
+	sort.Strings(fields)
+	return fields
+}
+
+// collectOptional рекурсивно собирает поля, не входящие в required своего объекта
+func collectOptional(fields *[]string, path string, props map[string]*types.Property, required []string, items *types.Property) {
+	requiredSet := make(map[string]bool, len(required))
+	for _, name := range required {
+		requiredSet[name] = true
+	}
+
+	for key, child := range props {
+		if child == nil {
+			continue
+		}
+		childPath := joinPath(path, key)
+		if !requiredSet[key] {
+			*fields = append(*fields, childPath)
+		}
+		collectOptional(fields, childPath, child.Properties, child.Required, child.Items)
+	}
+
+	if items != nil {
+		collectOptional(fields, joinPath(path, "0"), items.Properties, items.Required, items.Items)
+	}
+}
+
+// positionSegment - индекс позиции кортежа во внутреннем пути анализатора
+var positionSegment = regexp.MustCompile(`\[(\d+)\]`)
+
+// fieldPath переводит внутренний путь анализатора (".data[0].role", позиции
+// кортежа ".point[1]") в формат fieldmanager ("data.0.role", "point.1")
+func fieldPath(path string) string {
+	path = strings.ReplaceAll(path, "[0]", ".0")
+	if strings.Contains(path, "[") {
+		path = positionSegment.ReplaceAllString(path, ".$1")
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+// addCounts прибавляет счетчики src к dst, создавая dst при необходимости
+func addCounts(dst, src map[string]int) map[string]int {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]int, len(src))
+	}
+	for key, count := range src {
+		dst[key] += count
+	}
+	return dst
+}
+
+// addConflict добавляет типы к конфликту поля, создавая conflicts при необходимости
+func addConflict(conflicts map[string][]string, field string, kinds ...string) map[string][]string {
+	if conflicts == nil {
+		conflicts = make(map[string][]string)
+	}
+	merged := conflicts[field]
+	for _, kind := range kinds {
+		if !containsString(merged, kind) {
+			merged = append(merged, kind)
+		}
+	}
+	sort.Strings(merged)
+	conflicts[field] = merged
+	return conflicts
+}
+
+// containsString сообщает, что строка входит в список
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath собирает путь в формате fieldmanager
+func joinPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+// intersectRequired оставляет в required только поля, обязательные в обеих
+// схемах, в порядке существующего списка
+func intersectRequired(existing, new []string) []string {
+	newSet := make(map[string]bool, len(new))
+	for _, name := range new {
+		newSet[name] = true
+	}
+
+	result := make([]string, 0, len(existing))
+	for _, name := range existing {
+		if newSet[name] {
+			// Повтор в существующем списке попадает в результат один раз
+			delete(newSet, name)
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,410 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// maxStreamSamples - сколько записей потокового анализа хранится для
+// распознавания полиморфных элементов; остальные записи после анализа
+// отбрасываются
+const maxStreamSamples = 1000
+
+// utf8BOM - метка порядка байтов, с которой могут начинаться файлы
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// analyzeFileStream анализирует файл потоком, не читая его в память целиком
+func (a *Analyzer) analyzeFileStream(filename string) (*types.AnalysisResult, error) {
+	file, err := decompress.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	defer file.Close()
+
+	result, err := a.AnalyzeStream(file)
+	if errors.Is(err, ErrConcatenated) {
+		return a.analyzeFileNDJSON(filename)
+	}
+	return result, err
+}
+
+// ErrConcatenated возвращается потоковым анализом, если за первым
+// документом JSON следуют другие. Поток нельзя перечитать, поэтому такой
+// вход анализируется заново как NDJSON: файл - автоматически, поток -
+// вызовом AnalyzeNDJSON
+var ErrConcatenated = errors.New("за первым документом JSON следуют другие; анализируйте поток как NDJSON")
+
+// AnalyzeStream анализирует JSON из потока. Записи корневого массива или
+// массива data декодируются и объединяются со схемой по одной, поэтому
+// память ограничена размером одной записи, а не всего входа. Схема
+// совпадает со схемой анализа в памяти, кроме uniqueItems корневого массива
+// и полиморфных элементов, которые распознаются по первым maxStreamSamples
+// записям. С Config.Tokenize или DepthTruncate записи анализируются по
+// токенам (см. analyzeTokens, truncates). Поток из нескольких документов
+// подряд дает ErrConcatenated
+func (a *Analyzer) AnalyzeStream(r io.Reader) (*types.AnalysisResult, error) {
+	r, order := a.orderReader(a.reader(r), "")
+	if order != nil {
+		defer order()
+	}
+	reader := newStreamReader(r)
+	if a.config.Tokenize || a.truncates() {
+		return a.analyzeTokens(newScanner(reader, a.config.MaxDepth), order)
+	}
+	dec := json.NewDecoder(reader)
+	dec.UseNumber()
+
+	result := a.newResult()
+	st := newState(result.Statistics).withArena().withSampler(a.newSampler()).withOrder(order)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+	}
+
+	var schema *types.Property
+	switch tok {
+	case json.Delim('['):
+		st.stats.TypeDistribution["array"]++
+		a.recordKindAt("", types.TypeArray, st)
+		schema, err = a.streamArray(dec, "", st)
+	case json.Delim('{'):
+		a.recordKindAt("", types.TypeObject, st)
+		schema, err = a.streamObject(dec, st)
+	default:
+		schema, err = a.analyzeValue(tok, "", st)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !st.sampler.stopped() {
+		if dec.More() {
+			return nil, ErrConcatenated
+		}
+		if err := checkEnd(dec); err != nil {
+			return nil, err
+		}
+	}
+	return a.buildResult(result, schema, st)
+}
+
+// checkEnd проверяет, что после последнего документа в потоке остались
+// только пробелы. More возвращает false и перед лишними ] или }, поэтому
+// конец входа подтверждается только io.EOF
+func checkEnd(dec *json.Decoder) error {
+	var rest json.RawMessage
+	if err := dec.Decode(&rest); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("лишние данные после документа")
+		}
+		return fmt.Errorf("ошибка парсинга JSON: %w", err)
+	}
+	return nil
+}
+
+// newStreamReader возвращает буферизованный поток без метки порядка байтов
+func newStreamReader(r io.Reader) *bufio.Reader {
+	reader := bufio.NewReader(r)
+	if prefix, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+	return reader
+}
+
+// IsNDJSON сообщает, что файл в формате NDJSON (по расширению .ndjson,
+// в том числе сжатый: .ndjson.gz, .ndjson.zst): по одной записи JSON на строку
+func IsNDJSON(filename string) bool {
+	return strings.EqualFold(filepath.Ext(decompress.TrimExt(filename)), ".ndjson")
+}
+
+// analyzeFileNDJSON анализирует файл NDJSON
+func (a *Analyzer) analyzeFileNDJSON(filename string) (*types.AnalysisResult, error) {
+	file, err := decompress.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	defer file.Close()
+
+	return a.AnalyzeNDJSON(file)
+}
+
+// AnalyzeNDJSON анализирует поток NDJSON как корневой массив его записей.
+// Записи всегда объединяются по одной, как при потоковом анализе, поэтому
+// MaxInputSize не применяется. Записи могут разделяться любыми пробельными
+// символами, не только переводами строк. При Workers больше 1 записи
+// анализируются параллельно (см. analyzeNDJSONParallel), кроме DepthTruncate:
+// тогда записи читаются сканером одной горутиной (см. truncates)
+func (a *Analyzer) AnalyzeNDJSON(r io.Reader) (*types.AnalysisResult, error) {
+	r, order := a.orderReader(a.reader(r), "[0]")
+	if order != nil {
+		defer order()
+	}
+	var dec valueDecoder
+	switch {
+	case a.truncates():
+		dec = scanDecoder{newScanner(newStreamReader(r), a.config.MaxDepth)}
+	case a.workers() > 1:
+		return a.analyzeNDJSONParallel(r, order)
+	default:
+		decoder := json.NewDecoder(newStreamReader(r))
+		decoder.UseNumber()
+		dec = decoder
+	}
+
+	result := a.newResult()
+	st := newState(result.Statistics).withArena().withSampler(a.newSampler()).withOrder(order)
+	st.stats.TypeDistribution["array"]++
+	a.recordKindAt("", types.TypeArray, st)
+	// Записи - элементы корневого массива
+	st.depth++
+
+	property, count, samples, err := a.streamElements(dec, "", st)
+	if err != nil {
+		return nil, err
+	}
+	if decoder, ok := dec.(*json.Decoder); ok && !st.sampler.stopped() {
+		if err := checkEnd(decoder); err != nil {
+			return nil, err
+		}
+	}
+	schema, err := a.finishArray(property, count, samples, "[0]", st)
+	if err != nil {
+		return nil, err
+	}
+	return a.buildResult(result, schema, st)
+}
+
+// recordKindAt учитывает тип значения, которое при потоковом анализе не
+// проходит через analyzeValue
+func (a *Analyzer) recordKindAt(path string, kind types.JSONType, st *state) {
+	if a.config.Confidence || a.config.MinSamples > 0 {
+		st.recordKind(path, string(kind))
+	}
+}
+
+// streamObject анализирует корневой объект, открывающая скобка которого уже
+// прочитана. Массив data анализируется потоком, остальные поля - целиком
+func (a *Analyzer) streamObject(dec *json.Decoder, st *state) (*types.Property, error) {
+	obj := make(map[string]interface{})
+	var records *types.Property
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+		}
+		key, _ := tok.(string)
+
+		if key != "data" {
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+			}
+			obj[key] = value
+			continue
+		}
+
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+		}
+		if tok != json.Delim('[') {
+			value, err := decodeTokens(dec, tok)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = value
+			continue
+		}
+		st.depth++
+		records, err = a.streamArray(dec, ".data", st)
+		st.depth--
+		if err != nil {
+			return nil, err
+		}
+		// Массив уже проанализирован; в объекте остается пустой массив,
+		// чтобы поле учитывалось в статистике
+		obj[key] = []interface{}{}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+	}
+
+	property, err := a.analyzeObject(obj, "", st)
+	if err != nil {
+		return nil, err
+	}
+	if records != nil {
+		property.Properties["data"] = records
+	}
+	return property, nil
+}
+
+// streamArray анализирует элементы массива, открывающая скобка которого уже
+// прочитана, объединяя их схемы по мере чтения
+func (a *Analyzer) streamArray(dec *json.Decoder, path string, st *state) (*types.Property, error) {
+	st.depth++
+	defer func() { st.depth-- }()
+	property, count, samples, err := a.streamElements(dec, path, st)
+	if err != nil {
+		return nil, err
+	}
+	if st.sampler.stopped() {
+		return a.finishArray(property, count, samples, path+"[0]", st)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+	}
+	return a.finishArray(property, count, samples, path+"[0]", st)
+}
+
+// streamElements читает и объединяет значения, пока они есть в текущем
+// массиве потока или, на верхнем уровне, до конца потока. Возвращает схему
+// массива, число значений и выборку для распознавания полиморфных элементов
+func (a *Analyzer) streamElements(dec valueDecoder, path string, st *state) (*types.Property, int, []interface{}, error) {
+	property := &types.Property{Type: "array"}
+	itemPath := path + "[0]"
+
+	var samples []interface{}
+	count := 0
+	start := st.mark()
+	for dec.More() {
+		// Набранная выборка корневого массива завершает чтение входа
+		if path == "" && st.sampler.full() {
+			st.sampler.stop()
+			break
+		}
+		if !st.sampler.take() {
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return nil, 0, nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+			}
+			continue
+		}
+
+		var element interface{}
+		if err := dec.Decode(&element); err != nil {
+			return nil, 0, nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+		}
+		count++
+
+		// Сверх лимитов записи только считаются
+		if a.config.MaxRecords > 0 && count > a.config.MaxRecords {
+			continue
+		}
+
+		itemProperty, err := a.analyzeValue(element, itemPath, st)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		a.mergeItems(property, itemProperty, itemPath, st)
+		if count%compactInterval == 0 {
+			property.Items = st.compact(property.Items, start)
+		}
+		if a.config.DetectPolymorphic && len(samples) < maxStreamSamples {
+			samples = append(samples, element)
+		}
+	}
+	return property, count, samples, nil
+}
+
+// valueDecoder читает значения потока подряд: json.Decoder или scanDecoder
+type valueDecoder interface {
+	More() bool
+	Decode(v interface{}) error
+}
+
+// scanDecoder читает значения потока сканером. Decode декодирует значение
+// в *interface{} так же, как json.Decoder с UseNumber, а для других целей
+// только пропускает его
+type scanDecoder struct {
+	sc *scanner
+}
+
+// More сообщает, что в потоке есть еще значение
+func (d scanDecoder) More() bool {
+	_, err := d.sc.peek()
+	return err == nil
+}
+
+// Decode читает следующее значение потока
+func (d scanDecoder) Decode(v interface{}) error {
+	target, ok := v.(*interface{})
+	if !ok {
+		return d.sc.skip()
+	}
+	value, err := d.sc.value()
+	*target = value
+	return err
+}
+
+// finishArray дополняет схему прочитанного потоком массива из count
+// элементов: проверяет лимит записей, выставляет границы размера и
+// распознает полиморфные элементы по выборке samples
+func (a *Analyzer) finishArray(property *types.Property, count int, samples []interface{}, itemPath string, st *state) (*types.Property, error) {
+	if a.config.MaxRecords > 0 && count > a.config.MaxRecords {
+		return nil, &LimitError{Kind: LimitRecords, Actual: int64(count), Limit: int64(a.config.MaxRecords)}
+	}
+	if a.config.ArrayLimits {
+		n := count
+		property.MinItems, property.MaxItems = &n, &n
+	}
+	if a.config.DetectPolymorphic && len(samples) > 0 {
+		variants, err := a.polymorphicItems(samples, itemPath, st)
+		if err != nil {
+			return nil, err
+		}
+		if variants != nil {
+			property.Items = variants
+		}
+	}
+	return property, nil
+}
+
+// decodeTokens декодирует значение, первый токен которого уже прочитан
+func decodeTokens(dec *json.Decoder, tok json.Token) (interface{}, error) {
+	switch tok {
+	case json.Delim('{'):
+		obj := make(map[string]interface{})
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+			}
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+			}
+			name, _ := key.(string)
+			obj[name] = value
+		}
+		_, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+		}
+		return obj, nil
+	case json.Delim('['):
+		arr := make([]interface{}, 0)
+		for dec.More() {
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+			}
+			arr = append(arr, value)
+		}
+		_, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+		}
+		return arr, nil
+	}
+	return tok, nil
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+func TestTrailingData(t *testing.T) {
+	analyzers := []struct {
+		name    string
+		config  func(c *Config)
+		analyze func(a *Analyzer, input string) (*types.AnalysisResult, error)
+	}{
+		{"bytes", nil, func(a *Analyzer, input string) (*types.AnalysisResult, error) { return a.AnalyzeBytes([]byte(input)) }},
+		{"truncate", func(c *Config) { c.MaxDepth, c.DepthPolicy = 10, DepthTruncate }, func(a *Analyzer, input string) (*types.AnalysisResult, error) {
+			return a.AnalyzeBytes([]byte(input))
+		}},
+		{"stream", nil, func(a *Analyzer, input string) (*types.AnalysisResult, error) {
+			return a.AnalyzeStream(strings.NewReader(input))
+		}},
+		{"tokenize", func(c *Config) { c.Tokenize = true }, func(a *Analyzer, input string) (*types.AnalysisResult, error) {
+			return a.AnalyzeStream(strings.NewReader(input))
+		}},
+		{"ndjson", nil, func(a *Analyzer, input string) (*types.AnalysisResult, error) {
+			return a.AnalyzeNDJSON(strings.NewReader(input))
+		}},
+		{"ndjson/workers=2", func(c *Config) { c.Workers = 2 }, func(a *Analyzer, input string) (*types.AnalysisResult, error) {
+			return a.AnalyzeNDJSON(strings.NewReader(input))
+		}},
+	}
+	inputs := []struct {
+		input string
+		valid bool
+	}{
+		{`{"a": 1}`, true},
+		{"[1, 2]\n\t ", true},
+		{`{"a": 1}]`, false},
+		{`{"a": 1}}`, false},
+		{`[1, 2]]`, false},
+		{"{\"a\": 1}\n{\"b\": 2}\n]", false},
+	}
+	for _, an := range analyzers {
+		t.Run(an.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Workers = 1
+			if an.config != nil {
+				an.config(&config)
+			}
+			a := NewWithConfig(config)
+			for _, in := range inputs {
+				_, err := an.analyze(a, in.input)
+				if in.valid && err != nil {
+					t.Errorf("%q: %v", in.input, err)
+				}
+				if !in.valid && err == nil {
+					t.Errorf("%q: лишние данные после документа приняты", in.input)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// maxStructures ограничивает число различных форм в статистике: объекты
+// словарей с произвольными ключами не должны раздувать схему
+const maxStructures = 1000
+
+// recordStructure учитывает форму объекта: набор полей с типами значений по пути
+func (a *Analyzer) recordStructure(path string, obj map[string]interface{}, st *state) {
+	fields := make([]string, 0, len(obj))
+	for key, value := range obj {
+		fields = append(fields, key+":"+a.kindOf(value))
+	}
+	addStructure(path, fields, st)
+}
+
+// addStructure учитывает форму объекта по пути; fields - поля в виде
+// "имя:тип" в любом порядке
+func addStructure(path string, fields []string, st *state) {
+	sort.Strings(fields)
+
+	field := fieldPath(path)
+	hash := structureHash(field, fields)
+	if structure, ok := st.stats.Structures[hash]; ok {
+		structure.Count++
+		return
+	}
+	if len(st.stats.Structures) >= maxStructures {
+		return
+	}
+	if st.stats.Structures == nil {
+		st.stats.Structures = make(map[string]*types.Structure)
+	}
+	st.stats.Structures[hash] = &types.Structure{Path: field, Fields: fields, Count: 1}
+	st.stats.UniqueStructures = len(st.stats.Structures)
+}
+
+// structureHash возвращает хеш формы объекта по пути
+func structureHash(path string, fields []string) string {
+	h := fnv.New64a()
+	h.Write([]byte(path))
+	for _, field := range fields {
+		h.Write([]byte{0})
+		h.Write([]byte(field))
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// mergeStructures добавляет формы новой выборки к статистике
+func mergeStructures(existing, new *types.AnalysisStatistics) {
+	for hash, structure := range new.Structures {
+		if current, ok := existing.Structures[hash]; ok {
+			current.Count += structure.Count
+			continue
+		}
+		if len(existing.Structures) >= maxStructures {
+			continue
+		}
+		if existing.Structures == nil {
+			existing.Structures = make(map[string]*types.Structure)
+		}
+		added := *structure
+		existing.Structures[hash] = &added
+	}
+	existing.UniqueStructures = len(existing.Structures)
+}
@@ -0,0 +1,652 @@
+package analyzer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// maxInternedKeys ограничивает число запоминаемых имен полей: словари с
+// произвольными ключами не должны раздувать кеш
+const maxInternedKeys = 10000
+
+// scanner читает JSON из потока по токенам. В отличие от json.Decoder он
+// не создает значение для каждого токена: имена полей берутся из кеша,
+// а пропускаемые значения не декодируются вовсе
+type scanner struct {
+	r      *bufio.Reader
+	offset int64
+	buf    []byte
+	keys   map[string]string
+
+	// depth - число открытых объектов и массивов; объекты и массивы на
+	// глубине maxDepth и глубже value не разбирает (0 - без ограничения)
+	depth    int
+	maxDepth int
+}
+
+// truncatedValue - объект или массив глубже предела вложенности сканера,
+// пропущенный без разбора; значение - его тип
+type truncatedValue types.JSONType
+
+// newScanner создает сканер потока с пределом вложенности maxDepth
+func newScanner(r *bufio.Reader, maxDepth int) *scanner {
+	return &scanner{r: r, keys: make(map[string]string), maxDepth: maxDepth}
+}
+
+// tooDeep сообщает, что следующее значение находится на глубине maxDepth
+// или глубже
+func (s *scanner) tooDeep() bool {
+	return s.maxDepth > 0 && s.depth >= s.maxDepth
+}
+
+// peek возвращает следующий значащий байт, не читая его
+func (s *scanner) peek() (byte, error) {
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return 0, s.eofError(err)
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			s.offset++
+			continue
+		}
+		s.r.UnreadByte()
+		return c, nil
+	}
+}
+
+// next читает следующий значащий байт
+func (s *scanner) next() (byte, error) {
+	c, err := s.peek()
+	if err != nil {
+		return 0, err
+	}
+	s.r.ReadByte()
+	s.offset++
+	return c, nil
+}
+
+// expect читает следующий значащий байт и проверяет, что это want
+func (s *scanner) expect(want byte) error {
+	c, err := s.next()
+	if err != nil {
+		return err
+	}
+	if c != want {
+		return s.syntaxError(c)
+	}
+	return nil
+}
+
+// syntaxError описывает неожиданный символ во входных данных
+func (s *scanner) syntaxError(c byte) error {
+	return fmt.Errorf("неожиданный символ %q на позиции %d", c, s.offset)
+}
+
+// eofError превращает конец потока внутри значения в io.ErrUnexpectedEOF
+func (s *scanner) eofError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// object читает объект, вызывая field для каждого поля; field должен
+// прочитать значение поля
+func (s *scanner) object(field func(key string) error) error {
+	if err := s.expect('{'); err != nil {
+		return err
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+	if c, err := s.peek(); err != nil {
+		return err
+	} else if c == '}' {
+		s.next()
+		return nil
+	}
+	for {
+		key, err := s.key()
+		if err != nil {
+			return err
+		}
+		if err := s.expect(':'); err != nil {
+			return err
+		}
+		if err := field(key); err != nil {
+			return err
+		}
+		c, err := s.next()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case ',':
+			continue
+		case '}':
+			return nil
+		}
+		return s.syntaxError(c)
+	}
+}
+
+// array читает массив, вызывая element для каждого элемента; element должен
+// прочитать элемент
+func (s *scanner) array(element func() error) error {
+	if err := s.expect('['); err != nil {
+		return err
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+	if c, err := s.peek(); err != nil {
+		return err
+	} else if c == ']' {
+		s.next()
+		return nil
+	}
+	for {
+		if err := element(); err != nil {
+			return err
+		}
+		c, err := s.next()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case ',':
+			continue
+		case ']':
+			return nil
+		}
+		return s.syntaxError(c)
+	}
+}
+
+// value декодирует значение в те же типы, что json.Decoder с UseNumber.
+// Объекты и массивы глубже предела вложенности пропускаются, вместо них
+// возвращается truncatedValue: разбор не углубляется рекурсией без предела
+func (s *scanner) value() (interface{}, error) {
+	c, err := s.peek()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case (c == '{' || c == '[') && s.tooDeep():
+		kind := types.TypeObject
+		if c == '[' {
+			kind = types.TypeArray
+		}
+		return truncatedValue(kind), s.skip()
+	case c == '{':
+		obj := make(map[string]interface{})
+		err := s.object(func(key string) error {
+			value, err := s.value()
+			obj[key] = value
+			return err
+		})
+		return obj, err
+	case c == '[':
+		arr := make([]interface{}, 0)
+		err := s.array(func() error {
+			value, err := s.value()
+			arr = append(arr, value)
+			return err
+		})
+		return arr, err
+	case c == '"':
+		if err := s.readString(); err != nil {
+			return nil, err
+		}
+		return s.decodeString()
+	case c == '-' || c >= '0' && c <= '9':
+		return s.number()
+	case c == 't':
+		return true, s.literal("true")
+	case c == 'f':
+		return false, s.literal("false")
+	case c == 'n':
+		return nil, s.literal("null")
+	}
+	s.next()
+	return nil, s.syntaxError(c)
+}
+
+// skip пропускает значение, не декодируя его. Вложенные значения проверяются
+// только на парность скобок и кавычек
+func (s *scanner) skip() error {
+	c, err := s.peek()
+	if err != nil {
+		return err
+	}
+	switch c {
+	case '"':
+		return s.readString()
+	case '{', '[':
+	default:
+		_, err := s.value()
+		return err
+	}
+
+	depth := 0
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return s.eofError(err)
+		}
+		switch c {
+		case '"':
+			s.r.UnreadByte()
+			if err := s.readString(); err != nil {
+				return err
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		}
+		s.offset++
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// key читает имя поля. Повторяющиеся имена берутся из кеша, чтобы не
+// выделять строку для каждого поля каждой записи
+func (s *scanner) key() (string, error) {
+	if err := s.readString(); err != nil {
+		return "", err
+	}
+	if key, ok := s.keys[string(s.buf)]; ok {
+		return key, nil
+	}
+	key, err := s.decodeString()
+	if err != nil {
+		return "", err
+	}
+	if len(s.keys) < maxInternedKeys {
+		s.keys[string(s.buf)] = key
+	}
+	return key, nil
+}
+
+// readString читает строку в кавычках в buf, включая кавычки
+func (s *scanner) readString() error {
+	if err := s.expect('"'); err != nil {
+		return err
+	}
+	s.buf = append(s.buf[:0], '"')
+	for {
+		chunk, err := s.r.ReadSlice('"')
+		s.buf = append(s.buf, chunk...)
+		s.offset += int64(len(chunk))
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			return s.eofError(err)
+		}
+		// Кавычка экранирована, если перед ней нечетное число обратных слешей
+		slashes := 0
+		for i := len(s.buf) - 2; i > 0 && s.buf[i] == '\\'; i-- {
+			slashes++
+		}
+		if slashes%2 == 0 {
+			return nil
+		}
+	}
+}
+
+// decodeString возвращает строку, прочитанную readString. Строки без
+// экранирования копируются как есть, остальные декодирует encoding/json
+func (s *scanner) decodeString() (string, error) {
+	raw := s.buf[1 : len(s.buf)-1]
+	if plainString(raw) {
+		return string(raw), nil
+	}
+	var str string
+	if err := json.Unmarshal(s.buf, &str); err != nil {
+		return "", fmt.Errorf("некорректная строка на позиции %d: %w", s.offset, err)
+	}
+	return str, nil
+}
+
+// plainString сообщает, что содержимое строки не требует декодирования
+func plainString(raw []byte) bool {
+	ascii := true
+	for _, c := range raw {
+		if c < 0x20 || c == '\\' {
+			return false
+		}
+		ascii = ascii && c < utf8.RuneSelf
+	}
+	return ascii || utf8.Valid(raw)
+}
+
+// number читает число в исходной записи
+func (s *scanner) number() (json.Number, error) {
+	s.buf = s.buf[:0]
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", err
+		}
+		if c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' && (c < '0' || c > '9') {
+			s.r.UnreadByte()
+			break
+		}
+		s.buf = append(s.buf, c)
+	}
+	s.offset += int64(len(s.buf))
+	if !validNumber(s.buf) {
+		return "", fmt.Errorf("некорректное число %q на позиции %d", s.buf, s.offset)
+	}
+	return json.Number(s.buf), nil
+}
+
+// validNumber проверяет запись числа по грамматике JSON
+func validNumber(b []byte) bool {
+	i := 0
+	if i < len(b) && b[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(b) && b[i] == '0':
+		i++
+	case i < len(b) && b[i] >= '1' && b[i] <= '9':
+		i = skipDigits(b, i)
+	default:
+		return false
+	}
+	if i < len(b) && b[i] == '.' {
+		if j := skipDigits(b, i+1); j > i+1 {
+			i = j
+		} else {
+			return false
+		}
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		if j := skipDigits(b, i); j > i {
+			i = j
+		} else {
+			return false
+		}
+	}
+	return i == len(b)
+}
+
+// skipDigits возвращает позицию первого символа после цифр, начиная с i
+func skipDigits(b []byte, i int) int {
+	for i < len(b) && b[i] >= '0' && b[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// literal читает true, false или null
+func (s *scanner) literal(word string) error {
+	for i := 0; i < len(word); i++ {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return s.eofError(err)
+		}
+		s.offset++
+		if c != word[i] {
+			return s.syntaxError(c)
+		}
+	}
+	return nil
+}
+
+// analyzeTokens анализирует поток так же, как AnalyzeStream, но объекты
+// записей разбираются прямо по токенам: дерево значений не строится, а
+// схема объекта собирается по мере чтения его полей. Массивы внутри
+// записей и первые maxStreamSamples записей, нужные для распознавания
+// полиморфных элементов, декодируются как обычно
+func (a *Analyzer) analyzeTokens(sc *scanner, order func() *keyOrder) (*types.AnalysisResult, error) {
+	result := a.newResult()
+	st := newState(result.Statistics).withArena().withSampler(a.newSampler()).withOrder(order)
+
+	c, err := sc.peek()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON: %w", err)
+	}
+
+	var schema *types.Property
+	switch c {
+	case '[':
+		st.stats.TypeDistribution["array"]++
+		a.recordKindAt("", types.TypeArray, st)
+		schema, err = a.tokenArray(sc, "", st)
+	case '{':
+		a.recordKindAt("", types.TypeObject, st)
+		schema, err = a.tokenRoot(sc, st)
+	default:
+		var value interface{}
+		if value, err = sc.value(); err == nil {
+			schema, err = a.analyzeValue(value, "", st)
+		}
+	}
+	if err != nil {
+		return nil, wrapTokenError(err)
+	}
+	if _, err := sc.peek(); err == nil && !st.sampler.stopped() {
+		return nil, ErrConcatenated
+	}
+	return a.buildResult(result, schema, st)
+}
+
+// wrapTokenError помечает ошибки разбора потока как ошибки парсинга JSON;
+// ошибки анализа и превышения лимитов возвращаются как есть
+func wrapTokenError(err error) error {
+	var limit *LimitError
+	if errors.As(err, &limit) {
+		return err
+	}
+	return fmt.Errorf("ошибка парсинга JSON: %w", err)
+}
+
+// tokenRoot анализирует корневой объект: массив data разбирается по
+// токенам, остальные поля декодируются целиком
+func (a *Analyzer) tokenRoot(sc *scanner, st *state) (*types.Property, error) {
+	obj := make(map[string]interface{})
+	var records *types.Property
+	err := sc.object(func(key string) error {
+		if c, err := sc.peek(); err != nil {
+			return err
+		} else if key != "data" || c != '[' {
+			value, err := sc.value()
+			obj[key] = value
+			return err
+		}
+		var err error
+		st.depth++
+		records, err = a.tokenArray(sc, ".data", st)
+		st.depth--
+		// Массив уже проанализирован; в объекте остается пустой массив,
+		// чтобы поле учитывалось в статистике
+		obj[key] = []interface{}{}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	property, err := a.analyzeObject(obj, "", st)
+	if err != nil {
+		return nil, err
+	}
+	if records != nil {
+		property.Properties["data"] = records
+	}
+	return property, nil
+}
+
+// tokenArray анализирует записи массива по одной, как streamArray
+func (a *Analyzer) tokenArray(sc *scanner, path string, st *state) (*types.Property, error) {
+	property := &types.Property{Type: "array"}
+	itemPath := path + "[0]"
+	st.depth++
+	defer func() { st.depth-- }()
+
+	var samples []interface{}
+	count := 0
+	start := st.mark()
+	err := sc.array(func() error {
+		// Набранная выборка корневого массива завершает чтение входа
+		if path == "" && st.sampler.full() {
+			st.sampler.stop()
+			return errSampled
+		}
+		if !st.sampler.take() {
+			return sc.skip()
+		}
+		count++
+
+		// Сверх лимитов записи только считаются
+		if a.config.MaxRecords > 0 && count > a.config.MaxRecords {
+			return sc.skip()
+		}
+
+		var itemProperty *types.Property
+		var err error
+		if a.config.DetectPolymorphic && len(samples) < maxStreamSamples {
+			var element interface{}
+			if element, err = sc.value(); err != nil {
+				return err
+			}
+			samples = append(samples, element)
+			itemProperty, err = a.analyzeValue(element, itemPath, st)
+		} else {
+			itemProperty, _, err = a.tokenValue(sc, itemPath, st)
+		}
+		if err != nil {
+			return err
+		}
+		a.mergeItems(property, itemProperty, itemPath, st)
+		if count%compactInterval == 0 {
+			property.Items = st.compact(property.Items, start)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSampled) {
+		return nil, err
+	}
+	return a.finishArray(property, count, samples, itemPath, st)
+}
+
+// tokenValue анализирует значение из потока и возвращает его схему и тип.
+// Объекты разбираются по токенам, прочие значения декодируются
+func (a *Analyzer) tokenValue(sc *scanner, path string, st *state) (*types.Property, string, error) {
+	c, err := sc.peek()
+	if err != nil {
+		return nil, "", err
+	}
+	if c == '{' {
+		a.recordKindAt(path, types.TypeObject, st)
+		if a.tooDeep(st) {
+			if err := sc.skip(); err != nil {
+				return nil, "", err
+			}
+			property, err := a.truncated(types.TypeObject, st)
+			return property, string(types.TypeObject), err
+		}
+		property, err := a.tokenObject(sc, path, st)
+		return property, string(types.TypeObject), err
+	}
+
+	value, err := sc.value()
+	if err != nil {
+		return nil, "", err
+	}
+	property, err := a.analyzeValue(value, path, st)
+	return property, a.kindOf(value), err
+}
+
+// tokenObject анализирует объект по токенам, как analyzeObject. Из
+// повторяющихся полей объекта в схеме остается последнее
+func (a *Analyzer) tokenObject(sc *scanner, path string, st *state) (*types.Property, error) {
+	st.stats.TypeDistribution["object"]++
+	st.stats.TotalObjects++
+	counts := st.objectFields(path)
+	st.depth++
+	defer func() { st.depth-- }()
+
+	// Поля записей повторяются, поэтому размер берется по уже встреченным
+	property := st.property("object")
+	property.Properties = make(map[string]*types.Property, len(counts))
+	property.Required = make([]string, 0, len(counts))
+
+	fields := make([]string, 0, len(counts))
+	err := sc.object(func(key string) error {
+		child := st.childPath(path, key)
+		fieldProperty, kind, err := a.tokenValue(sc, child.path, st)
+		if err != nil {
+			return err
+		}
+		if _, repeated := property.Properties[key]; !repeated {
+			st.stats.FieldFrequency[key]++
+			st.recordField(counts, key, child.field, kind == string(types.TypeNull))
+			property.Required = append(property.Required, key)
+			fields = append(fields, child.structureField(key, kind))
+		}
+		property.Properties[key] = fieldProperty
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addStructure(path, fields, st)
+	return property, nil
+}
+
+// childPath - внутренний путь поля, его путь в формате fieldmanager и
+// описания поля в формах объекта по типам значения
+type childPath struct {
+	path   string
+	field  string
+	shapes map[string]string
+}
+
+// structureField возвращает описание поля в форме объекта ("имя:тип")
+func (c childPath) structureField(key, kind string) string {
+	shape, ok := c.shapes[kind]
+	if !ok {
+		shape = key + ":" + kind
+		c.shapes[kind] = shape
+	}
+	return shape
+}
+
+// childPath возвращает пути поля key объекта по пути path. Пути полей
+// записей повторяются, поэтому они вычисляются один раз, а не для каждой записи
+func (s *state) childPath(path, key string) childPath {
+	if child, ok := s.paths[[2]string{path, key}]; ok {
+		return child
+	}
+	child := childPath{path: path + "." + key, shapes: make(map[string]string)}
+	child.field = fieldPath(child.path)
+	if s.paths == nil {
+		s.paths = make(map[[2]string]childPath)
+	}
+	if len(s.paths) < maxInternedKeys {
+		s.paths[[2]string{path, key}] = child
+	}
+	return child
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+// BenchmarkAnalyzeStream сравнивает потоковый анализ через json.Decoder
+// с анализом по токенам на одном и том же массиве записей
+func BenchmarkAnalyzeStream(b *testing.B) {
+	data := benchRecords(benchRecordCount)
+	for _, bench := range []struct {
+		name     string
+		tokenize bool
+	}{{"decoder", false}, {"tokenizer", true}} {
+		b.Run(bench.name, func(b *testing.B) {
+			a := benchAnalyzer()
+			a.config.Tokenize = bench.tokenize
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := a.AnalyzeStream(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkScannerSkip измеряет пропуск значений сканером без декодирования,
+// как для записей вне выборки
+func BenchmarkScannerSkip(b *testing.B) {
+	data := benchRecords(benchRecordCount)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sc := newScanner(bufio.NewReader(bytes.NewReader(data)), 0)
+		if err := sc.skip(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
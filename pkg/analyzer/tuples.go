@@ -0,0 +1,180 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// maxTupleLength - наибольшая длина массива, который может быть описан как кортеж
+const maxTupleLength = 8
+
+// tupleCandidate сообщает, что короткий массив может оказаться экземпляром
+// кортежа и его позиции нужно описать отдельно. Массив одинаковых объектов
+// или массивов кандидатом не считается: такие элементы дорого анализировать
+// повторно, а кортежем они не являются
+func tupleCandidate(arr []interface{}) bool {
+	if len(arr) < 2 || len(arr) > maxTupleLength {
+		return false
+	}
+
+	first := ""
+	for _, element := range arr {
+		kind := valueKind(element)
+		switch {
+		case kind != "object" && kind != "array":
+			return true
+		case first == "":
+			first = kind
+		case kind != first:
+			return true
+		}
+	}
+	return false
+}
+
+// mixedPositions сообщает, что позиции кортежа содержат значения разных типов,
+// то есть их типы не объединяются в один (null не учитывается, integer
+// входит в number)
+func mixedPositions(positions []*types.Property) bool {
+	joined := ""
+	for _, position := range positions {
+		kind, ok := types.JoinTypes(joined, position.Type)
+		if !ok {
+			return true
+		}
+		joined = kind
+	}
+	return false
+}
+
+// valueKind возвращает JSON тип декодированного значения
+func valueKind(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case truncatedValue:
+		return string(v)
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	}
+	return "null"
+}
+
+// analyzePositions описывает каждый элемент массива-кандидата на глубине
+// depth отдельной схемой. Статистика позиций не ведется: элементы уже учтены
+// в схеме items
+func (a *Analyzer) analyzePositions(arr []interface{}, path string, depth int) ([]*types.Property, error) {
+	scratch := newState(scratchStats())
+	scratch.depth = depth
+
+	positions := make([]*types.Property, len(arr))
+	for i, element := range arr {
+		position, err := a.analyzeValue(element, positionPath(path, i), scratch)
+		if err != nil {
+			return nil, err
+		}
+		positions[i] = position
+	}
+	return positions, nil
+}
+
+// scratchStats создает статистику для вспомогательного анализа, результаты
+// которого в статистику схемы не попадают
+func scratchStats() *types.AnalysisStatistics {
+	return &types.AnalysisStatistics{
+		FieldFrequency:   make(map[string]int),
+		TypeDistribution: make(map[string]int),
+		EnumCandidates:   make(map[string][]interface{}),
+		FieldPresence:    make(map[string]int),
+		FieldNulls:       make(map[string]int),
+	}
+}
+
+// positionPath возвращает внутренний путь позиции кортежа
+func positionPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// mergeTuple объединяет описания позиций массивов. Кортежем остается массив,
+// который во всех выборках имеет одну длину и совместимые типы позиций;
+// иначе позиции сворачиваются в общую схему items
+func (a *Analyzer) mergeTuple(existing, new *types.Property, path string, st *state) {
+	switch {
+	case existing.PrefixItems == nil && new.PrefixItems == nil:
+		return
+	case existing.Items == nil && existing.PrefixItems == nil:
+		// Ранее массив был пустым - позиции берутся из новых данных
+		existing.PrefixItems, existing.ClosedItems = new.PrefixItems, new.ClosedItems
+		return
+	case new.Items == nil && new.PrefixItems == nil:
+		return
+	case positionsMatch(existing.PrefixItems, new.PrefixItems):
+		for i, position := range existing.PrefixItems {
+			a.mergeProperty(position, new.PrefixItems[i], positionPath(path, i), st)
+		}
+		return
+	}
+
+	a.foldPositions(existing, path, st)
+	a.foldPositions(new, path, st)
+}
+
+// foldPositions описывает кортеж общей схемой элементов
+func (a *Analyzer) foldPositions(prop *types.Property, path string, st *state) {
+	if prop.Items == nil {
+		for _, position := range prop.PrefixItems {
+			a.mergeItems(prop, position, path+"[0]", st)
+		}
+	}
+	prop.PrefixItems, prop.ClosedItems = nil, false
+}
+
+// positionsMatch сообщает, что кортежи одной длины и типы их позиций совместимы
+func positionsMatch(existing, new []*types.Property) bool {
+	if len(existing) == 0 || len(existing) != len(new) {
+		return false
+	}
+	for i, position := range existing {
+		if !types.CompatibleTypes(position.Type, new[i].Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyTuples закрепляет кортежи, подтвержденные всеми выборками: массивы
+// одной длины, позиции которых имеют совместимые, но различные между собой
+// типы, описываются позициями (prefixItems) и не допускают других элементов
+// (items: false). Конфликт типов items такого массива снимается - разные
+// типы относятся к разным позициям. Корневой массив остается списком записей
+func applyTuples(prop *types.Property, path string, st *state) {
+	if prop == nil {
+		return
+	}
+
+	for key, child := range prop.Properties {
+		applyTuples(child, path+"."+key, st)
+	}
+	for i, position := range prop.PrefixItems {
+		applyTuples(position, positionPath(path, i), st)
+	}
+	applyTuples(prop.Items, path+"[0]", st)
+
+	if prop.PrefixItems == nil {
+		return
+	}
+	if path == "" || prop.Type != "array" || !mixedPositions(prop.PrefixItems) {
+		prop.PrefixItems = nil
+		return
+	}
+	prop.Items, prop.ClosedItems = nil, true
+	delete(st.stats.TypeConflicts, fieldPath(path+"[0]"))
+}
@@ -0,0 +1,23 @@
+package analyzer
+
+import (
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/xmlinput"
+)
+
+// AnalyzeXML анализирует документ XML как объект с полем корневого
+// элемента: атрибуты становятся полями @имя, повторяющиеся элементы -
+// массивами (см. xmlinput.ToJSON). MaxInputSize ограничивает размер XML
+func (a *Analyzer) AnalyzeXML(data []byte) (*types.AnalysisResult, error) {
+	return a.analyzeConverted(data, xmlinput.ToJSON)
+}
+
+// analyzeFileXML анализирует файл XML, при необходимости распаковывая его.
+// Документ читается целиком: потоковый анализ к нему не применяется
+func (a *Analyzer) analyzeFileXML(filename string) (*types.AnalysisResult, error) {
+	data, err := a.ReadInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	return a.AnalyzeXML(data)
+}
@@ -0,0 +1,24 @@
+package analyzer
+
+import (
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/yamlinput"
+)
+
+// AnalyzeYAML анализирует документы YAML так же, как JSON: документ
+// преобразуется в JSON с сохранением порядка ключей (см. yamlinput.ToJSON),
+// а несколько документов, разделенных ---, анализируются как записи NDJSON.
+// MaxInputSize ограничивает размер YAML, а не полученного JSON
+func (a *Analyzer) AnalyzeYAML(data []byte) (*types.AnalysisResult, error) {
+	return a.analyzeConverted(data, yamlinput.ToJSON)
+}
+
+// analyzeFileYAML анализирует файл YAML, при необходимости распаковывая его.
+// Документ YAML читается целиком: потоковый анализ к нему не применяется
+func (a *Analyzer) analyzeFileYAML(filename string) (*types.AnalysisResult, error) {
+	data, err := a.ReadInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	return a.AnalyzeYAML(data)
+}
@@ -0,0 +1,248 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/changelog"
+	"github.com/yanodincov/json-schema-detector/pkg/signature"
+)
+
+// ManifestFile - имя файла манифеста в корне архива
+const ManifestFile = "index.json"
+
+// Директории архива
+const (
+	SchemasDir = "schemas"
+	DefsDir    = "defs"
+)
+
+// Manifest описывает содержимое архива схем
+type Manifest struct {
+	Name      string        `json:"name"`
+	Version   string        `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	Generator string        `json:"generator,omitempty"`
+	Schemas   []SchemaEntry `json:"schemas"`
+	Files     []FileEntry   `json:"files"`
+}
+
+// SchemaEntry описывает схему в архиве
+type SchemaEntry struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Version   string `json:"version,omitempty"`
+	Changelog string `json:"changelog,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// FileEntry описывает файл архива и его контрольную сумму
+type FileEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Bundle собирает схемы и сопутствующие файлы в один архив
+type Bundle struct {
+	Manifest Manifest
+	sources  map[string]content // Путь в архиве → файл
+}
+
+// content - файл архива. Содержимое читается один раз при добавлении, чтобы
+// в архив попали ровно те байты, контрольная сумма которых записана в
+// манифест, даже если файл на диске изменится до Write
+type content struct {
+	path string
+	data []byte
+}
+
+// New создает пустой архив с указанными именем и версией
+func New(name, version string) *Bundle {
+	return &Bundle{
+		Manifest: Manifest{
+			Name:      name,
+			Version:   version,
+			CreatedAt: time.Now().UTC(),
+			Schemas:   make([]SchemaEntry, 0),
+			Files:     make([]FileEntry, 0),
+		},
+		sources: make(map[string]content),
+	}
+}
+
+// AddSchema добавляет схему вместе с историей изменений и подписью, если они есть
+func (b *Bundle) AddSchema(name, schemaFile string) error {
+	data, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения схемы %s: %w", schemaFile, err)
+	}
+
+	result, err := analyzer.New().LoadSchemaBytes(data)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы %s: %w", schemaFile, err)
+	}
+
+	base := filepath.Base(schemaFile)
+	entry := SchemaEntry{
+		Name:    name,
+		Path:    path.Join(SchemasDir, base),
+		Version: result.Metadata.Version,
+	}
+	for _, existing := range b.Manifest.Schemas {
+		if existing.Name == name {
+			return fmt.Errorf("схема %s уже добавлена в архив", name)
+		}
+	}
+	// В архив попадает та же версия файла, из которой прочитана версия схемы
+	if err := b.addData(entry.Path, schemaFile, data); err != nil {
+		return err
+	}
+
+	if log := changelog.Path(schemaFile); fileExists(log) {
+		entry.Changelog = path.Join(SchemasDir, filepath.Base(log))
+		if err := b.AddFile(entry.Changelog, log); err != nil {
+			return err
+		}
+	}
+
+	if sig := signature.Path(schemaFile); fileExists(sig) {
+		entry.Signature = path.Join(SchemasDir, filepath.Base(sig))
+		if err := b.AddFile(entry.Signature, sig); err != nil {
+			return err
+		}
+	}
+
+	b.Manifest.Schemas = append(b.Manifest.Schemas, entry)
+	return nil
+}
+
+// AddFile добавляет произвольный файл в архив по указанному пути
+func (b *Bundle) AddFile(archivePath, source string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла %s: %w", source, err)
+	}
+	return b.addData(archivePath, source, data)
+}
+
+// addData добавляет в архив содержимое data файла filename
+func (b *Bundle) addData(archivePath, filename string, data []byte) error {
+	if archivePath == ManifestFile {
+		return fmt.Errorf("путь %s зарезервирован для манифеста", ManifestFile)
+	}
+	if existing, ok := b.sources[archivePath]; ok {
+		return fmt.Errorf("путь %s в архиве уже занят файлом %s", archivePath, existing.path)
+	}
+
+	sum := sha256.Sum256(data)
+	b.sources[archivePath] = content{path: filename, data: data}
+	b.Manifest.Files = append(b.Manifest.Files, FileEntry{
+		Path:   archivePath,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+	return nil
+}
+
+// AddDir добавляет JSON файлы директории в архив под указанным префиксом
+func (b *Bundle) AddDir(prefix, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(p) != ".json" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		return b.AddFile(path.Join(prefix, filepath.ToSlash(rel)), p)
+	})
+}
+
+// Write записывает архив tar.gz: сначала манифест, затем файлы в порядке путей
+func (b *Bundle) Write(w io.Writer) error {
+	sort.Slice(b.Manifest.Schemas, func(i, j int) bool {
+		return b.Manifest.Schemas[i].Name < b.Manifest.Schemas[j].Name
+	})
+	sort.Slice(b.Manifest.Files, func(i, j int) bool {
+		return b.Manifest.Files[i].Path < b.Manifest.Files[j].Path
+	})
+
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации манифеста: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeEntry(tw, ManifestFile, manifest, b.Manifest.CreatedAt); err != nil {
+		return err
+	}
+	for _, file := range b.Manifest.Files {
+		if err := writeEntry(tw, file.Path, b.sources[file.Path].data, b.Manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("ошибка сжатия архива: %w", err)
+	}
+	return nil
+}
+
+// WriteFile записывает архив в файл
+func (b *Bundle) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("ошибка создания архива: %w", err)
+	}
+
+	if err := b.Write(f); err != nil {
+		f.Close()
+		os.Remove(filename)
+		return err
+	}
+	return f.Close()
+}
+
+// writeEntry записывает один файл в tar архив
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	return nil
+}
+
+// fileExists сообщает, существует ли обычный файл
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,87 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteArchivesHashedBytes(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "user.schema.json")
+	extraFile := filepath.Join(dir, "notes.json")
+	if err := os.WriteFile(schemaFile, []byte(`{"type": "object"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(extraFile, []byte(`{"v": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := New("schemas", "1.0.0")
+	if err := b.AddSchema("user", schemaFile); err != nil {
+		t.Fatalf("AddSchema: %v", err)
+	}
+	if err := b.AddFile("extra/notes.json", extraFile); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+
+	// Файлы меняются между добавлением и записью архива
+	if err := os.WriteFile(schemaFile, []byte(`{"type": "array"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(extraFile); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := b.Write(&archive); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	files := readArchive(t, &archive)
+	var manifest Manifest
+	if err := json.Unmarshal(files[ManifestFile], &manifest); err != nil {
+		t.Fatalf("манифест: %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("файлов в манифесте = %d, want 2", len(manifest.Files))
+	}
+	for _, file := range manifest.Files {
+		sum := sha256.Sum256(files[file.Path])
+		if hex.EncodeToString(sum[:]) != file.SHA256 || int64(len(files[file.Path])) != file.Size {
+			t.Errorf("%s: содержимое архива не совпадает с манифестом", file.Path)
+		}
+	}
+}
+
+// readArchive возвращает файлы архива tar.gz по путям
+func readArchive(t *testing.T, r io.Reader) map[string][]byte {
+	t.Helper()
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = data
+	}
+}
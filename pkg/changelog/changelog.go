@@ -0,0 +1,123 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+)
+
+// header записывается в начало нового файла истории изменений
+const header = "# Schema Changelog\n"
+
+// Path возвращает путь к файлу истории изменений рядом со схемой
+func Path(schemaFile string) string {
+	dir := filepath.Dir(schemaFile)
+	base := filepath.Base(schemaFile)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(dir, base+".CHANGELOG.md")
+}
+
+// Entry описывает одну запись истории изменений
+type Entry struct {
+	Date    time.Time
+	Version string
+	Bump    compat.BumpLevel
+	Source  string
+	Changes []compat.Change
+}
+
+// Format возвращает запись в формате Markdown
+func (e *Entry) Format() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "\n## %s - %s\n\n", e.Version, e.Date.Format("2006-01-02"))
+	if e.Source != "" {
+		fmt.Fprintf(&b, "Источник: `%s`, уровень изменения: %s\n\n", e.Source, e.Bump)
+	} else {
+		fmt.Fprintf(&b, "Уровень изменения: %s\n\n", e.Bump)
+	}
+
+	if len(e.Changes) == 0 {
+		b.WriteString("- Без структурных изменений\n")
+		return b.String()
+	}
+
+	for _, change := range e.Changes {
+		fmt.Fprintf(&b, "- %s\n", Describe(change))
+	}
+
+	return b.String()
+}
+
+// Describe возвращает читаемое описание изменения
+func Describe(change compat.Change) string {
+	var text string
+	switch change.Kind {
+	case compat.ChangeFieldAdded:
+		text = "добавлено поле"
+	case compat.ChangeFieldRemoved:
+		text = "удалено поле"
+	case compat.ChangeTypeChanged:
+		text = "изменен тип поля"
+	case compat.ChangeRequiredAdded:
+		text = "поле стало обязательным"
+	case compat.ChangeRequiredRemoved:
+		text = "поле стало необязательным"
+	case compat.ChangeEnumAdded:
+		text = "поле преобразовано в enum"
+	case compat.ChangeEnumRemoved:
+		text = "снято ограничение enum"
+	case compat.ChangeEnumValuesAdded:
+		text = "добавлены значения enum"
+	case compat.ChangeEnumValuesRemoved:
+		text = "удалены значения enum"
+	case compat.ChangeVariantsChanged:
+		text = "изменены варианты oneOf/anyOf"
+	case compat.ChangeDescription:
+		text = "обновлено описание"
+	case compat.ChangeDefault:
+		text = "изменено default значение"
+	case compat.ChangeFormat:
+		text = "изменен format"
+	default:
+		text = string(change.Kind)
+	}
+
+	line := fmt.Sprintf("`%s`: %s", change.Path, text)
+	if change.Details != "" {
+		line += fmt.Sprintf(" (%s)", change.Details)
+	}
+	if change.Breaking() {
+		line += " **[breaking]**"
+	}
+	return line
+}
+
+// Append дописывает запись в файл истории изменений схемы и возвращает путь к нему
+func Append(schemaFile string, entry *Entry) (string, error) {
+	path := Path(schemaFile)
+
+	_, statErr := os.Stat(path)
+	isNew := os.IsNotExist(statErr)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("ошибка открытия файла истории изменений: %w", err)
+	}
+	defer file.Close()
+
+	content := entry.Format()
+	if isNew {
+		content = header + content
+	}
+
+	if _, err := file.WriteString(content); err != nil {
+		return "", fmt.Errorf("ошибка записи истории изменений: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,36 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+func TestDescribeMissingValue(t *testing.T) {
+	schema := func(prop *types.Property) *types.JSONSchema {
+		return &types.JSONSchema{Type: "object", Properties: map[string]*types.Property{"status": prop}}
+	}
+	tests := []struct {
+		name     string
+		old, new *types.Property
+		want     string
+	}{
+		{"default added", &types.Property{Type: "string"}, &types.Property{Type: "string", Default: "new"}, "(— → new)"},
+		{"default removed", &types.Property{Type: "string", Default: "new"}, &types.Property{Type: "string"}, "(new → —)"},
+		{"format added", &types.Property{Type: "string"}, &types.Property{Type: "string", Format: "email"}, `(— → "email")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := compat.Compare(schema(tt.old), schema(tt.new))
+			if len(report.Changes) != 1 {
+				t.Fatalf("изменений = %d, want 1", len(report.Changes))
+			}
+			line := Describe(report.Changes[0])
+			if strings.Contains(line, "<nil>") || strings.Contains(line, `""`) || !strings.Contains(line, tt.want) {
+				t.Errorf("Describe = %q, want %q", line, tt.want)
+			}
+		})
+	}
+}
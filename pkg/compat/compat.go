@@ -0,0 +1,569 @@
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// BumpLevel определяет уровень изменения версии схемы
+type BumpLevel int
+
+const (
+	BumpNone BumpLevel = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// String возвращает название уровня изменения
+func (l BumpLevel) String() string {
+	switch l {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText сериализует уровень изменения в виде строки
+func (l BumpLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// ParseBumpLevel разбирает название уровня изменения
+func ParseBumpLevel(name string) (BumpLevel, error) {
+	for _, level := range []BumpLevel{BumpNone, BumpPatch, BumpMinor, BumpMajor} {
+		if level.String() == name {
+			return level, nil
+		}
+	}
+	return BumpNone, fmt.Errorf("неизвестный уровень изменения: %s. Доступные: none, patch, minor, major", name)
+}
+
+// ChangeKind описывает вид изменения поля
+type ChangeKind string
+
+const (
+	ChangeFieldAdded        ChangeKind = "field_added"
+	ChangeFieldRemoved      ChangeKind = "field_removed"
+	ChangeTypeChanged       ChangeKind = "type_changed"
+	ChangeRequiredAdded     ChangeKind = "required_added"
+	ChangeRequiredRemoved   ChangeKind = "required_removed"
+	ChangeEnumAdded         ChangeKind = "enum_added"
+	ChangeEnumRemoved       ChangeKind = "enum_removed"
+	ChangeEnumValuesAdded   ChangeKind = "enum_values_added"
+	ChangeEnumValuesRemoved ChangeKind = "enum_values_removed"
+	ChangeVariantsChanged   ChangeKind = "variants_changed"
+	ChangeDescription       ChangeKind = "description_changed"
+	ChangeDefault           ChangeKind = "default_changed"
+	ChangeFormat            ChangeKind = "format_changed"
+	ChangeRef               ChangeKind = "ref_changed"
+	ChangeNullable          ChangeKind = "nullable_changed"
+	ChangeLimit             ChangeKind = "limit_changed"
+	ChangePattern           ChangeKind = "pattern_changed"
+	ChangeAdditional        ChangeKind = "additional_properties_changed"
+	ChangeTuple             ChangeKind = "tuple_changed"
+)
+
+// Change представляет одно изменение между версиями схемы
+type Change struct {
+	Path    string     `json:"path"`
+	Kind    ChangeKind `json:"kind"`
+	Level   BumpLevel  `json:"level"`
+	Details string     `json:"details,omitempty"`
+}
+
+// Breaking сообщает, нарушает ли изменение совместимость
+func (c Change) Breaking() bool {
+	return c.Level == BumpMajor
+}
+
+// Report содержит результат сравнения двух версий схемы
+type Report struct {
+	Changes []Change  `json:"changes"`
+	Bump    BumpLevel `json:"bump"`
+}
+
+// Compatible сообщает, что в отчете нет ломающих изменений
+func (r *Report) Compatible() bool {
+	return r.Bump < BumpMajor
+}
+
+// Breaking возвращает только ломающие изменения
+func (r *Report) Breaking() []Change {
+	var breaking []Change
+	for _, change := range r.Changes {
+		if change.Breaking() {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+// Compare сравнивает старую и новую версии схемы
+func Compare(oldSchema, newSchema *types.JSONSchema) *Report {
+	report := &Report{Changes: make([]Change, 0)}
+	compareProperty(report, "", schemaToProperty(oldSchema), schemaToProperty(newSchema))
+
+	// Общие определения сравниваются по именам; появление и исчезновение
+	// определения отражается изменением ссылок на него
+	if oldSchema != nil && newSchema != nil {
+		for name, oldDef := range oldSchema.Defs {
+			if newDef := newSchema.Defs[name]; oldDef != nil && newDef != nil {
+				compareProperty(report, joinPath(types.DefsSegment, name), oldDef, newDef)
+			}
+		}
+	}
+
+	sort.SliceStable(report.Changes, func(i, j int) bool {
+		return report.Changes[i].Path < report.Changes[j].Path
+	})
+	for _, change := range report.Changes {
+		if change.Level > report.Bump {
+			report.Bump = change.Level
+		}
+	}
+
+	return report
+}
+
+// BumpVersion повышает семантическую версию на указанный уровень
+func BumpVersion(version string, level BumpLevel) (string, error) {
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("некорректная версия схемы: %s", version)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("некорректная версия схемы: %s", version)
+		}
+		numbers[i] = n
+	}
+
+	switch level {
+	case BumpMajor:
+		numbers[0]++
+		numbers[1], numbers[2] = 0, 0
+	case BumpMinor:
+		numbers[1]++
+		numbers[2] = 0
+	case BumpPatch:
+		numbers[2]++
+	}
+
+	return fmt.Sprintf("%d.%d.%d", numbers[0], numbers[1], numbers[2]), nil
+}
+
+// StampVersion сравнивает новую схему с предыдущей и повышает версию в метаданных
+func StampVersion(previous *types.JSONSchema, result *types.AnalysisResult) (*Report, string, error) {
+	report := Compare(previous, result.Schema)
+	if result.Metadata == nil {
+		result.Metadata = &types.AnalysisMetadata{}
+	}
+
+	oldVersion := result.Metadata.Version
+	newVersion, err := BumpVersion(oldVersion, report.Bump)
+	if err != nil {
+		return nil, "", err
+	}
+	if report.Bump != BumpNone {
+		result.Metadata.Version = newVersion
+		result.Metadata.LastBump = report.Bump.String()
+	}
+
+	return report, oldVersion, nil
+}
+
+// compareProperty рекурсивно сравнивает два узла схемы
+func compareProperty(report *Report, path string, oldProp, newProp *types.Property) {
+	// Узлы со ссылкой описываются внешним определением и сравниваются только по ссылке
+	if oldProp.Ref != "" || newProp.Ref != "" {
+		if oldProp.Ref != newProp.Ref {
+			report.add(path, ChangeRef, BumpMinor, fmt.Sprintf("%s → %s", displayString(oldProp.Ref), displayString(newProp.Ref)))
+		}
+		return
+	}
+
+	if oldProp.Type != newProp.Type {
+		// Расширение типа по решетке (integer → number) только расширяет
+		// допустимые значения
+		level := BumpMajor
+		if newProp.Type != "" && types.IsSubtype(oldProp.Type, newProp.Type) {
+			level = BumpMinor
+		}
+		// Поле, которое было только null, стало nullable с конкретным типом
+		if oldProp.Type == "null" && newProp.Nullable {
+			level = BumpMinor
+		}
+		report.add(path, ChangeTypeChanged, level, fmt.Sprintf("%s → %s", displayType(oldProp.Type), displayType(newProp.Type)))
+	} else if oldProp.Nullable != newProp.Nullable {
+		// Разрешение null расширяет допустимые значения, запрет - сужает
+		if newProp.Nullable {
+			report.add(path, ChangeNullable, BumpMinor, "null разрешен")
+		} else {
+			report.add(path, ChangeNullable, BumpMajor, "null запрещен")
+		}
+	}
+
+	if oldProp.Description != newProp.Description {
+		report.add(path, ChangeDescription, BumpPatch, "")
+	}
+
+	if fmt.Sprintf("%v", oldProp.Default) != fmt.Sprintf("%v", newProp.Default) {
+		report.add(path, ChangeDefault, BumpPatch, fmt.Sprintf("%s → %s", displayValue(oldProp.Default), displayValue(newProp.Default)))
+	}
+
+	if oldProp.Format != newProp.Format {
+		// Снятие format только расширяет допустимые значения
+		level := BumpMajor
+		if newProp.Format == "" {
+			level = BumpMinor
+		}
+		report.add(path, ChangeFormat, level, fmt.Sprintf("%s → %s", displayString(oldProp.Format), displayString(newProp.Format)))
+	}
+
+	// Шаблон нельзя сравнить по строгости, поэтому любое его изменение
+	// считается ломающим, а снятие - расширением
+	switch {
+	case oldProp.Pattern == newProp.Pattern:
+	case newProp.Pattern == "":
+		report.add(path, ChangePattern, BumpMinor, fmt.Sprintf("pattern %q снят", oldProp.Pattern))
+	case oldProp.Pattern == "":
+		report.add(path, ChangePattern, BumpMajor, fmt.Sprintf("pattern %q", newProp.Pattern))
+	default:
+		report.add(path, ChangePattern, BumpMajor, fmt.Sprintf("%q → %q", oldProp.Pattern, newProp.Pattern))
+	}
+
+	compareLimit(report, path, "minLength", intLimit(oldProp.MinLength), intLimit(newProp.MinLength), false)
+	compareLimit(report, path, "maxLength", intLimit(oldProp.MaxLength), intLimit(newProp.MaxLength), true)
+	compareLimit(report, path, "minimum", numberLimit(oldProp.Minimum), numberLimit(newProp.Minimum), false)
+	compareLimit(report, path, "maximum", numberLimit(oldProp.Maximum), numberLimit(newProp.Maximum), true)
+	compareLimit(report, path, "minItems", intLimit(oldProp.MinItems), intLimit(newProp.MinItems), false)
+	compareLimit(report, path, "maxItems", intLimit(oldProp.MaxItems), intLimit(newProp.MaxItems), true)
+	switch {
+	case !oldProp.UniqueItems && newProp.UniqueItems:
+		report.add(path, ChangeLimit, BumpMajor, "uniqueItems")
+	case oldProp.UniqueItems && !newProp.UniqueItems:
+		report.add(path, ChangeLimit, BumpMinor, "uniqueItems снят")
+	}
+
+	compareEnum(report, path, oldProp.Enum, newProp.Enum)
+
+	if len(oldProp.OneOf) != len(newProp.OneOf) || len(oldProp.AnyOf) != len(newProp.AnyOf) {
+		report.add(path, ChangeVariantsChanged, BumpMajor, "")
+	}
+
+	compareProperties(report, path, oldProp, newProp)
+	compareMap(report, path, oldProp, newProp)
+
+	if len(oldProp.PrefixItems) > 0 || len(newProp.PrefixItems) > 0 {
+		compareTuple(report, path, oldProp, newProp)
+	} else if oldProp.Items != nil && newProp.Items != nil {
+		compareProperty(report, joinPath(path, "0"), oldProp.Items, newProp.Items)
+	} else if oldProp.Items != nil {
+		report.add(joinPath(path, "0"), ChangeFieldRemoved, BumpMajor, "")
+	} else if newProp.Items != nil {
+		report.add(joinPath(path, "0"), ChangeFieldAdded, BumpMinor, "")
+	}
+}
+
+// compareProperties сравнивает наборы полей объекта и списки required
+func compareProperties(report *Report, path string, oldProp, newProp *types.Property) {
+	oldRequired := toSet(oldProp.Required)
+	newRequired := toSet(newProp.Required)
+
+	for name, oldField := range oldProp.Properties {
+		fieldPath := joinPath(path, name)
+		newField, exists := newProp.Properties[name]
+		if !exists || newField == nil {
+			// Ключ объекта, ставшего словарем, описывается схемой значений
+			if types.MapValues(newProp.PatternProperties, newProp.AdditionalProperties) == nil {
+				report.add(fieldPath, ChangeFieldRemoved, BumpMajor, "")
+			}
+			continue
+		}
+		if oldField == nil {
+			continue
+		}
+		compareProperty(report, fieldPath, oldField, newField)
+	}
+
+	for name, newField := range newProp.Properties {
+		if newField == nil {
+			continue
+		}
+		if oldField, exists := oldProp.Properties[name]; exists && oldField != nil {
+			continue
+		}
+		fieldPath := joinPath(path, name)
+		if newRequired[name] {
+			report.add(fieldPath, ChangeFieldAdded, BumpMajor, "required")
+		} else {
+			report.add(fieldPath, ChangeFieldAdded, BumpMinor, "optional")
+		}
+	}
+
+	for name := range newRequired {
+		if !oldRequired[name] && oldProp.Properties[name] != nil {
+			report.add(joinPath(path, name), ChangeRequiredAdded, BumpMajor, "")
+		}
+	}
+	for name := range oldRequired {
+		if !newRequired[name] && newProp.Properties[name] != nil {
+			report.add(joinPath(path, name), ChangeRequiredRemoved, BumpMajor, "")
+		}
+	}
+}
+
+// compareMap сравнивает описание словарей (patternProperties,
+// additionalProperties). Закрытие объекта, ограничение значений словаря
+// схемой и ограничение ключей шаблоном сужают допустимые значения
+func compareMap(report *Report, path string, oldProp, newProp *types.Property) {
+	oldValues := types.MapValues(oldProp.PatternProperties, oldProp.AdditionalProperties)
+	newValues := types.MapValues(newProp.PatternProperties, newProp.AdditionalProperties)
+	oldClosed, newClosed := oldProp.AdditionalProperties.Closed(), newProp.AdditionalProperties.Closed()
+
+	switch {
+	case oldValues != nil && newValues != nil:
+		compareProperty(report, joinPath(path, "*"), oldValues, newValues)
+		compareKeyPattern(report, path, types.KeyPattern(oldProp.PatternProperties), types.KeyPattern(newProp.PatternProperties))
+	case newValues != nil:
+		// Закрытый объект становится словарем - это расширение
+		level := BumpMajor
+		if oldClosed {
+			level = BumpMinor
+		}
+		report.add(path, ChangeAdditional, level, "значения словаря описаны схемой")
+		return
+	case oldValues != nil && !newClosed:
+		report.add(path, ChangeAdditional, BumpMinor, "объект открыт для новых полей")
+		return
+	}
+
+	switch {
+	case !oldClosed && newClosed:
+		report.add(path, ChangeAdditional, BumpMajor, "объект закрыт для новых полей")
+	case oldClosed && !newClosed:
+		report.add(path, ChangeAdditional, BumpMinor, "объект открыт для новых полей")
+	}
+}
+
+// compareTuple сравнивает массивы, хотя бы один из которых описан как кортеж.
+// Список, ставший кортежем, ограничивает каждую позицию и длину массива;
+// удаление позиций закрытого кортежа отвергает прежние данные, добавление - нет
+func compareTuple(report *Report, path string, oldProp, newProp *types.Property) {
+	oldLen, newLen := len(oldProp.PrefixItems), len(newProp.PrefixItems)
+	switch {
+	case oldLen == 0:
+		report.add(path, ChangeTuple, BumpMajor, fmt.Sprintf("массив описан как кортеж из %d позиций", newLen))
+		return
+	case newLen == 0:
+		report.add(path, ChangeTuple, BumpMinor, "кортеж описан общей схемой элементов")
+		return
+	case newLen > oldLen:
+		report.add(path, ChangeTuple, BumpMinor, fmt.Sprintf("позиций кортежа: %d → %d", oldLen, newLen))
+	case newLen < oldLen && newProp.ClosedItems:
+		report.add(path, ChangeTuple, BumpMajor, fmt.Sprintf("позиций кортежа: %d → %d", oldLen, newLen))
+	}
+
+	for i := 0; i < oldLen && i < newLen; i++ {
+		compareProperty(report, joinPath(path, strconv.Itoa(i)), oldProp.PrefixItems[i], newProp.PrefixItems[i])
+	}
+}
+
+// compareKeyPattern сравнивает шаблоны ключей словаря
+func compareKeyPattern(report *Report, path, oldPattern, newPattern string) {
+	switch {
+	case oldPattern == newPattern:
+	case newPattern == "":
+		report.add(path, ChangeAdditional, BumpMinor, fmt.Sprintf("шаблон ключей %q снят", oldPattern))
+	case oldPattern == "":
+		report.add(path, ChangeAdditional, BumpMajor, fmt.Sprintf("шаблон ключей %q", newPattern))
+	default:
+		report.add(path, ChangeAdditional, BumpMajor, fmt.Sprintf("шаблон ключей %q → %q", oldPattern, newPattern))
+	}
+}
+
+// compareLimit сравнивает числовое ограничение. Для верхней границы (upper)
+// увеличение и снятие расширяют допустимые значения, для нижней - уменьшение и снятие
+func compareLimit(report *Report, path, keyword string, oldLimit, newLimit *float64, upper bool) {
+	switch {
+	case oldLimit == nil && newLimit == nil:
+		return
+	case oldLimit == nil:
+		report.add(path, ChangeLimit, BumpMajor, fmt.Sprintf("%s: %v", keyword, *newLimit))
+	case newLimit == nil:
+		report.add(path, ChangeLimit, BumpMinor, fmt.Sprintf("%s снят", keyword))
+	case *oldLimit != *newLimit:
+		widened := *newLimit < *oldLimit
+		if upper {
+			widened = *newLimit > *oldLimit
+		}
+		level := BumpMajor
+		if widened {
+			level = BumpMinor
+		}
+		report.add(path, ChangeLimit, level, fmt.Sprintf("%s: %v → %v", keyword, *oldLimit, *newLimit))
+	}
+}
+
+// intLimit представляет целочисленное ограничение для compareLimit
+func intLimit(limit *int) *float64 {
+	if limit == nil {
+		return nil
+	}
+	value := float64(*limit)
+	return &value
+}
+
+// numberLimit представляет ограничение в исходной записи числа для compareLimit
+func numberLimit(limit json.Number) *float64 {
+	value, err := limit.Float64()
+	if limit == "" || err != nil {
+		return nil
+	}
+	return &value
+}
+
+// compareEnum сравнивает списки допустимых значений
+func compareEnum(report *Report, path string, oldEnum, newEnum []interface{}) {
+	switch {
+	case len(oldEnum) == 0 && len(newEnum) == 0:
+		return
+	case len(oldEnum) == 0:
+		report.add(path, ChangeEnumAdded, BumpMajor, fmt.Sprintf("%v", newEnum))
+		return
+	case len(newEnum) == 0:
+		report.add(path, ChangeEnumRemoved, BumpMinor, "")
+		return
+	}
+
+	oldValues := valueSet(oldEnum)
+	newValues := valueSet(newEnum)
+
+	var added, removed []string
+	for value := range newValues {
+		if !oldValues[value] {
+			added = append(added, value)
+		}
+	}
+	for value := range oldValues {
+		if !newValues[value] {
+			removed = append(removed, value)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	if len(removed) > 0 {
+		report.add(path, ChangeEnumValuesRemoved, BumpMajor, strings.Join(removed, ", "))
+	}
+	if len(added) > 0 {
+		report.add(path, ChangeEnumValuesAdded, BumpMinor, strings.Join(added, ", "))
+	}
+}
+
+// add добавляет изменение в отчет
+func (r *Report) add(path string, kind ChangeKind, level BumpLevel, details string) {
+	if path == "" {
+		path = "$"
+	}
+	r.Changes = append(r.Changes, Change{Path: path, Kind: kind, Level: level, Details: details})
+}
+
+// schemaToProperty конвертирует корневую схему в Property для единообразного сравнения
+func schemaToProperty(schema *types.JSONSchema) *types.Property {
+	if schema == nil {
+		return &types.Property{}
+	}
+
+	return &types.Property{
+		Ref:         schema.Ref,
+		Type:        schema.Type,
+		Properties:  schema.Properties,
+		Items:       schema.Items,
+		Required:    schema.Required,
+		Enum:        schema.Enum,
+		Format:      schema.Format,
+		OneOf:       schema.OneOf,
+		AnyOf:       schema.AnyOf,
+		Description: schema.Description,
+		Default:     schema.Default,
+		MinItems:    schema.MinItems,
+		MaxItems:    schema.MaxItems,
+		UniqueItems: schema.UniqueItems,
+
+		PatternProperties:    schema.PatternProperties,
+		AdditionalProperties: schema.AdditionalProperties,
+	}
+}
+
+// joinPath собирает путь в формате, используемом fieldmanager
+func joinPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+// displayType возвращает читаемое имя типа
+func displayType(t string) string {
+	if t == "" {
+		return "any"
+	}
+	return t
+}
+
+// missingValue обозначает отсутствующее значение в описании изменения
+const missingValue = "—"
+
+// displayValue возвращает значение для описания изменения, отсутствующее
+// значение выводится как прочерк, а не как <nil>
+func displayValue(v interface{}) string {
+	if v == nil {
+		return missingValue
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// displayString возвращает строку в кавычках или прочерк для пустой строки
+func displayString(s string) string {
+	if s == "" {
+		return missingValue
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// toSet строит множество из списка строк
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// valueSet строит множество строковых представлений значений
+func valueSet(values []interface{}) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[fmt.Sprintf("%v", value)] = true
+	}
+	return set
+}
@@ -0,0 +1,190 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// FileSuffix - стандартное окончание имени файла контракта потребителя
+const FileSuffix = ".contract.json"
+
+// Contract описывает поля схемы, от которых зависит команда-потребитель
+type Contract struct {
+	Consumer string  `json:"consumer"`
+	Schema   string  `json:"schema"`
+	Fields   []Field `json:"fields"`
+
+	// File - путь к файлу, из которого загружен контракт
+	File string `json:"-"`
+}
+
+// Field описывает требование потребителя к одному полю
+type Field struct {
+	Path     string        `json:"path"`
+	Type     string        `json:"type,omitempty"`     // Ожидаемый тип поля
+	Required bool          `json:"required,omitempty"` // Потребитель рассчитывает, что поле всегда присутствует
+	Enum     []interface{} `json:"enum,omitempty"`     // Значения, которые умеет обрабатывать потребитель
+}
+
+// Violation описывает нарушение контракта схемой
+type Violation struct {
+	Consumer string `json:"consumer"`
+	Contract string `json:"contract"`
+	Path     string `json:"path"`
+	Reason   string `json:"reason"`
+}
+
+// Load читает контракт из файла
+func Load(path string) (*Contract, error) {
+	data, err := fileutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения контракта: %w", err)
+	}
+
+	c := &Contract{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга контракта %s: %w", path, err)
+	}
+	if c.Consumer == "" {
+		return nil, fmt.Errorf("в контракте %s не указан потребитель", path)
+	}
+	if c.Schema == "" {
+		return nil, fmt.Errorf("в контракте %s не указана схема", path)
+	}
+	c.File = path
+	return c, nil
+}
+
+// LoadDir читает все контракты из директории
+func LoadDir(dir string) ([]*Contract, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+FileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	contracts := make([]*Contract, 0, len(matches))
+	for _, path := range matches {
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		contracts = append(contracts, c)
+	}
+	return contracts, nil
+}
+
+// ForSchema отбирает контракты, объявленные для указанной схемы
+func ForSchema(contracts []*Contract, schema string) []*Contract {
+	var selected []*Contract
+	for _, c := range contracts {
+		if c.Schema == schema {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+// Check проверяет, что схема удовлетворяет всем требованиям контракта
+func (c *Contract) Check(schema *types.JSONSchema) []Violation {
+	fm := fieldmanager.New()
+	var violations []Violation
+
+	report := func(path, reason string) {
+		violations = append(violations, Violation{
+			Consumer: c.Consumer,
+			Contract: c.File,
+			Path:     path,
+			Reason:   reason,
+		})
+	}
+
+	for _, field := range c.Fields {
+		prop, err := fm.FindField(schema, field.Path)
+		if err != nil {
+			report(field.Path, "поле отсутствует в схеме")
+			continue
+		}
+
+		if field.Type != "" && !typeSatisfies(prop.Type, field.Type) {
+			actual := prop.Type
+			if actual == "" {
+				actual = "any"
+			}
+			report(field.Path, fmt.Sprintf("тип изменен: ожидается %s, в схеме %s", field.Type, actual))
+		}
+
+		if field.Required && !isRequired(fm, schema, field.Path) {
+			report(field.Path, "поле перестало быть обязательным")
+		}
+
+		if len(field.Enum) > 0 && len(prop.Enum) > 0 {
+			if unknown := unknownValues(prop.Enum, field.Enum); len(unknown) > 0 {
+				report(field.Path, "в enum появились значения, неизвестные потребителю: "+strings.Join(unknown, ", "))
+			}
+		}
+	}
+
+	return violations
+}
+
+// isRequired проверяет, входит ли поле в список required своего родителя
+func isRequired(fm *fieldmanager.FieldManager, schema *types.JSONSchema, path string) bool {
+	path = strings.TrimPrefix(path, ".")
+	parentPath, name := "", path
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		parentPath, name = path[:i], path[i+1:]
+	}
+
+	// Элементы массива не имеют списка required
+	if _, err := strconv.Atoi(name); err == nil {
+		return true
+	}
+
+	required := schema.Required
+	if parentPath != "" {
+		parent, err := fm.FindField(schema, parentPath)
+		if err != nil {
+			return false
+		}
+		required = parent.Required
+	}
+
+	for _, r := range required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownValues возвращает значения схемы, которых нет в списке потребителя
+func unknownValues(schemaEnum, known []interface{}) []string {
+	knownSet := make(map[string]bool, len(known))
+	for _, value := range known {
+		knownSet[fmt.Sprintf("%v", value)] = true
+	}
+
+	var unknown []string
+	for _, value := range schemaEnum {
+		if s := fmt.Sprintf("%v", value); !knownSet[s] {
+			unknown = append(unknown, s)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// typeSatisfies сообщает, что значения типа из схемы подходят потребителю,
+// ожидающему тип expected: integer является частным случаем number
+func typeSatisfies(actual, expected string) bool {
+	return types.IsSubtype(actual, expected)
+}
@@ -0,0 +1,304 @@
+// Package correlation ищет простые зависимости между полями объектов в выборке
+// (поле A присутствует ⇒ присутствует поле B; status = "closed" ⇒ closedAt не null)
+// и предлагает их как условия if/then для ручной проверки.
+package correlation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Kind описывает вид зависимости
+type Kind string
+
+const (
+	// KindPresence - присутствие одного поля влечет присутствие другого
+	KindPresence Kind = "presence"
+	// KindValue - значение одного поля влечет присутствие или отсутствие другого
+	KindValue Kind = "value"
+)
+
+// Options настраивает поиск зависимостей
+type Options struct {
+	// MinSupport - минимальное число объектов, в которых выполняется условие правила
+	MinSupport int
+	// MaxValues - максимальное число различных значений поля, чтобы его
+	// значения рассматривались как условия
+	MaxValues int
+}
+
+// DefaultOptions возвращает настройки поиска по умолчанию
+func DefaultOptions() Options {
+	return Options{MinSupport: 3, MaxValues: 10}
+}
+
+// Condition описывает условие на поле объекта. Без значения условие означает,
+// что поле присутствует и не равно null; Absent - что поле отсутствует или null.
+type Condition struct {
+	Field  string      `json:"field"`
+	Value  interface{} `json:"value,omitempty"`
+	Absent bool        `json:"absent,omitempty"`
+}
+
+// String возвращает читаемое описание условия
+func (c Condition) String() string {
+	switch {
+	case c.Value != nil:
+		return fmt.Sprintf("%s = %s", c.Field, formatValue(c.Value))
+	case c.Absent:
+		return c.Field + " отсутствует или null"
+	default:
+		return c.Field + " присутствует"
+	}
+}
+
+// Rule представляет найденную зависимость между полями объектов по пути Path
+type Rule struct {
+	Path    string    `json:"path"`
+	Kind    Kind      `json:"kind"`
+	If      Condition `json:"if"`
+	Then    Condition `json:"then"`
+	Support int       `json:"support"`
+	Objects int       `json:"objects"`
+}
+
+// String возвращает правило в виде «условие ⇒ следствие»
+func (r Rule) String() string {
+	return fmt.Sprintf("%s ⇒ %s", r.If, r.Then)
+}
+
+// Constraint возвращает правило в виде ключевых слов if/then JSON Schema
+func (r Rule) Constraint() map[string]interface{} {
+	return map[string]interface{}{
+		"if":   conditionSchema(r.If),
+		"then": conditionSchema(r.Then),
+	}
+}
+
+// Collect группирует объекты значения по пути в формате fieldmanager:
+// корневой объект - "", элементы массива data - "data.0"
+func Collect(value interface{}) map[string][]map[string]interface{} {
+	groups := make(map[string][]map[string]interface{})
+	collect(groups, "", value)
+	return groups
+}
+
+// collect рекурсивно собирает объекты по путям
+func collect(groups map[string][]map[string]interface{}, path string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		groups[path] = append(groups[path], v)
+		for key, child := range v {
+			collect(groups, joinPath(path, key), child)
+		}
+	case []interface{}:
+		for _, element := range v {
+			collect(groups, joinPath(path, "0"), element)
+		}
+	}
+}
+
+// Infer находит зависимости между полями во всех группах объектов
+func Infer(groups map[string][]map[string]interface{}, opts Options) []Rule {
+	paths := make([]string, 0, len(groups))
+	for path := range groups {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var rules []Rule
+	for _, path := range paths {
+		rules = append(rules, inferGroup(path, groups[path], opts)...)
+	}
+	return rules
+}
+
+// inferGroup находит зависимости между полями объектов одной группы
+func inferGroup(path string, objects []map[string]interface{}, opts Options) []Rule {
+	if len(objects) < opts.MinSupport || len(objects) < 2 {
+		return nil
+	}
+
+	// Для каждого поля запоминаем объекты, в которых оно присутствует и не null
+	present := make(map[string][]bool)
+	counts := make(map[string]int)
+	for i, obj := range objects {
+		for key, value := range obj {
+			if _, ok := present[key]; !ok {
+				present[key] = make([]bool, len(objects))
+			}
+			if value != nil {
+				present[key][i] = true
+				counts[key]++
+			}
+		}
+	}
+
+	fields := make([]string, 0, len(present))
+	for field := range present {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	// Следствием может быть только поле, которое присутствует не всегда
+	var optional []string
+	for _, field := range fields {
+		if counts[field] < len(objects) {
+			optional = append(optional, field)
+		}
+	}
+
+	var rules []Rule
+	for _, a := range optional {
+		if counts[a] < opts.MinSupport {
+			continue
+		}
+		for _, b := range optional {
+			if a == b || !implies(present[a], present[b], true) {
+				continue
+			}
+			rules = append(rules, Rule{
+				Path:    path,
+				Kind:    KindPresence,
+				If:      Condition{Field: a},
+				Then:    Condition{Field: b},
+				Support: counts[a],
+				Objects: len(objects),
+			})
+		}
+	}
+
+	for _, field := range fields {
+		values := distinctValues(objects, field, opts.MaxValues)
+		for _, candidate := range values {
+			matches := make([]bool, len(objects))
+			support := 0
+			for i, obj := range objects {
+				if v, ok := obj[field]; ok && v == candidate.value {
+					matches[i] = true
+					support++
+				}
+			}
+			if support < opts.MinSupport || support == len(objects) {
+				continue
+			}
+
+			for _, b := range optional {
+				if b == field {
+					continue
+				}
+				// Поле b присутствует не всегда, поэтому любое из следствий
+				// выделяет объекты с этим значением среди остальных
+				then := Condition{}
+				switch {
+				case implies(matches, present[b], true):
+					then = Condition{Field: b}
+				case counts[b] > 0 && implies(matches, present[b], false):
+					then = Condition{Field: b, Absent: true}
+				default:
+					continue
+				}
+				rules = append(rules, Rule{
+					Path:    path,
+					Kind:    KindValue,
+					If:      Condition{Field: field, Value: candidate.value},
+					Then:    then,
+					Support: support,
+					Objects: len(objects),
+				})
+			}
+		}
+	}
+
+	return rules
+}
+
+// implies проверяет, что во всех объектах, где выполнено premise, значение
+// conclusion равно expected
+func implies(premise, conclusion []bool, expected bool) bool {
+	for i := range premise {
+		if premise[i] && conclusion[i] != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// candidate - значение поля, пригодное для условия
+type candidate struct {
+	value interface{}
+	key   string
+}
+
+// distinctValues возвращает различные строковые и логические значения поля,
+// если их не больше maxValues и больше одного
+func distinctValues(objects []map[string]interface{}, field string, maxValues int) []candidate {
+	seen := make(map[string]bool)
+	var values []candidate
+	for _, obj := range objects {
+		v, ok := obj[field]
+		if !ok {
+			continue
+		}
+		switch v.(type) {
+		case nil:
+			continue
+		case string, bool:
+		default:
+			return nil
+		}
+
+		key := formatValue(v)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		values = append(values, candidate{value: v, key: key})
+		if len(values) > maxValues {
+			return nil
+		}
+	}
+	if len(values) < 2 {
+		return nil
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i].key < values[j].key })
+	return values
+}
+
+// conditionSchema строит подсхему JSON Schema для условия
+func conditionSchema(c Condition) map[string]interface{} {
+	switch {
+	case c.Value != nil:
+		return map[string]interface{}{
+			"properties": map[string]interface{}{c.Field: map[string]interface{}{"const": c.Value}},
+			"required":   []string{c.Field},
+		}
+	case c.Absent:
+		return map[string]interface{}{
+			"properties": map[string]interface{}{c.Field: map[string]interface{}{"type": "null"}},
+		}
+	default:
+		return map[string]interface{}{
+			"properties": map[string]interface{}{c.Field: map[string]interface{}{"not": map[string]interface{}{"type": "null"}}},
+			"required":   []string{c.Field},
+		}
+	}
+}
+
+// formatValue возвращает значение в виде литерала JSON
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// joinPath собирает путь в формате fieldmanager
+func joinPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
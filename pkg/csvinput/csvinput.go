@@ -0,0 +1,251 @@
+// Package csvinput читает таблицы CSV с заголовком как записи JSON: каждая
+// строка становится объектом с полями по именам колонок, а значения
+// получают тип, общий для всей колонки.
+package csvinput
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Типы значений колонок
+const (
+	KindInteger = "integer"
+	KindNumber  = "number"
+	KindBoolean = "boolean"
+	KindString  = "string"
+)
+
+var (
+	// integerPattern и numberPattern - целые и дробные числа в записи JSON;
+	// значения с ведущими нулями (почтовые индексы, коды) остаются строками
+	integerPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	numberPattern  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+	// utf8BOM - метка порядка байтов, которую записывает Excel
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// Options настраивает чтение таблицы
+type Options struct {
+	// Delimiter - разделитель значений; 0 - запятая
+	Delimiter rune
+}
+
+// Column - колонка таблицы и тип ее значений
+type Column struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// Infer читает таблицу и определяет тип каждой колонки: integer, number
+// или boolean, если ему соответствуют все непустые значения колонки
+// (целые вместе с дробными дают number), иначе string. Колонка без
+// непустых значений - string. Даты остаются строками, их формат
+// распознает анализатор
+func Infer(r io.Reader, opts Options) ([]Column, error) {
+	rd, err := newReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make([]string, len(rd.names))
+	for {
+		row, err := rd.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i, cell := range row {
+			kinds[i] = widen(kinds[i], cellKind(cell))
+		}
+	}
+
+	columns := make([]Column, len(rd.names))
+	for i, name := range rd.names {
+		kind := kinds[i]
+		if kind == "" {
+			kind = KindString
+		}
+		columns[i] = Column{Name: name, Kind: kind}
+	}
+	return columns, nil
+}
+
+// Convert читает таблицу и пишет ее строки в w как NDJSON: объект с полями
+// в порядке колонок и значениями типов columns. Пустая ячейка становится
+// null, ячейки, которых нет в короткой строке, - отсутствующими полями
+func Convert(r io.Reader, columns []Column, opts Options, w io.Writer) error {
+	rd, err := newReader(r, opts)
+	if err != nil {
+		return err
+	}
+	if len(rd.names) != len(columns) {
+		return fmt.Errorf("ошибка чтения CSV: в заголовке %d колонок, ожидалось %d", len(rd.names), len(columns))
+	}
+
+	// Имена колонок кодируются один раз
+	keys := make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column.Name)
+		if err != nil {
+			return err
+		}
+		keys[i] = append(key, ':')
+	}
+
+	out := bufio.NewWriter(w)
+	var buf []byte
+	for {
+		row, err := rd.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		buf = append(buf[:0], '{')
+		for i, cell := range row {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, keys[i]...)
+			if buf, err = appendValue(buf, cell, columns[i].Kind); err != nil {
+				return err
+			}
+		}
+		buf = append(buf, '}', '\n')
+		if _, err := out.Write(buf); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// reader читает строки таблицы после заголовка
+type reader struct {
+	csv   *csv.Reader
+	names []string
+}
+
+// newReader читает заголовок таблицы
+func newReader(r io.Reader, opts Options) (*reader, error) {
+	buffered := bufio.NewReader(r)
+	if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+	}
+
+	cr := csv.NewReader(buffered)
+	if opts.Delimiter != 0 {
+		cr.Comma = opts.Delimiter
+	}
+	// Длина строк проверяется в next: короткие строки допустимы
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("ошибка чтения CSV: нет строки заголовка")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+	}
+	names, err := columnNames(header)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{csv: cr, names: names}, nil
+}
+
+// next возвращает следующую строку таблицы или io.EOF
+func (r *reader) next() ([]string, error) {
+	row, err := r.csv.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+	}
+	if len(row) > len(r.names) {
+		line, _ := r.csv.FieldPos(0)
+		return nil, fmt.Errorf("ошибка чтения CSV: строка %d содержит %d значений при %d колонках", line, len(row), len(r.names))
+	}
+	return row, nil
+}
+
+// columnNames проверяет имена колонок заголовка; колонка без имени
+// называется по номеру (column3)
+func columnNames(header []string) ([]string, error) {
+	names := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = fmt.Sprintf("column%d", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("ошибка чтения CSV: повторяющееся имя колонки %q", name)
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names, nil
+}
+
+// cellKind возвращает тип значения ячейки; пустая ячейка типа не имеет
+func cellKind(cell string) string {
+	cell = strings.TrimSpace(cell)
+	switch {
+	case cell == "":
+		return ""
+	case strings.EqualFold(cell, "true"), strings.EqualFold(cell, "false"):
+		return KindBoolean
+	case integerPattern.MatchString(cell):
+		return KindInteger
+	case numberPattern.MatchString(cell):
+		return KindNumber
+	}
+	return KindString
+}
+
+// widen объединяет тип колонки с типом очередного значения: integer и
+// number дают number, прочие несовпадающие типы - string
+func widen(kind, cell string) string {
+	switch {
+	case cell == "":
+		return kind
+	case kind == "", kind == cell:
+		return cell
+	case kind == KindInteger && cell == KindNumber, kind == KindNumber && cell == KindInteger:
+		return KindNumber
+	}
+	return KindString
+}
+
+// appendValue записывает значение ячейки в JSON по типу колонки
+func appendValue(buf []byte, cell, kind string) ([]byte, error) {
+	trimmed := strings.TrimSpace(cell)
+	switch {
+	case trimmed == "":
+		return append(buf, "null"...), nil
+	case kind == KindBoolean:
+		return append(buf, strings.ToLower(trimmed)...), nil
+	case kind == KindInteger, kind == KindNumber:
+		return append(buf, trimmed...), nil
+	}
+	value, err := json.Marshal(cell)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, value...), nil
+}
@@ -0,0 +1,145 @@
+// Package decisions хранит решения пользователя по полям схемы (тип поля,
+// enum, полиморфный тип) и повторно применяет их к схеме после обновления,
+// чтобы неинтерактивные запуски давали тот же результат, что и ручной выбор.
+package decisions
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// Операции, решения по которым запоминаются
+const (
+	OperationType      = "type"
+	OperationEnum      = "enum"
+	OperationPolymorph = "polymorph"
+)
+
+// Decision - решение пользователя по одному полю схемы
+type Decision struct {
+	Operation string `json:"operation"`
+	// Type - выбранный тип поля (operation: type)
+	Type string `json:"type,omitempty"`
+	// Enum - выбранные значения поля (operation: enum)
+	Enum []interface{} `json:"enum,omitempty"`
+	// Variants - названия вариантов полиморфного типа (operation: polymorph)
+	Variants    []string  `json:"variants,omitempty"`
+	Description string    `json:"description,omitempty"`
+	DecidedAt   time.Time `json:"decided_at"`
+}
+
+// Applied - решение, примененное к схеме
+type Applied struct {
+	Path      string `json:"path"`
+	Operation string `json:"operation"`
+	// Changed сообщает, что схема расходилась с решением и была исправлена
+	Changed bool `json:"changed"`
+}
+
+// Variant создает вариант полиморфного типа с дискриминатором type
+func Variant(name string) *types.JSONSchema {
+	return &types.JSONSchema{
+		Type: "object",
+		Properties: map[string]*types.Property{
+			"type": {Type: "string", Enum: []interface{}{name}},
+		},
+		Description: fmt.Sprintf("Вариант %s", name),
+	}
+}
+
+// Apply применяет решения к полям схемы в порядке путей. Конфликты типов полей,
+// для которых принято решение о типе или полиморфизме, снимаются: выбор уже
+// сделан. Решения по полям, которых нет в схеме, пропускаются
+func Apply(result *types.AnalysisResult, decisions map[string]Decision) ([]Applied, error) {
+	paths := make([]string, 0, len(decisions))
+	for path := range decisions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	fm := fieldmanager.New()
+	applied := make([]Applied, 0, len(paths))
+	for _, path := range paths {
+		decision := decisions[path]
+		field, err := fm.FindField(result.Schema, path)
+		if err != nil {
+			continue
+		}
+
+		changed, err := apply(result, path, field, decision)
+		if err != nil {
+			return nil, fmt.Errorf("решение для %s: %w", path, err)
+		}
+		if decision.Description != "" && field.Description != decision.Description {
+			field.Description, changed = decision.Description, true
+		}
+		applied = append(applied, Applied{Path: path, Operation: decision.Operation, Changed: changed})
+	}
+	return applied, nil
+}
+
+// apply применяет одно решение и сообщает, изменилось ли поле
+func apply(result *types.AnalysisResult, path string, field *types.Property, decision Decision) (bool, error) {
+	switch decision.Operation {
+	case OperationType:
+		changed := field.Type != decision.Type
+		analyzer.ApplyOverrides(result.Schema, map[string]types.Override{path: {Type: decision.Type}}, result.Statistics)
+		return changed, nil
+	case OperationEnum:
+		changed := !sameValues(field.Enum, decision.Enum)
+		field.Enum = append([]interface{}{}, decision.Enum...)
+		// Значение из новых данных вне выбранного enum не может быть default
+		if field.Default != nil && !containsValue(field.Enum, field.Default) {
+			field.Default = nil
+		}
+		return changed, nil
+	case OperationPolymorph:
+		resolve(result.Statistics, path)
+		if len(field.OneOf) > 0 {
+			return false, nil
+		}
+		field.OneOf = make([]*types.JSONSchema, 0, len(decision.Variants))
+		for _, name := range decision.Variants {
+			field.OneOf = append(field.OneOf, Variant(name))
+		}
+		field.Type = ""
+		return true, nil
+	default:
+		return false, fmt.Errorf("неизвестная операция: %s", decision.Operation)
+	}
+}
+
+// resolve снимает конфликт типов поля в статистике
+func resolve(stats *types.AnalysisStatistics, path string) {
+	if stats != nil {
+		delete(stats.TypeConflicts, path)
+	}
+}
+
+// sameValues сообщает, что списки значений совпадают с учетом порядка
+func sameValues(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if fmt.Sprint(a[i]) != fmt.Sprint(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsValue сообщает, что значение входит в список
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if fmt.Sprint(v) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Package decompress прозрачно распаковывает входные файлы, сжатые gzip или
+// zstd. Формат определяется по первым байтам содержимого, а не по
+// расширению, поэтому выгрузки не нужно распаковывать на диск заранее.
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+)
+
+// Форматы сжатия
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+var (
+	// gzipMagic и zstdMagic - сигнатуры в начале сжатых данных
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	// extensions - расширения сжатых файлов
+	extensions = []string{".gz", ".gzip", ".zst", ".zstd"}
+)
+
+// Detect возвращает формат сжатия данных по их первым байтам или пустую
+// строку для несжатых данных
+func Detect(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return Zstd
+	}
+	return ""
+}
+
+// DetectFile возвращает формат сжатия файла или пустую строку
+func DetectFile(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(zstdMagic))
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return Detect(header[:n]), nil
+}
+
+// NewReader возвращает поток распакованных данных. Несжатый поток
+// возвращается как есть; Close закрывает только распаковщик, но не r
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	reader := bufio.NewReader(r)
+	header, _ := reader.Peek(len(zstdMagic))
+
+	switch Detect(header) {
+	case Gzip:
+		// Несколько склеенных файлов gzip читаются как один поток
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка распаковки gzip: %w", err)
+		}
+		return gz, nil
+	case Zstd:
+		zr, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка распаковки zstd: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(reader), nil
+}
+
+// Open открывает файл для чтения распакованного содержимого
+func Open(filename string) (io.ReadCloser, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileReader{ReadCloser: reader, file: file}, nil
+}
+
+// ReadFile читает распакованное содержимое файла, отбрасывая BOM в начале.
+// Несжатый файл читается как fileutil.ReadFile
+func ReadFile(filename string) ([]byte, error) {
+	format, err := DetectFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		return fileutil.ReadFile(filename)
+	}
+
+	reader, err := Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := ReadAll(reader, 0)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка распаковки %s: %w", format, err)
+	}
+	return data, nil
+}
+
+// ReadAll читает распакованный поток целиком, отбрасывая BOM в начале.
+// При limit больше 0 читается не больше limit+1 байт, поэтому превышение
+// лимита видно по длине результата без чтения всего потока
+func ReadAll(r io.Reader, limit int64) ([]byte, error) {
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return fileutil.TrimBOM(data), nil
+}
+
+// TrimExt отбрасывает расширение сжатия: dump.ndjson.zst становится
+// dump.ndjson. Остальные имена возвращаются как есть
+func TrimExt(filename string) string {
+	ext := filepath.Ext(filename)
+	for _, compressed := range extensions {
+		if strings.EqualFold(ext, compressed) {
+			return strings.TrimSuffix(filename, ext)
+		}
+	}
+	return filename
+}
+
+// fileReader закрывает распаковщик вместе с файлом
+type fileReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r *fileReader) Close() error {
+	r.ReadCloser.Close()
+	return r.file.Close()
+}
@@ -0,0 +1,327 @@
+// Package diagram строит диаграмму структуры схемы: вложенность объектов и
+// массивов и ветвление oneOf/anyOf, в форматах Mermaid и Graphviz DOT.
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// Форматы диаграммы
+const (
+	FormatMermaid = "mermaid"
+	FormatDOT     = "dot"
+)
+
+// Виды узлов диаграммы
+const (
+	KindObject  = "object"
+	KindVariant = "variant"
+)
+
+// Node - объект схемы или поле с вариантами
+type Node struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	// Fields - скалярные поля объекта в виде "name: type"
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Edge - вложенность объекта или ветка варианта
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label"`
+	// Variant отмечает ветку oneOf/anyOf
+	Variant bool `json:"variant,omitempty"`
+}
+
+// Graph - диаграмма структуры схемы
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// builder собирает граф и выдает узлам уникальные идентификаторы
+type builder struct {
+	graph *Graph
+	ids   map[string]bool
+}
+
+// Build строит диаграмму структуры схемы; name - подпись корневого узла
+func Build(name string, schema *types.JSONSchema) *Graph {
+	b := &builder{graph: &Graph{Nodes: make([]Node, 0), Edges: make([]Edge, 0)}, ids: make(map[string]bool)}
+	if schema == nil {
+		return b.graph
+	}
+	b.node(name, rootProperty(schema))
+	return b.graph
+}
+
+// rootProperty представляет корень схемы как Property
+func rootProperty(schema *types.JSONSchema) *types.Property {
+	return &types.Property{
+		Ref:        schema.Ref,
+		Type:       schema.Type,
+		Properties: schema.Properties,
+		Items:      schema.Items,
+		Required:   schema.Required,
+		OneOf:      schema.OneOf,
+		AnyOf:      schema.AnyOf,
+
+		PatternProperties:    schema.PatternProperties,
+		AdditionalProperties: schema.AdditionalProperties,
+	}
+}
+
+// node добавляет узел для свойства и возвращает его идентификатор. Массив
+// объектов описывается узлом элемента, скалярное значение - корневым узлом
+// с единственным полем
+func (b *builder) node(label string, prop *types.Property) string {
+	if prop.Type == string(types.TypeArray) && prop.Items != nil && structured(prop.Items) {
+		return b.node(label+"[]", prop.Items)
+	}
+	if values := types.MapValues(prop.PatternProperties, prop.AdditionalProperties); values != nil && len(prop.Properties) == 0 && structured(values) {
+		return b.node(label+"{*}", values)
+	}
+
+	if len(prop.OneOf) > 0 || len(prop.AnyOf) > 0 {
+		id := b.add(label, KindVariant, nil)
+		b.variants(id, "oneOf", prop.OneOf)
+		b.variants(id, "anyOf", prop.AnyOf)
+		return id
+	}
+
+	if prop.Type != string(types.TypeObject) {
+		return b.add(label, KindObject, []string{"value: " + typeName(prop)})
+	}
+
+	id := b.add(label, KindObject, nil)
+	required := make(map[string]bool, len(prop.Required))
+	for _, name := range prop.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(prop.Properties))
+	for name := range prop.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []string
+	for _, name := range names {
+		child := prop.Properties[name]
+		if child == nil {
+			continue
+		}
+		suffix := ""
+		if !required[name] {
+			suffix = "?"
+		}
+		if !structured(child) {
+			fields = append(fields, name+suffix+": "+typeName(child))
+			continue
+		}
+		childID := b.node(name, child)
+		b.graph.Edges = append(b.graph.Edges, Edge{From: id, To: childID, Label: name + edgeSuffix(child) + suffix})
+	}
+
+	// Словарь с перечисленными полями: значения описываются отдельным узлом
+	if values := types.MapValues(prop.PatternProperties, prop.AdditionalProperties); values != nil {
+		if structured(values) {
+			childID := b.node("*", values)
+			b.graph.Edges = append(b.graph.Edges, Edge{From: id, To: childID, Label: "*"})
+		} else {
+			fields = append(fields, "*: "+typeName(values))
+		}
+	}
+
+	b.setFields(id, fields)
+	return id
+}
+
+// variants добавляет ветки oneOf/anyOf узла
+func (b *builder) variants(id, keyword string, variants []*types.JSONSchema) {
+	for i, variant := range variants {
+		if variant == nil {
+			continue
+		}
+		label := fmt.Sprintf("%s[%d]", keyword, i)
+		childID := b.node(label, rootProperty(variant))
+		b.graph.Edges = append(b.graph.Edges, Edge{From: id, To: childID, Label: label, Variant: true})
+	}
+}
+
+// add добавляет узел с уникальным идентификатором
+func (b *builder) add(label, kind string, fields []string) string {
+	base := identifier(label)
+	id := base
+	for i := 2; b.ids[id]; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	b.ids[id] = true
+	b.graph.Nodes = append(b.graph.Nodes, Node{ID: id, Label: label, Kind: kind, Fields: fields})
+	return id
+}
+
+// setFields записывает поля узла после обхода потомков
+func (b *builder) setFields(id string, fields []string) {
+	for i := range b.graph.Nodes {
+		if b.graph.Nodes[i].ID == id {
+			b.graph.Nodes[i].Fields = fields
+			return
+		}
+	}
+}
+
+// structured сообщает, что свойство изображается отдельным узлом: объект с
+// полями или словарь, массив таких объектов либо поле с вариантами
+func structured(prop *types.Property) bool {
+	switch {
+	case prop == nil || prop.Ref != "":
+		return false
+	case len(prop.OneOf) > 0 || len(prop.AnyOf) > 0:
+		return true
+	case prop.Type == string(types.TypeObject):
+		return len(prop.Properties) > 0 || types.MapValues(prop.PatternProperties, prop.AdditionalProperties) != nil
+	case prop.Type == string(types.TypeArray):
+		return structured(prop.Items)
+	}
+	return false
+}
+
+// edgeSuffix отмечает на ребре массив или словарь
+func edgeSuffix(prop *types.Property) string {
+	switch {
+	case prop.Type == string(types.TypeArray):
+		return "[]"
+	case len(prop.Properties) == 0 && types.MapValues(prop.PatternProperties, prop.AdditionalProperties) != nil:
+		return "{*}"
+	}
+	return ""
+}
+
+// typeName описывает тип скалярного поля: string, integer[], [string, integer],
+// map<number>, ссылку $ref
+func typeName(prop *types.Property) string {
+	switch {
+	case prop.Ref != "":
+		return "$ref " + prop.Ref
+	case len(prop.PrefixItems) > 0:
+		positions := make([]string, len(prop.PrefixItems))
+		for i, position := range prop.PrefixItems {
+			positions[i] = typeName(position)
+		}
+		return "[" + strings.Join(positions, ", ") + "]"
+	case prop.Type == string(types.TypeArray) && prop.Items != nil:
+		return typeName(prop.Items) + "[]"
+	case prop.Type == string(types.TypeObject) && types.MapValues(prop.PatternProperties, prop.AdditionalProperties) != nil:
+		return "map<" + typeName(types.MapValues(prop.PatternProperties, prop.AdditionalProperties)) + ">"
+	}
+
+	name := prop.Type
+	if name == "" {
+		name = "any"
+	}
+	if prop.Format != "" {
+		name += " (" + prop.Format + ")"
+	}
+	if len(prop.Enum) > 0 {
+		name += " enum"
+	}
+	if prop.Nullable {
+		name += " | null"
+	}
+	return name
+}
+
+// unsafeID - символы, недопустимые в идентификаторах Mermaid и DOT
+var unsafeID = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// identifier превращает подпись узла в идентификатор диаграммы. Префикс
+// исключает совпадение с ключевыми словами Mermaid (end, graph) и DOT (node)
+func identifier(label string) string {
+	return "n_" + strings.Trim(unsafeID.ReplaceAllString(label, "_"), "_")
+}
+
+// Render выводит диаграмму в указанном формате
+func (g *Graph) Render(format string) (string, error) {
+	switch format {
+	case FormatMermaid:
+		return g.Mermaid(), nil
+	case FormatDOT:
+		return g.DOT(), nil
+	default:
+		return "", fmt.Errorf("неизвестный формат диаграммы: %s. Доступные: %s, %s", format, FormatMermaid, FormatDOT)
+	}
+}
+
+// Mermaid выводит диаграмму как flowchart Mermaid: узел - объект со списком
+// скалярных полей, ребро - вложенное поле, пунктир - ветка варианта
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, node := range g.Nodes {
+		lines := append([]string{"<b>" + mermaidEscape(node.Label) + "</b>"}, node.Fields...)
+		for i := 1; i < len(lines); i++ {
+			lines[i] = mermaidEscape(lines[i])
+		}
+		label := strings.Join(lines, "<br/>")
+		if node.Kind == KindVariant {
+			fmt.Fprintf(&b, "    %s{{\"%s\"}}\n", node.ID, label)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", node.ID, label)
+	}
+	for _, edge := range g.Edges {
+		arrow := "-->"
+		if edge.Variant {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "    %s %s|\"%s\"| %s\n", edge.From, arrow, mermaidEscape(edge.Label), edge.To)
+	}
+	return b.String()
+}
+
+// mermaidEscape заменяет символы, ломающие подписи Mermaid, HTML-сущностями
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "|", "#124;").Replace(s)
+}
+
+// DOT выводит диаграмму как граф Graphviz
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph schema {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=record, fontname=\"Helvetica\"];\n")
+	for _, node := range g.Nodes {
+		if node.Kind == KindVariant {
+			fmt.Fprintf(&b, "    %s [shape=diamond, label=\"%s\"];\n", node.ID, dotEscape(node.Label))
+			continue
+		}
+		rows := make([]string, 0, len(node.Fields))
+		for _, field := range node.Fields {
+			rows = append(rows, dotEscape(field)+`\l`)
+		}
+		fmt.Fprintf(&b, "    %s [label=\"{%s|%s}\"];\n", node.ID, dotEscape(node.Label), strings.Join(rows, ""))
+	}
+	for _, edge := range g.Edges {
+		style := ""
+		if edge.Variant {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "    %s -> %s [label=\"%s\"%s];\n", edge.From, edge.To, dotEscape(edge.Label), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotEscape экранирует символы, имеющие смысл в метках record-узлов DOT
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `{`, `\{`, `}`, `\}`, `|`, `\|`, `<`, `\<`, `>`, `\>`).Replace(s)
+}
@@ -0,0 +1,33 @@
+package diagram
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/internal/golden"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+)
+
+// TestGoldenRender сравнивает диаграммы схем testdata/golden/<случай>.schema.json
+// с эталонами <случай>.mmd.golden и <случай>.dot.golden
+func TestGoldenRender(t *testing.T) {
+	for _, input := range golden.Inputs(t, "testdata/golden/*.schema.json") {
+		name := strings.TrimSuffix(input, ".schema.json")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Схема загружается, как в export diagram
+			result, err := analyzer.New().LoadSchemaBytes(data)
+			if err != nil {
+				t.Fatalf("LoadSchemaBytes: %v", err)
+			}
+			graph := Build(filepath.Base(name), result.Schema)
+			golden.Assert(t, name+".mmd.golden", []byte(graph.Mermaid()))
+			golden.Assert(t, name+".dot.golden", []byte(graph.DOT()))
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Package errorshape распознает типовые конверты ошибок (code/message/details)
+// в схемах и выносит их в общее определение.
+package errorshape
+
+import (
+	"sort"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// Имена полей, по которым распознается конверт ошибки
+var (
+	codeFields    = []string{"code", "error_code", "errorCode", "status", "type"}
+	messageFields = []string{"message", "msg", "error_message", "errorMessage", "detail", "title"}
+	extraFields   = []string{"details", "errors", "error", "description", "trace_id", "traceId", "request_id", "requestId", "instance", "path", "timestamp"}
+)
+
+// envelopeFields содержит все поля, допустимые в конверте ошибки
+var envelopeFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	for _, group := range [][]string{codeFields, messageFields, extraFields} {
+		for _, name := range group {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// Match представляет найденный в схеме конверт ошибки
+type Match struct {
+	Path     string          `json:"path"`
+	Property *types.Property `json:"-"`
+}
+
+// IsEnvelope сообщает, что объект похож на конверт ошибки: в нем есть поле
+// с кодом и поле с сообщением, а остальные поля типичны для ошибок
+func IsEnvelope(prop *types.Property) bool {
+	if prop == nil || prop.Ref != "" || prop.Type != "object" || len(prop.Properties) < 2 {
+		return false
+	}
+
+	for name := range prop.Properties {
+		if !envelopeFields[name] {
+			return false
+		}
+	}
+
+	return hasAny(prop, codeFields) && hasAny(prop, messageFields)
+}
+
+// Find возвращает все конверты ошибок схемы. Вложенные в найденный конверт
+// объекты отдельно не возвращаются.
+func Find(schema *types.JSONSchema) []Match {
+	var matches []Match
+	if root := rootProperty(schema); IsEnvelope(root) {
+		return append(matches, Match{Path: "$", Property: root})
+	}
+
+	walk.Walk(schema, func(path string, p *types.Property) error {
+		if !IsEnvelope(p) {
+			return nil
+		}
+		matches = append(matches, Match{Path: path, Property: p})
+		return walk.SkipChildren
+	})
+	return matches
+}
+
+// Merge объединяет найденные конверты в одно определение: поля объединяются,
+// обязательными остаются поля, обязательные во всех конвертах
+func Merge(envelopes []*types.Property) *types.Property {
+	merged := &types.Property{
+		Type:       "object",
+		Properties: make(map[string]*types.Property),
+	}
+
+	var required map[string]bool
+	for _, envelope := range envelopes {
+		for name, prop := range envelope.Properties {
+			if prop == nil {
+				continue
+			}
+			if _, exists := merged.Properties[name]; !exists {
+				merged.Properties[name] = withoutDefaults(prop)
+			}
+		}
+
+		current := make(map[string]bool, len(envelope.Required))
+		for _, name := range envelope.Required {
+			if required == nil || required[name] {
+				current[name] = true
+			}
+		}
+		required = current
+	}
+
+	merged.Required = make([]string, 0, len(required))
+	for name := range required {
+		merged.Required = append(merged.Required, name)
+	}
+	sort.Strings(merged.Required)
+
+	return merged
+}
+
+// Replace заменяет найденные в схеме конверты ссылкой ref и возвращает их число
+func Replace(schema *types.JSONSchema, ref string) int {
+	// Схема ответа целиком описывает ошибку
+	if IsEnvelope(rootProperty(schema)) {
+		schema.Ref = ref
+		schema.Type = ""
+		schema.Properties = nil
+		schema.Required = nil
+		schema.Default = nil
+		schema.PatternProperties, schema.AdditionalProperties = nil, nil
+		return 1
+	}
+
+	replaced := 0
+	walk.Walk(schema, func(path string, p *types.Property) error {
+		if !IsEnvelope(p) {
+			return nil
+		}
+		*p = types.Property{Ref: ref, Description: p.Description}
+		replaced++
+		return walk.SkipChildren
+	})
+	return replaced
+}
+
+// hasAny сообщает, что у объекта есть хотя бы одно из полей
+func hasAny(prop *types.Property, names []string) bool {
+	for _, name := range names {
+		if _, exists := prop.Properties[name]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// rootProperty представляет корень схемы как Property
+func rootProperty(schema *types.JSONSchema) *types.Property {
+	if schema == nil || schema.Ref != "" {
+		return nil
+	}
+	return &types.Property{
+		Type:       schema.Type,
+		Properties: schema.Properties,
+		Required:   schema.Required,
+	}
+}
+
+// withoutDefaults возвращает копию свойства без default: значения из
+// отдельных ответов не описывают общее определение
+func withoutDefaults(prop *types.Property) *types.Property {
+	clone := *prop
+	clone.Default = nil
+	if prop.Items != nil {
+		clone.Items = withoutDefaults(prop.Items)
+	}
+	if prop.PrefixItems != nil {
+		clone.PrefixItems = make([]*types.Property, len(prop.PrefixItems))
+		for i, position := range prop.PrefixItems {
+			clone.PrefixItems[i] = withoutDefaults(position)
+		}
+	}
+	if prop.Properties != nil {
+		clone.Properties = make(map[string]*types.Property, len(prop.Properties))
+		for name, child := range prop.Properties {
+			if child != nil {
+				clone.Properties[name] = withoutDefaults(child)
+			}
+		}
+	}
+	return &clone
+}
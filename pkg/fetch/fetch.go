@@ -0,0 +1,180 @@
+// Package fetch загружает входные данные по HTTP(S) URL во временные файлы,
+// чтобы они анализировались так же, как локальные.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config настраивает загрузку
+type Config struct {
+	Headers map[string]string
+	Timeout time.Duration
+	// Retries - сколько раз повторить запрос после сетевой ошибки или
+	// ответа 429/5xx
+	Retries int
+	// RetryDelay - пауза перед первым повтором; каждая следующая вдвое
+	// длиннее. Заголовок Retry-After ответа имеет приоритет
+	RetryDelay time.Duration
+}
+
+// IsURL сообщает, что аргумент - HTTP(S) URL, а не путь к файлу
+func IsURL(arg string) bool {
+	lower := strings.ToLower(arg)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// ParseHeaders разбирает заголовки в формате "Имя: значение"
+func ParseHeaders(values []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(values))
+	for _, value := range values {
+		name, val, ok := strings.Cut(value, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("некорректный заголовок: %q, ожидается \"Имя: значение\"", value)
+		}
+		parsed[strings.TrimSpace(name)] = strings.TrimSpace(val)
+	}
+	return parsed, nil
+}
+
+// retryableError - ошибка попытки, после которой запрос стоит повторить
+type retryableError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// Download загружает данные по URL в файл директории dir и возвращает его
+// путь. Имя файла берется из последнего сегмента пути URL, расширение -
+// .ndjson для потоков NDJSON и .json для остальных ответов
+func Download(ctx context.Context, rawURL string, config Config, dir string) (string, error) {
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = time.Second
+	}
+	client := &http.Client{Timeout: config.Timeout}
+
+	delay := config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		file, err := download(ctx, client, rawURL, config.Headers, dir)
+		if err == nil {
+			return file, nil
+		}
+		var retryable *retryableError
+		if !errors.As(err, &retryable) || attempt >= config.Retries {
+			return "", err
+		}
+
+		wait := delay
+		if retryable.after > 0 {
+			wait = retryable.after
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// download выполняет одну попытку загрузки
+func download(ctx context.Context, client *http.Client, rawURL string, headers map[string]string, dir string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, application/x-ndjson;q=0.9, */*;q=0.1")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", &retryableError{err: fmt.Errorf("ошибка запроса %s: %w", rawURL, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("%s вернул статус %s", rawURL, resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return "", &retryableError{err: err, after: retryAfter(resp.Header.Get("Retry-After"))}
+		}
+		return "", err
+	}
+
+	file := filepath.Join(dir, FileName(rawURL, resp.Header.Get("Content-Type")))
+	f, err := os.Create(file)
+	if err != nil {
+		return "", fmt.Errorf("ошибка создания файла: %w", err)
+	}
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+		return "", &retryableError{err: fmt.Errorf("ошибка чтения ответа %s: %w", rawURL, err)}
+	}
+	return file, nil
+}
+
+// retryAfter разбирает заголовок Retry-After в секундах; дата и
+// некорректное значение дают 0
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// FileName возвращает имя файла для данных по URL: последний сегмент пути
+// (или хост, если путь пуст) с расширением по типу содержимого
+// (https://api.example.com/v1/users → users.json)
+func FileName(rawURL, contentType string) string {
+	name := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		name = path.Base(u.Path)
+		if name == "/" || name == "." {
+			name = u.Hostname()
+		}
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, name)
+	if name == "" {
+		name = "response"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if ext == ".ndjson" || ext == ".jsonl" || strings.Contains(mediaType, "ndjson") || strings.Contains(mediaType, "jsonl") {
+		return name + ".ndjson"
+	}
+	return name + ".json"
+}
@@ -2,12 +2,17 @@ package fieldmanager
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
 )
 
+// variantSegmentPattern соответствует сегменту пути oneOf[i]/anyOf[i]
+var variantSegmentPattern = regexp.MustCompile(`^(oneOf|anyOf)\[(\d+)\]$`)
+
 // FieldManager управляет полями в JSON Schema
 type FieldManager struct{}
 
@@ -18,12 +23,28 @@ func New() *FieldManager {
 
 // FindField находит поле по JSON Path в схеме
 func (fm *FieldManager) FindField(schema *types.JSONSchema, jsonPath string) (*types.Property, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("схема не задана")
+	}
+
 	// Парсим JSON Path
 	path, err := fm.parseJSONPath(jsonPath)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка парсинга пути: %w", err)
 	}
 
+	// Пути общих определений начинаются с $defs.<имя>
+	if path[0] == types.DefsSegment && len(path) > 1 {
+		def := schema.Defs[path[1]]
+		if def == nil {
+			return nil, fmt.Errorf("определение %s не найдено", path[1])
+		}
+		if len(path) == 2 {
+			return def, nil
+		}
+		return fm.findFieldRecursive(fm.propertyToSchema(def), path, 2)
+	}
+
 	// Начинаем поиск с корневой схемы
 	return fm.findFieldRecursive(schema, path, 0)
 }
@@ -35,9 +56,7 @@ func (fm *FieldManager) parseJSONPath(jsonPath string) ([]string, error) {
 	}
 
 	// Убираем начальную точку если есть
-	if strings.HasPrefix(jsonPath, ".") {
-		jsonPath = jsonPath[1:]
-	}
+	jsonPath = strings.TrimPrefix(jsonPath, ".")
 
 	// Разбиваем по точкам
 	segments := strings.Split(jsonPath, ".")
@@ -65,6 +84,17 @@ func (fm *FieldManager) findFieldRecursive(schema *types.JSONSchema, path []stri
 
 	segment := path[index]
 
+	// Сегмент oneOf[i]/anyOf[i] переходит в вариант полиморфного поля
+	if variant, ok, err := fm.variantSegment(schema, segment); ok {
+		if err != nil {
+			return nil, err
+		}
+		if index == len(path)-1 {
+			return nil, fmt.Errorf("путь %s указывает на вариант, а не на поле", segment)
+		}
+		return fm.findFieldRecursive(variant, path, index+1)
+	}
+
 	// Проверяем, является ли сегмент числовым индексом
 	if _, err := strconv.Atoi(segment); err == nil {
 		// Это индекс массива - нужно найти предыдущее поле (массив) и взять его items
@@ -79,17 +109,18 @@ func (fm *FieldManager) findFieldRecursive(schema *types.JSONSchema, path []stri
 			return nil, fmt.Errorf("не найдено поле %s: %w", prevSegment, err)
 		}
 
-		if prevField.Type != "array" || prevField.Items == nil {
+		element := arrayElement(prevField, segment)
+		if element == nil {
 			return nil, fmt.Errorf("поле %s не является массивом", prevSegment)
 		}
 
 		// Если это последний сегмент, возвращаем items
 		if index == len(path)-1 {
-			return prevField.Items, nil
+			return element, nil
 		}
 
 		// Иначе продолжаем поиск в items
-		itemSchema := fm.propertyToSchema(prevField.Items)
+		itemSchema := fm.propertyToSchema(element)
 		return fm.findFieldRecursive(itemSchema, path, index+1)
 	}
 
@@ -109,22 +140,22 @@ func (fm *FieldManager) findFieldRecursive(schema *types.JSONSchema, path []stri
 		nextSegment := path[index+1]
 		if _, err := strconv.Atoi(nextSegment); err == nil {
 			// Следующий сегмент - числовой индекс, поэтому текущее поле должно быть массивом
-			if field.Type == "array" && field.Items != nil {
+			if element := arrayElement(field, nextSegment); element != nil {
 				// Пропускаем индекс и идем к содержимому items
 				if index+2 >= len(path) {
 					// Если индекс - последний сегмент, возвращаем items
-					return field.Items, nil
+					return element, nil
 				}
 				// Иначе продолжаем поиск в items, пропуская индекс
-				itemSchema := fm.propertyToSchema(field.Items)
+				itemSchema := fm.propertyToSchema(element)
 				return fm.findFieldRecursive(itemSchema, path, index+2)
 			}
 			return nil, fmt.Errorf("поле %s должно быть массивом для индекса %s", segment, nextSegment)
 		}
 	}
 
-	// Если поле это объект, работаем с properties
-	if field.Type == "object" && field.Properties != nil {
+	// Если поле это объект или полиморфный тип, работаем с properties и вариантами
+	if (field.Type == "object" && (field.Properties != nil || types.MapValues(field.PatternProperties, field.AdditionalProperties) != nil)) || len(field.OneOf) > 0 || len(field.AnyOf) > 0 {
 		// Конвертируем Property в JSONSchema для рекурсии
 		objSchema := fm.propertyToSchema(field)
 		return fm.findFieldRecursive(objSchema, path, index+1)
@@ -133,11 +164,57 @@ func (fm *FieldManager) findFieldRecursive(schema *types.JSONSchema, path []stri
 	return nil, fmt.Errorf("невозможно перейти глубже по пути %s", segment)
 }
 
+// arrayElement возвращает схему элемента массива по индексу: позицию кортежа
+// или items; nil, если поле не массив или в кортеже нет такой позиции
+func arrayElement(field *types.Property, segment string) *types.Property {
+	if field.Type != "array" {
+		return nil
+	}
+	if len(field.PrefixItems) > 0 {
+		i, err := strconv.Atoi(segment)
+		if err == nil && i >= 0 && i < len(field.PrefixItems) {
+			return field.PrefixItems[i]
+		}
+		return field.Items
+	}
+	return field.Items
+}
+
+// variantSegment разбирает сегмент вида oneOf[i]/anyOf[i]
+func (fm *FieldManager) variantSegment(schema *types.JSONSchema, segment string) (*types.JSONSchema, bool, error) {
+	match := variantSegmentPattern.FindStringSubmatch(segment)
+	if match == nil {
+		return nil, false, nil
+	}
+
+	variants := schema.OneOf
+	if match[1] == "anyOf" {
+		variants = schema.AnyOf
+	}
+
+	i, _ := strconv.Atoi(match[2])
+	if i >= len(variants) || variants[i] == nil {
+		return nil, true, fmt.Errorf("вариант %s не найден", segment)
+	}
+	return variants[i], true, nil
+}
+
 // findFieldInSchema находит поле в конкретной схеме
 func (fm *FieldManager) findFieldInSchema(schema *types.JSONSchema, fieldName string) (*types.Property, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("поле %s не найдено", fieldName)
+	}
+
+	// Сегмент "*" обозначает значения объекта-словаря
+	if fieldName == "*" {
+		if values := types.MapValues(schema.PatternProperties, schema.AdditionalProperties); values != nil {
+			return values, nil
+		}
+	}
+
 	// Ищем поле по имени
 	if schema.Properties != nil {
-		if field, exists := schema.Properties[fieldName]; exists {
+		if field, exists := schema.Properties[fieldName]; exists && field != nil {
 			return field, nil
 		}
 	}
@@ -164,14 +241,27 @@ func (fm *FieldManager) findFieldInSchema(schema *types.JSONSchema, fieldName st
 
 // propertyToSchema конвертирует Property в JSONSchema
 func (fm *FieldManager) propertyToSchema(prop *types.Property) *types.JSONSchema {
+	if prop == nil {
+		return &types.JSONSchema{}
+	}
+
 	schema := &types.JSONSchema{
 		Type:        prop.Type,
 		Properties:  prop.Properties,
 		Required:    prop.Required,
 		Enum:        prop.Enum,
+		Format:      prop.Format,
 		OneOf:       prop.OneOf,
 		AnyOf:       prop.AnyOf,
+		Title:       prop.Title,
 		Description: prop.Description,
+		MinItems:    prop.MinItems,
+		MaxItems:    prop.MaxItems,
+		UniqueItems: prop.UniqueItems,
+
+		PropertyOrder:        prop.PropertyOrder,
+		PatternProperties:    prop.PatternProperties,
+		AdditionalProperties: prop.AdditionalProperties,
 	}
 
 	if prop.Items != nil {
@@ -183,14 +273,27 @@ func (fm *FieldManager) propertyToSchema(prop *types.Property) *types.JSONSchema
 
 // schemaToProperty конвертирует JSONSchema в Property
 func (fm *FieldManager) schemaToProperty(schema *types.JSONSchema) *types.Property {
+	if schema == nil {
+		return &types.Property{}
+	}
+
 	prop := &types.Property{
 		Type:        schema.Type,
 		Properties:  schema.Properties,
 		Required:    schema.Required,
 		Enum:        schema.Enum,
+		Format:      schema.Format,
 		OneOf:       schema.OneOf,
 		AnyOf:       schema.AnyOf,
+		Title:       schema.Title,
 		Description: schema.Description,
+		MinItems:    schema.MinItems,
+		MaxItems:    schema.MaxItems,
+		UniqueItems: schema.UniqueItems,
+
+		PropertyOrder:        schema.PropertyOrder,
+		PatternProperties:    schema.PatternProperties,
+		AdditionalProperties: schema.AdditionalProperties,
 	}
 
 	if schema.Items != nil {
@@ -203,61 +306,13 @@ func (fm *FieldManager) schemaToProperty(schema *types.JSONSchema) *types.Proper
 // ListFields возвращает список всех полей в схеме
 func (fm *FieldManager) ListFields(schema *types.JSONSchema) []string {
 	var fields []string
-	fm.listFieldsRecursive(schema, "", &fields)
+	_ = walk.WalkWithOptions(schema, walk.Options{SkipItems: true}, func(path string, _ *types.Property) error {
+		fields = append(fields, path)
+		return nil
+	})
 	return fields
 }
 
-// listFieldsRecursive рекурсивно собирает все поля
-func (fm *FieldManager) listFieldsRecursive(schema *types.JSONSchema, prefix string, fields *[]string) {
-	if schema.Properties != nil {
-		for fieldName, field := range schema.Properties {
-			fullPath := fieldName
-			if prefix != "" {
-				fullPath = prefix + "." + fieldName
-			}
-
-			*fields = append(*fields, fullPath)
-
-			// Рекурсивно обрабатываем вложенные объекты
-			if field.Type == "object" && field.Properties != nil {
-				subSchema := fm.propertyToSchema(field)
-				fm.listFieldsRecursive(subSchema, fullPath, fields)
-			}
-
-			// Рекурсивно обрабатываем массивы
-			if field.Type == "array" && field.Items != nil {
-				subSchema := fm.propertyToSchema(field.Items)
-				fm.listFieldsRecursive(subSchema, fullPath+".0", fields)
-			}
-		}
-	}
-
-	// Обрабатываем oneOf/anyOf
-	if schema.OneOf != nil {
-		for i, variant := range schema.OneOf {
-			variantPrefix := prefix
-			if prefix != "" {
-				variantPrefix = prefix + fmt.Sprintf(".oneOf[%d]", i)
-			} else {
-				variantPrefix = fmt.Sprintf("oneOf[%d]", i)
-			}
-			fm.listFieldsRecursive(variant, variantPrefix, fields)
-		}
-	}
-
-	if schema.AnyOf != nil {
-		for i, variant := range schema.AnyOf {
-			variantPrefix := prefix
-			if prefix != "" {
-				variantPrefix = prefix + fmt.Sprintf(".anyOf[%d]", i)
-			} else {
-				variantPrefix = fmt.Sprintf("anyOf[%d]", i)
-			}
-			fm.listFieldsRecursive(variant, variantPrefix, fields)
-		}
-	}
-}
-
 // UpdateField обновляет поле в схеме
 func (fm *FieldManager) UpdateField(schema *types.JSONSchema, jsonPath string, updater func(*types.Property) error) error {
 	field, err := fm.FindField(schema, jsonPath)
@@ -0,0 +1,46 @@
+package fieldmanager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// FuzzFindField проверяет поиск и проверку пути поля на произвольных
+// путях и схемах
+func FuzzFindField(f *testing.F) {
+	schema := `{"type": "object", "properties": {
+		"data": {"type": "array", "items": {"type": "object", "properties": {
+			"id": {"type": "integer"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"shape": {"oneOf": [{"type": "object", "properties": {"r": {"type": "number"}}}, {"type": "null"}]}
+		}}},
+		"meta": {"type": "object", "additionalProperties": {"type": "string"}}
+	}}`
+	paths := []string{
+		"data", "data[0].id", "data[].tags[0]", "data[0].shape.r", "meta.anything",
+		"", ".", "..", "[", "]", "[0]", "data[", "data[x]", "data[0]..id", "a.b.c.d.e",
+	}
+	for _, path := range paths {
+		f.Add([]byte(schema), path)
+	}
+	f.Add([]byte(`{"properties": null}`), "a")
+	f.Add([]byte(`{"type": "array"}`), "[0].a")
+	f.Add([]byte(`{"items": {"items": {}}}`), "[0][0].x")
+
+	f.Fuzz(func(t *testing.T, data []byte, path string) {
+		var s types.JSONSchema
+		if err := json.Unmarshal(data, &s); err != nil {
+			return
+		}
+		fm := New()
+		if _, err := fm.FindField(&s, path); err != nil {
+			return
+		}
+		if err := fm.ValidateJSONPath(&s, path); err != nil {
+			t.Fatalf("FindField нашел %q, а ValidateJSONPath отклонил: %v", path, err)
+		}
+		fm.ListFields(&s)
+	})
+}
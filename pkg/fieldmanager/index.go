@@ -0,0 +1,94 @@
+package fieldmanager
+
+import (
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// Index - индексированное представление схемы для поиска полей за O(1).
+// Строится лениво при первом обращении и сбрасывается при изменениях через Update
+// или явным вызовом Invalidate после изменения схемы в обход индекса.
+type Index struct {
+	fm     *FieldManager
+	schema *types.JSONSchema
+	nodes  map[string]*types.Property
+	fields []string
+	built  bool
+}
+
+// NewIndex создает индекс полей схемы
+func (fm *FieldManager) NewIndex(schema *types.JSONSchema) *Index {
+	return &Index{fm: fm, schema: schema}
+}
+
+// Lookup находит поле по JSON Path
+func (idx *Index) Lookup(jsonPath string) (*types.Property, error) {
+	idx.build()
+
+	path, err := idx.fm.parseJSONPath(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+	if node, ok := idx.nodes[strings.Join(path, ".")]; ok {
+		return node, nil
+	}
+
+	// Неканонические пути (например, поле варианта без префикса oneOf[i]) ищем обходом
+	return idx.fm.FindField(idx.schema, jsonPath)
+}
+
+// Fields возвращает пути всех полей схемы в том же виде, что и ListFields
+func (idx *Index) Fields() []string {
+	idx.build()
+
+	fields := make([]string, len(idx.fields))
+	copy(fields, idx.fields)
+	return fields
+}
+
+// Len возвращает количество проиндексированных узлов, включая items массивов
+func (idx *Index) Len() int {
+	idx.build()
+	return len(idx.nodes)
+}
+
+// Update изменяет поле и сбрасывает индекс, так как изменение может затронуть структуру
+func (idx *Index) Update(jsonPath string, updater func(*types.Property) error) error {
+	field, err := idx.Lookup(jsonPath)
+	if err != nil {
+		return err
+	}
+
+	defer idx.Invalidate()
+	return updater(field)
+}
+
+// Invalidate сбрасывает индекс; он будет перестроен при следующем обращении
+func (idx *Index) Invalidate() {
+	idx.built = false
+	idx.nodes = nil
+	idx.fields = nil
+}
+
+// build строит индекс, если он еще не построен
+func (idx *Index) build() {
+	if idx.built {
+		return
+	}
+
+	idx.nodes = make(map[string]*types.Property)
+	idx.fields = idx.fields[:0]
+
+	_ = walk.Walk(idx.schema, func(path string, p *types.Property) error {
+		idx.nodes[path] = p
+		return nil
+	})
+	_ = walk.WalkWithOptions(idx.schema, walk.Options{SkipItems: true}, func(path string, _ *types.Property) error {
+		idx.fields = append(idx.fields, path)
+		return nil
+	})
+
+	idx.built = true
+}
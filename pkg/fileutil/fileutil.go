@@ -0,0 +1,132 @@
+// Package fileutil содержит операции с файлами, одинаково работающие в Linux,
+// macOS и Windows: атомарную запись и чтение текстовых файлов, сохраненных
+// редакторами Windows (с BOM и переводами строк CRLF).
+package fileutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// utf8BOM - метка порядка байтов, которую добавляют некоторые редакторы Windows
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// WriteFile атомарно записывает данные в файл: данные пишутся во временный
+// файл в той же директории, который затем переименовывается в filename.
+// При ошибке прежнее содержимое файла не теряется. os.Rename заменяет
+// существующий файл и в Windows, если он не открыт другим процессом.
+func WriteFile(filename string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("ошибка замены файла %s: %w", filename, err)
+	}
+	return nil
+}
+
+// ReadFile читает текстовый файл, отбрасывая BOM в начале
+func ReadFile(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return TrimBOM(data), nil
+}
+
+// TrimBOM отбрасывает BOM в начале данных
+func TrimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, utf8BOM)
+}
+
+// NormalizeNewlines заменяет переводы строк CRLF на LF, чтобы содержимое,
+// извлеченное git с core.autocrlf=true, совпадало с исходным
+func NormalizeNewlines(data []byte) []byte {
+	if !bytes.Contains(data, []byte("\r\n")) {
+		return data
+	}
+	return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+}
+
+// ExpandGlobs раскрывает шаблоны путей (data/*.json) в список файлов. Шаблоны
+// раскрываются здесь, потому что командная оболочка Windows этого не делает.
+// Путь без метасимволов возвращается как есть, шаблон без совпадений -
+// ошибка, повторяющиеся пути пропускаются
+func ExpandGlobs(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			matches, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("неверный шаблон %s: %w", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("нет файлов по шаблону: %s", pattern)
+			}
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// WalkFiles возвращает файлы дерева директорий root, для которых match
+// возвращает true, в лексикографическом порядке путей. Скрытые директории
+// (.git, .cache) пропускаются, директория без подходящих файлов - ошибка
+func WalkFiles(root string, match func(path string) bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() && match(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обхода директории %s: %w", root, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("нет подходящих файлов в директории: %s", root)
+	}
+	return files, nil
+}
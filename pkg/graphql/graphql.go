@@ -0,0 +1,100 @@
+// Package graphql распознает ответы GraphQL и разделяет их на операции.
+package graphql
+
+import (
+	"sort"
+)
+
+// Ключи конверта ответа GraphQL
+const (
+	KeyData       = "data"
+	KeyErrors     = "errors"
+	KeyExtensions = "extensions"
+)
+
+// Response представляет разобранный ответ GraphQL
+type Response struct {
+	// Operations содержит значения корневых полей data по именам операций
+	Operations map[string]interface{}
+	// Errors содержит элементы массива errors
+	Errors []interface{}
+}
+
+// IsResponse проверяет, что значение является конвертом ответа GraphQL:
+// объект только с ключами data/errors/extensions, где data - объект или null,
+// а errors - массив, и присутствует хотя бы один из них. Без errors конверт
+// {"data": {...}} считается ответом GraphQL, только если все корневые поля
+// data - объекты, массивы или null, чтобы не спутать его с обычной оберткой REST.
+func IsResponse(value interface{}) bool {
+	obj, ok := value.(map[string]interface{})
+	if !ok || len(obj) == 0 {
+		return false
+	}
+
+	for key := range obj {
+		if key != KeyData && key != KeyErrors && key != KeyExtensions {
+			return false
+		}
+	}
+
+	data, hasData := obj[KeyData]
+	errors, hasErrors := obj[KeyErrors]
+	if !hasData && !hasErrors {
+		return false
+	}
+	if hasErrors {
+		if _, ok := errors.([]interface{}); !ok {
+			return false
+		}
+	}
+	if !hasData || data == nil {
+		return true
+	}
+
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if hasErrors {
+		return true
+	}
+	for _, field := range fields {
+		switch field.(type) {
+		case map[string]interface{}, []interface{}, nil:
+		default:
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// Split разделяет ответ GraphQL на операции и ошибки. Возвращает false,
+// если значение не является ответом GraphQL.
+func Split(value interface{}) (*Response, bool) {
+	if !IsResponse(value) {
+		return nil, false
+	}
+
+	obj := value.(map[string]interface{})
+	response := &Response{Operations: make(map[string]interface{})}
+
+	if data, ok := obj[KeyData].(map[string]interface{}); ok {
+		for name, result := range data {
+			response.Operations[name] = result
+		}
+	}
+	if errors, ok := obj[KeyErrors].([]interface{}); ok {
+		response.Errors = errors
+	}
+	return response, true
+}
+
+// OperationNames возвращает имена операций в отсортированном порядке
+func (r *Response) OperationNames() []string {
+	names := make([]string, 0, len(r.Operations))
+	for name := range r.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,273 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Parse разбирает JSON Patch документ
+func Parse(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON Patch: %w", err)
+	}
+	return patch, nil
+}
+
+// ApplyBytes применяет патч к JSON документу
+func (p Patch) ApplyBytes(doc []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(doc, &value); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга документа: %w", err)
+	}
+
+	result, err := p.Apply(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// Apply применяет операции патча к декодированному JSON значению по порядку
+func (p Patch) Apply(doc interface{}) (interface{}, error) {
+	var err error
+	for i, op := range p {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("операция %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// applyOperation применяет одну операцию
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	switch op.Op {
+	case OpAdd:
+		return addValue(doc, op.Path, deepCopy(op.Value))
+	case OpRemove:
+		result, _, err := removeValue(doc, op.Path)
+		return result, err
+	case OpReplace:
+		result, _, err := removeValue(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if op.Path == "" {
+			return deepCopy(op.Value), nil
+		}
+		return addValue(result, op.Path, deepCopy(op.Value))
+	case OpMove:
+		if op.Path != op.From && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("нельзя переместить значение внутрь самого себя")
+		}
+		result, value, err := removeValue(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(result, op.Path, value)
+	case OpCopy:
+		value, err := Get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, op.Path, deepCopy(value))
+	case OpTest:
+		value, err := Get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(normalize(value), normalize(op.Value)) {
+			return nil, fmt.Errorf("проверка не пройдена: значение отличается")
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("неизвестная операция: %q", op.Op)
+	}
+}
+
+// Get возвращает значение по JSON Pointer
+func Get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, exists := node[token]
+			if !exists {
+				return nil, fmt.Errorf("путь %s не найден", pointer)
+			}
+			current = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("путь %s не найден", pointer)
+		}
+	}
+	return current, nil
+}
+
+// leafFunc изменяет контейнер по последнему сегменту пути и возвращает новый контейнер
+type leafFunc func(container interface{}, token string) (interface{}, error)
+
+// addValue добавляет значение по JSON Pointer
+func addValue(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return modify(doc, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch node := container.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			i, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("родитель пути %s не является объектом или массивом", pointer)
+		}
+	})
+}
+
+// removeValue удаляет значение по JSON Pointer и возвращает удаленное значение
+func removeValue(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	var removed interface{}
+	result, err := modify(doc, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch node := container.(type) {
+		case map[string]interface{}:
+			value, exists := node[token]
+			if !exists {
+				return nil, fmt.Errorf("путь %s не найден", pointer)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return append(node[:i], node[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("путь %s не найден", pointer)
+		}
+	})
+	return result, removed, err
+}
+
+// modify спускается к родителю последнего сегмента и применяет к нему функцию
+func modify(node interface{}, tokens []string, fn leafFunc) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		child, exists := typed[tokens[0]]
+		if !exists {
+			return nil, fmt.Errorf("сегмент %q не найден", tokens[0])
+		}
+		updated, err := modify(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		typed[tokens[0]] = updated
+		return typed, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(typed)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := modify(typed[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		typed[i] = updated
+		return typed, nil
+	default:
+		return nil, fmt.Errorf("сегмент %q не найден", tokens[0])
+	}
+}
+
+// parsePointer разбирает JSON Pointer на сегменты
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("некорректный JSON Pointer: %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = UnescapeToken(token)
+	}
+	return tokens, nil
+}
+
+// arrayIndex разбирает индекс массива и проверяет, что он не превышает max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("некорректный индекс массива: %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("некорректный индекс массива: %q", token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("индекс массива %d вне диапазона", i)
+	}
+	return i, nil
+}
+
+// deepCopy копирует JSON значение, чтобы операции не разделяли общие узлы
+func deepCopy(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var copied interface{}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return value
+	}
+	return copied
+}
+
+// normalize приводит значение к виду, полученному из json.Unmarshal, для сравнения
+func normalize(value interface{}) interface{} {
+	return deepCopy(value)
+}
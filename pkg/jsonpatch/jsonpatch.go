@@ -0,0 +1,151 @@
+// Package jsonpatch строит и применяет JSON Patch (RFC 6902) для JSON документов.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Операции JSON Patch
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation представляет одну операцию JSON Patch
+type Operation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON сохраняет value для add/replace/test, даже если оно равно null
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type plain struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+	}
+	type withValue struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+
+	switch o.Op {
+	case OpAdd, OpReplace, OpTest:
+		return json.Marshal(withValue{Op: o.Op, Path: o.Path, Value: o.Value})
+	default:
+		return json.Marshal(plain{Op: o.Op, Path: o.Path, From: o.From})
+	}
+}
+
+// Patch - последовательность операций JSON Patch
+type Patch []Operation
+
+// DiffBytes строит патч, превращающий первый JSON документ во второй
+func DiffBytes(oldDoc, newDoc []byte) (Patch, error) {
+	var oldValue, newValue interface{}
+	if err := json.Unmarshal(oldDoc, &oldValue); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга исходного документа: %w", err)
+	}
+	if err := json.Unmarshal(newDoc, &newValue); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга нового документа: %w", err)
+	}
+	return Diff(oldValue, newValue), nil
+}
+
+// Diff строит патч между двумя декодированными JSON значениями
+func Diff(oldValue, newValue interface{}) Patch {
+	patch := make(Patch, 0)
+	diffValue(&patch, "", oldValue, newValue)
+	return patch
+}
+
+// diffValue сравнивает значения по указателю path
+func diffValue(patch *Patch, path string, oldValue, newValue interface{}) {
+	switch oldTyped := oldValue.(type) {
+	case map[string]interface{}:
+		if newTyped, ok := newValue.(map[string]interface{}); ok {
+			diffObject(patch, path, oldTyped, newTyped)
+			return
+		}
+	case []interface{}:
+		if newTyped, ok := newValue.([]interface{}); ok {
+			diffArray(patch, path, oldTyped, newTyped)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*patch = append(*patch, Operation{Op: OpReplace, Path: path, Value: newValue})
+	}
+}
+
+// diffObject сравнивает объекты в детерминированном порядке ключей
+func diffObject(patch *Patch, path string, oldObj, newObj map[string]interface{}) {
+	for _, key := range sortedKeys(oldObj) {
+		if _, exists := newObj[key]; !exists {
+			*patch = append(*patch, Operation{Op: OpRemove, Path: path + "/" + EscapeToken(key)})
+		}
+	}
+
+	for _, key := range sortedKeys(newObj) {
+		childPath := path + "/" + EscapeToken(key)
+		oldChild, exists := oldObj[key]
+		if !exists {
+			*patch = append(*patch, Operation{Op: OpAdd, Path: childPath, Value: newObj[key]})
+			continue
+		}
+		diffValue(patch, childPath, oldChild, newObj[key])
+	}
+}
+
+// diffArray сравнивает массивы поэлементно, добавляя или удаляя хвост
+func diffArray(patch *Patch, path string, oldArr, newArr []interface{}) {
+	common := len(oldArr)
+	if len(newArr) < common {
+		common = len(newArr)
+	}
+
+	for i := 0; i < common; i++ {
+		diffValue(patch, path+"/"+strconv.Itoa(i), oldArr[i], newArr[i])
+	}
+
+	// Удаляем с конца, чтобы индексы оставшихся элементов не смещались
+	for i := len(oldArr) - 1; i >= common; i-- {
+		*patch = append(*patch, Operation{Op: OpRemove, Path: path + "/" + strconv.Itoa(i)})
+	}
+	for i := common; i < len(newArr); i++ {
+		*patch = append(*patch, Operation{Op: OpAdd, Path: path + "/" + strconv.Itoa(i), Value: newArr[i]})
+	}
+}
+
+// EscapeToken экранирует сегмент JSON Pointer (RFC 6901)
+func EscapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// UnescapeToken восстанавливает сегмент JSON Pointer (RFC 6901)
+func UnescapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
+
+// sortedKeys возвращает ключи объекта в алфавитном порядке
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
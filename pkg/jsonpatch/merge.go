@@ -0,0 +1,42 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatchBytes применяет JSON Merge Patch (RFC 7386) к документу
+func MergePatchBytes(doc, patch []byte) ([]byte, error) {
+	var target, patchValue interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга документа: %w", err)
+	}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON Merge Patch: %w", err)
+	}
+	return json.Marshal(MergePatch(target, patchValue))
+}
+
+// MergePatch применяет JSON Merge Patch: объекты объединяются рекурсивно,
+// null удаляет ключ, любое другое значение заменяет целевое
+func MergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = MergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
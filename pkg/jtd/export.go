@@ -0,0 +1,312 @@
+package jtd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// exporter накапливает предупреждения о потерях при экспорте
+type exporter struct {
+	warnings []Warning
+}
+
+// FromSchema преобразует JSON Schema в схему JTD. Обязательные поля
+// становятся properties, остальные - optionalProperties, oneOf с
+// дискриминатором - формой discriminator, строковые enum - enum, nullable и
+// anyOf со значением null - nullable. Части схемы, которые JTD не выражает,
+// заменяются ближайшей допустимой схемой, и о каждой замене возвращается
+// предупреждение; ограничения значений (pattern, длины, диапазоны, форматы
+// кроме date-time) отбрасываются без предупреждений
+func FromSchema(schema *types.JSONSchema) (*Schema, []Warning) {
+	e := &exporter{}
+	if schema == nil {
+		return &Schema{}, nil
+	}
+	result := e.convert(rootProperty(schema), "")
+	for _, name := range sortedNames(schema.Defs) {
+		if result.Definitions == nil {
+			result.Definitions = make(map[string]*Schema, len(schema.Defs))
+		}
+		result.Definitions[name] = e.convert(schema.Defs[name], walk.Join(types.DefsSegment, name))
+	}
+	return result, e.warnings
+}
+
+// warn запоминает предупреждение об узле по пути path
+func (e *exporter) warn(path, format string, a ...interface{}) {
+	e.warnings = append(e.warnings, Warning{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// convert преобразует свойство с путем path
+func (e *exporter) convert(prop *types.Property, path string) *Schema {
+	if prop == nil {
+		return &Schema{}
+	}
+	s := e.form(prop, path)
+	if prop.Description != "" {
+		s.Metadata = map[string]interface{}{"description": prop.Description}
+	}
+	if prop.Nullable {
+		s.Nullable = true
+	}
+	if empty, _ := s.form(); empty == "empty" {
+		// Схема любого значения уже допускает null
+		s.Nullable = false
+	}
+	return s
+}
+
+// form выбирает форму JTD для свойства
+func (e *exporter) form(prop *types.Property, path string) *Schema {
+	switch {
+	case prop.Ref != "":
+		name, ok := strings.CutPrefix(prop.Ref, types.DefsRefPrefix)
+		if !ok || strings.Contains(name, "/") {
+			e.warn(path, "ссылка %s указывает не на определение $defs и заменена схемой любого значения", prop.Ref)
+			return &Schema{}
+		}
+		return &Schema{Ref: name}
+	case len(prop.OneOf) > 0:
+		return e.discriminated(prop.OneOf, path)
+	case len(prop.AnyOf) > 0:
+		return e.union(prop.AnyOf, path)
+	}
+
+	if len(prop.Enum) > 0 {
+		if values, ok := stringEnum(prop.Enum); ok && prop.Type == string(types.TypeString) {
+			return &Schema{Enum: values}
+		}
+		e.warn(path, "enum JTD поддерживает только строки, значения %s не сохранены", prop.Type)
+	}
+
+	switch types.JSONType(prop.Type) {
+	case types.TypeString:
+		if prop.Format == "date-time" {
+			return &Schema{Type: TypeTimestamp}
+		}
+		return &Schema{Type: TypeString}
+	case types.TypeInteger:
+		return &Schema{Type: e.integerType(prop, path)}
+	case types.TypeNumber:
+		return &Schema{Type: TypeFloat64}
+	case types.TypeBoolean:
+		return &Schema{Type: TypeBoolean}
+	case types.TypeArray:
+		if len(prop.PrefixItems) > 0 {
+			e.warn(path, "кортеж заменен массивом любых значений")
+			return &Schema{Elements: &Schema{}}
+		}
+		return &Schema{Elements: e.convert(prop.Items, walk.Join(path, "0"))}
+	case types.TypeObject:
+		return e.object(prop.Properties, prop.Required, types.MapValues(prop.PatternProperties, prop.AdditionalProperties), prop.AdditionalProperties.Closed(), path)
+	}
+	return &Schema{}
+}
+
+// object преобразует объект: объект-словарь без полей становится формой
+// values, объект с полями - формой properties
+func (e *exporter) object(props map[string]*types.Property, required []string, values *types.Property, closed bool, path string) *Schema {
+	if len(props) == 0 && values != nil {
+		return &Schema{Values: e.convert(values, walk.Join(path, "*"))}
+	}
+	if values != nil {
+		e.warn(path, "схема значений дополнительных полей не выражается вместе с properties, дополнительные поля разрешены без проверки")
+	}
+
+	isRequired := make(map[string]bool, len(required))
+	for _, key := range required {
+		isRequired[key] = true
+	}
+	s := &Schema{Properties: map[string]*Schema{}, AdditionalProperties: !closed}
+	for _, key := range sortedNames(props) {
+		child := e.convert(props[key], walk.Join(path, key))
+		if isRequired[key] {
+			s.Properties[key] = child
+			continue
+		}
+		if s.OptionalProperties == nil {
+			s.OptionalProperties = map[string]*Schema{}
+		}
+		s.OptionalProperties[key] = child
+	}
+	return s
+}
+
+// discriminated преобразует oneOf с дискриминатором - полем, которое есть в
+// каждом варианте со строковым enum из одного значения, - в форму
+// discriminator. Вариант null делает схему nullable
+func (e *exporter) discriminated(variants []*types.JSONSchema, path string) *Schema {
+	objects, nullable := withoutNullVariants(variants)
+	key := discriminator(objects)
+	if key == "" {
+		e.warn(path, "oneOf без дискриминатора заменен схемой любого значения")
+		return &Schema{}
+	}
+
+	s := &Schema{Discriminator: key, Mapping: make(map[string]*Schema, len(objects)), Nullable: nullable}
+	for i, variant := range objects {
+		variantPath := walk.Join(path, fmt.Sprintf("oneOf[%d]", i))
+		tag := variant.Properties[key].Enum[0].(string)
+		if _, ok := s.Mapping[tag]; ok {
+			e.warn(variantPath, "вариант с повторяющимся значением дискриминатора %q пропущен", tag)
+			continue
+		}
+		props := make(map[string]*types.Property, len(variant.Properties)-1)
+		for name, prop := range variant.Properties {
+			if name != key {
+				props[name] = prop
+			}
+		}
+		values := types.MapValues(variant.PatternProperties, variant.AdditionalProperties)
+		if len(props) == 0 && values != nil {
+			e.warn(variantPath, "схема значений объекта-словаря не выражается в варианте discriminator")
+			values = nil
+		}
+		mapped := e.object(props, variant.Required, values, variant.AdditionalProperties.Closed(), variantPath)
+		if variant.Description != "" {
+			mapped.Metadata = map[string]interface{}{"description": variant.Description}
+		}
+		s.Mapping[tag] = mapped
+	}
+	return s
+}
+
+// union преобразует anyOf: один тип вместе с null становится nullable
+// схемой этого типа, несколько типов - схемой любого значения
+func (e *exporter) union(variants []*types.JSONSchema, path string) *Schema {
+	rest, nullable := withoutNullVariants(variants)
+	if len(rest) != 1 {
+		e.warn(path, "anyOf из нескольких типов заменен схемой любого значения")
+		return &Schema{}
+	}
+	s := e.form(rootProperty(rest[0]), path)
+	s.Nullable = nullable
+	return s
+}
+
+// integerType выбирает целочисленный тип JTD по диапазону поля. Явные
+// minimum и maximum дают самый узкий тип, который их вмещает; наблюдаемый
+// диапазон или его отсутствие - int32 (uint32 с предупреждением для
+// неотрицательных значений сверх int32). JTD не имеет 64-битных целых,
+// поэтому значения вне int32 и uint32 описываются типом float64
+func (e *exporter) integerType(prop *types.Property, path string) string {
+	low, lowOK := bound(prop.Minimum)
+	high, highOK := bound(prop.Maximum)
+	if lowOK && highOK {
+		for _, name := range integerTypes {
+			if limits := integerRanges[name]; low >= float64(limits[0]) && high <= float64(limits[1]) {
+				return name
+			}
+		}
+	}
+
+	if prop.ObservedRange != nil {
+		if !lowOK {
+			low, lowOK = bound(prop.ObservedRange.Minimum)
+		}
+		if !highOK {
+			high, highOK = bound(prop.ObservedRange.Maximum)
+		}
+	}
+	switch {
+	case (!lowOK || low >= math.MinInt32) && (!highOK || high <= math.MaxInt32):
+		return TypeInt32
+	case lowOK && low >= 0 && (!highOK || high <= math.MaxUint32):
+		e.warn(path, "целые значения выходят за int32 и описаны типом uint32")
+		return TypeUint32
+	}
+	e.warn(path, "целые значения выходят за int32 и uint32 и описаны типом float64")
+	return TypeFloat64
+}
+
+// bound разбирает границу диапазона
+func bound(n json.Number) (float64, bool) {
+	if n == "" {
+		return 0, false
+	}
+	value, err := n.Float64()
+	return value, err == nil
+}
+
+// discriminator находит поле, которое есть в каждом варианте-объекте и
+// имеет в нем строковый enum из одного значения
+func discriminator(variants []*types.JSONSchema) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	for _, key := range sortedNames(variants[0].Properties) {
+		found := true
+		for _, variant := range variants {
+			prop := variant.Properties[key]
+			if variant.Type != string(types.TypeObject) || prop == nil || len(prop.Enum) != 1 {
+				found = false
+				break
+			}
+			if _, ok := prop.Enum[0].(string); !ok {
+				found = false
+				break
+			}
+		}
+		if found {
+			return key
+		}
+	}
+	return ""
+}
+
+// withoutNullVariants возвращает варианты без варианта null и сообщает,
+// был ли он
+func withoutNullVariants(variants []*types.JSONSchema) ([]*types.JSONSchema, bool) {
+	rest := make([]*types.JSONSchema, 0, len(variants))
+	nullable := false
+	for _, variant := range variants {
+		if variant == nil {
+			continue
+		}
+		if variant.Type == string(types.TypeNull) {
+			nullable = true
+			continue
+		}
+		rest = append(rest, variant)
+	}
+	return rest, nullable
+}
+
+// stringEnum возвращает значения enum без null, если все они строки
+func stringEnum(values []interface{}) ([]string, bool) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			result = append(result, v)
+		default:
+			return nil, false
+		}
+	}
+	return result, len(result) > 0
+}
+
+// rootProperty представляет корень схемы или вариант как Property
+func rootProperty(schema *types.JSONSchema) *types.Property {
+	return &types.Property{
+		Ref:         schema.Ref,
+		Type:        schema.Type,
+		Properties:  schema.Properties,
+		Items:       schema.Items,
+		Required:    schema.Required,
+		Enum:        schema.Enum,
+		Format:      schema.Format,
+		OneOf:       schema.OneOf,
+		AnyOf:       schema.AnyOf,
+		Description: schema.Description,
+
+		PatternProperties:    schema.PatternProperties,
+		AdditionalProperties: schema.AdditionalProperties,
+	}
+}
@@ -0,0 +1,48 @@
+package jtd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yanodincov/json-schema-detector/internal/golden"
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+)
+
+// TestGoldenExport сравнивает экспорт схем testdata/golden/<случай>.schema.json
+// с эталонами <случай>.jtd.golden.json: схема JTD и предупреждения о потерях
+func TestGoldenExport(t *testing.T) {
+	for _, input := range golden.Inputs(t, "testdata/golden/*.schema.json") {
+		name := strings.TrimSuffix(input, ".schema.json")
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Схема загружается, как в export jtd
+			result, err := analyzer.New().LoadSchemaBytes(data)
+			if err != nil {
+				t.Fatalf("LoadSchemaBytes: %v", err)
+			}
+			converted, warnings := FromSchema(result.Schema)
+			if warnings == nil {
+				warnings = []Warning{}
+			}
+			golden.AssertJSON(t, name+".jtd.golden.json", struct {
+				JTD      *Schema   `json:"jtd"`
+				Warnings []Warning `json:"warnings"`
+			}{converted, warnings})
+
+			// Эталон - корректная схема JTD
+			encoded, err := json.Marshal(converted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Parse(encoded); err != nil {
+				t.Errorf("экспорт не проходит проверку JTD: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package jtd
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// integerTypes - целочисленные типы JTD от самого узкого
+var integerTypes = []string{TypeInt8, TypeUint8, TypeInt16, TypeUint16, TypeInt32, TypeUint32}
+
+// integerRanges - диапазоны целочисленных типов JTD
+var integerRanges = map[string][2]int64{
+	TypeInt8:   {-1 << 7, 1<<7 - 1},
+	TypeUint8:  {0, 1<<8 - 1},
+	TypeInt16:  {-1 << 15, 1<<15 - 1},
+	TypeUint16: {0, 1<<16 - 1},
+	TypeInt32:  {-1 << 31, 1<<31 - 1},
+	TypeUint32: {0, 1<<32 - 1},
+}
+
+// ToSchema преобразует проверенную схему JTD в JSON Schema: целочисленные
+// типы становятся integer с minimum/maximum своего диапазона, timestamp -
+// строкой date-time, properties без additionalProperties - закрытым
+// объектом, values - объектом-словарем, discriminator - oneOf, в каждом
+// варианте которого поле дискриминатора имеет enum из одного значения,
+// definitions и ref - $defs и $ref. Из metadata сохраняется только description
+func ToSchema(s *Schema) *types.JSONSchema {
+	var schema *types.JSONSchema
+	if root := convert(s); root.Nullable {
+		// Корень схемы не бывает nullable
+		root.Nullable = false
+		schema = &types.JSONSchema{AnyOf: []*types.JSONSchema{variant(root), {Type: string(types.TypeNull)}}}
+	} else {
+		schema = variant(root)
+	}
+	schema.Schema = "http://json-schema.org/draft-07/schema#"
+
+	for _, name := range sortedNames(s.Definitions) {
+		if schema.Defs == nil {
+			schema.Defs = make(map[string]*types.Property, len(s.Definitions))
+		}
+		schema.Defs[name] = convert(s.Definitions[name])
+	}
+	return schema
+}
+
+// convert преобразует схему JTD в свойство
+func convert(s *Schema) *types.Property {
+	prop := form(s)
+	if description, ok := s.Metadata["description"].(string); ok {
+		prop.Description = description
+	}
+	return prop
+}
+
+// form преобразует форму схемы JTD
+func form(s *Schema) *types.Property {
+	switch {
+	case s.Ref != "":
+		ref := types.DefsRefPrefix + s.Ref
+		if s.Nullable {
+			return &types.Property{AnyOf: []*types.JSONSchema{{Ref: ref}, {Type: string(types.TypeNull)}}}
+		}
+		return &types.Property{Ref: ref}
+
+	case s.Type != "":
+		prop := &types.Property{Nullable: s.Nullable}
+		switch s.Type {
+		case TypeBoolean:
+			prop.Type = string(types.TypeBoolean)
+		case TypeString:
+			prop.Type = string(types.TypeString)
+		case TypeTimestamp:
+			prop.Type, prop.Format = string(types.TypeString), "date-time"
+		case TypeFloat32, TypeFloat64:
+			prop.Type = string(types.TypeNumber)
+		default:
+			limits := integerRanges[s.Type]
+			prop.Type = string(types.TypeInteger)
+			prop.Minimum = json.Number(strconv.FormatInt(limits[0], 10))
+			prop.Maximum = json.Number(strconv.FormatInt(limits[1], 10))
+		}
+		return prop
+
+	case s.Enum != nil:
+		prop := &types.Property{Type: string(types.TypeString), Nullable: s.Nullable}
+		for _, value := range s.Enum {
+			prop.Enum = append(prop.Enum, value)
+		}
+		return prop
+
+	case s.Elements != nil:
+		return &types.Property{Type: string(types.TypeArray), Items: convert(s.Elements), Nullable: s.Nullable}
+
+	case s.Properties != nil || s.OptionalProperties != nil:
+		prop := object(s)
+		prop.Nullable = s.Nullable
+		return prop
+
+	case s.Values != nil:
+		return &types.Property{
+			Type:                 string(types.TypeObject),
+			AdditionalProperties: &types.AdditionalProperties{Schema: convert(s.Values)},
+			Nullable:             s.Nullable,
+		}
+
+	case s.Discriminator != "":
+		prop := &types.Property{}
+		for _, tag := range sortedNames(s.Mapping) {
+			mapped := variant(object(s.Mapping[tag]))
+			mapped.Properties[s.Discriminator] = &types.Property{Type: string(types.TypeString), Enum: []interface{}{tag}}
+			mapped.Required = append(mapped.Required, s.Discriminator)
+			slices.Sort(mapped.Required)
+			if description, ok := s.Mapping[tag].Metadata["description"].(string); ok {
+				mapped.Description = description
+			}
+			prop.OneOf = append(prop.OneOf, mapped)
+		}
+		if s.Nullable {
+			prop.OneOf = append(prop.OneOf, &types.JSONSchema{Type: string(types.TypeNull)})
+		}
+		return prop
+	}
+	// Схема любого значения
+	return &types.Property{}
+}
+
+// object преобразует форму properties: обязательные поля попадают в
+// required, без additionalProperties объект закрыт
+func object(s *Schema) *types.Property {
+	prop := &types.Property{
+		Type:       string(types.TypeObject),
+		Properties: make(map[string]*types.Property, len(s.Properties)+len(s.OptionalProperties)),
+	}
+	for _, key := range sortedNames(s.Properties) {
+		prop.Properties[key] = convert(s.Properties[key])
+		prop.Required = append(prop.Required, key)
+	}
+	for _, key := range sortedNames(s.OptionalProperties) {
+		prop.Properties[key] = convert(s.OptionalProperties[key])
+	}
+	if !s.AdditionalProperties {
+		prop.AdditionalProperties = &types.AdditionalProperties{Allowed: false}
+	}
+	return prop
+}
+
+// variant представляет свойство как вариант oneOf/anyOf или корень схемы
+func variant(prop *types.Property) *types.JSONSchema {
+	return &types.JSONSchema{
+		Ref:         prop.Ref,
+		Type:        prop.Type,
+		Properties:  prop.Properties,
+		Items:       prop.Items,
+		Required:    prop.Required,
+		Enum:        prop.Enum,
+		Format:      prop.Format,
+		OneOf:       prop.OneOf,
+		AnyOf:       prop.AnyOf,
+		Description: prop.Description,
+
+		PatternProperties:    prop.PatternProperties,
+		AdditionalProperties: prop.AdditionalProperties,
+	}
+}
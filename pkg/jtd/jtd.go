@@ -0,0 +1,239 @@
+// Package jtd преобразует схемы между внутренней моделью JSON Schema и
+// JSON Type Definition (RFC 8927), которую используют генераторы кода.
+package jtd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// Типы значений JTD
+const (
+	TypeBoolean   = "boolean"
+	TypeString    = "string"
+	TypeTimestamp = "timestamp"
+	TypeFloat32   = "float32"
+	TypeFloat64   = "float64"
+	TypeInt8      = "int8"
+	TypeUint8     = "uint8"
+	TypeInt16     = "int16"
+	TypeUint16    = "uint16"
+	TypeInt32     = "int32"
+	TypeUint32    = "uint32"
+)
+
+// Schema - схема JSON Type Definition. Форма схемы определяется тем, какие
+// поля заданы: ref, type, enum, elements, properties/optionalProperties,
+// values, discriminator или ни одного (любое значение)
+type Schema struct {
+	Definitions map[string]*Schema     `json:"definitions,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Nullable    bool                   `json:"nullable,omitempty"`
+
+	Ref      string   `json:"ref,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+	Elements *Schema  `json:"elements,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	OptionalProperties   map[string]*Schema `json:"optionalProperties,omitempty"`
+	AdditionalProperties bool               `json:"additionalProperties,omitempty"`
+
+	Values *Schema `json:"values,omitempty"`
+
+	Discriminator string             `json:"discriminator,omitempty"`
+	Mapping       map[string]*Schema `json:"mapping,omitempty"`
+}
+
+// Warning описывает часть схемы, которую нельзя выразить в другом формате
+// без потерь
+type Warning struct {
+	// Path - путь узла в формате fieldmanager; пустой путь - корень схемы
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String возвращает предупреждение в виде "путь: сообщение"
+func (w Warning) String() string {
+	if w.Path == "" {
+		return "<root>: " + w.Message
+	}
+	return w.Path + ": " + w.Message
+}
+
+// MarshalJSON сохраняет пустой объект properties: без него объект без
+// обязательных и необязательных полей стал бы схемой любого значения
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if s.Properties != nil && len(s.Properties) == 0 && len(s.OptionalProperties) == 0 {
+		return json.Marshal(struct {
+			plain
+			Properties map[string]*Schema `json:"properties"`
+		}{plain(s), s.Properties})
+	}
+	return json.Marshal(plain(s))
+}
+
+// Parse разбирает схему JTD и проверяет ее по правилам RFC 8927: одна форма
+// на схему, определения только в корне, ссылки на существующие определения,
+// варианты discriminator - объекты без nullable и без поля дискриминатора
+func Parse(data []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var schema Schema
+	if err := dec.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга схемы JTD: %w", err)
+	}
+	if err := schema.check(&schema, "", true); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// form возвращает название формы схемы или ошибку, если форм несколько
+func (s *Schema) form() (string, error) {
+	var forms []string
+	if s.Ref != "" {
+		forms = append(forms, "ref")
+	}
+	if s.Type != "" {
+		forms = append(forms, "type")
+	}
+	if s.Enum != nil {
+		forms = append(forms, "enum")
+	}
+	if s.Elements != nil {
+		forms = append(forms, "elements")
+	}
+	if s.Properties != nil || s.OptionalProperties != nil {
+		forms = append(forms, "properties")
+	}
+	if s.Values != nil {
+		forms = append(forms, "values")
+	}
+	if s.Discriminator != "" || s.Mapping != nil {
+		forms = append(forms, "discriminator")
+	}
+	switch len(forms) {
+	case 0:
+		return "empty", nil
+	case 1:
+		return forms[0], nil
+	}
+	return "", fmt.Errorf("схема сочетает несколько форм: %s", strings.Join(forms, ", "))
+}
+
+// check проверяет схему s с путем path; root - корень с определениями
+func (s *Schema) check(root *Schema, path string, isRoot bool) error {
+	fail := func(format string, a ...interface{}) error {
+		return invalid(path, format, a...)
+	}
+
+	if s.Definitions != nil && !isRoot {
+		return fail("definitions допускаются только в корне схемы")
+	}
+	form, err := s.form()
+	if err != nil {
+		return fail("%v", err)
+	}
+	if s.AdditionalProperties && form != "properties" {
+		return fail("additionalProperties допускается только у схемы с properties")
+	}
+
+	switch form {
+	case "ref":
+		if root.Definitions[s.Ref] == nil {
+			return fail("ссылка на несуществующее определение %q", s.Ref)
+		}
+	case "type":
+		if !knownType(s.Type) {
+			return fail("неизвестный тип %q", s.Type)
+		}
+	case "enum":
+		if len(s.Enum) == 0 {
+			return fail("enum не может быть пустым")
+		}
+		seen := make(map[string]bool, len(s.Enum))
+		for _, value := range s.Enum {
+			if seen[value] {
+				return fail("повторяющееся значение enum %q", value)
+			}
+			seen[value] = true
+		}
+	case "elements":
+		if err := s.Elements.check(root, walk.Join(path, "0"), false); err != nil {
+			return err
+		}
+	case "properties":
+		for key, child := range s.Properties {
+			if _, ok := s.OptionalProperties[key]; ok {
+				return fail("поле %q одновременно обязательное и необязательное", key)
+			}
+			if err := child.check(root, walk.Join(path, key), false); err != nil {
+				return err
+			}
+		}
+		for key, child := range s.OptionalProperties {
+			if err := child.check(root, walk.Join(path, key), false); err != nil {
+				return err
+			}
+		}
+	case "values":
+		if err := s.Values.check(root, walk.Join(path, "*"), false); err != nil {
+			return err
+		}
+	case "discriminator":
+		if s.Discriminator == "" || s.Mapping == nil {
+			return fail("discriminator и mapping задаются вместе")
+		}
+		for tag, variant := range s.Mapping {
+			variantPath := walk.Join(path, tag)
+			if form, _ := variant.form(); form != "properties" || variant.Nullable {
+				return invalid(variantPath, "вариант mapping должен быть объектом properties без nullable")
+			}
+			_, required := variant.Properties[s.Discriminator]
+			_, optional := variant.OptionalProperties[s.Discriminator]
+			if required || optional {
+				return invalid(variantPath, "вариант не может описывать поле дискриминатора %q", s.Discriminator)
+			}
+			if err := variant.check(root, variantPath, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if isRoot {
+		for name, def := range s.Definitions {
+			if err := def.check(root, walk.Join("definitions", name), false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// knownType сообщает, что name - тип значения JTD
+func knownType(name string) bool {
+	switch name {
+	case TypeBoolean, TypeString, TypeTimestamp, TypeFloat32, TypeFloat64,
+		TypeInt8, TypeUint8, TypeInt16, TypeUint16, TypeInt32, TypeUint32:
+		return true
+	}
+	return false
+}
+
+// invalid возвращает ошибку проверки узла схемы по пути path
+func invalid(path, format string, a ...interface{}) error {
+	return fmt.Errorf("некорректная схема JTD: %s", Warning{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// sortedNames возвращает ключи словаря в отсортированном порядке
+func sortedNames[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,363 @@
+// Package kafkasample читает выборку сообщений топика Kafka как записи
+// NDJSON, чтобы схема потока событий строилась так же, как схема файла.
+package kafkasample
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Позиции, с которых читается топик
+const (
+	// StartLatest - последние сообщения, уже записанные в топик
+	StartLatest = "latest"
+	// StartEarliest - сообщения с начала топика
+	StartEarliest = "earliest"
+)
+
+// Механизмы аутентификации SASL
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// wireFormatHeader - длина заголовка Confluent Schema Registry: нулевой
+// байт и номер схемы
+const wireFormatHeader = 5
+
+// Config настраивает чтение топика
+type Config struct {
+	Brokers []string
+	Topic   string
+	// Count - сколько сообщений прочитать
+	Count int
+	// Start - StartLatest или StartEarliest
+	Start string
+	// Wait - сколько ждать следующего сообщения, прежде чем закончить
+	// выборку раньше Count
+	Wait time.Duration
+	// Timeout - таймаут подключения к брокерам
+	Timeout time.Duration
+
+	TLS bool
+	// SASL - механизм аутентификации; пустая строка - без аутентификации
+	SASL     string
+	User     string
+	Password string
+}
+
+// Stats описывает прочитанную выборку
+type Stats struct {
+	Topic      string `json:"topic"`
+	Partitions int    `json:"partitions"`
+	// Messages - прочитано сообщений, включая пропущенные
+	Messages int `json:"messages"`
+	// Records - сообщений JSON, переданных анализу
+	Records int `json:"records"`
+	// Tombstones - сообщений без значения (удаления в сжатых топиках)
+	Tombstones int `json:"tombstones"`
+	// Invalid - сообщений, значение которых не является JSON
+	Invalid int `json:"invalid"`
+}
+
+// ParseStart проверяет позицию чтения топика
+func ParseStart(value string) (string, error) {
+	switch value {
+	case StartLatest, StartEarliest:
+		return value, nil
+	}
+	return "", fmt.Errorf("неизвестная позиция чтения %q, ожидается %s или %s", value, StartLatest, StartEarliest)
+}
+
+// partition - раздел топика и диапазон его смещений для чтения
+type partition struct {
+	id int
+	// from - смещение первого сообщения, to - смещение после последнего;
+	// to < 0 - читать без ограничения
+	from, to int64
+}
+
+// Read читает до cfg.Count сообщений топика без группы потребителей, то
+// есть не сдвигая сохраненные смещения, и записывает в w значение каждого
+// сообщения JSON отдельной строкой. Сообщения без значения и не в формате
+// JSON пропускаются и учитываются в Stats; заголовок Confluent Schema
+// Registry перед JSON отбрасывается.
+//
+// StartLatest распределяет выборку между разделами поровну, насколько
+// хватает сообщений, и заканчивается на последнем записанном сообщении.
+// StartEarliest читает разделы с начала и ждет новых сообщений, если их
+// меньше Count. Выборка заканчивается раньше Count, если за cfg.Wait не
+// пришло ни одного сообщения
+func Read(ctx context.Context, cfg Config, w io.Writer) (*Stats, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("не указаны брокеры Kafka")
+	}
+	if cfg.Count <= 0 {
+		return nil, fmt.Errorf("число сообщений должно быть положительным")
+	}
+	if cfg.Wait <= 0 {
+		cfg.Wait = 10 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	dialer, err := newDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	partitions, err := plan(ctx, dialer, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{Topic: cfg.Topic, Partitions: len(partitions)}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	messages := make(chan kafka.Message)
+	errs := make(chan error, len(partitions))
+	var wg sync.WaitGroup
+	for _, p := range partitions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := consume(ctx, dialer, cfg, p, messages); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	idle := time.NewTimer(cfg.Wait)
+	defer idle.Stop()
+loop:
+	for stats.Messages < cfg.Count {
+		select {
+		case message := <-messages:
+			stats.Messages++
+			if err := write(w, message.Value, stats); err != nil {
+				return stats, err
+			}
+			idle.Reset(cfg.Wait)
+		case err := <-errs:
+			return stats, err
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		case <-idle.C:
+			break loop
+		case <-done:
+			break loop
+		}
+	}
+
+	cancel()
+	<-done
+	select {
+	case err := <-errs:
+		return stats, err
+	default:
+	}
+	return stats, nil
+}
+
+// write записывает значение сообщения строкой NDJSON
+func write(w io.Writer, value []byte, stats *Stats) error {
+	if len(value) == 0 {
+		stats.Tombstones++
+		return nil
+	}
+	if len(value) > wireFormatHeader && value[0] == 0 && !json.Valid(value) {
+		value = value[wireFormatHeader:]
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, value); err != nil {
+		stats.Invalid++
+		return nil
+	}
+	buf.WriteByte('\n')
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	stats.Records++
+	return nil
+}
+
+// consume читает раздел и передает сообщения в messages до конца
+// диапазона или отмены ctx
+func consume(ctx context.Context, dialer *kafka.Dialer, cfg Config, p partition, messages chan<- kafka.Message) error {
+	if p.to >= 0 && p.from >= p.to {
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   cfg.Brokers,
+		Topic:     cfg.Topic,
+		Partition: p.id,
+		Dialer:    dialer,
+		MaxWait:   time.Second,
+	})
+	defer reader.Close()
+	if err := reader.SetOffset(p.from); err != nil {
+		return fmt.Errorf("ошибка установки смещения раздела %d: %w", p.id, err)
+	}
+
+	for {
+		message, err := reader.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("ошибка чтения раздела %d: %w", p.id, err)
+		}
+		select {
+		case messages <- message:
+		case <-ctx.Done():
+			return nil
+		}
+		// В сжатых и транзакционных топиках последнего смещения может не
+		// быть, тогда раздел закончится по таймауту ожидания
+		if p.to >= 0 && message.Offset+1 >= p.to {
+			return nil
+		}
+	}
+}
+
+// plan получает разделы топика и выбирает диапазоны смещений для чтения
+func plan(ctx context.Context, dialer *kafka.Dialer, cfg Config) ([]partition, error) {
+	var lookupErr error
+	var found []kafka.Partition
+	for _, broker := range cfg.Brokers {
+		found, lookupErr = dialer.LookupPartitions(ctx, "tcp", broker, cfg.Topic)
+		if lookupErr == nil {
+			break
+		}
+	}
+	if lookupErr != nil {
+		return nil, fmt.Errorf("ошибка получения разделов топика %s: %w", cfg.Topic, lookupErr)
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("топик не найден: %s", cfg.Topic)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+
+	partitions := make([]partition, len(found))
+	available := make([]int64, len(found))
+	for i, p := range found {
+		first, last, err := offsets(ctx, dialer, p)
+		if err != nil {
+			return nil, err
+		}
+		partitions[i] = partition{id: p.ID, from: first, to: -1}
+		if cfg.Start == StartLatest {
+			partitions[i].to = last
+			available[i] = last - first
+		}
+	}
+	if cfg.Start != StartLatest {
+		return partitions, nil
+	}
+
+	for i, quota := range allocate(available, int64(cfg.Count)) {
+		partitions[i].from = partitions[i].to - quota
+	}
+	return partitions, nil
+}
+
+// offsets возвращает смещение первого сообщения раздела и смещение после
+// последнего
+func offsets(ctx context.Context, dialer *kafka.Dialer, p kafka.Partition) (int64, int64, error) {
+	address := fmt.Sprintf("%s:%d", p.Leader.Host, p.Leader.Port)
+	conn, err := dialer.DialLeader(ctx, "tcp", address, p.Topic, p.ID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("ошибка подключения к лидеру раздела %d: %w", p.ID, err)
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("ошибка чтения смещений раздела %d: %w", p.ID, err)
+	}
+	return first, last, nil
+}
+
+// allocate распределяет count сообщений между разделами поровну; раздел,
+// в котором сообщений меньше доли, отдает остаток другим
+func allocate(available []int64, count int64) []int64 {
+	quotas := make([]int64, len(available))
+	for count > 0 {
+		open := 0
+		for i := range available {
+			if quotas[i] < available[i] {
+				open++
+			}
+		}
+		if open == 0 {
+			break
+		}
+		share := max(count/int64(open), 1)
+		for i := range available {
+			if count == 0 {
+				break
+			}
+			take := min(share, available[i]-quotas[i], count)
+			quotas[i] += take
+			count -= take
+		}
+	}
+	return quotas
+}
+
+// newDialer настраивает подключение к брокерам
+func newDialer(cfg Config) (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{Timeout: cfg.Timeout, DualStack: true}
+	if cfg.TLS {
+		dialer.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	var mechanism sasl.Mechanism
+	switch strings.ToLower(cfg.SASL) {
+	case "":
+		return dialer, nil
+	case SASLPlain:
+		mechanism = plain.Mechanism{Username: cfg.User, Password: cfg.Password}
+	case SASLScramSHA256, SASLScramSHA512:
+		algorithm := scram.SHA256
+		if strings.EqualFold(cfg.SASL, SASLScramSHA512) {
+			algorithm = scram.SHA512
+		}
+		m, err := scram.Mechanism(algorithm, cfg.User, cfg.Password)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка настройки SASL: %w", err)
+		}
+		mechanism = m
+	default:
+		return nil, fmt.Errorf("неизвестный механизм SASL %q, ожидается %s, %s или %s", cfg.SASL, SASLPlain, SASLScramSHA256, SASLScramSHA512)
+	}
+	if cfg.User == "" {
+		return nil, errors.New("для аутентификации SASL укажите пользователя")
+	}
+	dialer.SASLMechanism = mechanism
+	return dialer, nil
+}
@@ -0,0 +1,131 @@
+// Package loginput извлекает JSON, встроенный в строки текстовых журналов,
+// чтобы полезная нагрузка структурированных логов анализировалась как
+// записи NDJSON.
+package loginput
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// utf8BOM - метка порядка байтов в начале файла
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Options настраивает извлечение JSON из строк
+type Options struct {
+	// Prefix - текст, после которого в строке начинается JSON (например,
+	// "payload="); пустая строка - первый сбалансированный объект {...}
+	Prefix string
+}
+
+// Stats описывает прочитанный журнал
+type Stats struct {
+	Lines int `json:"lines"`
+	// Records - строк, из которых извлечен JSON
+	Records int `json:"records"`
+}
+
+// Extract читает журнал построчно и записывает в w JSON каждой строки
+// отдельной строкой NDJSON. Без Options.Prefix из строки берется первый
+// сбалансированный участок {...}, который является корректным JSON; с
+// префиксом - значение JSON сразу после первого вхождения префикса
+// (пробелы между ними пропускаются). Строки без JSON пропускаются
+func Extract(r io.Reader, opts Options, w io.Writer) (*Stats, error) {
+	reader := bufio.NewReader(r)
+	if prefix, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		reader.Discard(len(utf8BOM))
+	}
+
+	stats := &Stats{}
+	var buf bytes.Buffer
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return stats, fmt.Errorf("ошибка чтения журнала: %w", readErr)
+		}
+		if len(line) > 0 {
+			stats.Lines++
+			if payload, ok := find(line, opts.Prefix); ok {
+				buf.Reset()
+				if err := json.Compact(&buf, payload); err != nil {
+					return stats, fmt.Errorf("строка %d: %w", stats.Lines, err)
+				}
+				buf.WriteByte('\n')
+				if _, err := w.Write(buf.Bytes()); err != nil {
+					return stats, err
+				}
+				stats.Records++
+			}
+		}
+		if readErr != nil {
+			return stats, nil
+		}
+	}
+}
+
+// find находит JSON в строке журнала
+func find(line []byte, prefix string) ([]byte, bool) {
+	if prefix != "" {
+		i := bytes.Index(line, []byte(prefix))
+		if i < 0 {
+			return nil, false
+		}
+		return leadingValue(line[i+len(prefix):])
+	}
+
+	for start := 0; start < len(line); start++ {
+		i := bytes.IndexByte(line[start:], '{')
+		if i < 0 {
+			return nil, false
+		}
+		start += i
+		if end, ok := balanced(line[start:]); ok && json.Valid(line[start:start+end]) {
+			return line[start : start+end], true
+		}
+	}
+	return nil, false
+}
+
+// leadingValue возвращает значение JSON в начале data; текст после него
+// игнорируется
+func leadingValue(data []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, false
+	}
+	return raw, true
+}
+
+// balanced возвращает длину участка data, начинающегося с { и
+// заканчивающегося парной }. Скобки внутри строк не учитываются
+func balanced(data []byte) (int, bool) {
+	depth, inString, escaped := 0, false, false
+	for i, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return i + 1, c == '}'
+			}
+		}
+	}
+	return 0, false
+}
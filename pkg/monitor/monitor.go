@@ -0,0 +1,265 @@
+// Package monitor периодически опрашивает HTTP эндпоинт и отслеживает изменения
+// структуры ответа.
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/compat"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// MaxResponseSize ограничивает размер читаемого ответа эндпоинта
+const MaxResponseSize = 32 << 20
+
+// SuccessClass - класс статуса успешных ответов, схема которых хранится в основном файле
+const SuccessClass = "2xx"
+
+// StatusClass возвращает класс HTTP статуса: 2xx, 4xx, 5xx
+func StatusClass(status int) string {
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// SchemaFileFor возвращает файл схемы для класса статуса: основной файл для 2xx,
+// иначе файл с классом перед расширением (users.schema.json → users.4xx.schema.json)
+func SchemaFileFor(schemaFile, class string) string {
+	if class == SuccessClass {
+		return schemaFile
+	}
+
+	suffix := ".schema.json"
+	if !strings.HasSuffix(schemaFile, suffix) {
+		suffix = filepath.Ext(schemaFile)
+	}
+	return strings.TrimSuffix(schemaFile, suffix) + "." + class + suffix
+}
+
+// Config настраивает монитор
+type Config struct {
+	URL        string
+	Method     string
+	Headers    map[string]string
+	SchemaFile string
+	Webhook    string
+	Timeout    time.Duration
+	// AlertLevel - минимальный уровень изменения, о котором отправляется оповещение
+	AlertLevel compat.BumpLevel
+}
+
+// Event описывает результат одной проверки эндпоинта
+type Event struct {
+	URL             string          `json:"url"`
+	Schema          string          `json:"schema"`
+	Time            time.Time       `json:"time"`
+	Status          int             `json:"status"`
+	StatusClass     string          `json:"status_class"`
+	Created         bool            `json:"created"`
+	Changed         bool            `json:"changed"`
+	PreviousVersion string          `json:"previous_version,omitempty"`
+	Version         string          `json:"version"`
+	Bump            string          `json:"bump"`
+	Changes         []compat.Change `json:"changes"`
+
+	level compat.BumpLevel
+}
+
+// Monitor опрашивает эндпоинт и обновляет схему его ответа
+type Monitor struct {
+	config   Config
+	client   *http.Client
+	analyzer *analyzer.Analyzer
+}
+
+// New создает монитор с указанной конфигурацией
+func New(config Config) *Monitor {
+	if config.Method == "" {
+		config.Method = http.MethodGet
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &Monitor{
+		config:   config,
+		client:   &http.Client{Timeout: config.Timeout},
+		analyzer: analyzer.New(),
+	}
+}
+
+// Check запрашивает эндпоинт, объединяет ответ со схемой его класса статуса
+// и сохраняет ее при изменениях. Тела успешных ответов (2xx) попадают в основную
+// схему, тела ошибок - в отдельные схемы 4xx/5xx, чтобы не смешивать их.
+// Если файла схемы еще нет, он создается по первому ответу.
+func (m *Monitor) Check(ctx context.Context) (*Event, error) {
+	body, status, err := m.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	class := StatusClass(status)
+	event := &Event{
+		URL:         m.config.URL,
+		Schema:      SchemaFileFor(m.config.SchemaFile, class),
+		Time:        time.Now(),
+		Status:      status,
+		StatusClass: class,
+		Changes:     make([]compat.Change, 0),
+	}
+
+	result, err := m.analyzer.AnalyzeBytes(body)
+	if err != nil {
+		if class != SuccessClass {
+			return nil, fmt.Errorf("эндпоинт вернул статус %d с телом не в формате JSON", status)
+		}
+		return nil, fmt.Errorf("ошибка анализа ответа: %w", err)
+	}
+
+	if err := m.update(event, result); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// update объединяет результат анализа со схемой события и сохраняет ее при изменениях
+func (m *Monitor) update(event *Event, result *types.AnalysisResult) error {
+	if _, err := os.Stat(event.Schema); os.IsNotExist(err) {
+		if err := m.analyzer.SaveSchema(result, event.Schema); err != nil {
+			return fmt.Errorf("ошибка сохранения схемы: %w", err)
+		}
+		event.Created = true
+		event.Version = result.Metadata.Version
+		event.Bump = compat.BumpNone.String()
+		return nil
+	}
+
+	existing, err := m.analyzer.LoadSchema(event.Schema)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки схемы: %w", err)
+	}
+	previous, err := existing.Schema.Clone()
+	if err != nil {
+		return fmt.Errorf("ошибка копирования схемы: %w", err)
+	}
+
+	merged, err := m.analyzer.MergeResults(existing, result)
+	if err != nil {
+		return fmt.Errorf("ошибка объединения схем: %w", err)
+	}
+
+	report, oldVersion, err := compat.StampVersion(previous, merged)
+	if err != nil {
+		return fmt.Errorf("ошибка определения версии схемы: %w", err)
+	}
+
+	event.PreviousVersion = oldVersion
+	event.Version = merged.Metadata.Version
+	event.Bump = report.Bump.String()
+	event.Changes = report.Changes
+	event.level = report.Bump
+
+	// Без изменений структуры файл не перезаписывается, чтобы не плодить коммиты
+	if len(report.Changes) == 0 {
+		return nil
+	}
+
+	if err := m.analyzer.SaveSchema(merged, event.Schema); err != nil {
+		return fmt.Errorf("ошибка сохранения схемы: %w", err)
+	}
+	event.Changed = true
+	return nil
+}
+
+// ShouldAlert сообщает, достигает ли изменение уровня оповещения
+func (m *Monitor) ShouldAlert(event *Event) bool {
+	return event.Changed && event.level >= m.config.AlertLevel
+}
+
+// webhookPayload - тело оповещения; поле text совместимо со Slack и Mattermost
+type webhookPayload struct {
+	Text string `json:"text"`
+	*Event
+}
+
+// Notify отправляет событие на webhook, если он настроен
+func (m *Monitor) Notify(ctx context.Context, event *Event) error {
+	if m.config.Webhook == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(webhookPayload{Text: Summary(event), Event: event})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации оповещения: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.Webhook, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка отправки webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook вернул статус %s", resp.Status)
+	}
+	return nil
+}
+
+// Summary возвращает краткое описание изменения для оповещения
+func Summary(event *Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Схема ответа %s (%s) изменилась: %s → %s (%s)", event.URL, event.StatusClass, event.PreviousVersion, event.Version, event.Bump)
+	for _, change := range event.Changes {
+		fmt.Fprintf(&b, "\n• %s: %s", change.Path, change.Kind)
+		if change.Details != "" {
+			fmt.Fprintf(&b, " (%s)", change.Details)
+		}
+	}
+	return b.String()
+}
+
+// fetch выполняет запрос к эндпоинту и возвращает тело успешного ответа
+func (m *Monitor) fetch(ctx context.Context) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, m.config.Method, m.config.URL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range m.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса %s: %w", m.config.URL, err)
+	}
+	defer resp.Body.Close()
+
+	// Тела ответов 1xx/3xx не описывают ни данные, ни ошибки эндпоинта
+	if class := StatusClass(resp.StatusCode); class != SuccessClass && resp.StatusCode < 400 {
+		return nil, resp.StatusCode, fmt.Errorf("эндпоинт вернул статус %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize+1))
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("ошибка чтения ответа: %w", err)
+	}
+	if len(body) > MaxResponseSize {
+		return nil, resp.StatusCode, fmt.Errorf("ответ превышает %d байт", MaxResponseSize)
+	}
+	return body, resp.StatusCode, nil
+}
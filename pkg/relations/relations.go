@@ -0,0 +1,316 @@
+// Package relations связывает схемы разных эндпоинтов объявленными связями
+// (orders.items.productId → products.id) и строит по ним обзор модели данных
+// в виде ER-диаграммы Mermaid или Graphviz DOT.
+package relations
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/fieldmanager"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// ExtensionKey - расширение схемы-источника, в котором хранятся ее связи
+const ExtensionKey = "x-relations"
+
+// Форматы экспорта обзора модели данных
+const (
+	FormatMermaid = "mermaid"
+	FormatDOT     = "dot"
+)
+
+// Relation описывает ссылку поля схемы на поле другой схемы
+type Relation struct {
+	// Field - путь поля схемы-источника в формате fieldmanager
+	Field string `json:"field"`
+	// Schema - имя схемы, на которую ссылается поле
+	Schema string `json:"schema"`
+	// Target - путь поля целевой схемы
+	Target string `json:"target"`
+}
+
+// String выводит связь в виде field → schema.target
+func (r Relation) String() string {
+	return fmt.Sprintf("%s → %s.%s", r.Field, r.Schema, r.Target)
+}
+
+// Load читает связи из расширения схемы
+func Load(schema *types.JSONSchema) ([]Relation, error) {
+	var relations []Relation
+	if _, err := types.DecodeExtension(schema.Extensions, ExtensionKey, &relations); err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", ExtensionKey, err)
+	}
+	return relations, nil
+}
+
+// Store записывает связи в расширение схемы; пустой список удаляет расширение
+func Store(schema *types.JSONSchema, relations []Relation) {
+	if len(relations) == 0 {
+		delete(schema.Extensions, ExtensionKey)
+		return
+	}
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].Field != relations[j].Field {
+			return relations[i].Field < relations[j].Field
+		}
+		return relations[i].String() < relations[j].String()
+	})
+	if schema.Extensions == nil {
+		schema.Extensions = make(map[string]interface{})
+	}
+	schema.Extensions[ExtensionKey] = relations
+}
+
+// Add добавляет связь, если ее еще нет, и сообщает, была ли она добавлена
+func Add(relations []Relation, relation Relation) ([]Relation, bool) {
+	for _, r := range relations {
+		if r == relation {
+			return relations, false
+		}
+	}
+	return append(relations, relation), true
+}
+
+// Remove удаляет связь и сообщает, была ли она найдена
+func Remove(relations []Relation, relation Relation) ([]Relation, bool) {
+	for i, r := range relations {
+		if r == relation {
+			return append(relations[:i], relations[i+1:]...), true
+		}
+	}
+	return relations, false
+}
+
+// ResolveField находит поле схемы по пути. Кроме точного пути принимается
+// сокращенный: без индексов массивов и сегментов словарей ("items.productId"
+// вместо "data.0.items.0.productId") и без префикса записи ответа. Если
+// сокращенному пути соответствует несколько полей, возвращается ошибка
+func ResolveField(schema *types.JSONSchema, path string) (string, *types.Property, error) {
+	index := fieldmanager.New().NewIndex(schema)
+	if field, err := index.Lookup(path); err == nil {
+		return path, field, nil
+	}
+
+	wanted := shortPath(path)
+	record := shortPath(recordPrefix(schema))
+	var matches []string
+	for _, candidate := range index.Fields() {
+		short := shortPath(candidate)
+		if short == wanted || record != "" && short == record+"."+wanted {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil, fmt.Errorf("поле %s не найдено", path)
+	case 1:
+		field, err := index.Lookup(matches[0])
+		return matches[0], field, err
+	default:
+		return "", nil, fmt.Errorf("путь %s неоднозначен: %s", path, strings.Join(matches, ", "))
+	}
+}
+
+// shortPath убирает из пути индексы массивов и сегменты значений словарей
+func shortPath(path string) string {
+	segments := strings.Split(path, ".")
+	kept := segments[:0]
+	for _, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil || segment == "*" || segment == "" {
+			continue
+		}
+		kept = append(kept, segment)
+	}
+	return strings.Join(kept, ".")
+}
+
+// recordPrefix возвращает путь записи ответа: "0" для корневого массива,
+// "data.0" для конверта с массивом data, "" для одиночного объекта
+func recordPrefix(schema *types.JSONSchema) string {
+	if schema.Items != nil {
+		return "0"
+	}
+	if data, ok := schema.Properties["data"]; ok && data != nil && data.Items != nil && data.Items.Type == "object" {
+		return "data.0"
+	}
+	return ""
+}
+
+// Attribute - поле записи в обзоре модели данных
+type Attribute struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Key - "PK" для полей, на которые ссылаются, "FK" для ссылающихся
+	Key string `json:"key,omitempty"`
+}
+
+// Entity - схема в обзоре модели данных
+type Entity struct {
+	Name       string      `json:"name"`
+	Attributes []Attribute `json:"attributes"`
+}
+
+// Link - связь между схемами в обзоре модели данных
+type Link struct {
+	From     string `json:"from"`
+	Field    string `json:"field"`
+	To       string `json:"to"`
+	Target   string `json:"target"`
+	Resolved bool   `json:"resolved"`
+}
+
+// Model - обзор модели данных: схемы и связи между ними
+type Model struct {
+	Entities []Entity `json:"entities"`
+	Links    []Link   `json:"links"`
+}
+
+// BuildModel строит обзор модели данных по схемам (имя → схема). Связи на
+// схемы, которых нет в наборе, попадают в модель с Resolved = false
+func BuildModel(schemas map[string]*types.JSONSchema) (*Model, error) {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	model := &Model{Entities: make([]Entity, 0, len(names)), Links: make([]Link, 0)}
+	keys := make(map[string]map[string]string)
+	mark := func(schema, field, key string) {
+		if keys[schema] == nil {
+			keys[schema] = make(map[string]string)
+		}
+		if keys[schema][field] == "" || key == "PK" {
+			keys[schema][field] = key
+		}
+	}
+
+	for _, name := range names {
+		relations, err := Load(schemas[name])
+		if err != nil {
+			return nil, fmt.Errorf("схема %s: %w", name, err)
+		}
+		for _, r := range relations {
+			_, resolved := schemas[r.Schema]
+			model.Links = append(model.Links, Link{From: name, Field: r.Field, To: r.Schema, Target: r.Target, Resolved: resolved})
+			mark(name, r.Field, "FK")
+			mark(r.Schema, r.Target, "PK")
+		}
+	}
+
+	for _, name := range names {
+		model.Entities = append(model.Entities, Entity{Name: name, Attributes: attributes(schemas[name], keys[name])})
+	}
+	return model, nil
+}
+
+// attributes перечисляет поля записи схемы с типами; пути отсчитываются
+// от записи ответа
+func attributes(schema *types.JSONSchema, keys map[string]string) []Attribute {
+	prefix := recordPrefix(schema)
+	attrs := make([]Attribute, 0)
+	_ = walk.WalkWithOptions(schema, walk.Options{SkipItems: true}, func(path string, p *types.Property) error {
+		name := path
+		if prefix != "" {
+			if !strings.HasPrefix(path, prefix+".") {
+				return nil
+			}
+			name = strings.TrimPrefix(path, prefix+".")
+		}
+		kind := p.Type
+		if kind == "" && p.Ref != "" {
+			kind = "ref"
+		}
+		attrs = append(attrs, Attribute{Name: name, Type: kind, Key: keys[path]})
+		return nil
+	})
+	return attrs
+}
+
+// unsafeName - символы, недопустимые в идентификаторах Mermaid и DOT
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// identifier превращает имя схемы или поля в идентификатор диаграммы
+func identifier(name string) string {
+	id := strings.Trim(unsafeName.ReplaceAllString(name, "_"), "_")
+	if id == "" {
+		return "_"
+	}
+	return id
+}
+
+// Render выводит модель в указанном формате
+func (m *Model) Render(format string) (string, error) {
+	switch format {
+	case FormatMermaid:
+		return m.Mermaid(), nil
+	case FormatDOT:
+		return m.DOT(), nil
+	default:
+		return "", fmt.Errorf("неизвестный формат: %s. Доступные: %s, %s", format, FormatMermaid, FormatDOT)
+	}
+}
+
+// Mermaid выводит модель как erDiagram Mermaid
+func (m *Model) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, entity := range m.Entities {
+		fmt.Fprintf(&b, "    %s {\n", identifier(entity.Name))
+		for _, attr := range entity.Attributes {
+			fmt.Fprintf(&b, "        %s %s", identifier(attr.Type), identifier(attr.Name))
+			if attr.Key != "" {
+				fmt.Fprintf(&b, " %s", attr.Key)
+			}
+			if identifier(attr.Name) != attr.Name {
+				fmt.Fprintf(&b, " %q", attr.Name)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, link := range m.Links {
+		fmt.Fprintf(&b, "    %s ||--o{ %s : %q\n", identifier(link.To), identifier(link.From), link.Field+" → "+link.Target)
+	}
+	return b.String()
+}
+
+// DOT выводит модель как граф Graphviz
+func (m *Model) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph schemas {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [shape=record, fontname=\"Helvetica\"];\n")
+	for _, entity := range m.Entities {
+		rows := make([]string, 0, len(entity.Attributes))
+		for _, attr := range entity.Attributes {
+			row := attr.Name + ": " + attr.Type
+			if attr.Key != "" {
+				row += " (" + attr.Key + ")"
+			}
+			rows = append(rows, dotEscape(row)+`\l`)
+		}
+		fmt.Fprintf(&b, "    %s [label=\"{%s|%s}\"];\n", identifier(entity.Name), dotEscape(entity.Name), strings.Join(rows, ""))
+	}
+	for _, link := range m.Links {
+		style := ""
+		if !link.Resolved {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "    %s -> %s [label=\"%s\"%s];\n", identifier(link.From), identifier(link.To), dotEscape(link.Field+" → "+link.Target), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotEscape экранирует символы, имеющие смысл в метках record-узлов DOT
+func dotEscape(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `{`, `\{`, `}`, `\}`, `|`, `\|`, `<`, `\<`, `>`, `\>`)
+	return replacer.Replace(s)
+}
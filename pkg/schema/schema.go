@@ -0,0 +1,252 @@
+// Package schema предоставляет builder для программного построения схем.
+//
+// Пример:
+//
+//	user := schema.NewObject().
+//		Prop("id", schema.String().Format("uuid")).
+//		Prop("name", schema.String().Description("Имя пользователя")).
+//		Optional("age", schema.Integer()).
+//		Prop("tags", schema.Array(schema.String()))
+//
+//	result := user.Result()
+//	err := analyzer.New().SaveSchema(result, "user.schema.json")
+package schema
+
+import (
+	"time"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// DraftURL - версия JSON Schema, используемая для построенных схем
+const DraftURL = "http://json-schema.org/draft-07/schema#"
+
+// Builder строит узел схемы
+type Builder struct {
+	prop *types.Property
+}
+
+// New создает builder для указанного типа
+func New(t types.JSONType) *Builder {
+	b := &Builder{prop: &types.Property{Type: string(t)}}
+	if t == types.TypeObject {
+		b.prop.Properties = make(map[string]*types.Property)
+	}
+	return b
+}
+
+// NewObject создает builder объекта
+func NewObject() *Builder {
+	return New(types.TypeObject)
+}
+
+// String создает builder строки
+func String() *Builder {
+	return New(types.TypeString)
+}
+
+// Number создает builder числа
+func Number() *Builder {
+	return New(types.TypeNumber)
+}
+
+// Integer создает builder целого числа
+func Integer() *Builder {
+	return New("integer")
+}
+
+// Boolean создает builder логического значения
+func Boolean() *Builder {
+	return New(types.TypeBoolean)
+}
+
+// Null создает builder значения null
+func Null() *Builder {
+	return New(types.TypeNull)
+}
+
+// Array создает builder массива с указанным типом элементов
+func Array(items *Builder) *Builder {
+	return New(types.TypeArray).Items(items)
+}
+
+// FromProperty создает builder поверх существующего свойства для его доработки
+func FromProperty(prop *types.Property) *Builder {
+	if prop == nil {
+		prop = &types.Property{}
+	}
+	return &Builder{prop: prop}
+}
+
+// Prop добавляет обязательное свойство объекта
+func (b *Builder) Prop(name string, value *Builder) *Builder {
+	b.setProperty(name, value)
+	b.Required(name)
+	return b
+}
+
+// Optional добавляет необязательное свойство объекта
+func (b *Builder) Optional(name string, value *Builder) *Builder {
+	b.setProperty(name, value)
+	return b
+}
+
+// Required отмечает свойства объекта как обязательные
+func (b *Builder) Required(names ...string) *Builder {
+	for _, name := range names {
+		if !contains(b.prop.Required, name) {
+			b.prop.Required = append(b.prop.Required, name)
+		}
+	}
+	return b
+}
+
+// Items задает тип элементов массива
+func (b *Builder) Items(items *Builder) *Builder {
+	if items != nil {
+		b.prop.Items = items.prop
+	}
+	return b
+}
+
+// Description задает описание
+func (b *Builder) Description(description string) *Builder {
+	b.prop.Description = description
+	return b
+}
+
+// Default задает значение по умолчанию
+func (b *Builder) Default(value interface{}) *Builder {
+	b.prop.Default = value
+	return b
+}
+
+// Nullable разрешает null наряду с основным типом
+func (b *Builder) Nullable() *Builder {
+	b.prop.Nullable = true
+	return b
+}
+
+// PreserveDefault защищает значение по умолчанию от перезатирания при обновлениях
+func (b *Builder) PreserveDefault() *Builder {
+	b.prop.PreserveDefault = true
+	return b
+}
+
+// Enum задает список допустимых значений
+func (b *Builder) Enum(values ...interface{}) *Builder {
+	b.prop.Enum = values
+	return b
+}
+
+// Format задает формат строки (uuid, date-time, email, ...)
+func (b *Builder) Format(format string) *Builder {
+	b.prop.Format = format
+	return b
+}
+
+// OneOf задает полиморфные варианты; базовый тип снимается, как и в update-field polymorph
+func (b *Builder) OneOf(variants ...*Builder) *Builder {
+	b.prop.OneOf = toSchemas(variants)
+	b.prop.Type = ""
+	return b
+}
+
+// AnyOf задает варианты anyOf
+func (b *Builder) AnyOf(variants ...*Builder) *Builder {
+	b.prop.AnyOf = toSchemas(variants)
+	b.prop.Type = ""
+	return b
+}
+
+// Extension задает произвольное расширение x-*
+func (b *Builder) Extension(key string, value interface{}) *Builder {
+	if b.prop.Extensions == nil {
+		b.prop.Extensions = make(map[string]interface{})
+	}
+	b.prop.Extensions[key] = value
+	return b
+}
+
+// Property возвращает построенное свойство
+func (b *Builder) Property() *types.Property {
+	return b.prop
+}
+
+// Schema возвращает корневую JSON Schema
+func (b *Builder) Schema() *types.JSONSchema {
+	schema := toSchema(b.prop)
+	schema.Schema = DraftURL
+	return schema
+}
+
+// Result возвращает результат, пригодный для SaveSchema и MergeResults
+func (b *Builder) Result() *types.AnalysisResult {
+	now := time.Now()
+	return &types.AnalysisResult{
+		Schema: b.Schema(),
+		Metadata: &types.AnalysisMetadata{
+			GeneratedAt: now,
+			UpdatedAt:   now,
+			Version:     "1.0.0",
+		},
+		Statistics: &types.AnalysisStatistics{
+			FieldFrequency:   make(map[string]int),
+			TypeDistribution: make(map[string]int),
+			EnumCandidates:   make(map[string][]interface{}),
+		},
+	}
+}
+
+// setProperty добавляет свойство в объект
+func (b *Builder) setProperty(name string, value *Builder) {
+	if b.prop.Properties == nil {
+		b.prop.Properties = make(map[string]*types.Property)
+	}
+	if value == nil {
+		value = New("")
+	}
+	b.prop.Properties[name] = value.prop
+}
+
+// toSchemas конвертирует builders в варианты oneOf/anyOf
+func toSchemas(builders []*Builder) []*types.JSONSchema {
+	schemas := make([]*types.JSONSchema, 0, len(builders))
+	for _, builder := range builders {
+		if builder != nil {
+			schemas = append(schemas, toSchema(builder.prop))
+		}
+	}
+	return schemas
+}
+
+// toSchema конвертирует Property в JSONSchema
+func toSchema(prop *types.Property) *types.JSONSchema {
+	return &types.JSONSchema{
+		Type:        prop.Type,
+		Properties:  prop.Properties,
+		Items:       prop.Items,
+		Required:    prop.Required,
+		Enum:        prop.Enum,
+		Format:      prop.Format,
+		OneOf:       prop.OneOf,
+		AnyOf:       prop.AnyOf,
+		Title:       prop.Title,
+		Description: prop.Description,
+		MinItems:    prop.MinItems,
+		MaxItems:    prop.MaxItems,
+		UniqueItems: prop.UniqueItems,
+		Default:     prop.Default,
+		Extensions:  prop.Extensions,
+	}
+}
+
+// contains проверяет наличие строки в списке
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/fileutil"
+)
+
+// FileSuffix - окончание имени файла отсоединенной подписи
+const FileSuffix = ".sig"
+
+// algorithm - маркер алгоритма Ed25519 в закодированных ключах и подписях
+const algorithm = "Ed"
+
+// keyIDSize - размер идентификатора ключа в байтах
+const keyIDSize = 8
+
+// PublicKey представляет открытый ключ для проверки подписей
+type PublicKey struct {
+	ID  [keyIDSize]byte
+	Key ed25519.PublicKey
+}
+
+// PrivateKey представляет закрытый ключ для подписи схем
+type PrivateKey struct {
+	ID  [keyIDSize]byte
+	Key ed25519.PrivateKey
+}
+
+// GenerateKey создает новую пару ключей Ed25519
+func GenerateKey() (*PublicKey, *PrivateKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка генерации ключа: %w", err)
+	}
+
+	id := keyID(pub)
+	return &PublicKey{ID: id, Key: pub}, &PrivateKey{ID: id, Key: priv}, nil
+}
+
+// Public возвращает открытый ключ, соответствующий закрытому
+func (k *PrivateKey) Public() *PublicKey {
+	return &PublicKey{ID: k.ID, Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+// KeyID возвращает идентификатор ключа в шестнадцатеричном виде
+func (k *PublicKey) KeyID() string {
+	return fmt.Sprintf("%X", k.ID[:])
+}
+
+// Sign подписывает данные и возвращает подпись в текстовом формате
+func (k *PrivateKey) Sign(data []byte) []byte {
+	sig := ed25519.Sign(k.Key, data)
+	return encode("подпись json-schema-detector, ключ "+k.Public().KeyID(), k.ID, sig)
+}
+
+// Verify проверяет подпись данных в текстовом формате
+func (k *PublicKey) Verify(data, sig []byte) error {
+	id, raw, err := decode(sig, ed25519.SignatureSize)
+	if err != nil {
+		return fmt.Errorf("некорректный файл подписи: %w", err)
+	}
+	if id != k.ID {
+		return fmt.Errorf("подпись создана другим ключом: %X, ожидался %s", id[:], k.KeyID())
+	}
+	if !ed25519.Verify(k.Key, data, raw) {
+		return fmt.Errorf("подпись не соответствует содержимому")
+	}
+	return nil
+}
+
+// MarshalText кодирует открытый ключ в текстовый формат
+func (k *PublicKey) MarshalText() ([]byte, error) {
+	return encode("открытый ключ json-schema-detector "+k.KeyID(), k.ID, k.Key), nil
+}
+
+// MarshalText кодирует закрытый ключ в текстовый формат
+func (k *PrivateKey) MarshalText() ([]byte, error) {
+	return encode("закрытый ключ json-schema-detector "+k.Public().KeyID(), k.ID, k.Key), nil
+}
+
+// ParsePublicKey разбирает открытый ключ из текстового формата
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	id, raw, err := decode(data, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный открытый ключ: %w", err)
+	}
+	key := ed25519.PublicKey(raw)
+	if err := checkKeyID(id, key); err != nil {
+		return nil, fmt.Errorf("некорректный открытый ключ: %w", err)
+	}
+	return &PublicKey{ID: id, Key: key}, nil
+}
+
+// ParsePrivateKey разбирает закрытый ключ из текстового формата. Открытая
+// часть ключа должна соответствовать его seed, а идентификатор - открытой
+// части, иначе подписи с этим ключом не пройдут проверку
+func ParsePrivateKey(data []byte) (*PrivateKey, error) {
+	id, raw, err := decode(data, ed25519.PrivateKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный закрытый ключ: %w", err)
+	}
+	key := ed25519.PrivateKey(raw)
+	if !bytes.Equal(ed25519.NewKeyFromSeed(key.Seed()), key) {
+		return nil, fmt.Errorf("некорректный закрытый ключ: открытая часть не соответствует ключу")
+	}
+	if err := checkKeyID(id, key.Public().(ed25519.PublicKey)); err != nil {
+		return nil, fmt.Errorf("некорректный закрытый ключ: %w", err)
+	}
+	return &PrivateKey{ID: id, Key: key}, nil
+}
+
+// LoadPublicKey читает открытый ключ из файла
+func LoadPublicKey(path string) (*PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения открытого ключа: %w", err)
+	}
+	return ParsePublicKey(data)
+}
+
+// LoadPrivateKey читает закрытый ключ из файла
+func LoadPrivateKey(path string) (*PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения закрытого ключа: %w", err)
+	}
+	return ParsePrivateKey(data)
+}
+
+// Path возвращает путь к файлу подписи для указанного файла
+func Path(file string) string {
+	return file + FileSuffix
+}
+
+// SignFile подписывает файл и записывает подпись рядом с ним. Переводы строк
+// CRLF подписываются как LF, чтобы подпись не зависела от core.autocrlf в git
+func SignFile(key *PrivateKey, file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+
+	path := Path(file)
+	if err := fileutil.WriteFile(path, key.Sign(fileutil.NormalizeNewlines(data)), 0644); err != nil {
+		return "", fmt.Errorf("ошибка записи подписи: %w", err)
+	}
+	return path, nil
+}
+
+// VerifyFile проверяет файл по отсоединенной подписи
+func VerifyFile(key *PublicKey, file, sigFile string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	sig, err := os.ReadFile(sigFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения подписи: %w", err)
+	}
+	return key.Verify(fileutil.NormalizeNewlines(data), sig)
+}
+
+// keyID вычисляет идентификатор ключа по открытому ключу
+func keyID(pub ed25519.PublicKey) [keyIDSize]byte {
+	var id [keyIDSize]byte
+	sum := sha256.Sum256(pub)
+	copy(id[:], sum[:keyIDSize])
+	return id
+}
+
+// checkKeyID сверяет идентификатор из закодированного ключа с вычисленным
+// по открытому ключу
+func checkKeyID(id [keyIDSize]byte, pub ed25519.PublicKey) error {
+	if want := keyID(pub); id != want {
+		return fmt.Errorf("идентификатор %X не соответствует ключу, ожидался %X", id[:], want[:])
+	}
+	return nil
+}
+
+// encode собирает текстовое представление: строка комментария и base64 с маркером алгоритма,
+// идентификатором ключа и данными, как в minisign
+func encode(comment string, id [keyIDSize]byte, payload []byte) []byte {
+	raw := make([]byte, 0, len(algorithm)+keyIDSize+len(payload))
+	raw = append(raw, algorithm...)
+	raw = append(raw, id[:]...)
+	raw = append(raw, payload...)
+
+	var buf bytes.Buffer
+	buf.WriteString("untrusted comment: " + comment + "\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(raw) + "\n")
+	return buf.Bytes()
+}
+
+// decode разбирает текстовое представление и проверяет размер данных
+func decode(data []byte, size int) ([keyIDSize]byte, []byte, error) {
+	var id [keyIDSize]byte
+
+	var encoded string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		encoded = line
+		break
+	}
+	if encoded == "" {
+		return id, nil, fmt.Errorf("данные не найдены")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return id, nil, err
+	}
+	if len(raw) != len(algorithm)+keyIDSize+size || string(raw[:len(algorithm)]) != algorithm {
+		return id, nil, fmt.Errorf("неподдерживаемый формат")
+	}
+
+	copy(id[:], raw[len(algorithm):len(algorithm)+keyIDSize])
+	return id, raw[len(algorithm)+keyIDSize:], nil
+}
@@ -0,0 +1,55 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyRoundTrip(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privText, _ := priv.MarshalText()
+	pubText, _ := pub.MarshalText()
+
+	parsedPriv, err := ParsePrivateKey(privText)
+	if err != nil {
+		t.Fatalf("ParsePrivateKey: %v", err)
+	}
+	parsedPub, err := ParsePublicKey(pubText)
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	data := []byte(`{"type": "object"}`)
+	if err := parsedPub.Verify(data, parsedPriv.Sign(data)); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestParseKeyRejectsWrongID(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ключ с чужим идентификатором подписывал бы подписи, которые не
+	// проходят проверку открытым ключом с этим идентификатором
+	if _, err := ParsePrivateKey(encode("закрытый ключ", other.ID, priv.Key)); err == nil || !strings.Contains(err.Error(), "идентификатор") {
+		t.Errorf("ParsePrivateKey с чужим идентификатором: error = %v", err)
+	}
+	if _, err := ParsePublicKey(encode("открытый ключ", other.ID, pub.Key)); err == nil || !strings.Contains(err.Error(), "идентификатор") {
+		t.Errorf("ParsePublicKey с чужим идентификатором: error = %v", err)
+	}
+
+	// Открытая часть закрытого ключа от другой пары
+	mixed := append(append(ed25519.PrivateKey{}, priv.Key.Seed()...), other.Key...)
+	if _, err := ParsePrivateKey(encode("закрытый ключ", other.ID, mixed)); err == nil || !strings.Contains(err.Error(), "открытая часть") {
+		t.Errorf("ParsePrivateKey с чужой открытой частью: error = %v", err)
+	}
+}
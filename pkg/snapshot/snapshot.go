@@ -0,0 +1,249 @@
+// Package snapshot хранит датированные снимки схем и строит по ним тренды.
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yanodincov/json-schema-detector/pkg/analyzer"
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+	"github.com/yanodincov/json-schema-detector/pkg/walk"
+)
+
+// TimeLayout - формат времени в имени файла снимка
+const TimeLayout = "20060102T150405Z"
+
+// fileSuffix - окончание имени файла снимка
+const fileSuffix = ".schema.json"
+
+// Snapshot описывает один сохраненный снимок схемы
+type Snapshot struct {
+	Time time.Time `json:"time"`
+	File string    `json:"file"`
+}
+
+// Store управляет снимками в директории: <dir>/<name>/<время>.schema.json
+type Store struct {
+	Dir string
+}
+
+// NewStore создает хранилище снимков в указанной директории
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Save сохраняет результат анализа как снимок на указанный момент времени
+func (s *Store) Save(name string, result *types.AnalysisResult, at time.Time) (*Snapshot, error) {
+	dir := filepath.Join(s.Dir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("ошибка создания директории снимков: %w", err)
+	}
+
+	at = at.UTC().Truncate(time.Second)
+	file := filepath.Join(dir, at.Format(TimeLayout)+fileSuffix)
+	if err := analyzer.New().SaveSchema(result, file); err != nil {
+		return nil, err
+	}
+	return &Snapshot{Time: at, File: file}, nil
+}
+
+// List возвращает снимки схемы в хронологическом порядке
+func (s *Store) List(name string) ([]Snapshot, error) {
+	matches, err := filepath.Glob(filepath.Join(s.Dir, name, "*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(matches))
+	for _, file := range matches {
+		stamp := strings.TrimSuffix(filepath.Base(file), fileSuffix)
+		at, err := time.Parse(TimeLayout, stamp)
+		if err != nil {
+			continue // Посторонние файлы в директории снимков пропускаем
+		}
+		snapshots = append(snapshots, Snapshot{Time: at, File: file})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// Names возвращает имена схем, для которых есть снимки
+func (s *Store) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка чтения директории снимков: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// FieldTrend описывает присутствие поля в последовательности снимков
+type FieldTrend struct {
+	Path      string    `json:"path"`
+	Presence  []bool    `json:"presence"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Transition описывает изменения между двумя соседними снимками
+type Transition struct {
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	AddedFields   []string            `json:"added_fields,omitempty"`
+	RemovedFields []string            `json:"removed_fields,omitempty"`
+	EnumAdded     map[string][]string `json:"enum_added,omitempty"`
+	EnumRemoved   map[string][]string `json:"enum_removed,omitempty"`
+}
+
+// Trend содержит эволюцию схемы по снимкам
+type Trend struct {
+	Snapshots   []Snapshot   `json:"snapshots"`
+	Fields      []FieldTrend `json:"fields"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// state содержит поля и значения enum одного снимка
+type state struct {
+	fields map[string]bool
+	enums  map[string]map[string]bool
+}
+
+// BuildTrend загружает снимки и вычисляет присутствие полей и изменения enum
+func BuildTrend(snapshots []Snapshot) (*Trend, error) {
+	trend := &Trend{
+		Snapshots:   snapshots,
+		Fields:      make([]FieldTrend, 0),
+		Transitions: make([]Transition, 0),
+	}
+
+	states := make([]*state, len(snapshots))
+	allFields := make(map[string]bool)
+	for i, snap := range snapshots {
+		result, err := analyzer.New().LoadSchema(snap.File)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки снимка %s: %w", snap.File, err)
+		}
+		st, err := collect(result.Schema)
+		if err != nil {
+			return nil, err
+		}
+		states[i] = st
+		for path := range st.fields {
+			allFields[path] = true
+		}
+	}
+
+	paths := sortedKeys(allFields)
+	for _, path := range paths {
+		field := FieldTrend{Path: path, Presence: make([]bool, len(snapshots))}
+		for i, st := range states {
+			if !st.fields[path] {
+				continue
+			}
+			field.Presence[i] = true
+			if field.FirstSeen.IsZero() {
+				field.FirstSeen = snapshots[i].Time
+			}
+			field.LastSeen = snapshots[i].Time
+		}
+		trend.Fields = append(trend.Fields, field)
+	}
+
+	for i := 1; i < len(states); i++ {
+		prev, curr := states[i-1], states[i]
+		transition := Transition{From: snapshots[i-1].Time, To: snapshots[i].Time}
+
+		for _, path := range paths {
+			switch {
+			case curr.fields[path] && !prev.fields[path]:
+				transition.AddedFields = append(transition.AddedFields, path)
+			case prev.fields[path] && !curr.fields[path]:
+				transition.RemovedFields = append(transition.RemovedFields, path)
+			}
+
+			if !prev.fields[path] || !curr.fields[path] {
+				continue
+			}
+			if added := difference(curr.enums[path], prev.enums[path]); len(added) > 0 {
+				if transition.EnumAdded == nil {
+					transition.EnumAdded = make(map[string][]string)
+				}
+				transition.EnumAdded[path] = added
+			}
+			if removed := difference(prev.enums[path], curr.enums[path]); len(removed) > 0 {
+				if transition.EnumRemoved == nil {
+					transition.EnumRemoved = make(map[string][]string)
+				}
+				transition.EnumRemoved[path] = removed
+			}
+		}
+
+		trend.Transitions = append(trend.Transitions, transition)
+	}
+
+	return trend, nil
+}
+
+// Empty сообщает, что между снимками не было изменений
+func (t *Transition) Empty() bool {
+	return len(t.AddedFields) == 0 && len(t.RemovedFields) == 0 && len(t.EnumAdded) == 0 && len(t.EnumRemoved) == 0
+}
+
+// collect собирает пути полей и значения enum схемы
+func collect(schema *types.JSONSchema) (*state, error) {
+	st := &state{
+		fields: make(map[string]bool),
+		enums:  make(map[string]map[string]bool),
+	}
+
+	err := walk.WalkWithOptions(schema, walk.Options{SkipItems: true}, func(path string, p *types.Property) error {
+		st.fields[path] = true
+		if len(p.Enum) > 0 {
+			values := make(map[string]bool, len(p.Enum))
+			for _, value := range p.Enum {
+				values[fmt.Sprintf("%v", value)] = true
+			}
+			st.enums[path] = values
+		}
+		return nil
+	})
+	return st, err
+}
+
+// difference возвращает отсортированные значения из a, которых нет в b
+func difference(a, b map[string]bool) []string {
+	var diff []string
+	for value := range a {
+		if !b[value] {
+			diff = append(diff, value)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// sortedKeys возвращает отсортированные ключи множества
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
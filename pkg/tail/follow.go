@@ -0,0 +1,200 @@
+// Package tail следит за растущим файлом NDJSON и проверяет новые записи
+// по схеме по мере их появления: легкий контроль контракта во время работы
+// сервиса без прокси перед ним.
+package tail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// utf8BOM - метка порядка байтов в начале файла
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// FollowOptions настраивает Follow
+type FollowOptions struct {
+	// FromStart - прочитать записи, уже записанные в файл; по умолчанию
+	// чтение начинается с конца файла
+	FromStart bool
+	// Poll - как часто проверять файл на новые строки
+	Poll time.Duration
+}
+
+// Follow читает строки файла path по мере их дописывания и передает
+// каждую непустую строку без перевода строки в handle, пока не будет
+// отменен ctx или handle не вернет ошибку. Недописанная последняя строка
+// ждет перевода строки. Если файл усечен, чтение начинается с начала, а
+// если файл заменен новым (ротация журнала), старый файл дочитывается
+// вместе с недописанной последней строкой и чтение продолжается с начала
+// нового. Пока файла нет - при запуске или после ротации, - Follow ждет его
+// появления; файл, появившийся после запуска, читается с начала
+func Follow(ctx context.Context, path string, opts FollowOptions, handle func(line []byte) error) error {
+	if opts.Poll <= 0 {
+		opts.Poll = time.Second
+	}
+
+	ticker := time.NewTicker(opts.Poll)
+	defer ticker.Stop()
+
+	f, err := waitFollowed(ctx, path, opts.FromStart, ticker.C)
+	if err != nil || f == nil {
+		return err
+	}
+	defer func() { f.file.Close() }()
+
+	for {
+		if err := f.drain(handle); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		next, err := f.reopen(path)
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			continue
+		}
+		// Ротация: дочитываем старый файл и переходим к новому. В старый
+		// файл больше не пишут, поэтому строка без перевода строки в его
+		// конце тоже передается в handle
+		if err := f.drain(handle); err == nil {
+			err = f.flush(handle)
+		}
+		if err != nil {
+			next.file.Close()
+			return err
+		}
+		f.file.Close()
+		f = next
+	}
+}
+
+// followed - открытый файл и позиция чтения в нем
+type followed struct {
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	pending []byte
+}
+
+// waitFollowed открывает файл path, дожидаясь его появления с каждым тиком
+// tick; nil без ошибки - ctx отменен раньше. Файл, которого не было при
+// первой попытке, читается с начала
+func waitFollowed(ctx context.Context, path string, fromStart bool, tick <-chan time.Time) (*followed, error) {
+	for {
+		f, err := openFollowed(path, fromStart)
+		if !errors.Is(err, os.ErrNotExist) {
+			return f, err
+		}
+		fromStart = true
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-tick:
+		}
+	}
+}
+
+// openFollowed открывает файл с начала или с конца
+func openFollowed(path string, fromStart bool) (*followed, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+	f := &followed{file: file, reader: bufio.NewReader(file)}
+	if !fromStart {
+		if f.offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("ошибка перехода в конец файла: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// drain передает в handle все полностью дописанные строки. Если файл стал
+// короче прочитанного, он читается заново с начала
+func (f *followed) drain(handle func(line []byte) error) error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	if info.Size() < f.offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("ошибка перехода в начало файла: %w", err)
+		}
+		f.reader.Reset(f.file)
+		f.offset, f.pending = 0, nil
+	}
+
+	for {
+		chunk, err := f.reader.ReadBytes('\n')
+		f.offset += int64(len(chunk))
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return fmt.Errorf("ошибка чтения файла: %w", err)
+			}
+			f.pending = append(f.pending, chunk...)
+			return nil
+		}
+
+		line := chunk
+		if len(f.pending) > 0 {
+			line = append(f.pending, chunk...)
+			f.pending = nil
+		}
+		line = bytes.TrimSpace(bytes.TrimPrefix(line, utf8BOM))
+		if len(line) == 0 {
+			continue
+		}
+		if err := handle(line); err != nil {
+			return err
+		}
+	}
+}
+
+// flush передает в handle недописанную последнюю строку файла
+func (f *followed) flush(handle func(line []byte) error) error {
+	line := bytes.TrimSpace(bytes.TrimPrefix(f.pending, utf8BOM))
+	f.pending = nil
+	if len(line) == 0 {
+		return nil
+	}
+	return handle(line)
+}
+
+// reopen возвращает новый файл по пути path, если прежний заменен, или
+// nil, если файл тот же или нового еще нет
+func (f *followed) reopen(path string) (*followed, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	current, err := f.file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	if os.SameFile(info, current) {
+		return nil, nil
+	}
+	next, err := openFollowed(path, true)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return next, err
+}
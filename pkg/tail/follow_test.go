@@ -0,0 +1,101 @@
+package tail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// follow запускает Follow в фоне и возвращает функцию, которая ждет want
+// строк, останавливает Follow и возвращает прочитанное
+func follow(t *testing.T, path string) func(want int) []string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, FollowOptions{Poll: 5 * time.Millisecond}, func(line []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, string(line))
+			return nil
+		})
+	}()
+
+	return func(want int) []string {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			n := len(lines)
+			mu.Unlock()
+			if n >= want {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("Follow: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(lines)
+	}
+}
+
+func TestFollowWaitsForFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	stop := follow(t, path)
+
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("{\"a\":1}\n{\"a\":2}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stop(2)
+	want := []string{`{"a":1}`, `{"a":2}`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("строки %q, ожидались %q", got, want)
+	}
+}
+
+func TestFollowRotationFlushesPartialLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.ndjson")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stop := follow(t, path)
+
+	time.Sleep(20 * time.Millisecond)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString("{\"a\":1}\n{\"a\":2}"); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	if err := os.Rename(path, filepath.Join(dir, "events.ndjson.1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{\"a\":3}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stop(3)
+	want := []string{`{"a":1}`, `{"a":2}`, `{"a":3}`}
+	if !slices.Equal(got, want) {
+		t.Fatalf("строки %q, ожидались %q", got, want)
+	}
+}
@@ -0,0 +1,283 @@
+package tail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/yanodincov/json-schema-detector/pkg/validator"
+)
+
+// alertExamples - сколько записей с ошибками приводится в одном оповещении
+const alertExamples = 5
+
+// Config настраивает проверку файла
+type Config struct {
+	DataFile   string
+	SchemaFile string
+	Follow     FollowOptions
+	Strict     bool
+	// Webhook - URL для оповещений о записях с ошибками; пустая строка -
+	// без оповещений
+	Webhook string
+	// AlertInterval - не чаще какого интервала отправляются оповещения;
+	// записи с ошибками за интервал собираются в одно оповещение
+	AlertInterval time.Duration
+	// Timeout - таймаут отправки оповещения
+	Timeout time.Duration
+	// Invalid, если задан, получает записи с ошибками и строки не в формате
+	// JSON строками JSON validator.InvalidRecord
+	Invalid io.Writer
+}
+
+// Event описывает запись с ошибками или строку, которая не является JSON
+// (InvalidRecord.Malformed)
+type Event struct {
+	Time time.Time `json:"time"`
+	validator.InvalidRecord
+}
+
+// Counters - значения счетчиков проверки
+type Counters struct {
+	Records   int64 `json:"records"`
+	Invalid   int64 `json:"invalid"`
+	Malformed int64 `json:"malformed"`
+	Alerts    int64 `json:"alerts"`
+}
+
+// Alert - оповещение о записях с ошибками за интервал
+type Alert struct {
+	File   string `json:"file"`
+	Schema string `json:"schema"`
+	// Invalid - записей с ошибками и строк не в формате JSON с прошлого
+	// оповещения; Events содержит первые из них
+	Invalid int     `json:"invalid"`
+	Events  []Event `json:"events"`
+}
+
+// Tail проверяет новые записи файла NDJSON по схеме
+type Tail struct {
+	config  Config
+	checker *validator.RecordChecker
+	client  *http.Client
+
+	records, invalid, malformed, alerts atomic.Int64
+}
+
+// New загружает схему и готовит проверку
+func New(config Config) (*Tail, error) {
+	if config.AlertInterval <= 0 {
+		config.AlertInterval = time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	checker, err := validator.New(config.Strict).NewRecordChecker(config.SchemaFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Tail{config: config, checker: checker, client: &http.Client{Timeout: config.Timeout}}, nil
+}
+
+// Counters возвращает текущие значения счетчиков
+func (t *Tail) Counters() Counters {
+	return Counters{
+		Records:   t.records.Load(),
+		Invalid:   t.invalid.Load(),
+		Malformed: t.malformed.Load(),
+		Alerts:    t.alerts.Load(),
+	}
+}
+
+// Run следит за файлом, пока не будет отменен ctx, и передает в onEvent
+// каждую запись с ошибками, а в onAlert - результат отправки каждого
+// оповещения. Строки не в формате JSON не прерывают проверку, а
+// учитываются как Malformed. Оповещения, накопленные к остановке,
+// отправляются перед возвратом
+func (t *Tail) Run(ctx context.Context, onEvent func(*Event), onAlert func(*Alert, error)) error {
+	events := make(chan Event, 64)
+	alerted := make(chan struct{})
+	go func() {
+		defer close(alerted)
+		t.alert(context.WithoutCancel(ctx), events, onAlert)
+	}()
+	defer func() {
+		close(events)
+		<-alerted
+	}()
+
+	number := 0
+	return Follow(ctx, t.config.DataFile, t.config.Follow, func(line []byte) error {
+		number++
+		t.records.Add(1)
+
+		event := &Event{Time: time.Now()}
+		result, err := t.checker.Check(line)
+		switch {
+		case err != nil:
+			t.malformed.Add(1)
+			event.InvalidRecord = validator.NewMalformedRecord(number, line, err)
+		case !result.Valid:
+			t.invalid.Add(1)
+			event.InvalidRecord = validator.NewInvalidRecord(number, line, result)
+		default:
+			return nil
+		}
+
+		if t.config.Invalid != nil {
+			data, err := json.Marshal(event.InvalidRecord)
+			if err != nil {
+				return fmt.Errorf("ошибка сериализации записи %d: %w", number, err)
+			}
+			if _, err := t.config.Invalid.Write(append(data, '\n')); err != nil {
+				return fmt.Errorf("ошибка записи записей с ошибками: %w", err)
+			}
+		}
+
+		onEvent(event)
+		if t.config.Webhook != "" {
+			events <- *event
+		}
+		return nil
+	})
+}
+
+// alert собирает записи с ошибками в оповещения: первое после затишья
+// отправляется сразу, последующие - не чаще AlertInterval
+func (t *Tail) alert(ctx context.Context, events <-chan Event, onAlert func(*Alert, error)) {
+	var pending *Alert
+	var last time.Time
+	var timer *time.Timer
+	var fire <-chan time.Time
+
+	flush := func() {
+		if pending == nil {
+			return
+		}
+		err := t.notify(ctx, pending)
+		if err == nil {
+			t.alerts.Add(1)
+		}
+		onAlert(pending, err)
+		pending, last, fire = nil, time.Now(), nil
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				flush()
+				return
+			}
+			if pending == nil {
+				pending = &Alert{File: t.config.DataFile, Schema: t.config.SchemaFile, Events: make([]Event, 0, alertExamples)}
+			}
+			pending.Invalid++
+			if len(pending.Events) < alertExamples {
+				pending.Events = append(pending.Events, event)
+			}
+			if fire != nil {
+				continue
+			}
+			if wait := t.config.AlertInterval - time.Since(last); wait > 0 {
+				timer = time.NewTimer(wait)
+				fire = timer.C
+				continue
+			}
+			flush()
+		case <-fire:
+			flush()
+		}
+	}
+}
+
+// webhookPayload - тело оповещения; поле text совместимо со Slack и Mattermost
+type webhookPayload struct {
+	Text string `json:"text"`
+	*Alert
+}
+
+// notify отправляет оповещение на webhook
+func (t *Tail) notify(ctx context.Context, alert *Alert) error {
+	data, err := json.Marshal(webhookPayload{Text: Summary(alert), Alert: alert})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации оповещения: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.Webhook, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка отправки webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook вернул статус %s", resp.Status)
+	}
+	return nil
+}
+
+// Summary возвращает краткое описание оповещения
+func Summary(alert *Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Записи %s не соответствуют схеме %s: %d", alert.File, alert.Schema, alert.Invalid)
+	for _, event := range alert.Events {
+		fmt.Fprintf(&b, "\n• запись %d: %s", event.Record, Describe(&event))
+	}
+	if rest := alert.Invalid - len(alert.Events); rest > 0 {
+		fmt.Fprintf(&b, "\n• и еще %d", rest)
+	}
+	return b.String()
+}
+
+// Describe возвращает первую ошибку записи и число остальных
+func Describe(event *Event) string {
+	if event.Malformed || len(event.Errors) == 0 {
+		return "не JSON"
+	}
+	first := event.Errors[0]
+	text := first.Description
+	if first.Field != "" && first.Field != "(root)" {
+		text = first.Field + ": " + text
+	}
+	if len(event.Errors) > 1 {
+		text += fmt.Sprintf(" (и еще ошибок: %d)", len(event.Errors)-1)
+	}
+	return text
+}
+
+// WriteMetrics записывает счетчики в текстовом формате Prometheus
+func (t *Tail) WriteMetrics(w io.Writer) error {
+	counters := t.Counters()
+	file := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(t.config.DataFile)
+	metrics := []struct {
+		name, help string
+		value      int64
+	}{
+		{"jsd_tail_records_total", "Проверено записей", counters.Records},
+		{"jsd_tail_invalid_records_total", "Записей, не соответствующих схеме", counters.Invalid},
+		{"jsd_tail_malformed_records_total", "Строк не в формате JSON", counters.Malformed},
+		{"jsd_tail_alerts_total", "Отправлено оповещений", counters.Alerts},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s{file=\"%s\"} %d\n", m.name, m.help, m.name, m.name, file, m.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
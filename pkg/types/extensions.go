@@ -0,0 +1,431 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ключи расширений, в которых хранятся служебные данные анализа
+const (
+	ExtensionAnalysisMeta  = "x-analysis-meta"
+	ExtensionAnalysisStats = "x-analysis-stats"
+)
+
+// knownExtensionFields содержит x-* ключи, которые уже представлены полями структур
+var knownExtensionFields = map[string]bool{
+	"x-preserve-default": true,
+	"x-default-conflict": true,
+	"x-observed-range":   true,
+	"x-confidence":       true,
+	"x-pending":          true,
+	"x-truncated":        true,
+}
+
+type jsonSchemaAlias JSONSchema
+
+type propertyAlias Property
+
+// schemaJSON переопределяет properties схемы, чтобы сохранять порядок полей
+// PropertyOrder; $schema, $ref и type вынесены сюда, чтобы остаться перед
+// properties
+type schemaJSON struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Properties *orderedProperties `json:"properties,omitempty"`
+	jsonSchemaAlias
+}
+
+// MarshalJSON сериализует схему вместе с расширениями x-*
+func (s JSONSchema) MarshalJSON() ([]byte, error) {
+	value := schemaJSON{Schema: s.Schema, Ref: s.Ref, Type: s.Type, jsonSchemaAlias: jsonSchemaAlias(s)}
+	if len(s.Properties) > 0 {
+		value.Properties = &orderedProperties{props: s.Properties, order: s.PropertyOrder}
+	}
+	return marshalWithExtensions(value, s.Extensions)
+}
+
+// UnmarshalJSON десериализует схему и собирает расширения x-*
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	var value schemaJSON
+	if err := Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	extensions, err := unmarshalExtensions(data)
+	if err != nil {
+		return err
+	}
+
+	*s = JSONSchema(value.jsonSchemaAlias)
+	s.Schema, s.Ref, s.Type = value.Schema, value.Ref, value.Type
+	if value.Properties != nil {
+		s.Properties, s.PropertyOrder = value.Properties.props, value.Properties.order
+	}
+	s.Extensions = extensions
+	return nil
+}
+
+// propertyJSON переопределяет type свойства, который может быть строкой или
+// списком типов, items, который может быть схемой или списком схем позиций
+// кортежа, и properties, порядок полей которого задает PropertyOrder.
+// Кортеж записывается в draft-07: items - позиции, additionalItems -
+// остальные элементы; prefixItems только читается из схем, сохраненных
+// прежними версиями
+type propertyJSON struct {
+	Type            interface{}        `json:"type,omitempty"`
+	Items           *itemsJSON         `json:"items,omitempty"`
+	AdditionalItems *itemsJSON         `json:"additionalItems,omitempty"`
+	PrefixItems     []*Property        `json:"prefixItems,omitempty"`
+	Properties      *orderedProperties `json:"properties,omitempty"`
+	propertyAlias
+}
+
+// MarshalJSON сериализует свойство вместе с расширениями x-*
+func (p Property) MarshalJSON() ([]byte, error) {
+	value := propertyJSON{propertyAlias: propertyAlias(p)}
+	if p.Type != "" {
+		value.Type = p.Type
+	}
+	switch {
+	case len(p.PrefixItems) > 0:
+		value.Items = &itemsJSON{tuple: p.PrefixItems}
+		if p.Items != nil || p.ClosedItems {
+			value.AdditionalItems = &itemsJSON{schema: p.Items}
+		}
+	case p.Items != nil || p.ClosedItems:
+		value.Items = &itemsJSON{schema: p.Items}
+	}
+	if len(p.Properties) > 0 {
+		value.Properties = &orderedProperties{props: p.Properties, order: p.PropertyOrder}
+	}
+	if p.Nullable && p.Type != "" && p.Type != string(TypeNull) {
+		value.Type = []string{p.Type, string(TypeNull)}
+		// Для nullable enum значение null должно входить в список допустимых
+		if len(p.Enum) > 0 && !containsNull(p.Enum) {
+			value.Enum = append(append([]interface{}{}, p.Enum...), nil)
+		}
+	}
+	return marshalWithExtensions(value, p.Extensions)
+}
+
+// UnmarshalJSON десериализует свойство и собирает расширения x-*
+func (p *Property) UnmarshalJSON(data []byte) error {
+	var value propertyJSON
+	if err := Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	extensions, err := unmarshalExtensions(data)
+	if err != nil {
+		return err
+	}
+
+	*p = Property(value.propertyAlias)
+	if err := p.setType(value.Type); err != nil {
+		return err
+	}
+	switch {
+	case value.Items != nil && value.Items.tuple != nil:
+		p.PrefixItems = value.Items.tuple
+		if value.AdditionalItems != nil {
+			p.Items, p.ClosedItems = value.AdditionalItems.schema, value.AdditionalItems.closed
+		}
+	case len(value.PrefixItems) > 0:
+		// Запись 2020-12 прежних версий: items - остальные элементы
+		p.PrefixItems = value.PrefixItems
+		if value.Items != nil {
+			p.Items, p.ClosedItems = value.Items.schema, value.Items.closed
+		}
+	case value.Items != nil:
+		p.Items, p.ClosedItems = value.Items.schema, value.Items.closed
+	}
+	if value.Properties != nil {
+		p.Properties, p.PropertyOrder = value.Properties.props, value.Properties.order
+	}
+	if p.Nullable && len(p.Enum) > 0 {
+		p.Enum = withoutNull(p.Enum)
+	}
+	p.Extensions = extensions
+	return nil
+}
+
+// setType разбирает значение ключевого слова type: строку или список из
+// одного типа и "null"
+func (p *Property) setType(value interface{}) error {
+	switch t := value.(type) {
+	case nil:
+		p.Type = ""
+	case string:
+		p.Type = t
+	case []interface{}:
+		var types []string
+		nullable := false
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("некорректный тип в списке type: %v", item)
+			}
+			if name == string(TypeNull) {
+				nullable = true
+				continue
+			}
+			types = append(types, name)
+		}
+		switch {
+		case len(types) == 0 && nullable:
+			p.Type = string(TypeNull)
+		case len(types) == 1:
+			p.Type = types[0]
+			p.Nullable = nullable
+		default:
+			return fmt.Errorf("неподдерживаемый список типов: %v", t)
+		}
+	default:
+		return fmt.Errorf("некорректное значение type: %v", value)
+	}
+	return nil
+}
+
+// itemsJSON представляет ключевые слова items и additionalItems: схему
+// элементов, список схем позиций кортежа или логическое значение; false
+// закрывает кортеж
+type itemsJSON struct {
+	schema *Property
+	tuple  []*Property
+	closed bool
+}
+
+// MarshalJSON сериализует items как схему, список схем или false
+func (i itemsJSON) MarshalJSON() ([]byte, error) {
+	switch {
+	case i.tuple != nil:
+		return json.Marshal(i.tuple)
+	case i.schema != nil:
+		return json.Marshal(i.schema)
+	}
+	return json.Marshal(false)
+}
+
+// UnmarshalJSON читает items в виде схемы, списка схем или true/false
+func (i *itemsJSON) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		*i = itemsJSON{closed: !allowed}
+		return nil
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		tuple := make([]*Property, 0)
+		if err := Unmarshal(data, &tuple); err != nil {
+			return err
+		}
+		*i = itemsJSON{tuple: tuple}
+		return nil
+	}
+
+	schema := &Property{}
+	if err := Unmarshal(data, schema); err != nil {
+		return err
+	}
+	*i = itemsJSON{schema: schema}
+	return nil
+}
+
+// orderedProperties представляет ключевое слово properties с порядком полей
+type orderedProperties struct {
+	props map[string]*Property
+	order []string
+}
+
+// MarshalJSON сериализует поля в порядке order, остальные - по алфавиту
+func (o orderedProperties) MarshalJSON() ([]byte, error) {
+	if len(o.order) == 0 {
+		return json.Marshal(o.props)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range OrderedKeys(o.props, o.order) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := json.Marshal(o.props[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON читает поля и запоминает их порядок в документе
+func (o *orderedProperties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	switch {
+	case err != nil:
+		return err
+	case tok == nil:
+		return nil
+	case tok != json.Delim('{'):
+		return fmt.Errorf("properties должно быть объектом")
+	}
+
+	o.props = make(map[string]*Property)
+	for dec.More() {
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		var prop *Property
+		if err := dec.Decode(&prop); err != nil {
+			return err
+		}
+		if _, repeated := o.props[key]; !repeated {
+			o.order = append(o.order, key)
+		}
+		o.props[key] = prop
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// OrderedKeys возвращает имена полей props: сначала в порядке order, затем
+// не вошедшие в него - по алфавиту
+func OrderedKeys(props map[string]*Property, order []string) []string {
+	keys := make([]string, 0, len(props))
+	listed := make(map[string]bool, len(order))
+	for _, key := range order {
+		if _, ok := props[key]; ok && !listed[key] {
+			listed[key] = true
+			keys = append(keys, key)
+		}
+	}
+	rest := len(keys)
+	for key := range props {
+		if !listed[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys[rest:])
+	return keys
+}
+
+// containsNull сообщает, что среди значений есть null
+func containsNull(values []interface{}) bool {
+	for _, value := range values {
+		if value == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutNull возвращает значения без null
+func withoutNull(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if value != nil {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// marshalWithExtensions дописывает расширения в конец JSON объекта, сохраняя порядок полей структуры
+func marshalWithExtensions(value interface{}, extensions map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(extensions) == 0 {
+		return data, nil
+	}
+
+	keys := make([]string, 0, len(extensions))
+	for key := range extensions {
+		if knownExtensionFields[key] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	empty := len(bytes.TrimSpace(data[1:len(data)-1])) == 0
+	for _, key := range keys {
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := json.Marshal(extensions[key])
+		if err != nil {
+			return nil, err
+		}
+		if !empty {
+			buf.WriteByte(',')
+		}
+		empty = false
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// unmarshalExtensions извлекает x-* ключи из JSON объекта
+func unmarshalExtensions(data []byte) (map[string]interface{}, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var extensions map[string]interface{}
+	for key, value := range raw {
+		if !strings.HasPrefix(key, "x-") || knownExtensionFields[key] {
+			continue
+		}
+
+		var decoded interface{}
+		if err := Unmarshal(value, &decoded); err != nil {
+			return nil, err
+		}
+		if extensions == nil {
+			extensions = make(map[string]interface{})
+		}
+		extensions[key] = decoded
+	}
+
+	return extensions, nil
+}
+
+// DecodeExtension преобразует значение расширения в указанную структуру
+func DecodeExtension(extensions map[string]interface{}, key string, target interface{}) (bool, error) {
+	value, exists := extensions[key]
+	if !exists || value == nil {
+		return false, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
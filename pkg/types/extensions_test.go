@@ -0,0 +1,58 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTupleDraft07(t *testing.T) {
+	tuple := Property{
+		Type:        "array",
+		PrefixItems: []*Property{{Type: "string"}, {Type: "integer"}},
+		ClosedItems: true,
+	}
+	data, err := json.Marshal(tuple)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"array","items":[{"type":"string"},{"type":"integer"}],"additionalItems":false}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	var decoded Property
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.PrefixItems) != 2 || !decoded.ClosedItems || decoded.Items != nil {
+		t.Errorf("Unmarshal = %+v, want закрытый кортеж из двух позиций", decoded)
+	}
+}
+
+func TestTupleLegacyPrefixItems(t *testing.T) {
+	tests := []struct {
+		input  string
+		closed bool
+		rest   string
+	}{
+		{`{"type":"array","items":false,"prefixItems":[{"type":"string"}]}`, true, ""},
+		{`{"type":"array","items":{"type":"boolean"},"prefixItems":[{"type":"string"}]}`, false, "boolean"},
+		{`{"type":"array","prefixItems":[{"type":"string"}]}`, false, ""},
+	}
+	for _, tt := range tests {
+		var decoded Property
+		if err := json.Unmarshal([]byte(tt.input), &decoded); err != nil {
+			t.Fatalf("%s: %v", tt.input, err)
+		}
+		if len(decoded.PrefixItems) != 1 || decoded.ClosedItems != tt.closed {
+			t.Errorf("%s: prefixItems %d, closed %v", tt.input, len(decoded.PrefixItems), decoded.ClosedItems)
+		}
+		rest := ""
+		if decoded.Items != nil {
+			rest = decoded.Items.Type
+		}
+		if rest != tt.rest {
+			t.Errorf("%s: additionalItems = %q, want %q", tt.input, rest, tt.rest)
+		}
+	}
+}
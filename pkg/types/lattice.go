@@ -0,0 +1,54 @@
+package types
+
+// Решетка типов JSON, по которой объединяются выборки и сравниваются версии
+// схемы. Снизу вверх:
+//
+//	null          - отсутствие значения; объединение с типом T дает nullable T
+//	integer       - частный случай number
+//	number, string, boolean, object, array - конкретные типы
+//	union         - несовместимые конкретные типы (конфликт, oneOf/anyOf)
+//	any ("")      - тип не ограничен
+//
+// Пустой тип узла схемы означает "любое значение" (поле без наблюдаемых
+// значений, узел с вариантами) и совместим с любым типом.
+
+// IsSubtype сообщает, что любое значение типа sub допустимо для типа super:
+// типы совпадают, integer входит в number, любой тип входит в any
+func IsSubtype(sub, super string) bool {
+	switch {
+	case sub == super, super == "":
+		return true
+	case sub == string(TypeInteger) && super == string(TypeNumber):
+		return true
+	}
+	return false
+}
+
+// SameKind сообщает, что типы совпадают с точностью до integer/number
+func SameKind(a, b string) bool {
+	return a != "" && b != "" && (IsSubtype(a, b) || IsSubtype(b, a))
+}
+
+// JoinTypes возвращает наименьший конкретный тип, в который входят оба типа.
+// null и any ("") не сужают другой тип: join(null, T) = T, join("", T) = T.
+// Для несовместимых типов ok = false - значения описываются объединением
+func JoinTypes(a, b string) (joined string, ok bool) {
+	switch {
+	case a == "" || a == string(TypeNull):
+		return b, true
+	case b == "" || b == string(TypeNull):
+		return a, true
+	case IsSubtype(a, b):
+		return b, true
+	case IsSubtype(b, a):
+		return a, true
+	}
+	return "", false
+}
+
+// CompatibleTypes сообщает, что значения двух типов описываются одной схемой
+// без объединения
+func CompatibleTypes(a, b string) bool {
+	_, ok := JoinTypes(a, b)
+	return ok
+}
@@ -0,0 +1,24 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// Unmarshal декодирует JSON, сохраняя числа в исходной записи (json.Number):
+// default, enum и examples при сохранении схемы выводятся так же, как в
+// исходных данных, без потери точности и экспоненциальной записи
+func Unmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Number переводит float64 в json.Number без экспоненциальной записи
+func Number(v float64) json.Number {
+	return json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+}
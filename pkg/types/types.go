@@ -1,6 +1,9 @@
 package types
 
 import (
+	"encoding/json"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -9,38 +12,250 @@ type AnalysisResult struct {
 	Schema     *JSONSchema         `json:"schema"`
 	Metadata   *AnalysisMetadata   `json:"metadata"`
 	Statistics *AnalysisStatistics `json:"statistics"`
+	// Warnings - предупреждения загрузки схемы (изменения, которые нельзя
+	// выразить в сохраняемой схеме без потерь) и анализа (массивы, элементы
+	// которых проанализированы не все)
+	Warnings []string `json:"-"`
 }
 
 // JSONSchema представляет JSON Schema
 type JSONSchema struct {
-	Schema      string                 `json:"$schema"`
-	Type        string                 `json:"type"`
+	Schema      string                 `json:"$schema,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Type        string                 `json:"type,omitempty"`
 	Properties  map[string]*Property   `json:"properties,omitempty"`
 	Items       *Property              `json:"items,omitempty"`
 	Required    []string               `json:"required,omitempty"`
 	Enum        []interface{}          `json:"enum,omitempty"`
+	Format      string                 `json:"format,omitempty"`
 	OneOf       []*JSONSchema          `json:"oneOf,omitempty"`
 	AnyOf       []*JSONSchema          `json:"anyOf,omitempty"`
+	Title       string                 `json:"title,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Default     interface{}            `json:"default,omitempty"`
+	MinItems    *int                   `json:"minItems,omitempty"`
+	MaxItems    *int                   `json:"maxItems,omitempty"`
+	UniqueItems bool                   `json:"uniqueItems,omitempty"`
 	Extensions  map[string]interface{} `json:"-"`
+
+	// PropertyOrder - порядок полей properties при сериализации; поля, которых
+	// нет в списке, следуют за перечисленными по алфавиту
+	PropertyOrder []string `json:"-"`
+
+	PatternProperties    map[string]*Property  `json:"patternProperties,omitempty"`
+	AdditionalProperties *AdditionalProperties `json:"additionalProperties,omitempty"`
+
+	// Defs - общие определения, на которые ссылаются узлы схемы через
+	// "$ref": "#/$defs/<имя>"
+	Defs map[string]*Property `json:"$defs,omitempty"`
+}
+
+// DefsRefPrefix - префикс ссылки на общее определение схемы
+const DefsRefPrefix = "#/$defs/"
+
+// DefsSegment - сегмент пути fieldmanager, с которого начинаются пути полей
+// общих определений ($defs.<имя>.<поле>)
+const DefsSegment = "$defs"
+
+// Clone возвращает глубокую копию схемы
+func (s *JSONSchema) Clone() (*JSONSchema, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := &JSONSchema{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
 }
 
 // Property представляет свойство в JSON Schema
 type Property struct {
-	Type        string                 `json:"type"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Type        string                 `json:"type,omitempty"`
 	Properties  map[string]*Property   `json:"properties,omitempty"`
 	Items       *Property              `json:"items,omitempty"`
 	Required    []string               `json:"required,omitempty"`
 	Enum        []interface{}          `json:"enum,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
 	OneOf       []*JSONSchema          `json:"oneOf,omitempty"`
 	AnyOf       []*JSONSchema          `json:"anyOf,omitempty"`
+	Title       string                 `json:"title,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Default     interface{}            `json:"default,omitempty"`
+	Examples    []interface{}          `json:"examples,omitempty"`
+	MinLength   *int                   `json:"minLength,omitempty"`
+	MaxLength   *int                   `json:"maxLength,omitempty"`
+	Minimum     json.Number            `json:"minimum,omitempty"`
+	Maximum     json.Number            `json:"maximum,omitempty"`
+	MinItems    *int                   `json:"minItems,omitempty"`
+	MaxItems    *int                   `json:"maxItems,omitempty"`
+	UniqueItems bool                   `json:"uniqueItems,omitempty"`
 	Extensions  map[string]interface{} `json:"-"`
 
+	// PropertyOrder - порядок полей properties при сериализации; поля, которых
+	// нет в списке, следуют за перечисленными по алфавиту
+	PropertyOrder []string `json:"-"`
+
+	PatternProperties    map[string]*Property  `json:"patternProperties,omitempty"`
+	AdditionalProperties *AdditionalProperties `json:"additionalProperties,omitempty"`
+
+	// PrefixItems описывает массив-кортеж: схему каждой позиции по порядку.
+	// Сериализуется в записи draft-07: "items" - список схем позиций, Items -
+	// "additionalItems"
+	PrefixItems []*Property `json:"-"`
+	// ClosedItems запрещает элементы сверх PrefixItems; сериализуется как "additionalItems": false
+	ClosedItems bool `json:"-"`
+
+	// Nullable разрешает null наряду с Type; сериализуется как "type": ["<Type>", "null"]
+	Nullable bool `json:"-"`
+
+	// ObservedPattern - общий шаблон значений выборки без учета порога
+	// уверенности; используется только при объединении схем и не сохраняется
+	ObservedPattern string `json:"-"`
+
+	// DefaultConflict отмечает, что объединенные выборки дали разные default:
+	// сброшенный default не восстанавливается следующими выборками, в том
+	// числе при update сохраненной схемы
+	DefaultConflict bool `json:"x-default-conflict,omitempty"`
+
 	// Дополнительные поля для управления поведением
-	PreserveDefault bool `json:"x-preserve-default,omitempty"` // Защита от перезатирания default
+	PreserveDefault bool   `json:"x-preserve-default,omitempty"` // Защита от перезатирания default
+	ObservedRange   *Range `json:"x-observed-range,omitempty"`   // Наблюдаемый диапазон числового поля
+
+	// Truncated отмечает объект или массив глубже Config.MaxDepth, вложенная
+	// структура которого не анализировалась
+	Truncated bool `json:"x-truncated,omitempty"`
+
+	// Confidence - уверенность в выведенных свойствах поля по данным выборок
+	Confidence *Confidence `json:"x-confidence,omitempty"`
+	// Pending - ограничения, выведенные по слишком малой выборке; они не
+	// проверяются, пока обновления не накопят достаточно значений
+	Pending *Pending `json:"x-pending,omitempty"`
+}
+
+// Range описывает диапазон числовых значений в исходной записи чисел
+type Range struct {
+	Minimum json.Number `json:"minimum"`
+	Maximum json.Number `json:"maximum"`
+}
+
+// Confidence описывает, насколько выведенные свойства поля подтверждены
+// данными: оценки от 0 до 1 растут с числом значений и долей значений,
+// согласных с выводом. Нулевая оценка означает, что свойство не выводилось
+type Confidence struct {
+	// Samples - число значений поля, по которым выполнена оценка
+	Samples int `json:"samples"`
+	// Type - доля значений выведенного типа
+	Type float64 `json:"type,omitempty"`
+	// Required - доля объектов, в которых поле присутствует (для обязательных полей)
+	Required float64 `json:"required,omitempty"`
+	// Enum - насколько полно выборка покрывает набор значений enum
+	Enum float64 `json:"enum,omitempty"`
+	// Format - уверенность в формате строк
+	Format float64 `json:"format,omitempty"`
+}
+
+// Pending хранит ограничения поля, отложенные до накопления выборки:
+// схема их не проверяет, а обновление возвращает их в схему, когда число
+// значений поля достигнет порога
+type Pending struct {
+	// Samples - число значений поля во всех выборках
+	Samples int `json:"samples"`
+	// Required сообщает, что поле присутствовало во всех объектах
+	Required    bool          `json:"required,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Pattern     string        `json:"pattern,omitempty"`
+	MinLength   *int          `json:"minLength,omitempty"`
+	MaxLength   *int          `json:"maxLength,omitempty"`
+	Minimum     json.Number   `json:"minimum,omitempty"`
+	Maximum     json.Number   `json:"maximum,omitempty"`
+	MinItems    *int          `json:"minItems,omitempty"`
+	MaxItems    *int          `json:"maxItems,omitempty"`
+	UniqueItems bool          `json:"uniqueItems,omitempty"`
+}
+
+// AdditionalProperties представляет ключевое слово additionalProperties:
+// логическое значение или схему значений объекта-словаря
+type AdditionalProperties struct {
+	// Allowed - значение ключевого слова без Schema; false закрывает объект
+	Allowed bool
+	// Schema описывает значения ключей, не перечисленных в properties
+	Schema *Property
+}
+
+// Values возвращает схему значений объекта-словаря или nil
+func (a *AdditionalProperties) Values() *Property {
+	if a == nil {
+		return nil
+	}
+	return a.Schema
+}
+
+// Closed сообщает, что объект не допускает полей сверх properties
+func (a *AdditionalProperties) Closed() bool {
+	return a != nil && a.Schema == nil && !a.Allowed
+}
+
+// MapValues возвращает схему значений объекта-словаря: единственную схему
+// patternProperties или схему additionalProperties; nil для обычного объекта
+func MapValues(patterns map[string]*Property, additional *AdditionalProperties) *Property {
+	if len(patterns) == 1 {
+		for _, values := range patterns {
+			return values
+		}
+	}
+	return additional.Values()
+}
+
+// KeyPattern возвращает шаблон ключей словаря с единственной схемой patternProperties
+func KeyPattern(patterns map[string]*Property) string {
+	if len(patterns) == 1 {
+		for pattern := range patterns {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// MarshalJSON сериализует additionalProperties как схему или true/false
+func (a AdditionalProperties) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+// UnmarshalJSON читает additionalProperties в виде схемы или true/false
+func (a *AdditionalProperties) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		*a = AdditionalProperties{Allowed: allowed}
+		return nil
+	}
+
+	schema := &Property{}
+	if err := Unmarshal(data, schema); err != nil {
+		return err
+	}
+	*a = AdditionalProperties{Schema: schema}
+	return nil
+}
+
+// Sampling описывает выборку записей верхнего уровня
+type Sampling struct {
+	MaxRecords int     `json:"max_records,omitempty"`
+	Rate       float64 `json:"rate,omitempty"`
+	Seed       int64   `json:"seed,omitempty"`
+	// Sampled - сколько записей проанализировано
+	Sampled int `json:"sampled"`
+	// Total - сколько записей было во входных данных; 0, если чтение
+	// остановлено после MaxRecords записей
+	Total int `json:"total,omitempty"`
 }
 
 // AnalysisMetadata содержит метаданные анализа
@@ -49,7 +264,39 @@ type AnalysisMetadata struct {
 	OptionalFields    []string                 `json:"optional_fields,omitempty"`
 	PolymorphicFields map[string][]string      `json:"polymorphic_patterns,omitempty"`
 	GeneratedAt       time.Time                `json:"generated_at"`
+	UpdatedAt         time.Time                `json:"updated_at"`
 	Version           string                   `json:"version"`
+	LastBump          string                   `json:"last_bump,omitempty"`
+	LengthMode        string                   `json:"length_mode,omitempty"`
+	RangeMode         string                   `json:"range_mode,omitempty"`
+	ArrayLimits       bool                     `json:"array_limits,omitempty"`
+	// MinSamples - число значений поля, начиная с которого выведенные
+	// ограничения записываются в схему; сохраняется для последующих обновлений
+	MinSamples int `json:"min_samples,omitempty"`
+	// EnumOrder - порядок значений выведенных enum; сохраняется, чтобы
+	// обновления дополняли enum в том же порядке
+	EnumOrder string `json:"enum_order,omitempty"`
+	// PropertyOrder - порядок полей объектов; при first-seen обновления
+	// сохраняют порядок полей файла и дописывают новые поля после прежних
+	PropertyOrder string `json:"property_order,omitempty"`
+	// MaxDepth - глубина, начиная с которой объекты и массивы описаны без
+	// вложенной структуры (x-truncated); сохраняется, чтобы обновления
+	// обрезали данные на той же глубине. 0 - глубокие значения не обрезаются
+	MaxDepth int `json:"max_depth,omitempty"`
+
+	// Sampling - выборка записей, по которой построена схема при последнем
+	// анализе; nil - проанализированы все записи
+	Sampling *Sampling `json:"sampling,omitempty"`
+
+	// Overrides - принудительные типы и форматы полей по шаблону пути; сохраняются
+	// в схеме, чтобы применяться и при последующих обновлениях
+	Overrides map[string]Override `json:"overrides,omitempty"`
+}
+
+// Override задает тип и формат поля независимо от выборки
+type Override struct {
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
 }
 
 // AnalysisStatistics содержит статистику анализа
@@ -59,6 +306,53 @@ type AnalysisStatistics struct {
 	FieldFrequency   map[string]int           `json:"field_frequency"`
 	TypeDistribution map[string]int           `json:"type_distribution"`
 	EnumCandidates   map[string][]interface{} `json:"enum_candidates"`
+
+	// FieldPresence и FieldNulls считают по пути поля (в формате fieldmanager),
+	// сколько раз поле встретилось и сколько раз из них было null
+	FieldPresence map[string]int `json:"field_presence,omitempty"`
+	FieldNulls    map[string]int `json:"field_nulls,omitempty"`
+
+	// TypeConflicts перечисляет по пути поля типы значений, которые не удалось
+	// совместить с типом в схеме; в схеме остается первый встреченный тип
+	TypeConflicts map[string][]string `json:"type_conflicts,omitempty"`
+
+	// Structures считает объекты каждой формы по хешу формы; UniqueStructures -
+	// число различных форм
+	Structures map[string]*Structure `json:"structures,omitempty"`
+}
+
+// Structure описывает форму объекта: путь и поля с типами значений
+type Structure struct {
+	Path string `json:"path"`
+	// Fields - поля формы в виде "имя:тип" по алфавиту
+	Fields []string `json:"fields"`
+	Count  int      `json:"count"`
+}
+
+// TopStructures возвращает до limit самых частых форм объектов; при равенстве
+// формы упорядочиваются по пути и полям
+func (s *AnalysisStatistics) TopStructures(limit int) []*Structure {
+	if s == nil {
+		return nil
+	}
+	structures := make([]*Structure, 0, len(s.Structures))
+	for _, structure := range s.Structures {
+		structures = append(structures, structure)
+	}
+	sort.Slice(structures, func(i, j int) bool {
+		a, b := structures[i], structures[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return strings.Join(a.Fields, ",") < strings.Join(b.Fields, ",")
+	})
+	if limit > 0 && len(structures) > limit {
+		structures = structures[:limit]
+	}
+	return structures
 }
 
 // JSONType представляет тип JSON значения
@@ -67,6 +361,7 @@ type JSONType string
 const (
 	TypeString  JSONType = "string"
 	TypeNumber  JSONType = "number"
+	TypeInteger JSONType = "integer"
 	TypeBoolean JSONType = "boolean"
 	TypeObject  JSONType = "object"
 	TypeArray   JSONType = "array"
@@ -0,0 +1,359 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/jsonpatch"
+)
+
+// CoerceOptions выбирает исправления, которые применяет Coerce
+type CoerceOptions struct {
+	// Types приводит значения, тип которых однозначно приводится к
+	// ожидаемому схемой (см. FixCoerce)
+	Types bool
+	// Defaults добавляет отсутствующие поля, у которых в схеме есть default,
+	// в том числе необязательные
+	Defaults bool
+	// DropUnknown удаляет поля объектов, не описанные схемой: их нет в
+	// properties, они не подходят под patternProperties, а
+	// additionalProperties не задает для них схему
+	DropUnknown bool
+}
+
+// ProjectOptions настраивает ProjectFile
+type ProjectOptions struct {
+	// RequiredOnly оставляет только обязательные поля объектов
+	RequiredOnly bool
+}
+
+// CoerceResult - исправленный документ и примененные исправления
+// (CoerceFile) или удаленные поля (ProjectFile)
+type CoerceResult struct {
+	// Data - исправленный документ с отступами; порядок полей сохраняется
+	Data  []byte `json:"-"`
+	Fixes []Fix  `json:"fixes"`
+	// Remaining - проверка исправленного документа по схеме
+	Remaining *ValidationResult `json:"remaining"`
+}
+
+// CoerceFile исправляет документ JSON из файла по схеме: приводит типы
+// значений, заполняет значения по умолчанию и удаляет неизвестные поля.
+// Схема выбирает узел для каждого значения так же, как Matcher: внутри
+// oneOf/anyOf - по подошедшим вариантам или ближайшему, если не подошел ни
+// один. Исправленный документ проверяется по схеме еще раз
+func (v *Validator) CoerceFile(dataFile, schemaFile string, opts CoerceOptions) (*CoerceResult, error) {
+	c := &coercer{opts: opts}
+	if opts.DropUnknown {
+		c.keep = describesKey
+	}
+	return v.transformFile(dataFile, schemaFile, c)
+}
+
+// ProjectFile оставляет в документе JSON из файла только поля, описанные
+// схемой (как CoerceOptions.DropUnknown), или только обязательные поля.
+// Объект, узла которого в схеме нет совсем (схема true, ссылка на другой
+// файл), остается целиком, а у объекта без описания полей ({"type":
+// "object"}) удаляются все поля. Значения не меняются
+func (v *Validator) ProjectFile(dataFile, schemaFile string, opts ProjectOptions) (*CoerceResult, error) {
+	keep := func(nodes []map[string]interface{}, key string) bool {
+		if len(nodes) == 0 {
+			return true
+		}
+		for _, node := range nodes {
+			if opts.RequiredOnly {
+				required, _ := node["required"].([]interface{})
+				if slices.Contains(required, interface{}(key)) {
+					return true
+				}
+			} else if describesKey([]map[string]interface{}{node}, key) && isShaped(node) {
+				return true
+			}
+		}
+		return false
+	}
+	return v.transformFile(dataFile, schemaFile, &coercer{keep: keep})
+}
+
+// transformFile строит копию документа из файла исправлениями c и
+// проверяет ее по схеме
+func (v *Validator) transformFile(dataFile, schemaFile string, c *coercer) (*CoerceResult, error) {
+	data, err := decompress.ReadFile(dataFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла данных: %w", err)
+	}
+	document, err := decodeOrdered(data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %w", err)
+	}
+	value, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %w", err)
+	}
+	matcher, err := NewMatcher(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &matchWalker{
+		m:       matcher,
+		seen:    make(map[string]bool),
+		closest: true,
+		nodes:   make(map[string][]map[string]interface{}),
+	}
+	if err := w.node(matcher.document, "", value, RootField); err != nil {
+		return nil, err
+	}
+
+	c.w, c.fixes = w, make([]Fix, 0)
+	fixed, err := json.MarshalIndent(c.value(document, RootField, ""), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации данных: %w", err)
+	}
+	fixed = append(fixed, '\n')
+
+	match, err := matcher.Match(fixed)
+	if err != nil {
+		return nil, err
+	}
+	return &CoerceResult{Data: fixed, Fixes: c.fixes, Remaining: match.ValidationResult}, nil
+}
+
+// coercer строит исправленную копию документа
+type coercer struct {
+	w    *matchWalker
+	opts CoerceOptions
+	// keep решает, оставить ли поле объекта по узлам схемы объекта; nil -
+	// оставлять все поля
+	keep  func(nodes []map[string]interface{}, key string) bool
+	fixes []Fix
+}
+
+// value возвращает исправленное значение по пути field и указателю pointer
+func (c *coercer) value(value interface{}, field, pointer string) interface{} {
+	nodes := c.w.nodes[field]
+
+	if c.opts.Types {
+		if kinds := expectedTypes(nodes); len(kinds) > 0 && !typeAllowed(value, kinds) {
+			for _, kind := range kinds {
+				if coerced, ok := coerce(value, kind); ok {
+					c.fixes = append(c.fixes, Fix{Field: field, Reason: FixCoerce, Value: value, Operation: jsonpatch.Operation{Op: jsonpatch.OpReplace, Path: pointer, Value: coerced}})
+					return coerced
+				}
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case *orderedObject:
+		return c.object(v, nodes, field, pointer)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			segment := fmt.Sprint(i)
+			items[i] = c.value(item, joinField(field, segment), pointer+"/"+segment)
+		}
+		return items
+	}
+	return value
+}
+
+// object возвращает исправленную копию объекта
+func (c *coercer) object(object *orderedObject, nodes []map[string]interface{}, field, pointer string) *orderedObject {
+	result := &orderedObject{values: make(map[string]interface{}, len(object.keys))}
+	for _, key := range object.keys {
+		childField, childPointer := joinField(field, key), pointer+"/"+escapePointer(key)
+		if c.keep != nil && !c.keep(nodes, key) {
+			c.fixes = append(c.fixes, Fix{Field: childField, Reason: FixDrop, Value: object.values[key], Operation: jsonpatch.Operation{Op: jsonpatch.OpRemove, Path: childPointer}})
+			continue
+		}
+		result.set(key, c.value(object.values[key], childField, childPointer))
+	}
+
+	if c.opts.Defaults {
+		for _, node := range nodes {
+			properties, _ := node["properties"].(map[string]interface{})
+			for _, key := range sortedKeys(properties) {
+				if _, present := result.values[key]; present {
+					continue
+				}
+				property, ok := c.w.m.resolveVariant(properties[key]).(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if def, ok := property["default"]; ok {
+					c.fixes = append(c.fixes, Fix{Field: joinField(field, key), Reason: FixDefault, Operation: jsonpatch.Operation{Op: jsonpatch.OpAdd, Path: pointer + "/" + escapePointer(key), Value: def}})
+					result.set(key, def)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// describesKey сообщает, что узлы схемы объекта описывают поле key. Если
+// ни один узел не описывает форму объекта, известными считаются все поля
+func describesKey(nodes []map[string]interface{}, key string) bool {
+	shaped := false
+	for _, node := range nodes {
+		if !isShaped(node) {
+			continue
+		}
+		shaped = true
+
+		properties, _ := node["properties"].(map[string]interface{})
+		patterns, _ := node["patternProperties"].(map[string]interface{})
+		if _, ok := properties[key]; ok {
+			return true
+		}
+		for pattern := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(key) {
+				return true
+			}
+		}
+		if _, ok := node["additionalProperties"].(map[string]interface{}); ok {
+			return true
+		}
+	}
+	return !shaped
+}
+
+// isShaped сообщает, что узел схемы описывает поля объекта
+func isShaped(node map[string]interface{}) bool {
+	_, hasProperties := node["properties"]
+	_, hasPatterns := node["patternProperties"]
+	_, hasAdditional := node["additionalProperties"]
+	return hasProperties || hasPatterns || hasAdditional
+}
+
+// typeAllowed сообщает, что тип значения входит в kinds
+func typeAllowed(value interface{}, kinds []string) bool {
+	actual := jsonType(value)
+	for _, kind := range kinds {
+		if kind == actual || kind == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType возвращает тип значения в терминах JSON Schema
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// orderedObject - объект JSON с полями в порядке записи
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// set задает поле, добавляя новое в конец
+func (o *orderedObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON записывает поля в порядке записи
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered разбирает один документ JSON, сохраняя порядок полей
+// объектов и записи чисел
+func decodeOrdered(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeToken(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("за первым документом JSON следуют другие; исправляется один документ")
+	}
+	return value, nil
+}
+
+// decodeToken разбирает значение, начиная со следующего токена
+func decodeToken(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		object := &orderedObject{values: make(map[string]interface{})}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeToken(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(fmt.Sprint(key), value)
+		}
+		_, err := decoder.Token()
+		return object, err
+	case '[':
+		items := make([]interface{}, 0)
+		for decoder.More() {
+			item, err := decodeToken(decoder)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := decoder.Token()
+		return items, err
+	}
+	return nil, fmt.Errorf("неожиданный разделитель %s", strings.TrimSpace(delim.String()))
+}
@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// errorOneOf - тип ошибки gojsonschema, когда значению подошел не ровно
+// один вариант oneOf
+const errorOneOf = "number_one_of"
+
+// explainOneOf заменяет общие ошибки oneOf результата объяснениями
+// Matcher: описание называет ближайший вариант, а Branches - причины отказа
+// каждого. Ошибки ближайшего варианта, которые gojsonschema добавляет вслед
+// за ошибкой oneOf, убираются, потому что они повторяются в Branches.
+// Объяснение не обязательно: если его не удалось построить, результат
+// остается как есть
+func explainOneOf(schemaFile string, data []byte, result *ValidationResult) {
+	if !hasOneOfError(result) {
+		return
+	}
+	matcher, err := NewMatcher(schemaFile)
+	if err != nil {
+		return
+	}
+	explainMatch(matcher, "", data, result)
+}
+
+// hasOneOfError сообщает, что в результате есть общая ошибка oneOf
+func hasOneOfError(result *ValidationResult) bool {
+	return slices.ContainsFunc(result.Errors, func(e ValidationError) bool { return e.Type == errorOneOf })
+}
+
+// explainMatch объясняет ошибки oneOf результата проверки по узлу схемы
+// с указателем pointer уже загруженным Matcher
+func explainMatch(matcher *Matcher, pointer string, data []byte, result *ValidationResult) {
+	matched, err := matcher.matchAt(pointer, data)
+	if err != nil {
+		return
+	}
+
+	used := make([]bool, len(matched.Matches))
+	errors := make([]ValidationError, 0, len(result.Errors))
+	for i := 0; i < len(result.Errors); i++ {
+		e := result.Errors[i]
+		if e.Type == errorOneOf {
+			if j := failedOneOf(matched.Matches, used, e.Field); j >= 0 {
+				used[j] = true
+				match := matched.Matches[j]
+				e.Description = describeOneOf(match)
+				e.Branches = match.Branches
+				i += mergedErrors(result.Errors[i+1:], match.Branches)
+			}
+		}
+		errors = append(errors, e)
+	}
+	result.Errors = errors
+}
+
+// failedOneOf находит еще не использованный узел oneOf по пути field, в
+// котором значению подошел не ровно один вариант
+func failedOneOf(matches []Match, used []bool, field string) int {
+	for i, match := range matches {
+		if !used[i] && match.Keyword == "oneOf" && match.Field == field && len(match.Matched) != 1 {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergedErrors возвращает число ошибок в начале errors, совпадающих с
+// ошибками одного из вариантов
+func mergedErrors(errors []ValidationError, branches []Branch) int {
+	for _, branch := range branches {
+		n := len(branch.Errors)
+		if n == 0 || n > len(errors) {
+			continue
+		}
+		if slices.EqualFunc(errors[:n], branch.Errors, func(a, b ValidationError) bool {
+			return a.Field == b.Field && a.Type == b.Type && a.Description == b.Description
+		}) {
+			return n
+		}
+	}
+	return 0
+}
+
+// describeOneOf описывает неудачный выбор варианта oneOf
+func describeOneOf(match Match) string {
+	if len(match.Matched) > 1 {
+		names := make([]string, 0, len(match.Matched))
+		for _, i := range match.Matched {
+			names = append(names, match.Branches[i].Name())
+		}
+		return fmt.Sprintf("Значение подходит сразу нескольким вариантам oneOf: %s", strings.Join(names, ", "))
+	}
+	for _, branch := range match.Branches {
+		if branch.Closest {
+			return fmt.Sprintf("Значение не подходит ни к одному из %d вариантов oneOf; ближайший - вариант %s", len(match.Branches), branch.Name())
+		}
+	}
+	return "Значение не подходит ни к одному из вариантов oneOf"
+}
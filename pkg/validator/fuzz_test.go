@@ -0,0 +1,36 @@
+package validator
+
+import "testing"
+
+// FuzzValidate проверяет, что валидация произвольных данных по
+// произвольной схеме не паникует, а ошибки описаны
+func FuzzValidate(f *testing.F) {
+	seeds := []struct{ data, schema string }{
+		{`{"id": 1}`, `{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}`},
+		{`{"id": "1"}`, `{"type": "object", "properties": {"id": {"type": "integer"}}}`},
+		{`[1, "a"]`, `{"type": "array", "items": [{"type": "integer"}, {"type": "string"}], "additionalItems": false}`},
+		{`{"kind": "square"}`, `{"oneOf": [{"properties": {"kind": {"enum": ["circle"]}}, "required": ["kind", "r"]}, {"properties": {"kind": {"enum": ["square"]}}, "required": ["kind", "side"]}]}`},
+		{`null`, `{"type": ["string", "null"]}`},
+		{`{"a": {"a": {}}}`, `{"$ref": "#/definitions/N", "definitions": {"N": {"type": "object", "additionalProperties": {"$ref": "#/definitions/N"}}}}`},
+		{`"x"`, `{"format": "date-time"}`},
+		{`{}`, `true`},
+		{`[]`, `{"$ref": "#/missing"}`},
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed.data), []byte(seed.schema), false)
+	}
+	f.Fuzz(func(t *testing.T, data, schema []byte, strict bool) {
+		result, err := New(strict).ValidateBytes(data, schema)
+		if err != nil {
+			return
+		}
+		if !result.Valid && len(result.Errors) == 0 {
+			t.Fatalf("невалидный результат без ошибок")
+		}
+		for _, e := range result.Errors {
+			if e.Description == "" {
+				t.Fatalf("ошибка без описания: %+v", e)
+			}
+		}
+	})
+}
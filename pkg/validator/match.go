@@ -0,0 +1,598 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// RootField - путь корня данных в ошибках валидации и объяснениях
+const RootField = "(root)"
+
+// Matcher проверяет фрагменты JSON по схеме из файла и объясняет, какие
+// варианты oneOf/anyOf и какие значения enum им соответствуют. Части схемы
+// компилируются один раз и переиспользуются между проверками, в том числе
+// из нескольких горутин
+type Matcher struct {
+	schemaURL string
+	document  interface{}
+
+	mu       sync.Mutex
+	compiled map[string]*gojsonschema.Schema
+}
+
+// MatchResult - результат проверки фрагмента с объяснением выбора вариантов
+type MatchResult struct {
+	*ValidationResult
+	Matches []Match `json:"matches,omitempty"`
+}
+
+// Match описывает выбор в одном узле схемы: варианты oneOf/anyOf, которым
+// соответствует значение, или совпавшее значение enum
+type Match struct {
+	// Field - путь значения в данных в формате ошибок валидации
+	Field string `json:"field"`
+	// Schema - ссылка на узел схемы с ключевым словом (#/properties/shape)
+	Schema  string `json:"schema"`
+	Keyword string `json:"keyword"`
+	// Matched - номера подошедших вариантов или значения enum
+	Matched []int `json:"matched"`
+	// Value - совпавшее значение enum
+	Value    interface{} `json:"value,omitempty"`
+	Branches []Branch    `json:"branches,omitempty"`
+}
+
+// Branch описывает вариант oneOf/anyOf и результат проверки значения по нему
+type Branch struct {
+	Index int    `json:"index"`
+	Label string `json:"label,omitempty"`
+	Valid bool   `json:"valid"`
+	// Closest отмечает вариант, ближайший к значению, если не подошел ни один
+	Closest bool              `json:"closest,omitempty"`
+	Errors  []ValidationError `json:"errors,omitempty"`
+}
+
+// Name возвращает номер варианта с описанием: 1 (kind="square")
+func (b Branch) Name() string {
+	if b.Label == "" {
+		return strconv.Itoa(b.Index)
+	}
+	return fmt.Sprintf("%d (%s)", b.Index, b.Label)
+}
+
+// NewMatcher загружает схему из файла. Относительные $ref разрешаются от
+// директории схемы, как в ValidateFile
+func NewMatcher(schemaFile string) (*Matcher, error) {
+	data, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла схемы: %w", err)
+	}
+	// Узлы обходятся в той же записи draft-07, в которой их компилирует
+	// gojsonschema, чтобы указатели на позиции кортежей совпадали
+	if data, err = prepareSchema(data); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга схемы: %w", err)
+	}
+	document, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга схемы: %w", err)
+	}
+	schemaPath, err := filepath.Abs(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла схемы: %w", err)
+	}
+
+	m := &Matcher{
+		schemaURL: fileURL(schemaPath),
+		document:  document,
+		compiled:  make(map[string]*gojsonschema.Schema),
+	}
+	// Корень компилируется сразу, чтобы ошибки схемы были видны до первой проверки
+	if _, err := m.compile(""); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Match проверяет JSON-значение по схеме и для каждого узла oneOf, anyOf и
+// enum, через который прошло значение, сообщает подошедшие варианты.
+// Внутрь вариантов oneOf/anyOf обход идет только по подошедшим, внутрь
+// allOf - по всем; ссылки на другие файлы проверяются, но не раскрываются
+func (m *Matcher) Match(data []byte) (*MatchResult, error) {
+	return m.matchAt("", data)
+}
+
+// matchAt выполняет Match по узлу схемы с указателем pointer
+func (m *Matcher) matchAt(pointer string, data []byte) (*MatchResult, error) {
+	start := time.Now()
+
+	value, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %w", err)
+	}
+	node, ok := resolvePointer(m.document, pointer)
+	if !ok {
+		return nil, fmt.Errorf("узел схемы не найден: #%s", pointer)
+	}
+	root, err := m.compile(pointer)
+	if err != nil {
+		return nil, err
+	}
+	validation, err := m.validate(root, value)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &matchWalker{m: m, seen: make(map[string]bool)}
+	if err := w.node(node, pointer, value, RootField); err != nil {
+		return nil, err
+	}
+
+	validation.ValidatedFields = New(false).countFieldsRecursive(value)
+	validation.Duration = time.Since(start)
+	return &MatchResult{ValidationResult: validation, Matches: w.matches}, nil
+}
+
+// compile компилирует узел схемы по JSON Pointer; пустой указатель - корень
+func (m *Matcher) compile(pointer string) (_ *gojsonschema.Schema, err error) {
+	defer recoverPanic(&err)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if schema, ok := m.compiled[pointer]; ok {
+		return schema, nil
+	}
+
+	ref := m.schemaURL
+	if pointer != "" {
+		ref += "#" + (&url.URL{Fragment: pointer}).EscapedFragment()
+	}
+	schema, err := gojsonschema.NewSchemaLoader().Compile(gojsonschema.NewReferenceLoaderFileSystem(ref, tupleFileSystem{}))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка компиляции схемы #%s: %w", pointer, err)
+	}
+	m.compiled[pointer] = schema
+	return schema, nil
+}
+
+// validate проверяет значение по скомпилированной схеме
+func (m *Matcher) validate(schema *gojsonschema.Schema, value interface{}) (*ValidationResult, error) {
+	result, err := validateValue(schema, value)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка валидации: %w", err)
+	}
+	return convertResult(result), nil
+}
+
+// matchWalker обходит схему вместе с данными и собирает объяснения
+type matchWalker struct {
+	m       *Matcher
+	matches []Match
+	// seen защищает от циклов $ref, которые не продвигаются по данным
+	seen map[string]bool
+
+	// closest продолжает обход внутри ближайшего варианта oneOf/anyOf, если
+	// не подошел ни один
+	closest bool
+	// nodes и pointers, если заданы, собирают узлы схемы, по которым
+	// проверяется значение по каждому пути, и JSON Pointer этих значений
+	nodes    map[string][]map[string]interface{}
+	pointers map[string]string
+}
+
+// node обходит узел схемы с указателем pointer для значения по пути field
+func (w *matchWalker) node(node interface{}, pointer string, value interface{}, field string) error {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		// Схемы true и false ничего не выбирают
+		return nil
+	}
+	key := pointer + "\x00" + field
+	if w.seen[key] {
+		return nil
+	}
+	w.seen[key] = true
+
+	if ref, ok := schema["$ref"].(string); ok {
+		// В draft-07 соседние с $ref ключевые слова не действуют
+		target, local := localPointer(ref)
+		if !local {
+			return nil
+		}
+		resolved, ok := resolvePointer(w.m.document, target)
+		if !ok {
+			return nil
+		}
+		return w.node(resolved, target, value, field)
+	}
+	if w.nodes != nil {
+		w.nodes[field] = append(w.nodes[field], schema)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		match := Match{Field: field, Schema: "#" + pointer, Keyword: "enum", Matched: []int{}}
+		for i, candidate := range enum {
+			if equalJSON(candidate, value) {
+				match.Matched = append(match.Matched, i)
+				match.Value = candidate
+				break
+			}
+		}
+		w.matches = append(w.matches, match)
+	}
+
+	if variants, ok := schema["allOf"].([]interface{}); ok {
+		for i, variant := range variants {
+			if err := w.node(variant, fmt.Sprintf("%s/allOf/%d", pointer, i), value, field); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		if variants, ok := schema[keyword].([]interface{}); ok {
+			if err := w.variants(keyword, variants, pointer, value, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return w.object(schema, pointer, v, field)
+	case []interface{}:
+		return w.array(schema, pointer, v, field)
+	}
+	return nil
+}
+
+// variants проверяет значение по каждому варианту oneOf/anyOf и продолжает
+// обход внутри подошедших
+func (w *matchWalker) variants(keyword string, variants []interface{}, pointer string, value interface{}, field string) error {
+	match := Match{Field: field, Schema: "#" + pointer, Keyword: keyword, Matched: []int{}}
+	for i, variant := range variants {
+		variantPointer := fmt.Sprintf("%s/%s/%d", pointer, keyword, i)
+		compiled, err := w.m.compile(variantPointer)
+		if err != nil {
+			return err
+		}
+		result, err := w.m.validate(compiled, value)
+		if err != nil {
+			return err
+		}
+
+		branch := Branch{Index: i, Label: w.m.variantLabel(variant), Valid: result.Valid}
+		if result.Valid {
+			match.Matched = append(match.Matched, i)
+		}
+		for _, e := range result.Errors {
+			// Пути ошибок варианта отсчитываются от проверяемого значения
+			if e.Field == RootField {
+				e.Field = field
+			} else {
+				e.Field = joinField(field, e.Field)
+			}
+			branch.Errors = append(branch.Errors, e)
+		}
+		match.Branches = append(match.Branches, branch)
+	}
+	if len(match.Matched) == 0 && len(match.Branches) > 0 {
+		match.Branches[w.m.closestBranch(variants, match.Branches, value, field)].Closest = true
+	}
+	w.matches = append(w.matches, match)
+
+	followed := match.Matched
+	if len(followed) == 0 && w.closest {
+		for _, branch := range match.Branches {
+			if branch.Closest {
+				followed = []int{branch.Index}
+			}
+		}
+	}
+	for _, i := range followed {
+		if err := w.node(variants[i], fmt.Sprintf("%s/%s/%d", pointer, keyword, i), value, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// child запоминает JSON Pointer элемента или поля значения по пути field
+func (w *matchWalker) child(field, segment string) string {
+	childField := joinField(field, segment)
+	if w.pointers != nil {
+		w.pointers[childField] = w.pointers[field] + "/" + escapePointer(segment)
+	}
+	return childField
+}
+
+// object продолжает обход по полям объекта: properties, patternProperties,
+// а для остальных полей - additionalProperties
+func (w *matchWalker) object(schema map[string]interface{}, pointer string, value map[string]interface{}, field string) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+	patterns, _ := schema["patternProperties"].(map[string]interface{})
+
+	for _, key := range sortedKeys(value) {
+		child, childField := value[key], w.child(field, key)
+		described := false
+		if sub, ok := properties[key]; ok {
+			described = true
+			if err := w.node(sub, pointer+"/properties/"+escapePointer(key), child, childField); err != nil {
+				return err
+			}
+		}
+		for _, pattern := range sortedKeys(patterns) {
+			re, err := regexp.Compile(pattern)
+			if err != nil || !re.MatchString(key) {
+				continue
+			}
+			described = true
+			if err := w.node(patterns[pattern], pointer+"/patternProperties/"+escapePointer(pattern), child, childField); err != nil {
+				return err
+			}
+		}
+		if !described {
+			if err := w.node(schema["additionalProperties"], pointer+"/additionalProperties", child, childField); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// array продолжает обход по элементам массива: items или позиции кортежа
+// и additionalItems
+func (w *matchWalker) array(schema map[string]interface{}, pointer string, value []interface{}, field string) error {
+	for i, item := range value {
+		itemField := w.child(field, strconv.Itoa(i))
+		var err error
+		switch items := schema["items"].(type) {
+		case map[string]interface{}:
+			err = w.node(items, pointer+"/items", item, itemField)
+		case []interface{}:
+			if i < len(items) {
+				err = w.node(items[i], fmt.Sprintf("%s/items/%d", pointer, i), item, itemField)
+			} else {
+				err = w.node(schema["additionalItems"], pointer+"/additionalItems", item, itemField)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closestBranch выбирает вариант, ближе всех подходящий значению, когда не
+// подошел ни один: сначала варианты того же типа, что и значение, затем с
+// совпавшим полем-дискриминатором, затем с меньшим числом ошибок
+func (m *Matcher) closestBranch(variants []interface{}, branches []Branch, value interface{}, field string) int {
+	distance := func(branch Branch) int {
+		for _, e := range branch.Errors {
+			if e.Field == field && e.Type == "invalid_type" {
+				return 2
+			}
+		}
+		object, isObject := value.(map[string]interface{})
+		if key, want, ok := discriminator(m.resolveVariant(variants[branch.Index])); ok && isObject && !equalJSON(object[key], want) {
+			return 1
+		}
+		return 0
+	}
+
+	closest := 0
+	for i := 1; i < len(branches); i++ {
+		d, best := distance(branches[i]), distance(branches[closest])
+		if d < best || d == best && len(branches[i].Errors) < len(branches[closest].Errors) {
+			closest = i
+		}
+	}
+	return closest
+}
+
+// resolveVariant раскрывает локальную ссылку варианта на один уровень
+func (m *Matcher) resolveVariant(node interface{}) interface{} {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return node
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if target, local := localPointer(ref); local {
+			if resolved, ok := resolvePointer(m.document, target); ok {
+				return resolved
+			}
+		}
+	}
+	return node
+}
+
+// variantLabel описывает вариант для человека: поле-дискриминатор с
+// единственным значением, title, ссылка или тип. Для локальной ссылки
+// описывается определение, на которое она указывает
+func (m *Matcher) variantLabel(node interface{}) string {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return fmt.Sprint(node)
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if label := describeSchema(m.resolveVariant(schema)); label != "" {
+			return label
+		}
+		return ref
+	}
+	return describeSchema(schema)
+}
+
+// describeSchema описывает схему без перехода по ссылкам
+func describeSchema(node interface{}) string {
+	schema, ok := node.(map[string]interface{})
+	if !ok || schema["$ref"] != nil {
+		return ""
+	}
+
+	if key, value, ok := discriminator(schema); ok {
+		data, _ := json.Marshal(value)
+		return key + "=" + string(data)
+	}
+	if title, ok := schema["title"].(string); ok && title != "" {
+		return title
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		names := make([]string, 0, len(t))
+		for _, name := range t {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, "|")
+	}
+	return ""
+}
+
+// discriminator возвращает первое по алфавиту поле схемы объекта с
+// единственным допустимым значением
+func discriminator(node interface{}) (string, interface{}, bool) {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, key := range sortedKeys(properties) {
+		if value, ok := singleValue(properties[key]); ok {
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// singleValue возвращает единственное допустимое значение поля: const или
+// enum из одного значения
+func singleValue(node interface{}) (interface{}, bool) {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	if value, ok := schema["const"]; ok {
+		return value, true
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) == 1 {
+		return enum[0], true
+	}
+	return nil, false
+}
+
+// localPointer возвращает JSON Pointer ссылки внутри того же документа
+func localPointer(ref string) (string, bool) {
+	fragment, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+	return fragment, true
+}
+
+// resolvePointer находит узел документа по JSON Pointer
+func resolvePointer(document interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return document, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	node := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := node.(type) {
+		case map[string]interface{}:
+			child, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			node = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// escapePointer экранирует ключ для JSON Pointer
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// joinField добавляет сегмент к пути данных
+func joinField(field, segment string) string {
+	if field == RootField {
+		return segment
+	}
+	return field + "." + segment
+}
+
+// equalJSON сравнивает JSON-значения; числа сравниваются по значению
+func equalJSON(a, b interface{}) bool {
+	return reflect.DeepEqual(normalizeNumbers(a), normalizeNumbers(b))
+}
+
+// normalizeNumbers заменяет json.Number на float64
+func normalizeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			result[key] = normalizeNumbers(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, child := range v {
+			result[i] = normalizeNumbers(child)
+		}
+		return result
+	}
+	return value
+}
+
+// decodeJSON разбирает одно JSON-значение, сохраняя числа как json.Number
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// sortedKeys возвращает ключи объекта в отсортированном порядке
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,330 @@
+package validator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+)
+
+// recordsBatch - сколько записей NDJSON проверяет один обработчик за раз
+const recordsBatch = 256
+
+// utf8BOM - метка порядка байтов UTF-8, которую пропускает чтение NDJSON
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ErrMalformedRecord - запись NDJSON не является одним значением JSON
+var ErrMalformedRecord = errors.New("запись не является JSON")
+
+// malformedType - тип ошибки записи, которая не является JSON
+const malformedType = "malformed"
+
+// RecordsOptions настраивает ValidateRecords
+type RecordsOptions struct {
+	// Workers - число обработчиков; 0 - по числу процессоров
+	Workers int
+	// Invalid, если задан, получает записи с ошибками в порядке входа -
+	// по строке JSON InvalidRecord на запись, - чтобы их можно было
+	// отложить и обработать повторно
+	Invalid io.Writer
+}
+
+// InvalidRecord - запись NDJSON с ошибками и их краткое описание. Для
+// ошибок oneOf в Errors следуют и ошибки ближайшего варианта
+type InvalidRecord struct {
+	// Record - номер записи во входе, начиная с 1
+	Record int           `json:"record"`
+	Errors []RecordError `json:"errors"`
+	// Data - исходная запись
+	Data json.RawMessage `json:"data"`
+	// Malformed - строка не является JSON: Data содержит ее как строку
+	// JSON, а Errors - ошибку разбора
+	Malformed bool `json:"malformed,omitempty"`
+}
+
+// RecordError - ошибка записи без значения и вариантов oneOf
+type RecordError struct {
+	Field       string `json:"field"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// batchOutcome - результат проверки пакета записей
+type batchOutcome struct {
+	result  *ValidationResult
+	invalid []InvalidRecord
+	err     error
+}
+
+// ValidateRecordsFile проверяет по схеме каждую запись файла NDJSON (см.
+// ValidateRecords). Файл, сжатый gzip или zstd, распаковывается при чтении
+func (v *Validator) ValidateRecordsFile(dataFile, schemaFile string, opts RecordsOptions) (*ValidationResult, error) {
+	file, err := decompress.Open(dataFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла данных: %w", err)
+	}
+	defer file.Close()
+
+	return v.ValidateRecords(file, schemaFile, opts)
+}
+
+// ValidateRecords проверяет по схеме каждую запись потока NDJSON отдельно.
+// Запись - непустая строка потока. Если схема описывает массив (так анализ
+// описывает NDJSON), записи проверяются по схеме его элементов (items).
+// Строка, которая не является JSON, не прерывает проверку: она считается
+// записью с ошибкой типа malformed и попадает в opts.Invalid с ошибкой
+// разбора (см. NewMalformedRecord). Записи проверяются пакетами
+// в opts.Workers обработчиках, а результаты пакетов собираются в порядке
+// записей, поэтому ошибки и записи в opts.Invalid идут в порядке входа
+// при любом числе обработчиков. У каждой ошибки указан номер записи
+// (Record), начиная с 1; ошибки oneOf объясняются, как в ValidateFile.
+// Поток читается одной горутиной, и в памяти одновременно не больше
+// Workers пакетов
+func (v *Validator) ValidateRecords(r io.Reader, schemaFile string, opts RecordsOptions) (*ValidationResult, error) {
+	start := time.Now()
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// Matcher и скомпилированные части схемы общие для всех обработчиков,
+	// поэтому объяснения не зависят от того, какой обработчик проверил запись
+	checker, err := v.NewRecordChecker(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// Как и при параллельном анализе, пакеты, ожидающие сборки, тоже
+	// занимают обработчик
+	slots := make(chan chan batchOutcome, max(workers-1, 0))
+	go func() {
+		defer close(slots)
+		emit := func(validate func() batchOutcome) bool {
+			slot := make(chan batchOutcome, 1)
+			select {
+			case slots <- slot:
+			case <-done:
+				return false
+			}
+			go func() { slot <- validate() }()
+			return true
+		}
+
+		reader := bufio.NewReader(r)
+		if prefix, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+			reader.Discard(len(utf8BOM))
+		}
+
+		first := 1
+		var records []json.RawMessage
+		flush := func() bool {
+			if len(records) == 0 {
+				return true
+			}
+			pending, from := records, first
+			first += len(records)
+			records = nil
+			return emit(func() batchOutcome { return checker.validateBatch(from, pending, opts.Invalid != nil) })
+		}
+
+		for {
+			line, err := reader.ReadBytes('\n')
+			if record := bytes.TrimSpace(line); len(record) > 0 {
+				records = append(records, record)
+				if len(records) == recordsBatch && !flush() {
+					return
+				}
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				if flush() {
+					emit(func() batchOutcome {
+						return batchOutcome{err: fmt.Errorf("ошибка чтения записей: %w", err)}
+					})
+				}
+				return
+			}
+		}
+		flush()
+	}()
+
+	result := &ValidationResult{Valid: true, Errors: make([]ValidationError, 0)}
+	for slot := range slots {
+		out := <-slot
+		if out.err != nil {
+			return nil, out.err
+		}
+		result.Valid = result.Valid && out.result.Valid
+		result.Errors = append(result.Errors, out.result.Errors...)
+		result.ValidatedFields += out.result.ValidatedFields
+		result.Records += out.result.Records
+		result.InvalidRecords += out.result.InvalidRecords
+		for _, record := range out.invalid {
+			if err := writeInvalid(opts.Invalid, record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// RecordChecker проверяет отдельные записи NDJSON по схеме так же, как
+// ValidateRecords. Check можно вызывать из нескольких горутин
+type RecordChecker struct {
+	validator *Validator
+	matcher   *Matcher
+	schema    *gojsonschema.Schema
+	pointer   string
+}
+
+// NewRecordChecker загружает схему для проверки записей NDJSON. Если схема
+// описывает массив, записи проверяются по схеме его элементов (items)
+func (v *Validator) NewRecordChecker(schemaFile string) (*RecordChecker, error) {
+	m, err := NewMatcher(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	pointer := recordPointer(m.document)
+	schema, err := m.compile(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordChecker{validator: v, matcher: m, schema: schema, pointer: pointer}, nil
+}
+
+// Check проверяет одну запись. Ошибки oneOf объясняются, как в ValidateFile;
+// номер записи в ошибках не заполняется. Для записи, которая не является
+// одним значением JSON, возвращается ошибка ErrMalformedRecord
+func (c *RecordChecker) Check(record []byte) (*ValidationResult, error) {
+	value, err := decodeRecord(record)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedRecord, err)
+	}
+	validation, err := validateValue(c.schema, value)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка валидации записи: %w", err)
+	}
+	result := convertResult(validation)
+	result.Records = 1
+	result.ValidatedFields = c.validator.countFieldsRecursive(value)
+	if !result.Valid {
+		result.InvalidRecords = 1
+		if hasOneOfError(result) {
+			explainMatch(c.matcher, c.pointer, record, result)
+		}
+	}
+	return result, nil
+}
+
+// NewInvalidRecord описывает запись с номером number и результат ее
+// проверки для отложенной обработки
+func NewInvalidRecord(number int, data []byte, result *ValidationResult) InvalidRecord {
+	record := InvalidRecord{Record: number, Errors: make([]RecordError, 0, len(result.Errors)), Data: data}
+	for _, e := range failures(result.Errors) {
+		record.Errors = append(record.Errors, RecordError{Field: e.Field, Type: e.Type, Description: e.Description})
+	}
+	return record
+}
+
+// NewMalformedRecord описывает строку с номером number, которая не является
+// JSON, и ошибку ее разбора для отложенной обработки
+func NewMalformedRecord(number int, line []byte, err error) InvalidRecord {
+	data, _ := json.Marshal(string(line))
+	return InvalidRecord{
+		Record:    number,
+		Errors:    []RecordError{{Field: "(root)", Type: malformedType, Description: err.Error()}},
+		Data:      data,
+		Malformed: true,
+	}
+}
+
+// decodeRecord разбирает запись NDJSON: ровно одно значение JSON
+func decodeRecord(record []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(record))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(record[decoder.InputOffset():])) > 0 {
+		return nil, fmt.Errorf("после значения в строке следуют другие данные")
+	}
+	return value, nil
+}
+
+// recordPointer возвращает указатель узла схемы, по которому проверяются
+// записи NDJSON: items, если схема описывает массив с одной схемой
+// элементов, иначе корень
+func recordPointer(document interface{}) string {
+	root, ok := document.(map[string]interface{})
+	if !ok || root["type"] != "array" {
+		return ""
+	}
+	if _, ok := root["items"].(map[string]interface{}); ok {
+		return "/items"
+	}
+	return ""
+}
+
+// writeInvalid записывает запись с ошибками строкой JSON
+func writeInvalid(w io.Writer, record InvalidRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации записи %d: %w", record.Record, err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("ошибка записи записей с ошибками: %w", err)
+	}
+	return nil
+}
+
+// validateBatch проверяет записи пакета, первая из которых имеет номер
+// first. С keep записи с ошибками возвращаются вместе с результатом
+func (c *RecordChecker) validateBatch(first int, records []json.RawMessage, keep bool) batchOutcome {
+	batch := &ValidationResult{Valid: true, Errors: make([]ValidationError, 0), Records: len(records)}
+	var invalid []InvalidRecord
+	for i, raw := range records {
+		result, err := c.Check(raw)
+		if errors.Is(err, ErrMalformedRecord) {
+			batch.Valid = false
+			batch.InvalidRecords++
+			batch.Errors = append(batch.Errors, ValidationError{Record: first + i, Field: "(root)", Type: malformedType, Description: err.Error()})
+			if keep {
+				invalid = append(invalid, NewMalformedRecord(first+i, raw, err))
+			}
+			continue
+		}
+		if err != nil {
+			return batchOutcome{err: fmt.Errorf("запись %d: %w", first+i, err)}
+		}
+		batch.ValidatedFields += result.ValidatedFields
+		if result.Valid {
+			continue
+		}
+
+		batch.Valid = false
+		batch.InvalidRecords++
+		for _, e := range result.Errors {
+			e.Record = first + i
+			batch.Errors = append(batch.Errors, e)
+		}
+		if keep {
+			invalid = append(invalid, NewInvalidRecord(first+i, raw, result))
+		}
+	}
+	return batchOutcome{result: batch, invalid: invalid}
+}
@@ -0,0 +1,173 @@
+package validator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// recordsSchema - схема записей NDJSON в записи analyze: массив объектов
+const recordsSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "array",
+	"items": {
+		"type": "object",
+		"properties": {"id": {"type": "integer"}, "name": {"type": "string"}},
+		"required": ["id", "name"]
+	}
+}`
+
+// writeSchema сохраняет схему во временный файл и возвращает путь к нему
+func writeSchema(t testing.TB, schema string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "records.schema.json")
+	if err := os.WriteFile(path, []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readInvalid разбирает вывод opts.Invalid
+func readInvalid(t testing.TB, data []byte) []InvalidRecord {
+	t.Helper()
+	var records []InvalidRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var record InvalidRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("строка %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestValidateRecordsMalformed(t *testing.T) {
+	schemaFile := writeSchema(t, recordsSchema)
+	input := strings.Join([]string{
+		`{"id": 1, "name": "a"}`,
+		`{"id": 2, "name": `,
+		`{"id": "3", "name": "c"}`,
+		``,
+		`not json`,
+		`{"id": 5, "name": "e"} {"id": 6}`,
+		`{"id": 7, "name": "g"}`,
+	}, "\n")
+
+	for _, workers := range []int{1, 3} {
+		var invalid bytes.Buffer
+		result, err := New(false).ValidateRecords(strings.NewReader(input), schemaFile, RecordsOptions{Workers: workers, Invalid: &invalid})
+		if err != nil {
+			t.Fatalf("workers=%d: ValidateRecords: %v", workers, err)
+		}
+		if result.Valid || result.Records != 6 || result.InvalidRecords != 4 {
+			t.Errorf("workers=%d: valid %v, records %d, invalid %d; want false, 6, 4", workers, result.Valid, result.Records, result.InvalidRecords)
+		}
+
+		records := readInvalid(t, invalid.Bytes())
+		want := []struct {
+			record    int
+			malformed bool
+		}{{2, true}, {3, false}, {4, true}, {5, true}}
+		if len(records) != len(want) {
+			t.Fatalf("workers=%d: %d записей в opts.Invalid, want %d", workers, len(records), len(want))
+		}
+		for i, w := range want {
+			got := records[i]
+			if got.Record != w.record || got.Malformed != w.malformed {
+				t.Errorf("workers=%d: запись %d = {record %d, malformed %v}, want {%d, %v}", workers, i, got.Record, got.Malformed, w.record, w.malformed)
+			}
+			if w.malformed && (len(got.Errors) != 1 || got.Errors[0].Type != malformedType || got.Errors[0].Description == "") {
+				t.Errorf("workers=%d: запись %d: errors = %+v, want ошибку разбора", workers, got.Record, got.Errors)
+			}
+		}
+		var line string
+		if err := json.Unmarshal(records[0].Data, &line); err != nil || line != `{"id": 2, "name":` {
+			t.Errorf("workers=%d: data = %s, want исходную строку", workers, records[0].Data)
+		}
+	}
+}
+
+// orderedRecords строит n записей NDJSON, где каждая седьмая не проходит
+// схему, а каждая тридцать первая не является JSON, и возвращает номера
+// записей с ошибками в порядке входа
+func orderedRecords(n int) (string, []int) {
+	var input strings.Builder
+	var invalid []int
+	for i := 1; i <= n; i++ {
+		switch {
+		case i%31 == 0:
+			fmt.Fprintf(&input, "{\"id\": %d,\n", i)
+			invalid = append(invalid, i)
+		case i%7 == 0:
+			fmt.Fprintf(&input, "{\"id\": \"%d\", \"name\": \"r%d\"}\n", i, i)
+			invalid = append(invalid, i)
+		default:
+			fmt.Fprintf(&input, "{\"id\": %d, \"name\": \"r%d\"}\n", i, i)
+		}
+	}
+	return input.String(), invalid
+}
+
+func TestValidateRecordsOrder(t *testing.T) {
+	schemaFile := writeSchema(t, recordsSchema)
+	// Записей на несколько пакетов и неполный последний пакет
+	records := 3*recordsBatch + 17
+	input, want := orderedRecords(records)
+
+	for _, workers := range []int{1, 2, 3, 8} {
+		var invalid bytes.Buffer
+		result, err := New(false).ValidateRecords(strings.NewReader(input), schemaFile, RecordsOptions{Workers: workers, Invalid: &invalid})
+		if err != nil {
+			t.Fatalf("workers=%d: ValidateRecords: %v", workers, err)
+		}
+		if result.Records != records || result.InvalidRecords != len(want) {
+			t.Errorf("workers=%d: records %d, invalid %d; want %d, %d", workers, result.Records, result.InvalidRecords, records, len(want))
+		}
+
+		numbers := make([]int, len(result.Errors))
+		for i, e := range result.Errors {
+			numbers[i] = e.Record
+		}
+		if !slices.Equal(numbers, want) {
+			t.Errorf("workers=%d: записи ошибок = %v, want %v", workers, numbers, want)
+		}
+
+		quarantined := readInvalid(t, invalid.Bytes())
+		got := make([]int, len(quarantined))
+		for i, record := range quarantined {
+			got[i] = record.Record
+			if malformed := record.Record%31 == 0; record.Malformed != malformed {
+				t.Errorf("workers=%d: запись %d malformed = %v, want %v", workers, record.Record, record.Malformed, malformed)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("workers=%d: записи в opts.Invalid = %v, want %v", workers, got, want)
+		}
+	}
+}
+
+// BenchmarkValidateRecords сравнивает проверку NDJSON одним и несколькими
+// обработчиками
+func BenchmarkValidateRecords(b *testing.B) {
+	schemaFile := writeSchema(b, recordsSchema)
+	input, _ := orderedRecords(16 * recordsBatch)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := New(false).ValidateRecords(strings.NewReader(input), schemaFile, RecordsOptions{Workers: workers, Invalid: io.Discard}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// applicators - ключевые слова, которые проверяют то же значение, не
+// спускаясь в данные: цикл ссылок через них не заканчивается
+var applicators = []string{"allOf", "anyOf", "oneOf", "not", "if", "then", "else"}
+
+// prepareSchema готовит схему к компиляции gojsonschema: отклоняет циклы
+// $ref, на которых gojsonschema уходит в бесконечную рекурсию, и переводит
+// кортежи в запись draft-07 (см. downgradeTuples)
+func prepareSchema(schema []byte) ([]byte, error) {
+	if bytes.Contains(schema, []byte(`"$ref"`)) {
+		decoder := json.NewDecoder(bytes.NewReader(schema))
+		decoder.UseNumber()
+		var document interface{}
+		if err := decoder.Decode(&document); err != nil {
+			return nil, err
+		}
+		if err := checkRefs(document); err != nil {
+			return nil, err
+		}
+	}
+	return downgradeTuples(schema)
+}
+
+// checkRefs проверяет локальные ссылки $ref схемы: ссылка должна быть
+// указателем JSON (#/...) или якорем, объявленным в $id, и цепочка ссылок
+// и applicators не должна возвращаться к узлу, не спустившись в данные
+// ({"$ref": "#"} в корне, взаимные ссылки определений друг на друга)
+func checkRefs(document interface{}) error {
+	anchors := make(map[string]bool)
+	var refs []string
+	walkSchema(document, "", func(pointer string, node map[string]interface{}) {
+		for _, key := range []string{"$id", "id"} {
+			if id, ok := node[key].(string); ok && strings.HasPrefix(id, "#") {
+				anchors[id] = true
+			}
+		}
+		if _, ok := node["$ref"]; ok {
+			refs = append(refs, pointer)
+		}
+	})
+
+	// Ребра графа: локальная ссылка и applicators узла
+	next := func(pointer string) ([]string, error) {
+		resolved, _ := resolvePointer(document, pointer)
+		node, ok := resolved.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		var edges []string
+		if ref, ok := node["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			target, err := url.PathUnescape(ref[1:])
+			if err != nil {
+				return nil, fmt.Errorf("некорректная ссылка $ref %q: %w", ref, err)
+			}
+			switch {
+			case target == "" || strings.HasPrefix(target, "/"):
+				edges = append(edges, target)
+			case !anchors[ref]:
+				return nil, fmt.Errorf("ссылка $ref %q не является указателем JSON (#/...) и не объявлена в $id", ref)
+			}
+		}
+		for _, key := range applicators {
+			switch child := node[key].(type) {
+			case map[string]interface{}:
+				edges = append(edges, pointer+"/"+key)
+			case []interface{}:
+				for i := range child {
+					edges = append(edges, pointer+"/"+key+"/"+strconv.Itoa(i))
+				}
+			}
+		}
+		return edges, nil
+	}
+
+	// Поиск в глубину с отметкой узлов на текущем пути
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(pointer string) error
+	visit = func(pointer string) error {
+		switch state[pointer] {
+		case visiting:
+			return fmt.Errorf("циклическая ссылка $ref через узел #%s", pointer)
+		case done:
+			return nil
+		}
+		state[pointer] = visiting
+		edges, err := next(pointer)
+		if err != nil {
+			return err
+		}
+		for _, edge := range edges {
+			if err := visit(edge); err != nil {
+				return err
+			}
+		}
+		state[pointer] = done
+		return nil
+	}
+	for _, pointer := range refs {
+		if err := visit(pointer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkSchema обходит объекты схемы с их указателями JSON, не заходя в
+// значения данных (enum, const, default, examples)
+func walkSchema(node interface{}, pointer string, visit func(pointer string, node map[string]interface{})) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		visit(pointer, v)
+		for key, child := range v {
+			if !dataKeywords[key] {
+				walkSchema(child, pointer+"/"+escapePointer(key), visit)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			walkSchema(child, pointer+"/"+strconv.Itoa(i), visit)
+		}
+	}
+}
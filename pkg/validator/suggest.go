@@ -0,0 +1,311 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
+	"github.com/yanodincov/json-schema-detector/pkg/jsonpatch"
+)
+
+// Причины исправлений данных
+const (
+	// FixCoerce - значение другого типа, которое однозначно приводится к
+	// ожидаемому: "42" → 42, 42 → "42", "true" → true
+	FixCoerce = "coerce"
+	// FixDefault - отсутствующее обязательное поле со значением default в схеме
+	FixDefault = "default"
+	// FixEnum - значение вне enum, похожее на одно из допустимых
+	FixEnum = "enum"
+	// FixDrop - поле объекта, не описанное схемой (см. CoerceOptions)
+	FixDrop = "drop"
+)
+
+var (
+	// integerValue и numberValue - строки с числами в записи JSON
+	integerValue = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	numberValue  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+// Fix - исправление данных: предложенное для ошибки валидации (см.
+// SuggestFile) или примененное CoerceFile
+type Fix struct {
+	// Field - путь значения в формате ошибок валидации
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+	// Value - текущее или удаляемое значение; для FixDefault не задается
+	Value     interface{}         `json:"value,omitempty"`
+	Operation jsonpatch.Operation `json:"operation"`
+}
+
+// Description описывает исправление для человека
+func (f Fix) Description() string {
+	replacement, _ := json.Marshal(f.Operation.Value)
+	switch f.Reason {
+	case FixDefault:
+		return fmt.Sprintf("добавить значение по умолчанию %s", replacement)
+	case FixDrop:
+		return "удалить поле, не описанное схемой"
+	}
+	current, _ := json.Marshal(f.Value)
+	reason := "приведение типа"
+	if f.Reason == FixEnum {
+		reason = "ближайшее значение enum"
+	}
+	return fmt.Sprintf("%s → %s (%s)", current, replacement, reason)
+}
+
+// Patch собирает исправления в JSON Patch (RFC 6902)
+func Patch(fixes []Fix) jsonpatch.Patch {
+	patch := make(jsonpatch.Patch, 0, len(fixes))
+	for _, fix := range fixes {
+		patch = append(patch, fix.Operation)
+	}
+	return patch
+}
+
+// SuggestFile предлагает исправления файла данных для частых ошибок
+// результата валидации: значения, тип которых однозначно приводится к
+// ожидаемому, отсутствующие обязательные поля со значением default и
+// опечатки в значениях enum. Для ошибок oneOf учитываются ошибки
+// ближайшего варианта. Остальные ошибки исправлений не получают
+func (v *Validator) SuggestFile(dataFile, schemaFile string, result *ValidationResult) ([]Fix, error) {
+	if result.Valid {
+		return nil, nil
+	}
+	data, err := decompress.ReadFile(dataFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла данных: %w", err)
+	}
+	value, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга данных: %w", err)
+	}
+	matcher, err := NewMatcher(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &matchWalker{
+		m:        matcher,
+		seen:     make(map[string]bool),
+		closest:  true,
+		nodes:    make(map[string][]map[string]interface{}),
+		pointers: map[string]string{RootField: ""},
+	}
+	if err := w.node(matcher.document, "", value, RootField); err != nil {
+		return nil, err
+	}
+
+	fixes := make([]Fix, 0)
+	fixed := make(map[string]bool)
+	for _, e := range failures(result.Errors) {
+		for _, fix := range w.suggest(e) {
+			// Одно значение исправляется один раз
+			if fixed[fix.Operation.Path] {
+				continue
+			}
+			fixed[fix.Operation.Path] = true
+			fixes = append(fixes, fix)
+		}
+	}
+	return fixes, nil
+}
+
+// failures возвращает ошибки вместе с ошибками ближайших вариантов oneOf
+func failures(errors []ValidationError) []ValidationError {
+	all := make([]ValidationError, 0, len(errors))
+	for _, e := range errors {
+		all = append(all, e)
+		for _, branch := range e.Branches {
+			if branch.Closest {
+				all = append(all, failures(branch.Errors)...)
+			}
+		}
+	}
+	return all
+}
+
+// suggest предлагает исправления для одной ошибки
+func (w *matchWalker) suggest(e ValidationError) []Fix {
+	pointer, ok := w.pointers[e.Field]
+	if !ok {
+		return nil
+	}
+	nodes := w.nodes[e.Field]
+
+	switch e.Type {
+	case "invalid_type":
+		for _, kind := range expectedTypes(nodes) {
+			if coerced, ok := coerce(e.Value, kind); ok {
+				return []Fix{{Field: e.Field, Reason: FixCoerce, Value: e.Value, Operation: jsonpatch.Operation{Op: jsonpatch.OpReplace, Path: pointer, Value: coerced}}}
+			}
+		}
+	case "required":
+		return w.m.defaults(e.Field, pointer, e.Value, nodes)
+	case "enum", "const":
+		text, ok := e.Value.(string)
+		if !ok {
+			return nil
+		}
+		if candidate, ok := closestValue(text, allowedValues(nodes)); ok {
+			return []Fix{{Field: e.Field, Reason: FixEnum, Value: e.Value, Operation: jsonpatch.Operation{Op: jsonpatch.OpReplace, Path: pointer, Value: candidate}}}
+		}
+	}
+	return nil
+}
+
+// defaults предлагает добавить отсутствующие обязательные поля объекта,
+// у которых в схеме есть default
+func (m *Matcher) defaults(field, pointer string, value interface{}, nodes []map[string]interface{}) []Fix {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var fixes []Fix
+	for _, node := range nodes {
+		required, _ := node["required"].([]interface{})
+		properties, _ := node["properties"].(map[string]interface{})
+		for _, name := range required {
+			key, ok := name.(string)
+			if !ok {
+				continue
+			}
+			if _, present := object[key]; present {
+				continue
+			}
+			property, ok := m.resolveVariant(properties[key]).(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if def, ok := property["default"]; ok {
+				fixes = append(fixes, Fix{Field: joinField(field, key), Reason: FixDefault, Operation: jsonpatch.Operation{Op: jsonpatch.OpAdd, Path: pointer + "/" + escapePointer(key), Value: def}})
+			}
+		}
+	}
+	return fixes
+}
+
+// expectedTypes собирает типы, которые узлы схемы допускают для значения
+func expectedTypes(nodes []map[string]interface{}) []string {
+	var kinds []string
+	for _, node := range nodes {
+		switch t := node["type"].(type) {
+		case string:
+			kinds = append(kinds, t)
+		case []interface{}:
+			for _, kind := range t {
+				if name, ok := kind.(string); ok {
+					kinds = append(kinds, name)
+				}
+			}
+		}
+	}
+	return kinds
+}
+
+// allowedValues собирает значения enum и const узлов схемы
+func allowedValues(nodes []map[string]interface{}) []interface{} {
+	var values []interface{}
+	for _, node := range nodes {
+		if enum, ok := node["enum"].([]interface{}); ok {
+			values = append(values, enum...)
+		}
+		if value, ok := node["const"]; ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// coerce приводит значение к типу kind, если приведение однозначно: строка
+// с числом или true/false, число или логическое значение - к строке
+func coerce(value interface{}, kind string) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		text := strings.TrimSpace(v)
+		switch {
+		case kind == "integer" && integerValue.MatchString(text):
+			return json.Number(text), true
+		case kind == "number" && numberValue.MatchString(text):
+			return json.Number(text), true
+		case kind == "boolean" && (strings.EqualFold(text, "true") || strings.EqualFold(text, "false")):
+			return strings.EqualFold(text, "true"), true
+		}
+	case json.Number:
+		if kind == "string" {
+			return v.String(), true
+		}
+	case bool:
+		if kind == "string" {
+			return fmt.Sprint(v), true
+		}
+	}
+	return nil, false
+}
+
+// closestValue находит строковое значение candidates, похожее на value:
+// совпадающее без учета регистра и разделителей или единственное с
+// наименьшим числом правок, не большим трети длины значения
+func closestValue(value string, candidates []interface{}) (string, bool) {
+	normalized := normalizeWord(value)
+	best, bestDistance, ties := "", -1, 0
+	for _, candidate := range candidates {
+		text, ok := candidate.(string)
+		if !ok || text == value {
+			continue
+		}
+		if normalizeWord(text) == normalized {
+			return text, true
+		}
+		d := editDistance([]rune(strings.ToLower(value)), []rune(strings.ToLower(text)))
+		switch {
+		case bestDistance < 0 || d < bestDistance:
+			best, bestDistance, ties = text, d, 0
+		case d == bestDistance:
+			ties++
+		}
+	}
+	limit := max(1, len([]rune(value))/3)
+	if bestDistance < 0 || bestDistance > limit || ties > 0 {
+		return "", false
+	}
+	return best, true
+}
+
+// normalizeWord приводит строку к нижнему регистру без пробелов, дефисов и
+// подчеркиваний: "In Progress" и "in_progress" совпадают
+func normalizeWord(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, value)
+}
+
+// editDistance - расстояние Левенштейна между строками
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = slices.Min([]int{previous[j] + 1, current[j-1] + 1, previous[j-1] + cost})
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
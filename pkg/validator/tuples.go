@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+)
+
+// prefixItemsKeyword - ключевое слово кортежа из JSON Schema 2020-12
+var prefixItemsKeyword = []byte(`"prefixItems"`)
+
+// downgradeTuples переводит кортежи prefixItems (схемы 2020-12 и схемы,
+// сохраненные прежними версиями) в запись draft-07, которую понимает
+// gojsonschema: "items" - список схем позиций, "additionalItems" -
+// прежнее значение items (false закрывает кортеж). Схемы без prefixItems
+// возвращаются без изменений
+func downgradeTuples(schema []byte) ([]byte, error) {
+	if !bytes.Contains(schema, prefixItemsKeyword) {
+		return schema, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(schema))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	rewriteTuples(document)
+	return json.Marshal(document)
+}
+
+// dataKeywords содержат значения данных, а не схемы, и не переписываются
+var dataKeywords = map[string]bool{
+	"enum":     true,
+	"const":    true,
+	"default":  true,
+	"examples": true,
+}
+
+// rewriteTuples рекурсивно заменяет prefixItems на items/additionalItems
+func rewriteTuples(node interface{}) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if positions, ok := v["prefixItems"].([]interface{}); ok {
+			if items, exists := v["items"]; exists {
+				v["additionalItems"] = items
+			}
+			v["items"] = positions
+			delete(v, "prefixItems")
+		}
+		for key, child := range v {
+			if !dataKeywords[key] {
+				rewriteTuples(child)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			rewriteTuples(child)
+		}
+	}
+}
+
+// tupleFileSystem открывает файлы схем для gojsonschema, переводя кортежи
+// в запись draft-07; так же обрабатываются схемы, подключенные через $ref
+type tupleFileSystem struct{}
+
+// Open читает файл и возвращает его содержимое после downgradeTuples
+func (tupleFileSystem) Open(name string) (http.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if data, err = prepareSchema(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &tupleFile{File: f, reader: bytes.NewReader(data)}, nil
+}
+
+// tupleFile отдает переписанное содержимое файла схемы
+type tupleFile struct {
+	*os.File
+	reader *bytes.Reader
+}
+
+func (f *tupleFile) Read(p []byte) (int, error) {
+	return f.reader.Read(p)
+}
+
+func (f *tupleFile) Seek(offset int64, whence int) (int64, error) {
+	return f.reader.Seek(offset, whence)
+}
@@ -3,10 +3,14 @@ package validator
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/xeipuuv/gojsonschema"
+	"github.com/yanodincov/json-schema-detector/pkg/decompress"
 )
 
 // Validator представляет валидатор JSON схем
@@ -20,14 +24,23 @@ type ValidationResult struct {
 	Errors          []ValidationError `json:"errors,omitempty"`
 	ValidatedFields int               `json:"validated_fields"`
 	Duration        time.Duration     `json:"duration"`
+	// Records и InvalidRecords - число проверенных записей NDJSON и записей
+	// с ошибками (см. ValidateRecords)
+	Records        int `json:"records,omitempty"`
+	InvalidRecords int `json:"invalid_records,omitempty"`
 }
 
 // ValidationError представляет ошибку валидации
 type ValidationError struct {
+	// Record - номер записи NDJSON с ошибкой, начиная с 1 (см. ValidateRecords)
+	Record      int         `json:"record,omitempty"`
 	Field       string      `json:"field"`
 	Type        string      `json:"type"`
 	Description string      `json:"description"`
 	Value       interface{} `json:"value,omitempty"`
+	// Branches - проверка значения по каждому варианту oneOf для ошибки
+	// выбора варианта (см. ValidateFile)
+	Branches []Branch `json:"branches,omitempty"`
 }
 
 // New создает новый валидатор
@@ -37,27 +50,35 @@ func New(strict bool) *Validator {
 	}
 }
 
-// ValidateFile валидирует JSON файл против схемы
+// ValidateFile валидирует JSON файл против схемы. Файл данных, сжатый gzip
+// или zstd, распаковывается при чтении. Общая ошибка oneOf заменяется
+// объяснением: причины отказа каждого варианта и ближайший из них
 func (v *Validator) ValidateFile(dataFile, schemaFile string) (*ValidationResult, error) {
 	start := time.Now()
 
 	// Читаем файл данных
-	dataBytes, err := os.ReadFile(dataFile)
+	dataBytes, err := decompress.ReadFile(dataFile)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения файла данных: %w", err)
 	}
 
-	// Читаем файл схемы
-	schemaBytes, err := os.ReadFile(schemaFile)
+	// Схема загружается по пути, чтобы относительные $ref на общие определения
+	// разрешались от директории схемы
+	if _, err := os.Stat(schemaFile); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла схемы: %w", err)
+	}
+	schemaPath, err := filepath.Abs(schemaFile)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения файла схемы: %w", err)
 	}
 
-	// Валидируем
-	result, err := v.ValidateBytes(dataBytes, schemaBytes)
+	// Валидируем; кортежи prefixItems переводятся в запись draft-07
+	result, err := v.validateLoaders(gojsonschema.NewReferenceLoaderFileSystem(fileURL(schemaPath), tupleFileSystem{}), gojsonschema.NewBytesLoader(dataBytes))
 	if err != nil {
 		return nil, err
 	}
+	result.ValidatedFields = v.countFields(dataBytes)
+	explainOneOf(schemaFile, dataBytes, result)
 
 	result.Duration = time.Since(start)
 	return result, nil
@@ -65,17 +86,87 @@ func (v *Validator) ValidateFile(dataFile, schemaFile string) (*ValidationResult
 
 // ValidateBytes валидирует JSON данные против схемы
 func (v *Validator) ValidateBytes(data, schema []byte) (*ValidationResult, error) {
-	// Создаем загрузчики для gojsonschema
+	// Создаем загрузчики для gojsonschema; кортежи prefixItems переводятся в запись draft-07
+	schema, err := prepareSchema(schema)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга схемы: %w", err)
+	}
 	schemaLoader := gojsonschema.NewBytesLoader(schema)
 	documentLoader := gojsonschema.NewBytesLoader(data)
 
+	validationResult, err := v.validateLoaders(schemaLoader, documentLoader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подсчитываем количество проверенных полей
+	validationResult.ValidatedFields = v.countFields(data)
+
+	return validationResult, nil
+}
+
+// DefaultMetaSchemaURL используется, если в схеме не указан $schema
+const DefaultMetaSchemaURL = "http://json-schema.org/draft-07/schema"
+
+// metaSchemaURLs содержит мета-схемы поддерживаемых draft версий
+var metaSchemaURLs = map[string]bool{
+	"http://json-schema.org/draft-04/schema": true,
+	"http://json-schema.org/draft-06/schema": true,
+	"http://json-schema.org/draft-07/schema": true,
+}
+
+// ValidateSchemaBytes проверяет схему на соответствие мета-схеме ее draft версии
+func (v *Validator) ValidateSchemaBytes(schema []byte) (*ValidationResult, error) {
+	var header struct {
+		Schema string `json:"$schema"`
+	}
+	if err := json.Unmarshal(schema, &header); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга схемы: %w", err)
+	}
+
+	metaURL := DefaultMetaSchemaURL
+	if header.Schema != "" {
+		metaURL = strings.TrimSuffix(strings.Replace(header.Schema, "https://", "http://", 1), "#")
+		if !metaSchemaURLs[metaURL] {
+			return nil, fmt.Errorf("неподдерживаемая версия JSON Schema: %s", header.Schema)
+		}
+	}
+
+	// Мета-схемы draft-04/06/07 встроены в gojsonschema, сеть не используется
+	return v.validateLoaders(gojsonschema.NewReferenceLoader(metaURL), gojsonschema.NewBytesLoader(schema))
+}
+
+// validateLoaders выполняет валидацию и преобразует результат
+func (v *Validator) validateLoaders(schemaLoader, documentLoader gojsonschema.JSONLoader) (_ *ValidationResult, err error) {
+	defer recoverPanic(&err)
+
 	// Выполняем валидацию
 	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка валидации: %w", err)
 	}
 
-	// Преобразуем результат
+	return convertResult(result), nil
+}
+
+// recoverPanic превращает панику gojsonschema в ошибку: на числах вне
+// диапазона big.Rat (например, 4E49484948) в схеме или данных библиотека
+// разыменовывает nil
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("ошибка валидации: %v", r)
+	}
+}
+
+// validateValue проверяет значение по скомпилированной схеме, не давая
+// панике gojsonschema уронить процесс (см. recoverPanic)
+func validateValue(schema *gojsonschema.Schema, value interface{}) (_ *gojsonschema.Result, err error) {
+	defer recoverPanic(&err)
+	return schema.Validate(gojsonschema.NewGoLoader(value))
+}
+
+// convertResult преобразует результат gojsonschema
+func convertResult(result *gojsonschema.Result) *ValidationResult {
 	validationResult := &ValidationResult{
 		Valid:  result.Valid(),
 		Errors: make([]ValidationError, 0),
@@ -93,10 +184,7 @@ func (v *Validator) ValidateBytes(data, schema []byte) (*ValidationResult, error
 		}
 	}
 
-	// Подсчитываем количество проверенных полей
-	validationResult.ValidatedFields = v.countFields(data)
-
-	return validationResult, nil
+	return validationResult
 }
 
 // countFields подсчитывает количество полей в JSON
@@ -129,3 +217,15 @@ func (v *Validator) countFieldsRecursive(data interface{}) int {
 
 	return count
 }
+
+// fileURL строит file:// URL для абсолютного пути. Путь Windows (C:\schemas)
+// записывается как file:///C:/schemas; "+" экранируется, потому что загрузчик
+// gojsonschema декодирует путь как строку запроса
+func fileURL(path string) string {
+	slashed := filepath.ToSlash(path)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	u := url.URL{Scheme: "file", Path: slashed}
+	return strings.ReplaceAll(u.String(), "+", "%2B")
+}
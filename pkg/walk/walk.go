@@ -0,0 +1,177 @@
+// Package walk обходит дерево JSON Schema и позволяет изменять узлы на месте.
+package walk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/yanodincov/json-schema-detector/pkg/types"
+)
+
+// SkipChildren, возвращенная из WalkFunc при прямом обходе, пропускает потомков узла
+var SkipChildren = errors.New("skip children")
+
+// Order определяет порядок обхода
+type Order int
+
+const (
+	// PreOrder вызывает функцию для узла до его потомков
+	PreOrder Order = iota
+	// PostOrder вызывает функцию для узла после его потомков
+	PostOrder
+)
+
+// WalkFunc вызывается для каждого узла. Путь строится в формате fieldmanager:
+// поля через точку, элементы массива как ".0", значения объекта-словаря
+// (patternProperties или additionalProperties) как ".*", позиции кортежа
+// (prefixItems) как ".0", ".1", ..., варианты как ".oneOf[i]".
+// Узел можно изменять на месте; при прямом обходе потомки читаются после вызова.
+type WalkFunc func(path string, p *types.Property) error
+
+// Options настраивает обход
+type Options struct {
+	Order Order
+	// SkipItems не передает в функцию сами узлы items (путь "<массив>.0")
+	// и значений словаря (путь "<объект>.*"), но продолжает обход их полей
+	SkipItems bool
+}
+
+// Walk обходит все узлы схемы в прямом порядке
+func Walk(schema *types.JSONSchema, fn WalkFunc) error {
+	return WalkWithOptions(schema, Options{}, fn)
+}
+
+// WalkPostOrder обходит все узлы схемы в обратном порядке
+func WalkPostOrder(schema *types.JSONSchema, fn WalkFunc) error {
+	return WalkWithOptions(schema, Options{Order: PostOrder}, fn)
+}
+
+// WalkWithOptions обходит узлы схемы с указанными настройками
+func WalkWithOptions(schema *types.JSONSchema, opts Options, fn WalkFunc) error {
+	if schema == nil {
+		return nil
+	}
+
+	w := &walker{opts: opts, fn: fn}
+	if err := w.children("", schema.Properties, schema.Items, nil, types.MapValues(schema.PatternProperties, schema.AdditionalProperties), schema.OneOf, schema.AnyOf); err != nil {
+		return err
+	}
+	return w.defs(schema.Defs)
+}
+
+// WalkProperty обходит свойство и его потомков, начиная с указанного пути
+func WalkProperty(path string, prop *types.Property, opts Options, fn WalkFunc) error {
+	w := &walker{opts: opts, fn: fn}
+	return w.node(path, prop, false)
+}
+
+// Join добавляет сегмент к пути
+func Join(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+// walker хранит состояние обхода
+type walker struct {
+	opts Options
+	fn   WalkFunc
+}
+
+// node обходит один узел
+func (w *walker) node(path string, prop *types.Property, isItems bool) error {
+	if prop == nil {
+		return nil
+	}
+
+	visit := !(isItems && w.opts.SkipItems)
+
+	if visit && w.opts.Order == PreOrder {
+		if err := w.fn(path, prop); err != nil {
+			if err == SkipChildren {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if err := w.children(path, prop.Properties, prop.Items, prop.PrefixItems, types.MapValues(prop.PatternProperties, prop.AdditionalProperties), prop.OneOf, prop.AnyOf); err != nil {
+		return err
+	}
+
+	if visit && w.opts.Order == PostOrder {
+		if err := w.fn(path, prop); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// children обходит потомков узла в детерминированном порядке. Позиции
+// кортежа различаются типами, поэтому передаются в функцию и при SkipItems
+func (w *walker) children(path string, properties map[string]*types.Property, items *types.Property, positions []*types.Property, values *types.Property, oneOf, anyOf []*types.JSONSchema) error {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := w.node(Join(path, name), properties[name], false); err != nil {
+			return err
+		}
+	}
+
+	if err := w.node(Join(path, "0"), items, true); err != nil {
+		return err
+	}
+
+	for i, position := range positions {
+		if err := w.node(Join(path, strconv.Itoa(i)), position, false); err != nil {
+			return err
+		}
+	}
+
+	if err := w.node(Join(path, "*"), values, true); err != nil {
+		return err
+	}
+
+	if err := w.variants(path, "oneOf", oneOf); err != nil {
+		return err
+	}
+	return w.variants(path, "anyOf", anyOf)
+}
+
+// defs обходит общие определения схемы по путям "$defs.<имя>"
+func (w *walker) defs(defs map[string]*types.Property) error {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := w.node(Join(types.DefsSegment, name), defs[name], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// variants обходит поля вариантов oneOf/anyOf
+func (w *walker) variants(path, keyword string, variants []*types.JSONSchema) error {
+	for i, variant := range variants {
+		if variant == nil {
+			continue
+		}
+		prefix := Join(path, fmt.Sprintf("%s[%d]", keyword, i))
+		if err := w.children(prefix, variant.Properties, variant.Items, nil, types.MapValues(variant.PatternProperties, variant.AdditionalProperties), variant.OneOf, variant.AnyOf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Package xmlinput преобразует документы XML в JSON, чтобы устаревшие
+// выгрузки XML получали JSON Schema так же, как JSON. Элементы становятся
+// полями объектов, атрибуты - полями с префиксом @, текст элементов с
+// атрибутами или вложенными элементами - полем #text.
+package xmlinput
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// AttributePrefix - префикс полей атрибутов элемента
+	AttributePrefix = "@"
+	// TextKey - поле текста элемента с атрибутами или вложенными элементами
+	TextKey = "#text"
+
+	// maxDepth - предел вложенности элементов
+	maxDepth = 10000
+)
+
+// element - элемент документа
+type element struct {
+	name     string
+	attrs    []xml.Attr
+	children []*element
+	text     strings.Builder
+}
+
+// ToJSON преобразует документ XML в объект JSON с единственным полем -
+// именем корневого элемента. Элемент без атрибутов и вложенных элементов
+// становится строкой со своим текстом, остальные - объектами. Элемент,
+// который хотя бы у одного родителя повторяется, везде становится
+// массивом, чтобы записи одной ленты имели одну форму. Текст остается
+// строкой: типы и форматы значений определяет анализатор. Пространства
+// имен отбрасываются, комментарии и инструкции обработки пропускаются
+func ToJSON(data []byte) ([]byte, error) {
+	root, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	repeated := make(map[string]bool)
+	collectRepeated(root, root.name, repeated)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeString(&buf, root.name)
+	buf.WriteByte(':')
+	writeElement(&buf, root, root.name, repeated)
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// parse читает дерево элементов документа
+func parse(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return nil, fmt.Errorf("кодировка не поддерживается, преобразуйте документ в UTF-8")
+	}
+
+	var root *element
+	var stack []*element
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(stack) >= maxDepth {
+				return nil, fmt.Errorf("ошибка парсинга XML: вложенность превышает %d уровней", maxDepth)
+			}
+			el := &element{name: t.Name.Local, attrs: attributes(t.Attr)}
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			case root != nil:
+				return nil, fmt.Errorf("ошибка парсинга XML: второй корневой элемент <%s>", t.Name.Local)
+			default:
+				root = el
+			}
+			stack = append(stack, el)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("ошибка парсинга XML: нет корневого элемента")
+	}
+	return root, nil
+}
+
+// attributes отбрасывает объявления пространств имен
+func attributes(attrs []xml.Attr) []xml.Attr {
+	result := make([]xml.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			continue
+		}
+		result = append(result, attr)
+	}
+	return result
+}
+
+// collectRepeated отмечает пути элементов, которые повторяются у
+// какого-либо родителя
+func collectRepeated(el *element, path string, repeated map[string]bool) {
+	counts := make(map[string]int)
+	for _, child := range el.children {
+		counts[child.name]++
+	}
+	for _, child := range el.children {
+		childPath := path + "/" + child.name
+		if counts[child.name] > 1 {
+			repeated[childPath] = true
+		}
+		collectRepeated(child, childPath, repeated)
+	}
+}
+
+// writeElement записывает элемент как значение JSON
+func writeElement(buf *bytes.Buffer, el *element, path string, repeated map[string]bool) {
+	text := strings.TrimSpace(el.text.String())
+	if len(el.attrs) == 0 && len(el.children) == 0 {
+		writeString(buf, text)
+		return
+	}
+
+	// Вложенные элементы группируются по имени в порядке первого появления
+	var names []string
+	groups := make(map[string][]*element)
+	for _, child := range el.children {
+		if _, ok := groups[child.name]; !ok {
+			names = append(names, child.name)
+		}
+		groups[child.name] = append(groups[child.name], child)
+	}
+
+	buf.WriteByte('{')
+	fields := 0
+	field := func(name string) {
+		if fields > 0 {
+			buf.WriteByte(',')
+		}
+		fields++
+		writeString(buf, name)
+		buf.WriteByte(':')
+	}
+
+	for _, attr := range el.attrs {
+		field(AttributePrefix + attr.Name.Local)
+		writeString(buf, attr.Value)
+	}
+	for _, name := range names {
+		childPath := path + "/" + name
+		field(name)
+		if !repeated[childPath] {
+			writeElement(buf, groups[name][0], childPath, repeated)
+			continue
+		}
+		buf.WriteByte('[')
+		for i, child := range groups[name] {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeElement(buf, child, childPath, repeated)
+		}
+		buf.WriteByte(']')
+	}
+	if text != "" {
+		field(TextKey)
+		writeString(buf, text)
+	}
+	buf.WriteByte('}')
+}
+
+// writeString записывает строку JSON
+func writeString(buf *bytes.Buffer, value string) {
+	data, _ := json.Marshal(value)
+	buf.Write(data)
+}
@@ -0,0 +1,253 @@
+// Package yamlinput преобразует документы YAML в JSON, чтобы фикстуры и
+// выгрузки конфигураций анализировались так же, как JSON. Порядок ключей
+// сохраняется, якоря и ссылки (&x, *x, <<: *x) раскрываются.
+package yamlinput
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxDepth - предел вложенности документа вместе с раскрытыми ссылками;
+// защищает от ссылок, которые раскрываются бесконечно
+const maxDepth = 10000
+
+var (
+	// integerLiteral и numberLiteral - числа, запись которых в YAML
+	// совпадает с записью JSON и переносится как есть
+	integerLiteral = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	numberLiteral  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+)
+
+// ToJSON преобразует документы YAML в JSON. Один документ становится
+// значением JSON, несколько документов (разделенных ---) - строками NDJSON,
+// по строке на документ. Метки времени остаются строками, пользовательские
+// теги (!Ref) не меняют значения. Ключи, которые не являются скалярами, и
+// значения .inf и .nan, не представимые в JSON, дают ошибку
+func ToJSON(data []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var buf bytes.Buffer
+	documents := 0
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга YAML: %w", err)
+		}
+		if documents > 0 {
+			buf.WriteByte('\n')
+		}
+		if err := writeNode(&buf, &document, 0); err != nil {
+			return nil, err
+		}
+		documents++
+	}
+	if documents == 0 {
+		return nil, fmt.Errorf("ошибка парсинга YAML: нет ни одного документа")
+	}
+	return buf.Bytes(), nil
+}
+
+// writeNode записывает узел YAML как значение JSON
+func writeNode(buf *bytes.Buffer, node *yaml.Node, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("ошибка парсинга YAML: вложенность превышает %d уровней", maxDepth)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeNode(buf, node.Content[0], depth)
+	case yaml.AliasNode:
+		return writeNode(buf, node.Alias, depth+1)
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNode(buf, item, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.MappingNode:
+		return writeMapping(buf, node, depth)
+	case yaml.ScalarNode:
+		return writeScalar(buf, node)
+	}
+	return fmt.Errorf("ошибка парсинга YAML: неизвестный вид узла в строке %d", node.Line)
+}
+
+// writeMapping записывает отображение как объект JSON. Ключи слияния
+// (<<: *base) добавляют поля ссылки, которых нет в самом отображении
+func writeMapping(buf *bytes.Buffer, node *yaml.Node, depth int) error {
+	pairs, err := mappingPairs(node, depth)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteByte('{')
+	for i, pair := range pairs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(pair.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := writeNode(buf, pair.value, depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// pair - поле отображения
+type pair struct {
+	key   string
+	value *yaml.Node
+}
+
+// mappingPairs возвращает поля отображения в порядке записи; поле,
+// повторенное явно или пришедшее из слияния, остается на первом месте со
+// значением, которое действует по правилам YAML
+func mappingPairs(node *yaml.Node, depth int) ([]pair, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("ошибка парсинга YAML: вложенность превышает %d уровней", maxDepth)
+	}
+
+	var pairs []pair
+	index := make(map[string]int)
+	var merged []pair
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, value := node.Content[i], node.Content[i+1]
+		if keyNode.Kind == yaml.ScalarNode && keyNode.Tag == "!!merge" {
+			sources, err := mergeSources(value, depth)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, sources...)
+			continue
+		}
+
+		key, err := mappingKey(keyNode)
+		if err != nil {
+			return nil, err
+		}
+		if j, ok := index[key]; ok {
+			pairs[j].value = value
+			continue
+		}
+		index[key] = len(pairs)
+		pairs = append(pairs, pair{key: key, value: value})
+	}
+
+	// Явные поля важнее полей слияния, а из нескольких ссылок слияния
+	// действует первая
+	for _, p := range merged {
+		if _, ok := index[p.key]; ok {
+			continue
+		}
+		index[p.key] = len(pairs)
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+// mergeSources возвращает поля отображений ключа слияния: одной ссылки или
+// последовательности ссылок
+func mergeSources(node *yaml.Node, depth int) ([]pair, error) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		return mappingPairs(node, depth+1)
+	case yaml.SequenceNode:
+		var pairs []pair
+		for _, item := range node.Content {
+			sourcePairs, err := mergeSources(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, sourcePairs...)
+		}
+		return pairs, nil
+	}
+	return nil, fmt.Errorf("ошибка парсинга YAML: ключ слияния << в строке %d ссылается не на отображение", node.Line)
+}
+
+// mappingKey возвращает ключ отображения как строку
+func mappingKey(node *yaml.Node) (string, error) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.ScalarNode {
+		return "", fmt.Errorf("ошибка парсинга YAML: ключ в строке %d не является скаляром, в JSON такие ключи не представимы", node.Line)
+	}
+	return node.Value, nil
+}
+
+// writeScalar записывает скаляр по его тегу
+func writeScalar(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!null":
+		buf.WriteString("null")
+		return nil
+	case "!!bool":
+		var value bool
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("ошибка парсинга YAML: %w", err)
+		}
+		buf.WriteString(strconv.FormatBool(value))
+		return nil
+	case "!!int":
+		if integerLiteral.MatchString(node.Value) {
+			buf.WriteString(node.Value)
+			return nil
+		}
+		// 0x1F, 0o17, +5 и другие записи YAML
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("ошибка парсинга YAML: %w", err)
+		}
+		fmt.Fprint(buf, value)
+		return nil
+	case "!!float":
+		if numberLiteral.MatchString(node.Value) {
+			buf.WriteString(node.Value)
+			return nil
+		}
+		var value float64
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("ошибка парсинга YAML: %w", err)
+		}
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return fmt.Errorf("ошибка парсинга YAML: значение %s в строке %d не представимо в JSON", node.Value, node.Line)
+		}
+		buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+		return nil
+	}
+
+	// Строки, метки времени, двоичные данные и пользовательские теги
+	value, _ := json.Marshal(node.Value)
+	buf.Write(value)
+	return nil
+}